@@ -2,19 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"shared/severity"
 	"system-monitor/internal/alerts"
 	"system-monitor/internal/config"
 	"system-monitor/internal/dashboard"
 	"system-monitor/internal/datasource"
+	"system-monitor/pkg/ticker"
 
 	"github.com/sirupsen/logrus"
 )
 
+// shutdownTimeout bounds how long in-flight dashboard requests get to finish
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	// Set up logging
 	logrus.SetFormatter(&logrus.TextFormatter{
@@ -70,35 +78,25 @@ func main() {
 	}
 
 	// Start alert processing in a goroutine
-	go func() {
-		ticker := time.NewTicker(cfg.MetricsInterval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				latestMetrics, err := dataSource.GetLatestMetrics(ctx)
-				if err != nil {
-					logrus.Errorf("Failed to get latest metrics: %v", err)
-					continue
-				}
-				if latestMetrics != nil {
-					alertManager.ProcessMetrics(latestMetrics)
-				}
-			}
+	go ticker.Every(ctx, cfg.MetricsInterval, func(ctx context.Context) error {
+		latestMetrics, err := dataSource.GetLatestMetrics(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get latest metrics: %w", err)
 		}
-	}()
+		if latestMetrics != nil {
+			alertManager.ProcessMetrics(latestMetrics)
+		}
+		return nil
+	}, ticker.WithName("Alert processing"))
 
 	// Start dashboard server in a goroutine
 	go func() {
-		if err := dashboardServer.Start(); err != nil {
+		if err := dashboardServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.Errorf("Dashboard server error: %v", err)
 		}
 	}()
 
-	logrus.Infof("✅ System Monitor is running!")
+	logrus.Infof("%s System Monitor is running!", severity.Lookup("success").Emoji)
 	logrus.Infof("📊 Dashboard available at: %s", cfg.GetDashboardURL())
 	logrus.Infof("🔔 Alert backend: %s", cfg.AlertBackendType)
 	logrus.Infof("📈 Data source: %s", cfg.DataSourceType)
@@ -112,7 +110,13 @@ func main() {
 
 	logrus.Info("🛑 Shutting down System Monitor...")
 
-	// Graceful shutdown
+	// Graceful shutdown, letting in-flight dashboard requests finish first
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	if err := dashboardServer.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("Error shutting down dashboard server: %v", err)
+	}
+	shutdownCancel()
+
 	cancel()
 
 	// Stop alert manager
@@ -130,5 +134,5 @@ func main() {
 		logrus.Errorf("Error closing alert backend: %v", err)
 	}
 
-	logrus.Info("✅ System Monitor shutdown complete")
+	logrus.Infof("%s System Monitor shutdown complete", severity.Lookup("success").Emoji)
 }
@@ -7,6 +7,7 @@ require (
 	github.com/shirou/gopsutil/v3 v3.23.8
 	github.com/sirupsen/logrus v1.9.3
 	github.com/slack-go/slack v0.12.3
+	shared v0.0.0
 )
 
 require (
@@ -20,3 +21,5 @@ require (
 	github.com/yusufpapurcu/wmi v1.2.3 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 )
+
+replace shared => ../shared
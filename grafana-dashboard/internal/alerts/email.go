@@ -0,0 +1,165 @@
+package alerts
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EmailAlertBackend implements AlertBackend by sending email over SMTP. It
+// upgrades to TLS with STARTTLS when the server offers it, and authenticates
+// only when a username is configured.
+type EmailAlertBackend struct {
+	host string
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailAlertBackend creates a new email alert backend sending from from to
+// every address in to through the SMTP server at host:port
+func NewEmailAlertBackend(host, port, username, password, from string, to []string) *EmailAlertBackend {
+	backend := &EmailAlertBackend{
+		host: host,
+		addr: net.JoinHostPort(host, port),
+		from: from,
+		to:   to,
+	}
+	if username != "" {
+		backend.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return backend
+}
+
+// SendAlert emails the alert to every recipient
+func (eab *EmailAlertBackend) SendAlert(ctx context.Context, alert *Alert) error {
+	client, err := eab.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if eab.auth != nil {
+		if err := client.Auth(eab.auth); err != nil {
+			return fmt.Errorf("failed to authenticate with SMTP server: %w", err)
+		}
+	}
+	if err := client.Mail(eab.from); err != nil {
+		return fmt.Errorf("failed to set email sender: %w", err)
+	}
+	for _, to := range eab.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to add email recipient %s: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to start email body: %w", err)
+	}
+	if _, err := w.Write(eab.formatMessage(alert)); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := client.Quit(); err != nil {
+		logrus.Warnf("Failed to close SMTP session cleanly: %v", err)
+	}
+
+	logrus.Infof("Sent email alert: %s - %s", alert.Severity, alert.Title)
+	return nil
+}
+
+// dial connects to the SMTP server, says hello and upgrades to TLS if offered.
+// The connection is bounded by ctx's deadline, if any.
+func (eab *EmailAlertBackend) dial(ctx context.Context) (*smtp.Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", eab.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, eab.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: eab.host}); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to start TLS with SMTP server: %w", err)
+		}
+	}
+	return client, nil
+}
+
+// formatMessage renders the alert as a plain-text email, with the severity in
+// the subject and the explanation and metadata in the body
+func (eab *EmailAlertBackend) formatMessage(alert *Alert) []byte {
+	style := alert.Style()
+	subject := fmt.Sprintf("[%s] %s", style.Label, alert.Title)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s %s (%s)\r\n\r\n%s\r\n", style.Emoji, alert.Title, style.Label, alert.Message)
+	if alert.Explanation != nil {
+		fmt.Fprintf(&body, "\r\nWhy: %s\r\n", alert.Explanation)
+	}
+	if len(alert.Metadata) > 0 {
+		keys := make([]string, 0, len(alert.Metadata))
+		for key := range alert.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		body.WriteString("\r\nDetails:\r\n")
+		for _, key := range keys {
+			fmt.Fprintf(&body, "- %s: %v\r\n", key, alert.Metadata[key])
+		}
+	}
+	fmt.Fprintf(&body, "\r\nTimestamp: %s\r\n", alert.Timestamp.Format(time.RFC3339))
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", eab.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(eab.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body.String())
+	return []byte(msg.String())
+}
+
+// HealthCheck checks that the SMTP server accepts a session
+func (eab *EmailAlertBackend) HealthCheck(ctx context.Context) error {
+	client, err := eab.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("SMTP server health check failed: %w", err)
+	}
+	return client.Quit()
+}
+
+// Close closes the email backend. Each alert uses its own SMTP session, so
+// there is nothing to release.
+func (eab *EmailAlertBackend) Close() error {
+	return nil
+}
@@ -0,0 +1,172 @@
+package alerts
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"system-monitor/internal/config"
+	"testing"
+	"time"
+)
+
+// smtpMessage is one message received by stubSMTP
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// stubSMTP runs a minimal plaintext SMTP server on an ephemeral port and sends
+// each message it receives on the returned channel
+func stubSMTP(t *testing.T) (host, port string, messages <-chan smtpMessage) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error listening, got %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan smtpMessage, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, received)
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, received
+}
+
+func serveSMTP(conn net.Conn, received chan<- smtpMessage) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 stub ESMTP")
+
+	var msg smtpMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250 stub")
+		case "MAIL":
+			msg = smtpMessage{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = string(data)
+			received <- msg
+			tp.PrintfLine("250 OK")
+		case "NOOP", "RSET":
+			tp.PrintfLine("250 OK")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+func TestEmailAlertBackendSendsAlert(t *testing.T) {
+	host, port, messages := stubSMTP(t)
+	backend := NewEmailAlertBackend(host, port, "", "", "monitor@example.com", []string{"ops@example.com", "oncall@example.com"})
+
+	alert := &Alert{
+		Type:      "cpu_high_usage",
+		Title:     "High CPU Usage",
+		Message:   "CPU usage is 95.0%",
+		Severity:  "critical",
+		Timestamp: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		Metadata:  map[string]interface{}{"cpu_usage": 95.0, "host": "web-1"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := backend.SendAlert(ctx, alert); err != nil {
+		t.Fatalf("Expected no error sending alert, got %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stub SMTP server to receive a message")
+	}
+
+	if msg.from != "monitor@example.com" {
+		t.Errorf("Expected sender monitor@example.com, got %q", msg.from)
+	}
+	if strings.Join(msg.to, ",") != "ops@example.com,oncall@example.com" {
+		t.Errorf("Expected both recipients, got %v", msg.to)
+	}
+	for _, want := range []string{
+		"Subject: [Critical] High CPU Usage",
+		"To: ops@example.com, oncall@example.com",
+		"CPU usage is 95.0%",
+		"- cpu_usage: 95",
+		"- host: web-1",
+		"Timestamp: 2026-01-01T12:00:00Z",
+	} {
+		if !strings.Contains(msg.data, want) {
+			t.Errorf("Expected %q in message, got:\n%s", want, msg.data)
+		}
+	}
+
+	if err := backend.HealthCheck(ctx); err != nil {
+		t.Errorf("Expected healthy SMTP server, got %v", err)
+	}
+}
+
+func TestEmailAlertBackendHealthCheckFailsWhenUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no error listening, got %v", err)
+	}
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	backend := NewEmailAlertBackend(host, port, "", "", "monitor@example.com", []string{"ops@example.com"})
+	if err := backend.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected an error for an unreachable SMTP server, got nil")
+	}
+}
+
+func TestFactoryCreatesEmailBackend(t *testing.T) {
+	cfg := &config.Config{
+		AlertBackendType: config.AlertBackendEmail,
+		SMTPHost:         "smtp.example.com",
+		SMTPPort:         "587",
+		EmailFrom:        "monitor@example.com",
+		EmailTo:          []string{"ops@example.com"},
+	}
+
+	backend, err := NewAlertBackendFactory().CreateAlertBackend(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := backend.(*EmailAlertBackend); !ok {
+		t.Errorf("Expected *EmailAlertBackend, got %T", backend)
+	}
+
+	cfg.EmailTo = nil
+	if _, err := NewAlertBackendFactory().CreateAlertBackend(cfg); err == nil {
+		t.Error("Expected an error without recipients, got nil")
+	}
+}
@@ -0,0 +1,66 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+)
+
+// Explanation records how a threshold alert was evaluated, so operators can see
+// why it fired without reconstructing the rule from metadata
+type Explanation struct {
+	Metric         string        `json:"metric"`
+	Unit           string        `json:"unit"`
+	Observed       float64       `json:"observed"`
+	Threshold      float64       `json:"threshold"`
+	Operator       string        `json:"operator"`
+	CriticalFactor float64       `json:"critical_factor"`
+	SeverityRule   string        `json:"severity_rule"`
+	Cooldown       time.Duration `json:"cooldown"`
+	LastAlert      *time.Time    `json:"last_alert,omitempty"`
+}
+
+// String renders the explanation as a single human-readable line
+func (e *Explanation) String() string {
+	cooldown := "no previous alert"
+	if e.LastAlert != nil {
+		cooldown = fmt.Sprintf("last alert %s ago", time.Since(*e.LastAlert).Round(time.Second))
+	}
+	return fmt.Sprintf("%s %s %s %s; %s; cooldown %s, %s",
+		e.Metric, e.format(e.Observed), e.Operator, e.format(e.Threshold),
+		e.SeverityRule, e.Cooldown, cooldown)
+}
+
+// format prints a value in the metric's unit
+func (e *Explanation) format(v float64) string {
+	if e.Unit == "ms" {
+		return fmt.Sprintf("%.0fms", v)
+	}
+	return fmt.Sprintf("%.1f%s", v, e.Unit)
+}
+
+// evaluate decides the severity of a threshold breach and explains the decision.
+// Severity is critical when observed exceeds threshold*criticalFactor, warning otherwise.
+// It must be called with am.mu held.
+func (am *AlertManager) evaluate(metric, unit, alertKey, alertType string, observed, threshold, criticalFactor float64) (string, *Explanation) {
+	critical := threshold * criticalFactor
+	severity := "warning"
+	if observed > critical {
+		severity = "critical"
+	}
+
+	exp := &Explanation{
+		Metric:         metric,
+		Unit:           unit,
+		Observed:       observed,
+		Threshold:      threshold,
+		Operator:       ">",
+		CriticalFactor: criticalFactor,
+		Cooldown:       am.config.CooldownFor(alertType, severity),
+	}
+	exp.SeverityRule = fmt.Sprintf("%s because critical requires > %s (%gx threshold)",
+		severity, exp.format(critical), criticalFactor)
+	if last, ok := am.lastAlert[alertKey]; ok {
+		exp.LastAlert = &last
+	}
+	return severity, exp
+}
@@ -13,9 +13,32 @@ func NewAlertBackendFactory() *AlertBackendFactory {
 	return &AlertBackendFactory{}
 }
 
-// CreateAlertBackend creates an alert backend based on configuration
+// CreateAlertBackend creates an alert backend based on configuration. When
+// AlertBackendType lists several types, alerts fan out to all of them.
 func (f *AlertBackendFactory) CreateAlertBackend(cfg *config.Config) (AlertBackend, error) {
-	switch cfg.AlertBackendType {
+	types := cfg.AlertBackendType.Split()
+	switch len(types) {
+	case 0:
+		return f.createAlertBackend(cfg, cfg.AlertBackendType)
+	case 1:
+		return f.createAlertBackend(cfg, types[0])
+	}
+
+	backends := make([]AlertBackend, 0, len(types))
+	for _, backendType := range types {
+		backend, err := f.createAlertBackend(cfg, backendType)
+		if err != nil {
+			NewMultiAlertBackend(backends...).Close()
+			return nil, err
+		}
+		backends = append(backends, backend)
+	}
+	return NewMultiAlertBackend(backends...), nil
+}
+
+// createAlertBackend creates a single alert backend of the given type
+func (f *AlertBackendFactory) createAlertBackend(cfg *config.Config, backendType config.AlertBackendType) (AlertBackend, error) {
+	switch backendType {
 	case config.AlertBackendSlack:
 		return f.createSlackAlertBackend(cfg)
 	case config.AlertBackendWebhook:
@@ -25,7 +48,7 @@ func (f *AlertBackendFactory) CreateAlertBackend(cfg *config.Config) (AlertBacke
 	case "noop":
 		return f.createNoOpAlertBackend(cfg)
 	default:
-		return nil, fmt.Errorf("unsupported alert backend type: %s", cfg.AlertBackendType)
+		return nil, fmt.Errorf("unsupported alert backend type: %s", backendType)
 	}
 }
 
@@ -46,8 +69,14 @@ func (f *AlertBackendFactory) createWebhookAlertBackend(cfg *config.Config) (Ale
 
 // createEmailAlertBackend creates an email alert backend
 func (f *AlertBackendFactory) createEmailAlertBackend(cfg *config.Config) (AlertBackend, error) {
-	// Implementation for email backend
-	return nil, fmt.Errorf("email alert backend not implemented yet")
+	if cfg.SMTPHost == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required for email alert backend")
+	}
+	if cfg.EmailFrom == "" || len(cfg.EmailTo) == 0 {
+		return nil, fmt.Errorf("EMAIL_FROM and EMAIL_TO are required for email alert backend")
+	}
+
+	return NewEmailAlertBackend(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom, cfg.EmailTo), nil
 }
 
 // createNoOpAlertBackend creates a no-op alert backend for testing
@@ -0,0 +1,53 @@
+package alerts
+
+import "sync"
+
+// DefaultAlertHistorySize is how many sent alerts are kept when the
+// configuration does not say
+const DefaultAlertHistorySize = 100
+
+// alertHistory is a fixed-size ring buffer of sent alerts. Once full, each new
+// alert overwrites the oldest one.
+type alertHistory struct {
+	mu     sync.Mutex
+	alerts []*Alert
+	next   int // index the next alert is written to once the buffer is full
+}
+
+func newAlertHistory(size int) *alertHistory {
+	if size <= 0 {
+		size = DefaultAlertHistorySize
+	}
+	return &alertHistory{alerts: make([]*Alert, 0, size)}
+}
+
+// add records alert, dropping the oldest alert if the buffer is full
+func (h *alertHistory) add(alert *Alert) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.alerts) < cap(h.alerts) {
+		h.alerts = append(h.alerts, alert)
+		return
+	}
+	h.alerts[h.next] = alert
+	h.next = (h.next + 1) % len(h.alerts)
+}
+
+// latest returns up to limit alerts, newest first. A limit of 0 or less
+// returns every recorded alert.
+func (h *alertHistory) latest(limit int) []*Alert {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := len(h.alerts)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	result := make([]*Alert, 0, limit)
+	for i := 1; i <= limit; i++ {
+		// The newest alert sits just before next, wrapping around
+		result = append(result, h.alerts[(h.next-i+n)%n])
+	}
+	return result
+}
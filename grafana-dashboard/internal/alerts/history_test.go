@@ -0,0 +1,50 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"system-monitor/internal/config"
+	"testing"
+	"time"
+)
+
+func TestAlertHistory(t *testing.T) {
+	am := NewAlertManager(&config.Config{AlertHistorySize: 3}, &recordingBackend{})
+
+	for i := 1; i <= 5; i++ {
+		alert := &Alert{ID: fmt.Sprintf("alert-%d", i), Type: "test", Severity: "info", Timestamp: time.Now()}
+		if err := am.send(context.Background(), alert); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{limit: 0, want: []string{"alert-5", "alert-4", "alert-3"}},
+		{limit: 2, want: []string{"alert-5", "alert-4"}},
+		{limit: 10, want: []string{"alert-5", "alert-4", "alert-3"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			history := am.GetHistory(tt.limit)
+			var got []string
+			for _, alert := range history {
+				got = append(got, alert.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAlertHistorySkipsFailedSends(t *testing.T) {
+	am := NewAlertManager(&config.Config{}, &failingBackend{err: fmt.Errorf("backend down")})
+
+	am.send(context.Background(), &Alert{ID: "alert-1", Type: "test", Timestamp: time.Now()})
+	if history := am.GetHistory(0); len(history) != 0 {
+		t.Errorf("Expected only sent alerts in the history, got %d", len(history))
+	}
+}
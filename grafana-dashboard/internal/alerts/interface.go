@@ -3,7 +3,9 @@ package alerts
 import (
 	"context"
 	"fmt"
+	"shared/severity"
 	"sync"
+	"sync/atomic"
 	"system-monitor/internal/config"
 	"system-monitor/internal/datasource"
 	"time"
@@ -42,25 +44,80 @@ type Alert struct {
 	Severity  string                 `json:"severity"`
 	Timestamp time.Time              `json:"timestamp"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+
+	// Explanation is set for threshold alerts and describes why they fired
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// Style returns how the alert's severity is rendered
+func (a *Alert) Style() severity.Style {
+	return severity.Lookup(a.Severity)
+}
+
+// Thresholds are the levels above which CPU, memory and latency alerts fire
+type Thresholds struct {
+	CPU     float64 `json:"cpu_threshold"`
+	Memory  float64 `json:"memory_threshold"`
+	Latency int64   `json:"latency_threshold"`
+}
+
+// ThresholdUpdate changes some of the alert thresholds. Nil fields keep their
+// current values.
+type ThresholdUpdate struct {
+	CPU     *float64
+	Memory  *float64
+	Latency *int64
 }
 
+// DefaultAlertSendTimeout bounds how long the backend gets to send one alert
+const DefaultAlertSendTimeout = 10 * time.Second
+
 // AlertManager manages alert processing
 type AlertManager struct {
-	config    *config.Config
-	backend   AlertBackend
-	state     AlertState
-	lastAlert map[string]time.Time
-	mu        sync.RWMutex
-	stopChan  chan struct{}
+	config      *config.Config
+	backend     AlertBackend
+	stopChan    chan struct{}
+	sendTimeout time.Duration
+
+	// processMu serializes ProcessMetrics, so an alert still being sent is not
+	// fired again by the next metrics before it is recorded
+	processMu sync.Mutex
+
+	// mu guards state, lastAlert, breaches and thresholds; read them through
+	// GetAlertState, LastAlert and Thresholds. It is never held while the
+	// backend sends, so a slow backend does not block readers.
+	mu         sync.RWMutex
+	state      AlertState
+	lastAlert  map[string]time.Time
+	thresholds Thresholds
+	// breaches counts consecutive samples over each alert's threshold
+	breaches map[string]int
+
+	// sent counts alerts the backend accepted, and history keeps the latest of them
+	sent    atomic.Uint64
+	history *alertHistory
 }
 
 // NewAlertManager creates a new alert manager
 func NewAlertManager(config *config.Config, backend AlertBackend) *AlertManager {
+	historySize := DefaultAlertHistorySize
+	var thresholds Thresholds
+	if config != nil {
+		if config.AlertHistorySize > 0 {
+			historySize = config.AlertHistorySize
+		}
+		thresholds = Thresholds{CPU: config.CPUThreshold, Memory: config.MemoryThreshold, Latency: config.LatencyThreshold}
+	}
+
 	return &AlertManager{
-		config:    config,
-		backend:   backend,
-		lastAlert: make(map[string]time.Time),
-		stopChan:  make(chan struct{}),
+		config:      config,
+		backend:     backend,
+		lastAlert:   make(map[string]time.Time),
+		breaches:    make(map[string]int),
+		stopChan:    make(chan struct{}),
+		sendTimeout: DefaultAlertSendTimeout,
+		history:     newAlertHistory(historySize),
+		thresholds:  thresholds,
 	}
 }
 
@@ -77,49 +134,162 @@ func (am *AlertManager) Stop() error {
 	return nil
 }
 
-// ProcessMetrics processes metrics and sends alerts if thresholds are exceeded
+// pendingAlert is an alert a check decided to fire, waiting to be sent
+type pendingAlert struct {
+	alert    *Alert
+	alertKey string
+	// name is the metric named in the failure log
+	name string
+	// sent is logged once the backend accepts the alert
+	sent string
+	// mark records the alert in the state once it is sent
+	mark func(state *AlertState)
+}
+
+// ProcessMetrics processes metrics and sends alerts if thresholds are exceeded.
+// Alerts are decided under am.mu but sent after releasing it, each within the
+// send timeout, and recorded once the backend accepts them.
 func (am *AlertManager) ProcessMetrics(metrics *datasource.Metrics) {
+	am.processMu.Lock()
+	defer am.processMu.Unlock()
+
 	am.mu.Lock()
-	defer am.mu.Unlock()
+	var pending []*pendingAlert
+	for _, check := range []func(*datasource.Metrics) *pendingAlert{
+		am.checkCPUAlerts,
+		am.checkMemoryAlerts,
+		am.checkLatencyAlerts,
+	} {
+		if p := check(metrics); p != nil {
+			pending = append(pending, p)
+		}
+	}
+	am.mu.Unlock()
 
-	// Check CPU alerts
-	am.checkCPUAlerts(metrics)
+	for _, p := range pending {
+		if err := am.sendWithTimeout(p.alert); err != nil {
+			logrus.Errorf("Failed to send %s alert: %v", p.name, err)
+			continue
+		}
 
-	// Check memory alerts
-	am.checkMemoryAlerts(metrics)
+		am.mu.Lock()
+		am.lastAlert[p.alertKey] = time.Now()
+		p.mark(&am.state)
+		am.mu.Unlock()
 
-	// Check latency alerts
-	am.checkLatencyAlerts(metrics)
+		logrus.Info(p.sent)
+	}
 }
 
-// GetAlertState returns the current alert state
+// GetAlertState returns a copy of the current alert state
 func (am *AlertManager) GetAlertState() AlertState {
 	am.mu.RLock()
 	defer am.mu.RUnlock()
 	return am.state
 }
 
-// checkCPUAlerts checks CPU usage and sends alerts if needed
-func (am *AlertManager) checkCPUAlerts(metrics *datasource.Metrics) {
+// Thresholds returns the thresholds alerts currently fire at
+func (am *AlertManager) Thresholds() Thresholds {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.thresholds
+}
+
+// UpdateThresholds merges update into the thresholds alerts fire at and
+// returns the result, taking effect from the next processed metrics. The merge
+// is validated and applied in one step, so concurrent updates to different
+// thresholds are not lost. Configured clear thresholds above the new
+// thresholds are treated as equal to them.
+func (am *AlertManager) UpdateThresholds(update ThresholdUpdate) (Thresholds, error) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	thresholds := am.thresholds
+	if update.CPU != nil {
+		thresholds.CPU = *update.CPU
+	}
+	if update.Memory != nil {
+		thresholds.Memory = *update.Memory
+	}
+	if update.Latency != nil {
+		thresholds.Latency = *update.Latency
+	}
+	if err := config.ValidateThresholds(thresholds.CPU, thresholds.Memory, thresholds.Latency); err != nil {
+		return am.thresholds, err
+	}
+	am.thresholds = thresholds
+	return thresholds, nil
+}
+
+// LastAlert returns when the alert for alertKey was last sent, if ever
+func (am *AlertManager) LastAlert(alertKey string) (time.Time, bool) {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	sent, ok := am.lastAlert[alertKey]
+	return sent, ok
+}
+
+// AlertsSent returns how many alerts the backend has accepted
+func (am *AlertManager) AlertsSent() uint64 {
+	return am.sent.Load()
+}
+
+// GetHistory returns up to limit of the most recently sent alerts, newest
+// first. A limit of 0 or less returns every alert still in the history.
+func (am *AlertManager) GetHistory(limit int) []*Alert {
+	return am.history.latest(limit)
+}
+
+// send delivers alert through the backend, counting and recording it if the
+// backend accepts it
+func (am *AlertManager) send(ctx context.Context, alert *Alert) error {
+	if err := am.backend.SendAlert(ctx, alert); err != nil {
+		return err
+	}
+	am.sent.Add(1)
+	am.history.add(alert)
+	return nil
+}
+
+// sendWithTimeout sends alert, giving the backend at most the send timeout
+func (am *AlertManager) sendWithTimeout(alert *Alert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), am.sendTimeout)
+	defer cancel()
+	return am.send(ctx, alert)
+}
+
+// markSent records that the alert for alertKey was just sent
+func (am *AlertManager) markSent(alertKey string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.lastAlert[alertKey] = time.Now()
+}
+
+// checkCPUAlerts checks CPU usage and returns the alert to send, if any.
+// It must be called with am.mu held.
+func (am *AlertManager) checkCPUAlerts(metrics *datasource.Metrics) *pendingAlert {
 	if am.config == nil {
-		return
+		return nil
 	}
 
-	cpuThreshold := am.config.CPUThreshold
+	cpuThreshold := am.thresholds.CPU
 	cpuUsage := metrics.CPU
 
-	// Check if we can send an alert (cooldown period)
 	alertKey := "cpu_warning"
-	if !am.canSendAlert(alertKey) {
-		return
-	}
 
 	// Check CPU threshold
 	if cpuUsage > cpuThreshold {
+		// Wait until the breach has lasted long enough
+		if !am.sustained(alertKey, true) {
+			return nil
+		}
+
 		// Determine severity
-		severity := "warning"
-		if cpuUsage > cpuThreshold*1.5 {
-			severity = "critical"
+		severity, explanation := am.evaluate("cpu", "%", alertKey, "cpu_high_usage", cpuUsage, cpuThreshold, 1.5)
+
+		// Check if we can send an alert (cooldown period)
+		if !am.canSendAlert(alertKey, "cpu_high_usage", severity) {
+			return nil
 		}
 
 		// Create alert
@@ -135,52 +305,59 @@ func (am *AlertManager) checkCPUAlerts(metrics *datasource.Metrics) {
 				"threshold": cpuThreshold,
 				"host":      "localhost",
 			},
+			Explanation: explanation,
 		}
 
-		// Send alert
-		ctx := context.Background()
-		if err := am.backend.SendAlert(ctx, alert); err != nil {
-			logrus.Errorf("Failed to send CPU alert: %v", err)
-			return
+		return &pendingAlert{
+			alert:    alert,
+			alertKey: alertKey,
+			name:     "CPU",
+			sent:     fmt.Sprintf("%s CPU Alert sent: %.1f%% usage (threshold: %.1f%%)", alert.Style().Emoji, cpuUsage, cpuThreshold),
+			mark: func(state *AlertState) {
+				if severity == "critical" {
+					state.CPUCritical = true
+				} else {
+					state.CPUWarning = true
+				}
+			},
 		}
+	} else {
+		am.sustained(alertKey, false)
 
-		// Update state and mark alert as sent
-		am.lastAlert[alertKey] = time.Now()
-		if severity == "critical" {
-			am.state.CPUCritical = true
-		} else {
-			am.state.CPUWarning = true
+		// Reset state once CPU usage drops to the clear threshold
+		if cpuUsage <= clearLevel(am.config.CPUClearThreshold, cpuThreshold) {
+			am.state.CPUWarning = false
+			am.state.CPUCritical = false
 		}
-
-		logrus.Infof("🚨 CPU Alert sent: %.1f%% usage (threshold: %.1f%%)", cpuUsage, cpuThreshold)
-	} else {
-		// Reset state if CPU usage is back to normal
-		am.state.CPUWarning = false
-		am.state.CPUCritical = false
 	}
+	return nil
 }
 
-// checkMemoryAlerts checks memory usage and sends alerts if needed
-func (am *AlertManager) checkMemoryAlerts(metrics *datasource.Metrics) {
+// checkMemoryAlerts checks memory usage and returns the alert to send, if any.
+// It must be called with am.mu held.
+func (am *AlertManager) checkMemoryAlerts(metrics *datasource.Metrics) *pendingAlert {
 	if am.config == nil {
-		return
+		return nil
 	}
 
-	memoryThreshold := am.config.MemoryThreshold
+	memoryThreshold := am.thresholds.Memory
 	memoryUsage := metrics.Memory.Percent
 
-	// Check if we can send an alert (cooldown period)
 	alertKey := "memory_warning"
-	if !am.canSendAlert(alertKey) {
-		return
-	}
 
 	// Check memory threshold
 	if memoryUsage > memoryThreshold {
+		// Wait until the breach has lasted long enough
+		if !am.sustained(alertKey, true) {
+			return nil
+		}
+
 		// Determine severity
-		severity := "warning"
-		if memoryUsage > memoryThreshold*1.2 {
-			severity = "critical"
+		severity, explanation := am.evaluate("memory", "%", alertKey, "memory_high_usage", memoryUsage, memoryThreshold, 1.2)
+
+		// Check if we can send an alert (cooldown period)
+		if !am.canSendAlert(alertKey, "memory_high_usage", severity) {
+			return nil
 		}
 
 		// Create alert
@@ -196,52 +373,59 @@ func (am *AlertManager) checkMemoryAlerts(metrics *datasource.Metrics) {
 				"threshold":    memoryThreshold,
 				"host":         "localhost",
 			},
+			Explanation: explanation,
 		}
 
-		// Send alert
-		ctx := context.Background()
-		if err := am.backend.SendAlert(ctx, alert); err != nil {
-			logrus.Errorf("Failed to send memory alert: %v", err)
-			return
+		return &pendingAlert{
+			alert:    alert,
+			alertKey: alertKey,
+			name:     "memory",
+			sent:     fmt.Sprintf("%s Memory Alert sent: %.1f%% usage (threshold: %.1f%%)", alert.Style().Emoji, memoryUsage, memoryThreshold),
+			mark: func(state *AlertState) {
+				if severity == "critical" {
+					state.MemoryCritical = true
+				} else {
+					state.MemoryWarning = true
+				}
+			},
 		}
+	} else {
+		am.sustained(alertKey, false)
 
-		// Update state and mark alert as sent
-		am.lastAlert[alertKey] = time.Now()
-		if severity == "critical" {
-			am.state.MemoryCritical = true
-		} else {
-			am.state.MemoryWarning = true
+		// Reset state once memory usage drops to the clear threshold
+		if memoryUsage <= clearLevel(am.config.MemoryClearThreshold, memoryThreshold) {
+			am.state.MemoryWarning = false
+			am.state.MemoryCritical = false
 		}
-
-		logrus.Infof("🚨 Memory Alert sent: %.1f%% usage (threshold: %.1f%%)", memoryUsage, memoryThreshold)
-	} else {
-		// Reset state if memory usage is back to normal
-		am.state.MemoryWarning = false
-		am.state.MemoryCritical = false
 	}
+	return nil
 }
 
-// checkLatencyAlerts checks latency and sends alerts if needed
-func (am *AlertManager) checkLatencyAlerts(metrics *datasource.Metrics) {
+// checkLatencyAlerts checks latency and returns the alert to send, if any.
+// It must be called with am.mu held.
+func (am *AlertManager) checkLatencyAlerts(metrics *datasource.Metrics) *pendingAlert {
 	if am.config == nil {
-		return
+		return nil
 	}
 
-	latencyThreshold := am.config.LatencyThreshold
+	latencyThreshold := am.thresholds.Latency
 	latency := metrics.Latency.HTTPLatency
 
-	// Check if we can send an alert (cooldown period)
 	alertKey := "latency_warning"
-	if !am.canSendAlert(alertKey) {
-		return
-	}
 
 	// Check latency threshold
 	if latency > latencyThreshold {
+		// Wait until the breach has lasted long enough
+		if !am.sustained(alertKey, true) {
+			return nil
+		}
+
 		// Determine severity
-		severity := "warning"
-		if latency > latencyThreshold*2 {
-			severity = "critical"
+		severity, explanation := am.evaluate("http_latency", "ms", alertKey, "latency_high", float64(latency), float64(latencyThreshold), 2)
+
+		// Check if we can send an alert (cooldown period)
+		if !am.canSendAlert(alertKey, "latency_high", severity) {
+			return nil
 		}
 
 		// Create alert
@@ -257,29 +441,32 @@ func (am *AlertManager) checkLatencyAlerts(metrics *datasource.Metrics) {
 				"threshold": latencyThreshold,
 				"host":      "localhost",
 			},
+			Explanation: explanation,
 		}
 
-		// Send alert
-		ctx := context.Background()
-		if err := am.backend.SendAlert(ctx, alert); err != nil {
-			logrus.Errorf("Failed to send latency alert: %v", err)
-			return
+		return &pendingAlert{
+			alert:    alert,
+			alertKey: alertKey,
+			name:     "latency",
+			sent:     fmt.Sprintf("%s Latency Alert sent: %dms (threshold: %dms)", alert.Style().Emoji, latency, latencyThreshold),
+			mark: func(state *AlertState) {
+				if severity == "critical" {
+					state.LatencyCritical = true
+				} else {
+					state.LatencyWarning = true
+				}
+			},
 		}
+	} else {
+		am.sustained(alertKey, false)
 
-		// Update state and mark alert as sent
-		am.lastAlert[alertKey] = time.Now()
-		if severity == "critical" {
-			am.state.LatencyCritical = true
-		} else {
-			am.state.LatencyWarning = true
+		// Reset state once latency drops to the clear threshold
+		if latency <= clearLevel(am.config.LatencyClearThreshold, latencyThreshold) {
+			am.state.LatencyWarning = false
+			am.state.LatencyCritical = false
 		}
-
-		logrus.Infof("🚨 Latency Alert sent: %dms (threshold: %dms)", latency, latencyThreshold)
-	} else {
-		// Reset state if latency is back to normal
-		am.state.LatencyWarning = false
-		am.state.LatencyCritical = false
 	}
+	return nil
 }
 
 // sendStartupAlert sends a startup notification
@@ -302,10 +489,12 @@ func (am *AlertManager) sendStartupAlert() {
 		},
 	}
 
-	ctx := context.Background()
-	if err := am.backend.SendAlert(ctx, alert); err != nil {
+	// Sent without holding am.mu, so a slow backend does not block GetAlertState
+	if err := am.sendWithTimeout(alert); err != nil {
 		logrus.Errorf("Failed to send startup alert: %v", err)
+		return
 	}
+	am.markSent(alert.Type)
 }
 
 // sendShutdownAlert sends a shutdown notification
@@ -326,14 +515,38 @@ func (am *AlertManager) sendShutdownAlert() {
 		},
 	}
 
-	ctx := context.Background()
-	if err := am.backend.SendAlert(ctx, alert); err != nil {
+	if err := am.sendWithTimeout(alert); err != nil {
 		logrus.Errorf("Failed to send shutdown alert: %v", err)
+		return
+	}
+	am.markSent(alert.Type)
+}
+
+// sustained records whether the latest sample for alertKey breached its
+// threshold and reports whether enough consecutive samples have, so the alert
+// may fire. It must be called with am.mu held.
+func (am *AlertManager) sustained(alertKey string, breached bool) bool {
+	if !breached {
+		am.breaches[alertKey] = 0
+		return false
+	}
+	am.breaches[alertKey]++
+	return am.breaches[alertKey] >= max(am.config.AlertFireAfter, 1)
+}
+
+// clearLevel returns the level an active alert clears at: the configured clear
+// threshold, or the firing threshold when none is set or it is higher
+func clearLevel[T float64 | int64](clear, threshold T) T {
+	if clear == 0 || clear > threshold {
+		return threshold
 	}
+	return clear
 }
 
-// canSendAlert checks if enough time has passed since the last alert
-func (am *AlertManager) canSendAlert(alertKey string) bool {
+// canSendAlert checks if enough time has passed since the last alert, using the
+// cooldown configured for the alert's type or severity. It must be called with
+// am.mu held.
+func (am *AlertManager) canSendAlert(alertKey, alertType, severity string) bool {
 	if am.config == nil {
 		return false
 	}
@@ -343,7 +556,7 @@ func (am *AlertManager) canSendAlert(alertKey string) bool {
 		return true
 	}
 
-	cooldown := am.config.AlertCooldown
+	cooldown := am.config.CooldownFor(alertType, severity)
 	return time.Since(lastAlertTime) > cooldown
 }
 
@@ -0,0 +1,351 @@
+package alerts
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"system-monitor/internal/config"
+	"system-monitor/internal/datasource"
+	"testing"
+	"time"
+)
+
+// recordingBackend records every alert it is asked to send
+type recordingBackend struct {
+	mu     sync.Mutex
+	alerts []*Alert
+}
+
+func (r *recordingBackend) SendAlert(ctx context.Context, alert *Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func (r *recordingBackend) HealthCheck(ctx context.Context) error { return nil }
+
+func (r *recordingBackend) Close() error { return nil }
+
+func (r *recordingBackend) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.alerts)
+}
+
+func TestCooldownOverridesPerSeverity(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:     50,
+		MemoryThreshold:  100,
+		LatencyThreshold: 1 << 40,
+		AlertCooldown:    time.Hour,
+		AlertCooldownOverrides: map[string]time.Duration{
+			"critical": time.Millisecond,
+			"warning":  2 * time.Hour,
+		},
+	}
+
+	tests := []struct {
+		name string
+		cpu  float64
+		want int
+	}{
+		{name: "critical re-fires after short cooldown", cpu: 90, want: 2},
+		{name: "warning suppressed within global window", cpu: 60, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &recordingBackend{}
+			am := NewAlertManager(cfg, backend)
+			metrics := &datasource.Metrics{CPU: tt.cpu}
+
+			am.ProcessMetrics(metrics)
+			time.Sleep(5 * time.Millisecond)
+			am.ProcessMetrics(metrics)
+
+			if got := backend.count(); got != tt.want {
+				t.Errorf("Expected %d alerts, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAlertExplanation(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:     50,
+		MemoryThreshold:  100,
+		LatencyThreshold: 1 << 40,
+		AlertCooldown:    time.Hour,
+		AlertCooldownOverrides: map[string]time.Duration{
+			"critical": time.Millisecond,
+		},
+	}
+	backend := &recordingBackend{}
+	am := NewAlertManager(cfg, backend)
+
+	// A first breach below the critical bound fires a warning
+	am.ProcessMetrics(&datasource.Metrics{CPU: 60})
+	if got := backend.count(); got != 1 {
+		t.Fatalf("Expected 1 alert, got %d", got)
+	}
+	warning := backend.alerts[0]
+	if warning.Severity != "warning" {
+		t.Errorf("Expected severity warning, got %s", warning.Severity)
+	}
+	exp := warning.Explanation
+	if exp == nil {
+		t.Fatal("Expected warning alert to carry an explanation")
+	}
+	if exp.Metric != "cpu" || exp.Observed != 60 || exp.Threshold != 50 || exp.Operator != ">" || exp.CriticalFactor != 1.5 {
+		t.Errorf("Expected cpu 60 > 50 with factor 1.5, got %+v", exp)
+	}
+	if !strings.HasPrefix(exp.SeverityRule, "warning because critical requires > 75.0%") {
+		t.Errorf("Expected warning severity rule, got %q", exp.SeverityRule)
+	}
+	if exp.Cooldown != time.Hour {
+		t.Errorf("Expected cooldown %v, got %v", time.Hour, exp.Cooldown)
+	}
+	if exp.LastAlert != nil {
+		t.Errorf("Expected no previous alert, got %v", exp.LastAlert)
+	}
+
+	// Escalating past the critical bound fires again once the critical cooldown has passed
+	time.Sleep(5 * time.Millisecond)
+	am.ProcessMetrics(&datasource.Metrics{CPU: 90})
+	if got := backend.count(); got != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", got)
+	}
+	critical := backend.alerts[1]
+	if critical.Severity != "critical" {
+		t.Errorf("Expected severity critical, got %s", critical.Severity)
+	}
+	exp = critical.Explanation
+	if exp == nil {
+		t.Fatal("Expected critical alert to carry an explanation")
+	}
+	if exp.Observed != 90 || exp.Threshold != 50 {
+		t.Errorf("Expected cpu 90 > 50, got %+v", exp)
+	}
+	if !strings.HasPrefix(exp.SeverityRule, "critical because critical requires > 75.0%") {
+		t.Errorf("Expected critical severity rule, got %q", exp.SeverityRule)
+	}
+	if exp.Cooldown != time.Millisecond {
+		t.Errorf("Expected critical cooldown %v, got %v", time.Millisecond, exp.Cooldown)
+	}
+	if exp.LastAlert == nil {
+		t.Error("Expected the earlier warning to be recorded as the last alert")
+	}
+	if s := exp.String(); !strings.Contains(s, "cpu 90.0% > 50.0%") || !strings.Contains(s, "last alert") {
+		t.Errorf("Expected rendered explanation to describe the comparison, got %q", s)
+	}
+}
+
+func TestAlertStateConcurrentAccess(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:     50,
+		MemoryThreshold:  50,
+		LatencyThreshold: 100,
+		AlertCooldown:    time.Nanosecond,
+	}
+	backend := &recordingBackend{}
+	am := NewAlertManager(cfg, backend)
+
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				fn(i)
+			}
+		}()
+	}
+
+	run(func(int) { am.Start() })
+	run(func(int) { am.Stop() })
+	run(func(i int) {
+		// Alternate between breaching and recovering so state flips both ways
+		if i%2 == 0 {
+			am.ProcessMetrics(&datasource.Metrics{
+				CPU:     90,
+				Memory:  datasource.MemoryInfo{Percent: 90},
+				Latency: datasource.LatencyInfo{HTTPLatency: 500},
+			})
+		} else {
+			am.ProcessMetrics(&datasource.Metrics{})
+		}
+	})
+	run(func(int) {
+		_ = am.GetAlertState()
+		am.LastAlert("system_startup")
+	})
+	wg.Wait()
+
+	if _, ok := am.LastAlert("system_startup"); !ok {
+		t.Error("Expected the startup alert to be recorded")
+	}
+	if _, ok := am.LastAlert("system_shutdown"); !ok {
+		t.Error("Expected the shutdown alert to be recorded")
+	}
+	if state := am.GetAlertState(); state.CPUWarning || state.CPUCritical {
+		t.Errorf("Expected CPU state to be clear after recovering, got %+v", state)
+	}
+}
+
+func TestHysteresisSuppressesFlapping(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:      80,
+		CPUClearThreshold: 70,
+		MemoryThreshold:   100,
+		LatencyThreshold:  1 << 40,
+		AlertFireAfter:    3,
+		// Short enough that the cooldown alone would not stop repeats
+		AlertCooldown: time.Nanosecond,
+	}
+	backend := &recordingBackend{}
+	am := NewAlertManager(cfg, backend)
+
+	process := func(cpu float64) {
+		time.Sleep(time.Millisecond)
+		am.ProcessMetrics(&datasource.Metrics{CPU: cpu})
+	}
+
+	// Oscillating around the threshold never breaches for 3 samples in a row
+	for i := 0; i < 10; i++ {
+		process(85)
+		process(75)
+	}
+	if got := backend.count(); got != 0 {
+		t.Fatalf("Expected no alerts while oscillating, got %d", got)
+	}
+
+	// A sustained breach fires once
+	process(85)
+	process(85)
+	process(85)
+	if got := backend.count(); got != 1 {
+		t.Fatalf("Expected 1 alert after a sustained breach, got %d", got)
+	}
+
+	// Oscillating above the clear threshold neither fires again nor clears
+	for i := 0; i < 10; i++ {
+		process(75)
+		process(85)
+	}
+	if got := backend.count(); got != 1 {
+		t.Errorf("Expected no more alerts while oscillating, got %d", got)
+	}
+	if state := am.GetAlertState(); !state.CPUWarning {
+		t.Errorf("Expected the CPU alert to stay active above the clear threshold, got %+v", state)
+	}
+
+	process(65)
+	if state := am.GetAlertState(); state.CPUWarning || state.CPUCritical {
+		t.Errorf("Expected the CPU alert to clear at the clear threshold, got %+v", state)
+	}
+}
+
+// blockingBackend holds every alert until release is closed or the send's
+// context is done
+type blockingBackend struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newBlockingBackend() *blockingBackend {
+	return &blockingBackend{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (b *blockingBackend) SendAlert(ctx context.Context, alert *Alert) error {
+	b.once.Do(func() { close(b.started) })
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *blockingBackend) HealthCheck(ctx context.Context) error { return nil }
+
+func (b *blockingBackend) Close() error { return nil }
+
+func TestSlowBackendDoesNotBlockReaders(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:     50,
+		MemoryThreshold:  100,
+		LatencyThreshold: 1 << 40,
+		AlertCooldown:    time.Hour,
+	}
+	backend := newBlockingBackend()
+	am := NewAlertManager(cfg, backend)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		am.ProcessMetrics(&datasource.Metrics{CPU: 90})
+	}()
+	<-backend.started
+
+	// State, thresholds and threshold updates stay available while the alert is sent
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		if state := am.GetAlertState(); state.CPUCritical {
+			t.Errorf("Expected the alert to be recorded only once sent, got %+v", state)
+		}
+		am.Thresholds()
+		cpu := 60.0
+		if _, err := am.UpdateThresholds(ThresholdUpdate{CPU: &cpu}); err != nil {
+			t.Errorf("Expected no error updating thresholds, got %v", err)
+		}
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("Expected readers not to wait for the backend")
+	}
+
+	close(backend.release)
+	<-done
+	if state := am.GetAlertState(); !state.CPUCritical {
+		t.Errorf("Expected the sent alert to be recorded, got %+v", state)
+	}
+	if _, ok := am.LastAlert("cpu_warning"); !ok {
+		t.Error("Expected the sent alert to start its cooldown")
+	}
+}
+
+func TestAlertSendTimeout(t *testing.T) {
+	cfg := &config.Config{
+		CPUThreshold:     50,
+		MemoryThreshold:  100,
+		LatencyThreshold: 1 << 40,
+		AlertCooldown:    time.Hour,
+	}
+	am := NewAlertManager(cfg, newBlockingBackend())
+	am.sendTimeout = 10 * time.Millisecond
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		am.ProcessMetrics(&datasource.Metrics{CPU: 90})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the send to give up after the send timeout")
+	}
+
+	if state := am.GetAlertState(); state.CPUWarning || state.CPUCritical {
+		t.Errorf("Expected a failed send not to be recorded, got %+v", state)
+	}
+	if _, ok := am.LastAlert("cpu_warning"); ok {
+		t.Error("Expected a failed send not to start the cooldown")
+	}
+	if got := am.AlertsSent(); got != 0 {
+		t.Errorf("Expected 0 alerts sent, got %d", got)
+	}
+}
@@ -0,0 +1,57 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MultiAlertBackend implements AlertBackend by fanning every call out to a set
+// of backends. Alerts are sent to all of them concurrently, so a failing or
+// slow backend does not hold up the others.
+type MultiAlertBackend struct {
+	backends []AlertBackend
+}
+
+// NewMultiAlertBackend creates a backend that sends to every one of backends
+func NewMultiAlertBackend(backends ...AlertBackend) *MultiAlertBackend {
+	return &MultiAlertBackend{backends: backends}
+}
+
+// SendAlert sends the alert to every backend and returns their errors joined
+func (m *MultiAlertBackend) SendAlert(ctx context.Context, alert *Alert) error {
+	return m.each(func(backend AlertBackend) error {
+		return backend.SendAlert(ctx, alert)
+	})
+}
+
+// HealthCheck reports healthy only if every backend is
+func (m *MultiAlertBackend) HealthCheck(ctx context.Context) error {
+	return m.each(func(backend AlertBackend) error {
+		return backend.HealthCheck(ctx)
+	})
+}
+
+// Close closes every backend, even if closing one of them fails
+func (m *MultiAlertBackend) Close() error {
+	return m.each(AlertBackend.Close)
+}
+
+// each calls fn for every backend concurrently and joins the errors, each
+// prefixed with the backend's type
+func (m *MultiAlertBackend) each(fn func(AlertBackend) error) error {
+	errs := make([]error, len(m.backends))
+	var wg sync.WaitGroup
+	for i, backend := range m.backends {
+		wg.Add(1)
+		go func(i int, backend AlertBackend) {
+			defer wg.Done()
+			if err := fn(backend); err != nil {
+				errs[i] = fmt.Errorf("%T: %w", backend, err)
+			}
+		}(i, backend)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
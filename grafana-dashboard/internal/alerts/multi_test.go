@@ -0,0 +1,79 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"system-monitor/internal/config"
+	"testing"
+	"time"
+)
+
+// failingBackend fails every call with err
+type failingBackend struct {
+	err    error
+	closed bool
+}
+
+func (f *failingBackend) SendAlert(ctx context.Context, alert *Alert) error { return f.err }
+
+func (f *failingBackend) HealthCheck(ctx context.Context) error { return f.err }
+
+func (f *failingBackend) Close() error {
+	f.closed = true
+	return f.err
+}
+
+func TestMultiAlertBackendSendsToAll(t *testing.T) {
+	first, second := &recordingBackend{}, &recordingBackend{}
+	multi := NewMultiAlertBackend(first, second)
+
+	alert := &Alert{Type: "cpu_high_usage", Title: "High CPU Usage", Severity: "critical", Timestamp: time.Now()}
+	if err := multi.SendAlert(context.Background(), alert); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.count() != 1 || second.count() != 1 {
+		t.Errorf("Expected both backends to receive the alert, got %d and %d", first.count(), second.count())
+	}
+	if err := multi.HealthCheck(context.Background()); err != nil {
+		t.Errorf("Expected healthy backends, got %v", err)
+	}
+}
+
+func TestMultiAlertBackendFailureDoesNotBlockOthers(t *testing.T) {
+	down := &failingBackend{err: errors.New("backend down")}
+	up := &recordingBackend{}
+	multi := NewMultiAlertBackend(down, up)
+
+	alert := &Alert{Type: "cpu_high_usage", Title: "High CPU Usage", Severity: "critical", Timestamp: time.Now()}
+	err := multi.SendAlert(context.Background(), alert)
+	if err == nil || !errors.Is(err, down.err) {
+		t.Errorf("Expected the failing backend's error, got %v", err)
+	}
+	if up.count() != 1 {
+		t.Errorf("Expected the healthy backend to still receive the alert, got %d", up.count())
+	}
+	if err := multi.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected unhealthy when one backend is unhealthy, got nil")
+	}
+	if err := multi.Close(); err == nil || !down.closed {
+		t.Errorf("Expected Close to close every backend and report the failure, got %v", err)
+	}
+}
+
+func TestFactoryCreatesMultiBackend(t *testing.T) {
+	cfg := &config.Config{AlertBackendType: "noop, noop"}
+	backend, err := NewAlertBackendFactory().CreateAlertBackend(cfg)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	multi, ok := backend.(*MultiAlertBackend)
+	if !ok || len(multi.backends) != 2 {
+		t.Fatalf("Expected a MultiAlertBackend with 2 backends, got %#v", backend)
+	}
+
+	cfg.AlertBackendType = "noop,carrier-pigeon"
+	if _, err := NewAlertBackendFactory().CreateAlertBackend(cfg); err == nil || !strings.Contains(err.Error(), "carrier-pigeon") {
+		t.Errorf("Expected an unsupported type error, got %v", err)
+	}
+}
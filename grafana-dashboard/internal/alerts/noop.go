@@ -16,8 +16,12 @@ func NewNoOpAlertBackend() *NoOpAlertBackend {
 
 // SendAlert logs the alert but doesn't send it anywhere
 func (n *NoOpAlertBackend) SendAlert(ctx context.Context, alert *Alert) error {
-	logrus.Infof("🚨 [NO-OP] Alert would be sent: %s - %s (Severity: %s)",
-		alert.Type, alert.Title, alert.Severity)
+	logrus.Infof("%s [NO-OP] Alert would be sent: %s - %s (Severity: %s)",
+		alert.Style().Emoji, alert.Type, alert.Title, alert.Style().Label)
+
+	if alert.Explanation != nil {
+		logrus.Infof("🔎 Why: %s", alert.Explanation)
+	}
 
 	if len(alert.Metadata) > 0 {
 		logrus.Infof("📋 Alert metadata: %+v", alert.Metadata)
@@ -34,24 +34,18 @@ func NewSlackAlertBackend(token, channel string) (*SlackAlertBackend, error) {
 	}, nil
 }
 
-// SendAlert sends an alert to Slack
+// SendAlert sends an alert to Slack, in an attachment colored by severity
 func (sab *SlackAlertBackend) SendAlert(ctx context.Context, alert *Alert) error {
-	// Build the message
-	text := fmt.Sprintf("%s *%s*\n%s", getEmoji(alert.Severity), alert.Title, alert.Message)
-
-	// Add metadata
-	if len(alert.Metadata) > 0 {
-		text += "\n\n*Details:*\n"
-		for key, value := range alert.Metadata {
-			text += fmt.Sprintf("• %s: %v\n", key, value)
-		}
-	}
-
-	text += fmt.Sprintf("\n*Timestamp:* %s", alert.Timestamp.Format(time.RFC3339))
+	style := alert.Style()
 
 	// Send to Slack
 	_, _, err := sab.client.PostMessageContext(ctx, sab.channel,
-		slack.MsgOptionText(text, false),
+		slack.MsgOptionAttachments(slack.Attachment{
+			Color:      style.Color,
+			Fallback:   fmt.Sprintf("%s: %s", style.Label, alert.Title),
+			Text:       formatAlert(alert),
+			MarkdownIn: []string{"text"},
+		}),
 		slack.MsgOptionUsername("System Monitor"),
 		slack.MsgOptionIconEmoji(":computer:"),
 	)
@@ -64,6 +58,28 @@ func (sab *SlackAlertBackend) SendAlert(ctx context.Context, alert *Alert) error
 	return nil
 }
 
+// formatAlert renders the alert as Slack message text
+func formatAlert(alert *Alert) string {
+	style := alert.Style()
+	text := fmt.Sprintf("%s *%s* (%s)\n%s", style.Emoji, alert.Title, style.Label, alert.Message)
+
+	// Explain the evaluation that triggered the alert
+	if alert.Explanation != nil {
+		text += fmt.Sprintf("\n*Why:* %s", alert.Explanation)
+	}
+
+	// Add metadata
+	if len(alert.Metadata) > 0 {
+		text += "\n\n*Details:*\n"
+		for key, value := range alert.Metadata {
+			text += fmt.Sprintf("• %s: %v\n", key, value)
+		}
+	}
+
+	text += fmt.Sprintf("\n*Timestamp:* %s", alert.Timestamp.Format(time.RFC3339))
+	return text
+}
+
 // HealthCheck checks if the Slack backend is healthy
 func (sab *SlackAlertBackend) HealthCheck(ctx context.Context) error {
 	_, err := sab.client.AuthTestContext(ctx)
@@ -75,19 +91,3 @@ func (sab *SlackAlertBackend) Close() error {
 	// No specific cleanup needed for Slack client
 	return nil
 }
-
-// getEmoji returns the appropriate emoji for the alert severity
-func getEmoji(severity string) string {
-	switch severity {
-	case "critical":
-		return "🚨"
-	case "warning":
-		return "⚠️"
-	case "info":
-		return "ℹ️"
-	case "success":
-		return "✅"
-	default:
-		return "ℹ️"
-	}
-}
@@ -0,0 +1,34 @@
+package alerts
+
+import (
+	"shared/severity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatAlertSeverityStyle(t *testing.T) {
+	alert := &Alert{
+		Title:     "High CPU",
+		Message:   "CPU usage is 97%",
+		Severity:  "critical",
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	if text := formatAlert(alert); !strings.HasPrefix(text, "🚨 *High CPU* (Critical)\nCPU usage is 97%") {
+		t.Errorf("Expected default critical rendering, got %q", text)
+	}
+	if color := alert.Style().Color; color != "#A30200" {
+		t.Errorf("Expected default critical color #A30200, got %s", color)
+	}
+
+	t.Cleanup(severity.Reset)
+	severity.Register("critical", severity.Style{Color: "#FF0000", Emoji: ":fire:", Label: "Page"})
+
+	if text := formatAlert(alert); !strings.HasPrefix(text, ":fire: *High CPU* (Page)\n") {
+		t.Errorf("Expected the registered override, got %q", text)
+	}
+	if color := alert.Style().Color; color != "#FF0000" {
+		t.Errorf("Expected overridden color #FF0000, got %s", color)
+	}
+}
@@ -26,43 +26,91 @@ const (
 	AlertBackendWebhook AlertBackendType = "webhook"
 )
 
-// Config holds all configuration for the monitoring system
+// Split returns the individual backend types of a comma-separated list such as
+// "slack,webhook". A single type is returned as a list of one.
+func (t AlertBackendType) Split() []AlertBackendType {
+	var types []AlertBackendType
+	for _, part := range strings.Split(string(t), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			types = append(types, AlertBackendType(part))
+		}
+	}
+	return types
+}
+
+// Config holds all configuration for the monitoring system. Fields tagged
+// secret:"true" hold credentials and are masked by Redacted.
 type Config struct {
 	// Data Source Configuration
-	DataSourceType DataSourceType
-	DataSourceURL  string
+	DataSourceType DataSourceType `json:"data_source_type"`
+	DataSourceURL  string         `json:"data_source_url"`
 
 	// Grafana Configuration
-	GrafanaURL      string
-	GrafanaUsername string
-	GrafanaPassword string
-	GrafanaAPIKey   string
+	GrafanaURL      string `json:"grafana_url"`
+	GrafanaUsername string `json:"grafana_username"`
+	GrafanaPassword string `json:"grafana_password" secret:"true"`
+	GrafanaAPIKey   string `json:"grafana_api_key" secret:"true"`
 
 	// Prometheus Configuration
-	PrometheusURL string
+	PrometheusURL string `json:"prometheus_url"`
+
+	// Remote data source health: consecutive failed checks before it is
+	// reported unhealthy, and consecutive successful ones before it recovers
+	DataSourceHealthFailures   int `json:"data_source_health_failures"`
+	DataSourceHealthRecoveries int `json:"data_source_health_recoveries"`
 
 	// Alert Configuration
-	AlertBackendType AlertBackendType
-	SlackBotToken    string
-	SlackChannel     string
-	WebhookURL       string
+	AlertBackendType AlertBackendType `json:"alert_backend_type"`
+	SlackBotToken    string           `json:"slack_bot_token" secret:"true"`
+	SlackChannel     string           `json:"slack_channel"`
+	WebhookURL       string           `json:"webhook_url" secret:"true"` // webhook URLs usually embed a token
+
+	// Email alert backend: SMTP server, credentials (optional) and envelope
+	SMTPHost     string   `json:"smtp_host"`
+	SMTPPort     string   `json:"smtp_port"`
+	SMTPUsername string   `json:"smtp_username"`
+	SMTPPassword string   `json:"smtp_password" secret:"true"`
+	EmailFrom    string   `json:"email_from"`
+	EmailTo      []string `json:"email_to"`
 
 	// Threshold Configuration
-	CPUThreshold     float64
-	MemoryThreshold  float64
-	LatencyThreshold int64
+	CPUThreshold     float64 `json:"cpu_threshold"`
+	MemoryThreshold  float64 `json:"memory_threshold"`
+	LatencyThreshold int64   `json:"latency_threshold"`
+	// Clear thresholds: an active alert only clears once its metric drops to
+	// this level, so values hovering around the threshold do not flap. 0 means
+	// the same as the firing threshold.
+	CPUClearThreshold     float64 `json:"cpu_clear_threshold"`
+	MemoryClearThreshold  float64 `json:"memory_clear_threshold"`
+	LatencyClearThreshold int64   `json:"latency_clear_threshold"`
+	// AlertFireAfter is how many consecutive samples must breach a threshold
+	// before its alert fires
+	AlertFireAfter int `json:"alert_fire_after"`
 
 	// Alert Settings
-	AlertCooldown time.Duration
+	AlertCooldown time.Duration `json:"alert_cooldown"`
+	// AlertCooldownOverrides maps an alert severity (e.g. "critical") or alert
+	// type (e.g. "cpu_high_usage") to its own cooldown; type wins over severity
+	AlertCooldownOverrides map[string]time.Duration `json:"alert_cooldown_overrides"`
+	// AlertHistorySize is how many sent alerts are kept for GET /api/alerts/history
+	AlertHistorySize int `json:"alert_history_size"`
 
 	// Dashboard Settings
-	DashboardPort string
+	DashboardPort string `json:"dashboard_port"`
+	TLSCertFile   string `json:"tls_cert_file"`
+	TLSKeyFile    string `json:"tls_key_file"`
+	// AdminToken guards admin endpoints such as GET /config; empty disables them
+	AdminToken string `json:"admin_token" secret:"true"`
 
 	// Metrics Collection
-	MetricsInterval time.Duration
+	MetricsInterval time.Duration `json:"metrics_interval"`
+	// MetricsHotWindow is how long raw samples are kept before being rolled up
+	// into MetricsDownsampleResolution averages; 0 disables downsampling
+	MetricsHotWindow            time.Duration `json:"metrics_hot_window"`
+	MetricsDownsampleResolution time.Duration `json:"metrics_downsample_resolution"`
 
 	// Environment
-	Environment string
+	Environment string `json:"environment"`
 }
 
 // LoadConfig loads configuration from environment variables
@@ -84,10 +132,38 @@ func LoadConfig() (*Config, error) {
 		LatencyThreshold: getEnvAsInt64("LATENCY_THRESHOLD", 500),
 		AlertCooldown:    getEnvAsDuration("ALERT_COOLDOWN", 5*time.Minute),
 		DashboardPort:    getEnv("DASHBOARD_PORT", "8080"),
+		TLSCertFile:      getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:       getEnv("TLS_KEY_FILE", ""),
+		AdminToken:       getEnv("ADMIN_TOKEN", ""),
 		MetricsInterval:  getEnvAsDuration("METRICS_INTERVAL", 5*time.Second),
 		Environment:      getEnv("ENVIRONMENT", "development"),
 	}
 
+	config.SMTPHost = getEnv("SMTP_HOST", "")
+	config.SMTPPort = getEnv("SMTP_PORT", "587")
+	config.SMTPUsername = getEnv("SMTP_USERNAME", "")
+	config.SMTPPassword = getEnv("SMTP_PASSWORD", "")
+	config.EmailFrom = getEnv("EMAIL_FROM", "")
+	config.EmailTo = getEnvAsList("EMAIL_TO")
+
+	config.CPUClearThreshold = getEnvAsFloat("CPU_CLEAR_THRESHOLD", 0)
+	config.MemoryClearThreshold = getEnvAsFloat("MEMORY_CLEAR_THRESHOLD", 0)
+	config.LatencyClearThreshold = getEnvAsInt64("LATENCY_CLEAR_THRESHOLD", 0)
+	config.AlertFireAfter = int(getEnvAsInt64("ALERT_FIRE_AFTER", 1))
+	config.AlertHistorySize = int(getEnvAsInt64("ALERT_HISTORY_SIZE", 100))
+
+	config.DataSourceHealthFailures = int(getEnvAsInt64("DATA_SOURCE_HEALTH_FAILURES", 3))
+	config.DataSourceHealthRecoveries = int(getEnvAsInt64("DATA_SOURCE_HEALTH_RECOVERIES", 2))
+
+	config.MetricsHotWindow = getEnvAsDuration("METRICS_HOT_WINDOW", 0)
+	config.MetricsDownsampleResolution = getEnvAsDuration("METRICS_DOWNSAMPLE_RESOLUTION", time.Minute)
+
+	overrides, err := parseCooldownOverrides(os.Getenv("ALERT_COOLDOWN_OVERRIDES"))
+	if err != nil {
+		return nil, err
+	}
+	config.AlertCooldownOverrides = overrides
+
 	// Validate configuration based on data source type
 	if err := config.validateDataSourceConfig(); err != nil {
 		return nil, err
@@ -103,6 +179,21 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Validate TLS settings
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	// Validate intervals and durations
+	if err := config.validateTimings(); err != nil {
+		return nil, err
+	}
+
+	// Validate alert cooldowns
+	if err := config.validateCooldowns(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
@@ -121,12 +212,28 @@ func (c *Config) validateDataSourceConfig() error {
 			return fmt.Errorf("PROMETHEUS_URL is required when using prometheus data source")
 		}
 	}
+	if c.DataSourceHealthFailures < 1 {
+		return fmt.Errorf("DATA_SOURCE_HEALTH_FAILURES must be at least 1, got %d", c.DataSourceHealthFailures)
+	}
+	if c.DataSourceHealthRecoveries < 1 {
+		return fmt.Errorf("DATA_SOURCE_HEALTH_RECOVERIES must be at least 1, got %d", c.DataSourceHealthRecoveries)
+	}
 	return nil
 }
 
 // validateAlertBackendConfig validates alert backend specific configuration
 func (c *Config) validateAlertBackendConfig() error {
-	switch c.AlertBackendType {
+	for _, backendType := range c.AlertBackendType.Split() {
+		if err := c.validateAlertBackend(backendType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAlertBackend validates the configuration of a single alert backend type
+func (c *Config) validateAlertBackend(backendType AlertBackendType) error {
+	switch backendType {
 	case AlertBackendSlack:
 		if c.SlackBotToken == "" {
 			return fmt.Errorf("SLACK_BOT_TOKEN is required when using slack alert backend")
@@ -138,24 +245,125 @@ func (c *Config) validateAlertBackendConfig() error {
 		if c.WebhookURL == "" {
 			return fmt.Errorf("WEBHOOK_URL is required when using webhook alert backend")
 		}
+	case AlertBackendEmail:
+		if c.SMTPHost == "" {
+			return fmt.Errorf("SMTP_HOST is required when using email alert backend")
+		}
+		if c.EmailFrom == "" || len(c.EmailTo) == 0 {
+			return fmt.Errorf("EMAIL_FROM and EMAIL_TO are required when using email alert backend")
+		}
 	}
 	return nil
 }
 
 // validateThresholds validates threshold values
 func (c *Config) validateThresholds() error {
-	if c.CPUThreshold < 0 || c.CPUThreshold > 100 {
+	if err := ValidateThresholds(c.CPUThreshold, c.MemoryThreshold, c.LatencyThreshold); err != nil {
+		return err
+	}
+	if c.CPUClearThreshold < 0 || c.CPUClearThreshold > c.CPUThreshold {
+		return fmt.Errorf("CPU_CLEAR_THRESHOLD must be between 0 and CPU_THRESHOLD")
+	}
+	if c.MemoryClearThreshold < 0 || c.MemoryClearThreshold > c.MemoryThreshold {
+		return fmt.Errorf("MEMORY_CLEAR_THRESHOLD must be between 0 and MEMORY_THRESHOLD")
+	}
+	if c.LatencyClearThreshold < 0 || c.LatencyClearThreshold > c.LatencyThreshold {
+		return fmt.Errorf("LATENCY_CLEAR_THRESHOLD must be between 0 and LATENCY_THRESHOLD")
+	}
+	if c.AlertFireAfter < 1 {
+		return fmt.Errorf("ALERT_FIRE_AFTER must be at least 1, got %d", c.AlertFireAfter)
+	}
+	if c.AlertHistorySize < 1 {
+		return fmt.Errorf("ALERT_HISTORY_SIZE must be at least 1, got %d", c.AlertHistorySize)
+	}
+	return nil
+}
+
+// ValidateThresholds checks alert thresholds, whether loaded at startup or
+// changed at runtime
+func ValidateThresholds(cpu, memory float64, latency int64) error {
+	if cpu < 0 || cpu > 100 {
 		return fmt.Errorf("CPU_THRESHOLD must be between 0 and 100")
 	}
-	if c.MemoryThreshold < 0 || c.MemoryThreshold > 100 {
+	if memory < 0 || memory > 100 {
 		return fmt.Errorf("MEMORY_THRESHOLD must be between 0 and 100")
 	}
-	if c.LatencyThreshold < 0 {
+	if latency < 0 {
 		return fmt.Errorf("LATENCY_THRESHOLD must be positive")
 	}
 	return nil
 }
 
+// validateTimings validates interval and duration settings. A zero or negative
+// METRICS_INTERVAL would otherwise panic in time.NewTicker at startup.
+func (c *Config) validateTimings() error {
+	if c.MetricsInterval <= 0 {
+		return fmt.Errorf("METRICS_INTERVAL must be a positive duration, got %v", c.MetricsInterval)
+	}
+	if c.AlertCooldown <= 0 {
+		return fmt.Errorf("ALERT_COOLDOWN must be a positive duration, got %v", c.AlertCooldown)
+	}
+	if c.MetricsHotWindow < 0 {
+		return fmt.Errorf("METRICS_HOT_WINDOW must not be negative, got %v", c.MetricsHotWindow)
+	}
+	if c.MetricsHotWindow > 0 && c.MetricsDownsampleResolution <= c.MetricsInterval {
+		return fmt.Errorf("METRICS_DOWNSAMPLE_RESOLUTION must be longer than METRICS_INTERVAL, got %v", c.MetricsDownsampleResolution)
+	}
+	return nil
+}
+
+// validateCooldowns validates the per-severity/type alert cooldown overrides
+func (c *Config) validateCooldowns() error {
+	for key, cooldown := range c.AlertCooldownOverrides {
+		if cooldown <= 0 {
+			return fmt.Errorf("ALERT_COOLDOWN_OVERRIDES: cooldown for %q must be positive", key)
+		}
+	}
+	return nil
+}
+
+// CooldownFor returns the cooldown for an alert, preferring a per-type override,
+// then a per-severity override, then the global AlertCooldown
+func (c *Config) CooldownFor(alertType, severity string) time.Duration {
+	if cooldown, ok := c.AlertCooldownOverrides[alertType]; ok {
+		return cooldown
+	}
+	if cooldown, ok := c.AlertCooldownOverrides[severity]; ok {
+		return cooldown
+	}
+	return c.AlertCooldown
+}
+
+// parseCooldownOverrides parses a comma-separated list of key=duration pairs,
+// e.g. "critical=30s,warning=15m,cpu_high_usage=1m"
+func parseCooldownOverrides(value string) (map[string]time.Duration, error) {
+	overrides := make(map[string]time.Duration)
+	if strings.TrimSpace(value) == "" {
+		return overrides, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, raw, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("ALERT_COOLDOWN_OVERRIDES: invalid entry %q, expected key=duration", pair)
+		}
+
+		cooldown, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("ALERT_COOLDOWN_OVERRIDES: invalid duration for %q: %w", key, err)
+		}
+		overrides[key] = cooldown
+	}
+
+	return overrides, nil
+}
+
 // getDataSourceType gets data source type from environment
 func getDataSourceType(key string, fallback DataSourceType) DataSourceType {
 	if value := os.Getenv(key); value != "" {
@@ -167,15 +375,25 @@ func getDataSourceType(key string, fallback DataSourceType) DataSourceType {
 	return fallback
 }
 
-// getAlertBackendType gets alert backend type from environment
+// getAlertBackendType gets alert backend type from environment. The value may
+// be a comma-separated list of types; it falls back when any of them is unknown.
 func getAlertBackendType(key string, fallback AlertBackendType) AlertBackendType {
-	if value := os.Getenv(key); value != "" {
-		switch AlertBackendType(value) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	types := AlertBackendType(value).Split()
+	if len(types) == 0 {
+		return fallback
+	}
+	for _, t := range types {
+		switch t {
 		case AlertBackendSlack, AlertBackendEmail, AlertBackendWebhook, "noop":
-			return AlertBackendType(value)
+		default:
+			return fallback
 		}
 	}
-	return fallback
+	return AlertBackendType(value)
 }
 
 // getEnv gets an environment variable with a fallback default value
@@ -186,6 +404,18 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvAsList gets a comma-separated environment variable as a list, skipping
+// empty entries
+func getEnvAsList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // getEnvAsFloat gets an environment variable as float64 with a fallback default value
 func getEnvAsFloat(key string, fallback float64) float64 {
 	if value := os.Getenv(key); value != "" {
@@ -228,9 +458,17 @@ func (c *Config) IsProduction() bool {
 
 // GetDashboardURL returns the full dashboard URL
 func (c *Config) GetDashboardURL() string {
+	if c.IsTLSEnabled() {
+		return fmt.Sprintf("https://localhost:%s", c.DashboardPort)
+	}
 	return fmt.Sprintf("http://localhost:%s", c.DashboardPort)
 }
 
+// IsTLSEnabled returns true if the dashboard should be served over HTTPS
+func (c *Config) IsTLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
 // IsGrafanaEnabled returns true if Grafana integration is enabled
 func (c *Config) IsGrafanaEnabled() bool {
 	return c.DataSourceType == DataSourceGrafana
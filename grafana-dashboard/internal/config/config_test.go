@@ -0,0 +1,152 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCooldownOverrides(t *testing.T) {
+	overrides, err := parseCooldownOverrides(" critical=30s, warning=15m ,cpu_high_usage=1m")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]time.Duration{
+		"critical":       30 * time.Second,
+		"warning":        15 * time.Minute,
+		"cpu_high_usage": time.Minute,
+	}
+	for key, want := range expected {
+		if got := overrides[key]; got != want {
+			t.Errorf("Expected %s cooldown %v, got %v", key, want, got)
+		}
+	}
+
+	for _, invalid := range []string{"critical", "critical=soon", "=30s"} {
+		if _, err := parseCooldownOverrides(invalid); err == nil {
+			t.Errorf("Expected error for %q, got nil", invalid)
+		}
+	}
+}
+
+func TestCooldownFor(t *testing.T) {
+	cfg := &Config{
+		AlertCooldown: 5 * time.Minute,
+		AlertCooldownOverrides: map[string]time.Duration{
+			"critical":       30 * time.Second,
+			"cpu_high_usage": time.Minute,
+		},
+	}
+
+	tests := []struct {
+		alertType string
+		severity  string
+		want      time.Duration
+	}{
+		{"cpu_high_usage", "critical", time.Minute},
+		{"memory_high_usage", "critical", 30 * time.Second},
+		{"memory_high_usage", "warning", 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.CooldownFor(tt.alertType, tt.severity); got != tt.want {
+			t.Errorf("Expected CooldownFor(%s, %s) = %v, got %v", tt.alertType, tt.severity, tt.want, got)
+		}
+	}
+
+	cfg.AlertCooldownOverrides["warning"] = 0
+	if err := cfg.validateCooldowns(); err == nil {
+		t.Error("Expected error for zero override, got nil")
+	}
+}
+
+func TestLoadConfigRejectsInvalidTimings(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"zero metrics interval", "METRICS_INTERVAL", "0s", "METRICS_INTERVAL must be a positive duration"},
+		{"negative metrics interval", "METRICS_INTERVAL", "-5s", "METRICS_INTERVAL must be a positive duration"},
+		{"zero alert cooldown", "ALERT_COOLDOWN", "0s", "ALERT_COOLDOWN must be a positive duration"},
+		{"negative alert cooldown", "ALERT_COOLDOWN", "-1m", "ALERT_COOLDOWN must be a positive duration"},
+		{"negative hot window", "METRICS_HOT_WINDOW", "-1h", "METRICS_HOT_WINDOW must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ALERT_BACKEND_TYPE", "slack")
+			t.Setenv("SLACK_BOT_TOKEN", "xoxb-test")
+			t.Setenv(tt.key, tt.value)
+
+			cfg, err := LoadConfig()
+			if err == nil {
+				t.Fatalf("Expected validation error, got config with %s=%s", tt.key, tt.value)
+			}
+			if cfg != nil {
+				t.Errorf("Expected nil config on error, got %+v", cfg)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaultTimingsAreValid(t *testing.T) {
+	t.Setenv("ALERT_BACKEND_TYPE", "slack")
+	t.Setenv("SLACK_BOT_TOKEN", "xoxb-test")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.MetricsInterval != 5*time.Second || cfg.AlertCooldown != 5*time.Minute {
+		t.Errorf("Expected default timings 5s/5m, got %v/%v", cfg.MetricsInterval, cfg.AlertCooldown)
+	}
+}
+
+func TestLoadConfigMultipleAlertBackends(t *testing.T) {
+	t.Setenv("ALERT_BACKEND_TYPE", "slack,webhook")
+	t.Setenv("SLACK_BOT_TOKEN", "xoxb-test")
+
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "WEBHOOK_URL is required") {
+		t.Fatalf("Expected every listed backend to be validated, got %v", err)
+	}
+
+	t.Setenv("WEBHOOK_URL", "https://hooks.example.com/alert")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	types := cfg.AlertBackendType.Split()
+	if len(types) != 2 || types[0] != AlertBackendSlack || types[1] != AlertBackendWebhook {
+		t.Errorf("Expected slack and webhook backends, got %v", types)
+	}
+}
+
+func TestLoadConfigClearThresholds(t *testing.T) {
+	t.Setenv("ALERT_BACKEND_TYPE", "slack")
+	t.Setenv("SLACK_BOT_TOKEN", "xoxb-test")
+	t.Setenv("CPU_THRESHOLD", "80")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.CPUClearThreshold != 0 || cfg.AlertFireAfter != 1 {
+		t.Errorf("Expected no clear threshold and firing after 1 sample, got %v/%d", cfg.CPUClearThreshold, cfg.AlertFireAfter)
+	}
+
+	t.Setenv("CPU_CLEAR_THRESHOLD", "70")
+	if cfg, err = LoadConfig(); err != nil || cfg.CPUClearThreshold != 70 {
+		t.Errorf("Expected a clear threshold of 70, got %v (err %v)", cfg, err)
+	}
+
+	t.Setenv("CPU_CLEAR_THRESHOLD", "90")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "CPU_CLEAR_THRESHOLD") {
+		t.Errorf("Expected a clear threshold above the threshold to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces secret values in Redacted output
+const RedactedValue = "[REDACTED]"
+
+// Redacted returns the effective configuration keyed by JSON field name, safe
+// to expose over the API. Fields tagged secret:"true" are replaced with
+// RedactedValue when set (unset secrets stay empty so it is still visible that
+// they are missing). Durations are rendered as strings such as "5m0s".
+func (c *Config) Redacted() map[string]interface{} {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	out := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		value := v.Field(i).Interface()
+		switch typed := value.(type) {
+		case time.Duration:
+			value = typed.String()
+		case map[string]time.Duration:
+			durations := make(map[string]string, len(typed))
+			for key, d := range typed {
+				durations[key] = d.String()
+			}
+			value = durations
+		}
+		if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+			value = RedactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// prometheusContentType is the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelEscaper escapes label values for the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// hostname returns the host label for exported metrics
+func hostname() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "localhost"
+	}
+	return host
+}
+
+// handlePrometheusMetrics serves the latest metrics and internal counters in the
+// Prometheus text exposition format. Gauges are left out until a sample exists.
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	labels := fmt.Sprintf(`{host="%s"}`, labelEscaper.Replace(s.hostname))
+	write := func(name, help, kind string, value interface{}) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %v\n", name, help, name, kind, name, labels, value)
+	}
+
+	w.Header().Set("Content-Type", prometheusContentType)
+
+	metrics, err := s.dataSource.GetLatestMetrics(r.Context())
+	if err != nil {
+		logrus.Errorf("Failed to get latest metrics for Prometheus: %v", err)
+	}
+	if metrics != nil {
+		write("system_monitor_cpu_usage_percent", "CPU usage in percent.", "gauge", metrics.CPU)
+		write("system_monitor_memory_usage_percent", "Memory usage in percent.", "gauge", metrics.Memory.Percent)
+		write("system_monitor_memory_used_bytes", "Memory in use, in bytes.", "gauge", metrics.Memory.Used)
+		write("system_monitor_http_latency_seconds", "HTTP latency of the last probe, in seconds.", "gauge", float64(metrics.Latency.HTTPLatency)/1000)
+	}
+
+	write("system_monitor_alerts_sent_total", "Alerts accepted by the alert backend.", "counter", s.alerts.AlertsSent())
+	write("system_monitor_collection_errors_total", "Failed attempts to collect or query metrics.", "counter", s.dataSource.CollectionErrors())
+}
@@ -1,10 +1,16 @@
 package dashboard
 
 import (
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
+	"shared/tlsconfig"
 	"system-monitor/internal/alerts"
 	"system-monitor/internal/config"
 	"system-monitor/internal/datasource"
@@ -19,6 +25,9 @@ type Server struct {
 	dataSource datasource.DataSource
 	alerts     *alerts.AlertManager
 	router     *mux.Router
+	httpServer *http.Server
+	startTime  time.Time
+	hostname   string
 }
 
 // NewServer creates a new dashboard server
@@ -28,8 +37,12 @@ func NewServer(cfg *config.Config, dataSource datasource.DataSource, alertManage
 		dataSource: dataSource,
 		alerts:     alertManager,
 		router:     mux.NewRouter(),
+		startTime:  time.Now(),
+		hostname:   hostname(),
 	}
 
+	s.httpServer = &http.Server{Handler: s.router}
+
 	s.setupRoutes()
 	return s
 }
@@ -44,12 +57,18 @@ func (s *Server) setupRoutes() {
 	s.router.HandleFunc("/api/metrics/latest", s.handleGetLatestMetrics).Methods("GET")
 	s.router.HandleFunc("/api/metrics/history", s.handleGetMetricsHistory).Methods("GET")
 	s.router.HandleFunc("/api/alerts/state", s.handleGetAlertState).Methods("GET")
+	s.router.HandleFunc("/api/alerts/history", s.handleGetAlertHistory).Methods("GET")
 	s.router.HandleFunc("/api/charts/cpu", s.handleGetCPUChart).Methods("GET")
 	s.router.HandleFunc("/api/charts/memory", s.handleGetMemoryChart).Methods("GET")
 	s.router.HandleFunc("/api/charts/latency", s.handleGetLatencyChart).Methods("GET")
+	s.router.HandleFunc("/api/charts/disk", s.handleGetDiskChart).Methods("GET")
 	s.router.HandleFunc("/api/config", s.handleGetConfig).Methods("GET")
 	s.router.HandleFunc("/api/config", s.handleUpdateConfig).Methods("PUT")
 	s.router.HandleFunc("/api/health", s.handleHealthCheck).Methods("GET")
+	s.router.HandleFunc("/metrics", s.handlePrometheusMetrics).Methods("GET")
+
+	// Admin routes
+	s.router.Handle("/config", s.adminOnly(http.HandlerFunc(s.handleGetEffectiveConfig))).Methods("GET")
 
 	// Dashboard page
 	s.router.HandleFunc("/", s.handleDashboard).Methods("GET")
@@ -58,8 +77,38 @@ func (s *Server) setupRoutes() {
 // Start starts the web server
 func (s *Server) Start() error {
 	addr := ":" + s.config.DashboardPort
-	logrus.Infof("Starting dashboard server on %s", addr)
-	return http.ListenAndServe(addr, s.router)
+	logrus.Infof("Starting dashboard server on %s (TLS: %v)", addr, s.config.IsTLSEnabled())
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on ln, over TLS when a certificate pair is configured.
+// Certificates are reloaded from disk when they change. After Shutdown, Serve
+// returns http.ErrServerClosed.
+func (s *Server) Serve(ln net.Listener) error {
+	server := s.httpServer
+
+	if !s.config.IsTLSEnabled() {
+		return server.Serve(ln)
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(s.config.TLSCertFile, s.config.TLSKeyFile)
+	if err != nil {
+		ln.Close()
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	server.TLSConfig = tlsconfig.ServerConfig(reloader)
+	return server.ServeTLS(ln, "", "")
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to finish,
+// or for ctx to be done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
 
 // handleDashboard serves the main dashboard page
@@ -132,6 +181,21 @@ func (s *Server) handleGetAlertState(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, state)
 }
 
+// handleGetAlertHistory returns recently sent alerts, newest first, optionally
+// capped by the limit query parameter
+func (s *Server) handleGetAlertHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid limit, expected a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	sendJSON(w, s.alerts.GetHistory(limit))
+}
+
 // handleGetCPUChart returns CPU usage chart data
 func (s *Server) handleGetCPUChart(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -189,15 +253,35 @@ func (s *Server) handleGetLatencyChart(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, chart)
 }
 
+// handleGetDiskChart returns disk usage chart data
+func (s *Server) handleGetDiskChart(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	diskData, err := s.dataSource.GetDiskHistory(ctx, 1*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	timestamps, err := s.dataSource.GetTimestamps(ctx, 1*time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chart := generateDiskChart(timestamps, diskData)
+	sendJSON(w, chart)
+}
+
 // handleGetConfig returns the current configuration
 func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
+	thresholds := s.alerts.Thresholds()
 	config := map[string]interface{}{
 		"data_source_type":   s.config.DataSourceType,
 		"data_source_url":    s.config.DataSourceURL,
 		"alert_backend_type": s.config.AlertBackendType,
-		"cpu_threshold":      s.config.CPUThreshold,
-		"memory_threshold":   s.config.MemoryThreshold,
-		"latency_threshold":  s.config.LatencyThreshold,
+		"cpu_threshold":      thresholds.CPU,
+		"memory_threshold":   thresholds.Memory,
+		"latency_threshold":  thresholds.Latency,
 		"alert_cooldown":     s.config.AlertCooldown.Seconds(),
 		"metrics_interval":   s.config.MetricsInterval.Seconds(),
 		"dashboard_port":     s.config.DashboardPort,
@@ -206,23 +290,58 @@ func (s *Server) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	sendJSON(w, config)
 }
 
-// handleUpdateConfig updates configuration values
+// handleGetEffectiveConfig returns the full effective configuration with secrets
+// redacted, including thresholds changed through PUT /api/config
+func (s *Server) handleGetEffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	effective := s.config.Redacted()
+	thresholds := s.alerts.Thresholds()
+	effective["cpu_threshold"] = thresholds.CPU
+	effective["memory_threshold"] = thresholds.Memory
+	effective["latency_threshold"] = thresholds.Latency
+	sendJSON(w, effective)
+}
+
+// adminOnly guards a handler with the ADMIN_TOKEN sent in the X-Admin-Token header.
+// When no token is configured the admin routes are disabled.
+func (s *Server) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.AdminToken == "" {
+			http.Error(w, "Admin API is disabled", http.StatusForbidden)
+			return
+		}
+		provided := r.Header.Get("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(s.config.AdminToken)) != 1 {
+			http.Error(w, "Invalid admin token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleUpdateConfig updates the alert thresholds. Thresholds left out of the
+// request keep their current values.
 func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
-	var config map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+	var update map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
 	// Update thresholds if provided
-	if cpuThreshold, ok := config["cpu_threshold"].(float64); ok {
-		s.config.CPUThreshold = cpuThreshold
+	var thresholds alerts.ThresholdUpdate
+	if cpuThreshold, ok := update["cpu_threshold"].(float64); ok {
+		thresholds.CPU = &cpuThreshold
 	}
-	if memoryThreshold, ok := config["memory_threshold"].(float64); ok {
-		s.config.MemoryThreshold = memoryThreshold
+	if memoryThreshold, ok := update["memory_threshold"].(float64); ok {
+		thresholds.Memory = &memoryThreshold
 	}
-	if latencyThreshold, ok := config["latency_threshold"].(float64); ok {
-		s.config.LatencyThreshold = int64(latencyThreshold)
+	if latencyThreshold, ok := update["latency_threshold"].(float64); ok {
+		latency := int64(latencyThreshold)
+		thresholds.Latency = &latency
+	}
+	if _, err := s.alerts.UpdateThresholds(thresholds); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	sendJSON(w, map[string]string{"status": "updated"})
@@ -240,10 +359,12 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	}
 
 	health := map[string]interface{}{
-		"status":      "healthy",
-		"data_source": dataSourceHealth,
-		"timestamp":   time.Now().Format(time.RFC3339),
-		"uptime":      time.Since(time.Now()).String(), // This would need to be tracked properly
+		"status":           "healthy",
+		"data_source":      dataSourceHealth,
+		"data_source_type": s.config.DataSourceType,
+		"metrics_interval": s.config.MetricsInterval.String(),
+		"timestamp":        time.Now().Format(time.RFC3339),
+		"uptime":           time.Since(s.startTime).String(),
 	}
 
 	if dataSourceHealth == "unhealthy" {
@@ -379,3 +500,38 @@ func generateLatencyChart(timestamps []time.Time, latencyData []int64) ChartData
 		},
 	}
 }
+
+// generateDiskChart creates a disk usage line chart
+func generateDiskChart(timestamps []time.Time, diskData []float64) ChartData {
+	// Convert timestamps to readable format
+	xAxis := make([]string, len(timestamps))
+	for i, t := range timestamps {
+		xAxis[i] = t.Format("15:04:05")
+	}
+
+	// Convert disk data to interface slice
+	data := make([]interface{}, len(diskData))
+	for i, v := range diskData {
+		data[i] = v
+	}
+
+	return ChartData{
+		Title: "Disk Usage Over Time",
+		XAxis: xAxis,
+		Series: []SeriesData{
+			{
+				Name:  "Disk Usage (%)",
+				Data:  data,
+				Type:  "line",
+				Color: "#fac858",
+			},
+		},
+		Options: map[string]interface{}{
+			"yAxis": map[string]interface{}{
+				"min":  0,
+				"max":  100,
+				"name": "Disk Usage (%)",
+			},
+		},
+	}
+}
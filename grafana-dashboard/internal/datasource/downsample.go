@@ -0,0 +1,72 @@
+package datasource
+
+import "time"
+
+// rollup accumulates the samples of one downsampling bucket. Sums are kept
+// instead of averages so more samples can be folded in without losing precision.
+type rollup struct {
+	bucket time.Time
+	count  int
+
+	cpu         float64
+	memTotal    float64
+	memUsed     float64
+	memAvail    float64
+	memPercent  float64
+	httpLatency float64
+	dbLatency   float64
+	apiLatency  float64
+	diskTotal   float64
+	diskUsed    float64
+	diskPercent float64
+	bytesSent   float64
+	bytesRecv   float64
+}
+
+// add folds a raw sample into the rollup
+func (r *rollup) add(m *Metrics) {
+	r.count++
+	r.cpu += m.CPU
+	r.memTotal += float64(m.Memory.Total)
+	r.memUsed += float64(m.Memory.Used)
+	r.memAvail += float64(m.Memory.Available)
+	r.memPercent += m.Memory.Percent
+	r.httpLatency += float64(m.Latency.HTTPLatency)
+	r.dbLatency += float64(m.Latency.DBLatency)
+	r.apiLatency += float64(m.Latency.APILatency)
+	r.diskTotal += float64(m.Disk.Total)
+	r.diskUsed += float64(m.Disk.Used)
+	r.diskPercent += m.Disk.Percent
+	r.bytesSent += float64(m.Network.BytesSent)
+	r.bytesRecv += float64(m.Network.BytesRecv)
+}
+
+// metrics returns the bucket as a single sample holding the averages of the
+// samples folded into it, timestamped at the start of the bucket
+func (r *rollup) metrics() *Metrics {
+	n := float64(r.count)
+	return &Metrics{
+		Timestamp: r.bucket,
+		CPU:       r.cpu / n,
+		Memory: MemoryInfo{
+			Total:     uint64(r.memTotal / n),
+			Used:      uint64(r.memUsed / n),
+			Available: uint64(r.memAvail / n),
+			Percent:   r.memPercent / n,
+		},
+		Latency: LatencyInfo{
+			HTTPLatency: int64(r.httpLatency / n),
+			DBLatency:   int64(r.dbLatency / n),
+			APILatency:  int64(r.apiLatency / n),
+		},
+		Disk: DiskInfo{
+			Total:   uint64(r.diskTotal / n),
+			Used:    uint64(r.diskUsed / n),
+			Percent: r.diskPercent / n,
+		},
+		Network: NetworkInfo{
+			BytesSent: uint64(r.bytesSent / n),
+			BytesRecv: uint64(r.bytesRecv / n),
+		},
+	}
+}
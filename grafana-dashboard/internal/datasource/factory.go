@@ -29,7 +29,11 @@ func (f *Factory) CreateDataSource(cfg *config.Config) (DataSource, error) {
 
 // createLocalDataSource creates a local data source
 func (f *Factory) createLocalDataSource(cfg *config.Config) (DataSource, error) {
-	return NewLocalDataSource(1000), nil // Keep last 1000 metrics
+	ds := NewLocalDataSource(1000) // Keep last 1000 metrics
+	if cfg.MetricsHotWindow > 0 {
+		ds.WithDownsampling(cfg.MetricsHotWindow, cfg.MetricsDownsampleResolution)
+	}
+	return ds, nil
 }
 
 // createGrafanaDataSource creates a Grafana data source
@@ -42,7 +46,7 @@ func (f *Factory) createGrafanaDataSource(cfg *config.Config) (DataSource, error
 		dsConfig.WithCredentials(cfg.GrafanaUsername, cfg.GrafanaPassword)
 	}
 
-	return NewGrafanaDataSource(dsConfig), nil
+	return WithHealthThresholds(NewGrafanaDataSource(dsConfig), cfg.DataSourceHealthFailures, cfg.DataSourceHealthRecoveries), nil
 }
 
 // createPrometheusDataSource creates a Prometheus data source
@@ -50,5 +54,6 @@ func (f *Factory) createPrometheusDataSource(cfg *config.Config) (DataSource, er
 	// For now, return a simplified implementation
 	// In a real implementation, you'd create a PrometheusDataSource
 	dsConfig := NewDataSourceConfig(DataSourcePrometheus, cfg.PrometheusURL)
-	return NewGrafanaDataSource(dsConfig), nil // Reuse Grafana implementation for now
+	ds := NewGrafanaDataSource(dsConfig) // Reuse Grafana implementation for now
+	return WithHealthThresholds(ds, cfg.DataSourceHealthFailures, cfg.DataSourceHealthRecoveries), nil
 }
@@ -3,9 +3,14 @@ package datasource
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -15,6 +20,9 @@ import (
 type GrafanaDataSource struct {
 	config     *DataSourceConfig
 	httpClient *http.Client
+
+	// queryErrors counts failed queries, not counting those whose context was cancelled
+	queryErrors atomic.Uint64
 }
 
 // NewGrafanaDataSource creates a new Grafana data source
@@ -27,69 +35,97 @@ func NewGrafanaDataSource(config *DataSourceConfig) *GrafanaDataSource {
 	}
 }
 
-// GetLatestMetrics returns the most recent metrics from Grafana
-func (ds *GrafanaDataSource) GetLatestMetrics(ctx context.Context) (*Metrics, error) {
-	// Query Grafana API for latest metrics
-	// This is a simplified implementation - in practice, you'd query specific panels/dashboards
-
-	// For now, we'll simulate getting metrics from Grafana
-	// In a real implementation, you'd make API calls to Grafana's query endpoints
+// PromQL queries behind each metric. Latency is the p95 HTTP request duration in
+// milliseconds, and network traffic is the bytes transferred over the last minute.
+const (
+	cpuQuery     = `100 - (avg(irate(node_cpu_seconds_total{mode="idle"}[5m])) * 100)`
+	memoryQuery  = `(node_memory_MemTotal_bytes - node_memory_MemAvailable_bytes) / node_memory_MemTotal_bytes * 100`
+	latencyQuery = `histogram_quantile(0.95, sum by (le) (rate(http_request_duration_seconds_bucket[5m]))) * 1000`
+	diskQuery    = `(1 - node_filesystem_avail_bytes{mountpoint="/"} / node_filesystem_size_bytes{mountpoint="/"}) * 100`
+	netSentQuery = `sum(increase(node_network_transmit_bytes_total{device!="lo"}[1m]))`
+	netRecvQuery = `sum(increase(node_network_receive_bytes_total{device!="lo"}[1m]))`
+)
 
-	cpuQuery := fmt.Sprintf("%s/api/datasources/proxy/1/api/v1/query?query=100%%20-%%20(avg%%20by%%20(instance)%%20(irate(node_cpu_seconds_total{mode=\"idle\"}[5m]))%%20*%%20100)", ds.config.URL)
-	memoryQuery := fmt.Sprintf("%s/api/datasources/proxy/1/api/v1/query?query=(node_memory_MemTotal_bytes%%20-%%20node_memory_MemAvailable_bytes)%%20/%%20node_memory_MemTotal_bytes%%20*%%20100", ds.config.URL)
+// metricQueries maps each query to the Metrics field its values fill
+var metricQueries = []struct {
+	query string
+	set   func(m *Metrics, value float64)
+}{
+	{cpuQuery, func(m *Metrics, v float64) { m.CPU = v }},
+	{memoryQuery, func(m *Metrics, v float64) { m.Memory.Percent = v }},
+	{latencyQuery, func(m *Metrics, v float64) { m.Latency.HTTPLatency = int64(v) }},
+	{diskQuery, func(m *Metrics, v float64) { m.Disk.Percent = v }},
+	{netSentQuery, func(m *Metrics, v float64) { m.Network.BytesSent = uint64(v) }},
+	{netRecvQuery, func(m *Metrics, v float64) { m.Network.BytesRecv = uint64(v) }},
+}
 
-	// Simulate API calls (in real implementation, you'd make actual HTTP requests)
-	cpuUsage, err := ds.queryGrafanaMetric(ctx, cpuQuery)
-	if err != nil {
-		logrus.Warnf("Failed to query CPU metric: %v", err)
-		cpuUsage = 0
-	}
+// grafanaProxyPath is the Grafana datasource proxy for the Prometheus datasource
+const grafanaProxyPath = "/api/datasources/proxy/1"
 
-	memoryUsage, err := ds.queryGrafanaMetric(ctx, memoryQuery)
-	if err != nil {
-		logrus.Warnf("Failed to query memory metric: %v", err)
-		memoryUsage = 0
-	}
+// historyPoints is roughly how many points a history range query returns
+const historyPoints = 120
 
-	// Simulate latency metric
-	latencyUsage := int64(50 + (time.Now().UnixNano() % 200))
+// errNoData is returned when a query succeeds but matches no series
+var errNoData = errors.New("no data in response")
 
-	return &Metrics{
-		Timestamp: time.Now(),
-		CPU:       cpuUsage,
-		Memory: MemoryInfo{
-			Percent: memoryUsage,
-		},
-		Latency: LatencyInfo{
-			HTTPLatency: latencyUsage,
-		},
-	}, nil
+// GetLatestMetrics returns the most recent metrics from Grafana. A metric whose
+// query returns no data is reported as 0.
+func (ds *GrafanaDataSource) GetLatestMetrics(ctx context.Context) (*Metrics, error) {
+	metrics := &Metrics{Timestamp: time.Now()}
+	for _, mq := range metricQueries {
+		value, err := ds.queryInstant(ctx, mq.query)
+		if errors.Is(err, errNoData) {
+			logrus.Debugf("No data for query %q", mq.query)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		mq.set(metrics, value)
+	}
+	return metrics, nil
 }
 
-// GetMetricsHistory returns historical metrics from Grafana
+// GetMetricsHistory returns historical metrics from Grafana, one per step of a range
+// query over start..end, both inclusive. Points missing from a series are reported as 0, and a range
+// where no query returns data yields no metrics.
 func (ds *GrafanaDataSource) GetMetricsHistory(ctx context.Context, start, end time.Time) ([]*Metrics, error) {
-	// Query Grafana API for historical metrics
-	// This would involve making range queries to Grafana's API
-
-	// For now, return a simplified implementation
-	var metrics []*Metrics
-	duration := end.Sub(start)
-	points := int(duration.Minutes()) / 5 // 5-minute intervals
-
-	for i := 0; i < points; i++ {
-		timestamp := start.Add(time.Duration(i*5) * time.Minute)
-		metrics = append(metrics, &Metrics{
-			Timestamp: timestamp,
-			CPU:       float64(30 + (i % 70)), // Simulated CPU usage
-			Memory: MemoryInfo{
-				Percent: float64(40 + (i % 60)), // Simulated memory usage
-			},
-			Latency: LatencyInfo{
-				HTTPLatency: int64(50 + (i % 200)), // Simulated latency
-			},
-		})
+	step := end.Sub(start) / historyPoints
+	if step < 15*time.Second {
+		step = 15 * time.Second
+	}
+
+	byTime := make(map[int64]*Metrics)
+	at := func(ts time.Time) *Metrics {
+		m, ok := byTime[ts.UnixNano()]
+		if !ok {
+			m = &Metrics{Timestamp: ts}
+			byTime[ts.UnixNano()] = m
+		}
+		return m
+	}
+
+	for _, mq := range metricQueries {
+		points, err := ds.queryRange(ctx, mq.query, start, end, step)
+		if errors.Is(err, errNoData) {
+			logrus.Debugf("No data for query %q between %s and %s", mq.query, start, end)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			mq.set(at(p.Timestamp), p.Value)
+		}
 	}
 
+	metrics := make([]*Metrics, 0, len(byTime))
+	for _, m := range byTime {
+		metrics = append(metrics, m)
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Timestamp.Before(metrics[j].Timestamp)
+	})
 	return metrics, nil
 }
 
@@ -147,6 +183,42 @@ func (ds *GrafanaDataSource) GetLatencyHistory(ctx context.Context, duration tim
 	return result, nil
 }
 
+// GetDiskHistory returns disk usage history from Grafana
+func (ds *GrafanaDataSource) GetDiskHistory(ctx context.Context, duration time.Duration) ([]float64, error) {
+	end := time.Now()
+	start := end.Add(-duration)
+
+	metrics, err := ds.GetMetricsHistory(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []float64
+	for _, metric := range metrics {
+		result = append(result, metric.Disk.Percent)
+	}
+
+	return result, nil
+}
+
+// GetNetworkHistory returns network traffic history from Grafana
+func (ds *GrafanaDataSource) GetNetworkHistory(ctx context.Context, duration time.Duration) ([]NetworkInfo, error) {
+	end := time.Now()
+	start := end.Add(-duration)
+
+	metrics, err := ds.GetMetricsHistory(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []NetworkInfo
+	for _, metric := range metrics {
+		result = append(result, metric.Network)
+	}
+
+	return result, nil
+}
+
 // GetTimestamps returns timestamps for metrics from Grafana
 func (ds *GrafanaDataSource) GetTimestamps(ctx context.Context, duration time.Duration) ([]time.Time, error) {
 	end := time.Now()
@@ -174,12 +246,7 @@ func (ds *GrafanaDataSource) HealthCheck(ctx context.Context) error {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
 
-	// Add authentication if configured
-	if ds.config.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+ds.config.APIKey)
-	} else if ds.config.Username != "" && ds.config.Password != "" {
-		req.SetBasicAuth(ds.config.Username, ds.config.Password)
-	}
+	ds.authorize(req)
 
 	resp, err := ds.httpClient.Do(req)
 	if err != nil {
@@ -194,63 +261,153 @@ func (ds *GrafanaDataSource) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// CollectionErrors returns how many queries to Grafana failed
+func (ds *GrafanaDataSource) CollectionErrors() uint64 {
+	return ds.queryErrors.Load()
+}
+
 // Close closes the data source connection
 func (ds *GrafanaDataSource) Close() error {
 	// No specific cleanup needed for HTTP client
 	return nil
 }
 
-// queryGrafanaMetric queries a specific metric from Grafana
-func (ds *GrafanaDataSource) queryGrafanaMetric(ctx context.Context, queryURL string) (float64, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", queryURL, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Add authentication if configured
+// authorize adds the configured API key or basic auth credentials to req
+func (ds *GrafanaDataSource) authorize(req *http.Request) {
 	if ds.config.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+ds.config.APIKey)
 	} else if ds.config.Username != "" && ds.config.Password != "" {
 		req.SetBasicAuth(ds.config.Username, ds.config.Password)
 	}
+}
 
-	resp, err := ds.httpClient.Do(req)
+// samplePoint is one value of a time series
+type samplePoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// queryResult is the data of a Prometheus query API response. Instant queries fill
+// Value and range queries fill Values, each as [unix seconds, "value"] pairs.
+type queryResult struct {
+	ResultType string `json:"resultType"`
+	Result     []struct {
+		Value  []interface{}   `json:"value"`
+		Values [][]interface{} `json:"values"`
+	} `json:"result"`
+}
+
+// queryInstant evaluates query now and returns the value of its first series
+func (ds *GrafanaDataSource) queryInstant(ctx context.Context, query string) (float64, error) {
+	result, err := ds.query(ctx, "/api/v1/query", url.Values{"query": {query}})
 	if err != nil {
-		return 0, fmt.Errorf("failed to query Grafana: %w", err)
+		return 0, err
+	}
+	if len(result.Result) == 0 {
+		return 0, errNoData
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("Grafana query failed with status: %d", resp.StatusCode)
+	point, err := parseSample(result.Result[0].Value)
+	if err != nil {
+		return 0, err
 	}
+	return point.Value, nil
+}
 
-	// Parse the response (simplified - in practice, you'd parse the actual Prometheus response format)
-	var response struct {
-		Data struct {
-			Result []struct {
-				Value []interface{} `json:"value"`
-			} `json:"result"`
-		} `json:"data"`
+// queryRange evaluates query every step between start and end and returns the
+// points of its first series
+func (ds *GrafanaDataSource) queryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]samplePoint, error) {
+	result, err := ds.query(ctx, "/api/v1/query_range", url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Result) == 0 || len(result.Result[0].Values) == 0 {
+		return nil, errNoData
+	}
+
+	points := make([]samplePoint, 0, len(result.Result[0].Values))
+	for _, raw := range result.Result[0].Values {
+		point, err := parseSample(raw)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// query calls a Prometheus query API endpoint, through the Grafana datasource proxy
+// unless the data source talks to Prometheus directly
+func (ds *GrafanaDataSource) query(ctx context.Context, path string, params url.Values) (result *queryResult, err error) {
+	defer func() {
+		if err != nil && ctx.Err() == nil {
+			ds.queryErrors.Add(1)
+		}
+	}()
+
+	base := ds.config.URL + grafanaProxyPath
+	if ds.config.Type == DataSourcePrometheus {
+		base = ds.config.URL
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
+	req, err := http.NewRequestWithContext(ctx, "GET", base+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	ds.authorize(req)
 
-	if len(response.Data.Result) == 0 || len(response.Data.Result[0].Value) < 2 {
-		return 0, fmt.Errorf("no data in response")
+	resp, err := ds.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Grafana: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Extract the metric value
-	valueStr, ok := response.Data.Result[0].Value[1].(string)
+	var response struct {
+		Status string      `json:"status"`
+		Error  string      `json:"error"`
+		Data   queryResult `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || response.Status == "error" {
+		if response.Error != "" {
+			return nil, fmt.Errorf("Grafana query failed with status %d: %s", resp.StatusCode, response.Error)
+		}
+		return nil, fmt.Errorf("Grafana query failed with status: %d", resp.StatusCode)
+	}
+	return &response.Data, nil
+}
+
+// parseSample parses a [unix seconds, "value"] pair
+func parseSample(raw []interface{}) (samplePoint, error) {
+	if len(raw) < 2 {
+		return samplePoint{}, fmt.Errorf("invalid sample in response: %v", raw)
+	}
+	seconds, ok := raw[0].(float64)
 	if !ok {
-		return 0, fmt.Errorf("invalid value format in response")
+		return samplePoint{}, fmt.Errorf("invalid timestamp format in response")
+	}
+	valueStr, ok := raw[1].(string)
+	if !ok {
+		return samplePoint{}, fmt.Errorf("invalid value format in response")
 	}
 
 	value, err := strconv.ParseFloat(valueStr, 64)
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse metric value: %w", err)
+		return samplePoint{}, fmt.Errorf("failed to parse metric value: %w", err)
 	}
-
-	return value, nil
+	// NaN (e.g. a quantile over no requests) means the series has no value at that point
+	if math.IsNaN(value) {
+		value = 0
+	}
+	return samplePoint{
+		Timestamp: time.Unix(0, int64(seconds*float64(time.Second))).UTC(),
+		Value:     value,
+	}, nil
 }
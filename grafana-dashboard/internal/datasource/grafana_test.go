@@ -0,0 +1,137 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubGrafana serves the Prometheus query API behind the Grafana datasource proxy.
+// series maps each query to its samples as [unix seconds, value] pairs; queries
+// without samples return an empty result.
+func stubGrafana(t *testing.T, series map[string][][2]float64) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"status":"error","error":"unauthorized"}`)
+			return
+		}
+
+		samples := series[r.URL.Query().Get("query")]
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case grafanaProxyPath + "/api/v1/query":
+			if len(samples) == 0 {
+				fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+				return
+			}
+			last := samples[len(samples)-1]
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[%v,"%v"]}]}}`, last[0], last[1])
+		case grafanaProxyPath + "/api/v1/query_range":
+			if r.URL.Query().Get("start") == "" || r.URL.Query().Get("end") == "" || r.URL.Query().Get("step") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"status":"error","error":"missing range parameters"}`)
+				return
+			}
+			var values []string
+			for _, s := range samples {
+				values = append(values, fmt.Sprintf(`[%v,"%v"]`, s[0], s[1]))
+			}
+			result := ""
+			if len(values) > 0 {
+				result = `{"metric":{},"values":[` + strings.Join(values, ",") + `]}`
+			}
+			fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[%s]}}`, result)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newStubGrafanaSource(server *httptest.Server) *GrafanaDataSource {
+	return NewGrafanaDataSource(NewDataSourceConfig(DataSourceGrafana, server.URL).WithAPIKey("test-key"))
+}
+
+func TestGrafanaMetricsHistory(t *testing.T) {
+	server := stubGrafana(t, map[string][][2]float64{
+		cpuQuery:     {{1700000000, 12.5}, {1700000060, 40}, {1700000120, 55.25}},
+		memoryQuery:  {{1700000000, 61}, {1700000060, 62}, {1700000120, 63}},
+		latencyQuery: {{1700000060, 180.7}},
+	})
+	ds := newStubGrafanaSource(server)
+
+	start := time.Unix(1700000000, 0)
+	metrics, err := ds.GetMetricsHistory(context.Background(), start, start.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("GetMetricsHistory() error = %v", err)
+	}
+	if len(metrics) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(metrics))
+	}
+
+	want := []struct {
+		cpu, memory float64
+		latency     int64
+	}{{12.5, 61, 0}, {40, 62, 180}, {55.25, 63, 0}}
+	for i, m := range metrics {
+		if !m.Timestamp.Equal(start.Add(time.Duration(i) * time.Minute)) {
+			t.Errorf("Point %d at %v, want %v", i, m.Timestamp, start.Add(time.Duration(i)*time.Minute))
+		}
+		if m.CPU != want[i].cpu || m.Memory.Percent != want[i].memory || m.Latency.HTTPLatency != want[i].latency {
+			t.Errorf("Point %d = cpu %v, memory %v, latency %v, want %+v", i, m.CPU, m.Memory.Percent, m.Latency.HTTPLatency, want[i])
+		}
+	}
+
+	cpu, err := ds.GetCPUHistory(context.Background(), time.Hour)
+	if err != nil || len(cpu) != 3 || cpu[2] != 55.25 {
+		t.Errorf("GetCPUHistory() = %v, %v, want the three CPU samples", cpu, err)
+	}
+}
+
+func TestGrafanaLatestMetrics(t *testing.T) {
+	server := stubGrafana(t, map[string][][2]float64{
+		cpuQuery:    {{1700000000, 33.3}},
+		memoryQuery: {{1700000000, 71}},
+	})
+
+	m, err := newStubGrafanaSource(server).GetLatestMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("GetLatestMetrics() error = %v", err)
+	}
+	// Latency has no data, so it is reported as 0 rather than simulated
+	if m.CPU != 33.3 || m.Memory.Percent != 71 || m.Latency.HTTPLatency != 0 {
+		t.Errorf("GetLatestMetrics() = cpu %v, memory %v, latency %v, want 33.3, 71, 0", m.CPU, m.Memory.Percent, m.Latency.HTTPLatency)
+	}
+}
+
+func TestGrafanaNoData(t *testing.T) {
+	ds := newStubGrafanaSource(stubGrafana(t, nil))
+
+	metrics, err := ds.GetMetricsHistory(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	if err != nil || len(metrics) != 0 {
+		t.Errorf("GetMetricsHistory() with no data = %d points, %v, want none", len(metrics), err)
+	}
+}
+
+func TestGrafanaQueryErrors(t *testing.T) {
+	server := stubGrafana(t, nil)
+	ds := NewGrafanaDataSource(NewDataSourceConfig(DataSourceGrafana, server.URL).WithAPIKey("wrong-key"))
+
+	if _, err := ds.GetLatestMetrics(context.Background()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("GetLatestMetrics() with a bad key error = %v, want unauthorized", err)
+	}
+	if _, err := ds.GetMetricsHistory(context.Background(), time.Now().Add(-time.Hour), time.Now()); err == nil {
+		t.Error("GetMetricsHistory() with a bad key succeeded")
+	}
+	if ds.CollectionErrors() == 0 {
+		t.Error("CollectionErrors() = 0 after failed queries")
+	}
+}
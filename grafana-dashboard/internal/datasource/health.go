@@ -0,0 +1,77 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// smoothedHealth wraps a data source so a single failed health check does not
+// mark it unhealthy. It takes failureThreshold failures in a row to report
+// unhealthy and recoveryThreshold successes in a row to report healthy again.
+type smoothedHealth struct {
+	DataSource
+
+	failureThreshold  int
+	recoveryThreshold int
+
+	// mu guards the health state and streaks below
+	mu        sync.Mutex
+	healthy   bool
+	failures  int
+	successes int
+	lastErr   error
+}
+
+// WithHealthThresholds wraps ds so its HealthCheck only reports unhealthy after
+// failures consecutive failed checks and only recovers after recoveries
+// consecutive successful ones. Thresholds of 1 or less report every check as is.
+func WithHealthThresholds(ds DataSource, failures, recoveries int) DataSource {
+	if failures <= 1 && recoveries <= 1 {
+		return ds
+	}
+	return &smoothedHealth{
+		DataSource:        ds,
+		failureThreshold:  max(failures, 1),
+		recoveryThreshold: max(recoveries, 1),
+		healthy:           true,
+	}
+}
+
+// HealthCheck checks the wrapped data source and reports the smoothed status
+func (s *smoothedHealth) HealthCheck(ctx context.Context) error {
+	err := s.DataSource.HealthCheck(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.failures++
+		s.successes = 0
+		s.lastErr = err
+		if s.healthy && s.failures >= s.failureThreshold {
+			s.healthy = false
+			logrus.Warnf("Data source marked unhealthy after %d consecutive failed health checks", s.failures)
+		}
+	} else {
+		s.successes++
+		s.failures = 0
+		if !s.healthy && s.successes >= s.recoveryThreshold {
+			s.healthy = true
+			logrus.Infof("Data source recovered after %d consecutive successful health checks", s.successes)
+		}
+	}
+
+	if s.healthy {
+		if err != nil {
+			logrus.Debugf("Data source health check failed (%d/%d before unhealthy): %v", s.failures, s.failureThreshold, err)
+		}
+		return nil
+	}
+	if err == nil {
+		return fmt.Errorf("recovering (%d/%d successful checks): %w", s.successes, s.recoveryThreshold, s.lastErr)
+	}
+	return err
+}
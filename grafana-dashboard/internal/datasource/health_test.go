@@ -0,0 +1,59 @@
+package datasource
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// scriptedHealth is a data source whose health checks follow a script of
+// results, true meaning the check passes
+type scriptedHealth struct {
+	DataSource
+	results []bool
+	calls   int
+}
+
+func (s *scriptedHealth) HealthCheck(ctx context.Context) error {
+	ok := s.results[s.calls%len(s.results)]
+	s.calls++
+	if ok {
+		return nil
+	}
+	return errors.New("connection refused")
+}
+
+func TestHealthThresholdsIgnoreAlternatingFailures(t *testing.T) {
+	stub := &scriptedHealth{results: []bool{false, true}}
+	ds := WithHealthThresholds(stub, 2, 2)
+
+	for i := 0; i < 10; i++ {
+		if err := ds.HealthCheck(context.Background()); err != nil {
+			t.Fatalf("Expected check %d to stay healthy while failures alternate, got %v", i, err)
+		}
+	}
+}
+
+func TestHealthThresholdsFlipAfterStreak(t *testing.T) {
+	// fail, fail, fail | pass, fail, pass, pass
+	stub := &scriptedHealth{results: []bool{false, false, false, true, false, true, true}}
+	ds := WithHealthThresholds(stub, 3, 2)
+
+	want := []bool{true, true, false, false, false, false, true}
+	for i, healthy := range want {
+		err := ds.HealthCheck(context.Background())
+		if got := err == nil; got != healthy {
+			t.Errorf("Expected check %d healthy=%v, got error %v", i, healthy, err)
+		}
+	}
+}
+
+func TestHealthThresholdsDisabled(t *testing.T) {
+	stub := &scriptedHealth{results: []bool{false}}
+	if ds := WithHealthThresholds(stub, 1, 1); ds != DataSource(stub) {
+		t.Fatalf("Expected thresholds of 1 to return the data source unwrapped, got %T", ds)
+	}
+	if err := stub.HealthCheck(context.Background()); err == nil {
+		t.Error("Expected the first failure to be reported, got nil")
+	}
+}
@@ -11,6 +11,8 @@ type Metrics struct {
 	CPU       float64     `json:"cpu"`
 	Memory    MemoryInfo  `json:"memory"`
 	Latency   LatencyInfo `json:"latency"`
+	Disk      DiskInfo    `json:"disk"`
+	Network   NetworkInfo `json:"network"`
 }
 
 // MemoryInfo contains memory-related metrics
@@ -28,6 +30,20 @@ type LatencyInfo struct {
 	APILatency  int64 `json:"api_latency"`
 }
 
+// DiskInfo contains disk usage of the monitored filesystem
+type DiskInfo struct {
+	Total   uint64  `json:"total"`
+	Used    uint64  `json:"used"`
+	Percent float64 `json:"percent"`
+}
+
+// NetworkInfo contains the bytes transferred over all interfaces since the
+// previous sample
+type NetworkInfo struct {
+	BytesSent uint64 `json:"bytes_sent"`
+	BytesRecv uint64 `json:"bytes_recv"`
+}
+
 // DataSource defines the interface for different data sources
 type DataSource interface {
 	// GetLatestMetrics returns the most recent metrics
@@ -45,9 +61,18 @@ type DataSource interface {
 	// GetLatencyHistory returns latency history
 	GetLatencyHistory(ctx context.Context, duration time.Duration) ([]int64, error)
 
+	// GetDiskHistory returns disk usage history, in percent
+	GetDiskHistory(ctx context.Context, duration time.Duration) ([]float64, error)
+
+	// GetNetworkHistory returns network traffic history
+	GetNetworkHistory(ctx context.Context, duration time.Duration) ([]NetworkInfo, error)
+
 	// GetTimestamps returns timestamps for metrics
 	GetTimestamps(ctx context.Context, duration time.Duration) ([]time.Time, error)
 
+	// CollectionErrors returns how many times collecting or querying metrics failed
+	CollectionErrors() uint64
+
 	// HealthCheck checks if the data source is healthy
 	HealthCheck(ctx context.Context) error
 
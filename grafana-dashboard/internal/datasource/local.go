@@ -3,11 +3,16 @@ package datasource
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
 	"github.com/sirupsen/logrus"
+
+	"system-monitor/pkg/ticker"
 )
 
 // LocalDataSource implements DataSource for local system metrics
@@ -17,6 +22,22 @@ type LocalDataSource struct {
 	mu              sync.RWMutex
 	stopChan        chan struct{}
 	latencyMeasurer *LatencyMeasurer
+	now             func() time.Time
+
+	// diskPath is the filesystem whose usage is collected. lastNet holds the
+	// interface counters of the previous sample, so each sample reports the
+	// bytes transferred since then; it is only touched by collectMetrics.
+	diskPath string
+	lastNet  *net.IOCountersStat
+
+	// collectionErrors counts failed reads of system metrics
+	collectionErrors atomic.Uint64
+
+	// Downsampling: raw samples are kept for hotWindow, older ones are rolled
+	// into averages over resolution-sized buckets. Disabled when hotWindow is 0.
+	hotWindow  time.Duration
+	resolution time.Duration
+	rollups    []*rollup
 }
 
 // NewLocalDataSource creates a new local data source
@@ -26,29 +47,42 @@ func NewLocalDataSource(maxHistory int) *LocalDataSource {
 		maxHistory:      maxHistory,
 		stopChan:        make(chan struct{}),
 		latencyMeasurer: NewLatencyMeasurer(),
+		now:             time.Now,
+		diskPath:        "/",
 	}
 }
 
-// Start begins collecting metrics at the specified interval
-func (ds *LocalDataSource) Start(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	logrus.Info("Starting local metrics collection")
+// WithDownsampling keeps raw samples only for hotWindow and rolls older samples
+// into averages over resolution-sized buckets. History queries return the
+// rolled-up samples followed by the raw ones. Up to maxHistory raw samples and
+// maxHistory buckets are kept.
+func (ds *LocalDataSource) WithDownsampling(hotWindow, resolution time.Duration) *LocalDataSource {
+	ds.hotWindow = hotWindow
+	ds.resolution = resolution
+	return ds
+}
 
-	for {
+// Start begins collecting metrics at the specified interval. It returns when
+// ctx is cancelled or Stop is called.
+func (ds *LocalDataSource) Start(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
 		select {
-		case <-ctx.Done():
-			logrus.Info("Stopping local metrics collection")
-			return
 		case <-ds.stopChan:
-			logrus.Info("Stopping local metrics collection")
-			return
-		case <-ticker.C:
-			metrics := ds.collectMetrics()
-			ds.addMetrics(metrics)
+			cancel()
+		case <-ctx.Done():
 		}
-	}
+	}()
+
+	logrus.Info("Starting local metrics collection")
+
+	ticker.Every(ctx, interval, func(context.Context) error {
+		ds.addMetrics(ds.collectMetrics())
+		return nil
+	}, ticker.WithName("local metrics collection"))
+
+	logrus.Info("Stopping local metrics collection")
 }
 
 // Stop stops the metrics collection
@@ -68,14 +102,15 @@ func (ds *LocalDataSource) GetLatestMetrics(ctx context.Context) (*Metrics, erro
 	return ds.metrics[len(ds.metrics)-1], nil
 }
 
-// GetMetricsHistory returns historical metrics within a time range
+// GetMetricsHistory returns historical metrics within a time range, including samples
+// taken exactly at start or end
 func (ds *LocalDataSource) GetMetricsHistory(ctx context.Context, start, end time.Time) ([]*Metrics, error) {
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
 	var result []*Metrics
-	for _, metric := range ds.metrics {
-		if metric.Timestamp.After(start) && metric.Timestamp.Before(end) {
+	for _, metric := range ds.history() {
+		if !metric.Timestamp.Before(start) && !metric.Timestamp.After(end) {
 			result = append(result, metric)
 		}
 	}
@@ -87,10 +122,10 @@ func (ds *LocalDataSource) GetCPUHistory(ctx context.Context, duration time.Dura
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
-	cutoff := time.Now().Add(-duration)
+	cutoff := ds.now().Add(-duration)
 	var result []float64
 
-	for _, metric := range ds.metrics {
+	for _, metric := range ds.history() {
 		if metric.Timestamp.After(cutoff) {
 			result = append(result, metric.CPU)
 		}
@@ -103,10 +138,10 @@ func (ds *LocalDataSource) GetMemoryHistory(ctx context.Context, duration time.D
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
-	cutoff := time.Now().Add(-duration)
+	cutoff := ds.now().Add(-duration)
 	var result []float64
 
-	for _, metric := range ds.metrics {
+	for _, metric := range ds.history() {
 		if metric.Timestamp.After(cutoff) {
 			result = append(result, metric.Memory.Percent)
 		}
@@ -119,10 +154,10 @@ func (ds *LocalDataSource) GetLatencyHistory(ctx context.Context, duration time.
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
-	cutoff := time.Now().Add(-duration)
+	cutoff := ds.now().Add(-duration)
 	var result []int64
 
-	for _, metric := range ds.metrics {
+	for _, metric := range ds.history() {
 		if metric.Timestamp.After(cutoff) {
 			result = append(result, metric.Latency.HTTPLatency)
 		}
@@ -130,15 +165,47 @@ func (ds *LocalDataSource) GetLatencyHistory(ctx context.Context, duration time.
 	return result, nil
 }
 
+// GetDiskHistory returns disk usage history
+func (ds *LocalDataSource) GetDiskHistory(ctx context.Context, duration time.Duration) ([]float64, error) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	cutoff := ds.now().Add(-duration)
+	var result []float64
+
+	for _, metric := range ds.history() {
+		if metric.Timestamp.After(cutoff) {
+			result = append(result, metric.Disk.Percent)
+		}
+	}
+	return result, nil
+}
+
+// GetNetworkHistory returns network traffic history
+func (ds *LocalDataSource) GetNetworkHistory(ctx context.Context, duration time.Duration) ([]NetworkInfo, error) {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+
+	cutoff := ds.now().Add(-duration)
+	var result []NetworkInfo
+
+	for _, metric := range ds.history() {
+		if metric.Timestamp.After(cutoff) {
+			result = append(result, metric.Network)
+		}
+	}
+	return result, nil
+}
+
 // GetTimestamps returns timestamps for metrics
 func (ds *LocalDataSource) GetTimestamps(ctx context.Context, duration time.Duration) ([]time.Time, error) {
 	ds.mu.RLock()
 	defer ds.mu.RUnlock()
 
-	cutoff := time.Now().Add(-duration)
+	cutoff := ds.now().Add(-duration)
 	var result []time.Time
 
-	for _, metric := range ds.metrics {
+	for _, metric := range ds.history() {
 		if metric.Timestamp.After(cutoff) {
 			result = append(result, metric.Timestamp)
 		}
@@ -152,6 +219,11 @@ func (ds *LocalDataSource) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// CollectionErrors returns how many times reading a system metric failed
+func (ds *LocalDataSource) CollectionErrors() uint64 {
+	return ds.collectionErrors.Load()
+}
+
 // Close closes the data source connection
 func (ds *LocalDataSource) Close() error {
 	ds.Stop()
@@ -160,13 +232,14 @@ func (ds *LocalDataSource) Close() error {
 
 // collectMetrics gathers current system metrics
 func (ds *LocalDataSource) collectMetrics() *Metrics {
-	now := time.Now()
+	now := ds.now()
 
 	// Collect CPU metrics
 	cpuPercent, err := cpu.Percent(0, false)
 	var cpuUsage float64
 	if err != nil {
 		logrus.Errorf("Failed to collect CPU metrics: %v", err)
+		ds.collectionErrors.Add(1)
 		cpuUsage = 0
 	} else if len(cpuPercent) > 0 {
 		cpuUsage = cpuPercent[0]
@@ -177,6 +250,7 @@ func (ds *LocalDataSource) collectMetrics() *Metrics {
 	var memoryInfo MemoryInfo
 	if err != nil {
 		logrus.Errorf("Failed to collect memory metrics: %v", err)
+		ds.collectionErrors.Add(1)
 		memoryInfo = MemoryInfo{}
 	} else {
 		memoryInfo = MemoryInfo{
@@ -190,11 +264,51 @@ func (ds *LocalDataSource) collectMetrics() *Metrics {
 	// Collect latency metrics
 	latencyInfo := ds.latencyMeasurer.MeasureLatency()
 
+	// Collect disk metrics
+	var diskInfo DiskInfo
+	if usage, err := disk.Usage(ds.diskPath); err != nil {
+		logrus.Errorf("Failed to collect disk metrics: %v", err)
+		ds.collectionErrors.Add(1)
+	} else {
+		diskInfo = DiskInfo{
+			Total:   usage.Total,
+			Used:    usage.Used,
+			Percent: usage.UsedPercent,
+		}
+	}
+
 	return &Metrics{
 		Timestamp: now,
 		CPU:       cpuUsage,
 		Memory:    memoryInfo,
 		Latency:   latencyInfo,
+		Disk:      diskInfo,
+		Network:   ds.collectNetwork(),
+	}
+}
+
+// collectNetwork returns the bytes transferred since the previous call. The
+// first call, and any call after the counters went backwards (e.g. an
+// interface was reset), reports nothing.
+func (ds *LocalDataSource) collectNetwork() NetworkInfo {
+	counters, err := net.IOCounters(false)
+	if err != nil || len(counters) == 0 {
+		if err != nil {
+			logrus.Errorf("Failed to collect network metrics: %v", err)
+			ds.collectionErrors.Add(1)
+		}
+		return NetworkInfo{}
+	}
+
+	current := counters[0]
+	last := ds.lastNet
+	ds.lastNet = &current
+	if last == nil || current.BytesSent < last.BytesSent || current.BytesRecv < last.BytesRecv {
+		return NetworkInfo{}
+	}
+	return NetworkInfo{
+		BytesSent: current.BytesSent - last.BytesSent,
+		BytesRecv: current.BytesRecv - last.BytesRecv,
 	}
 }
 
@@ -205,12 +319,61 @@ func (ds *LocalDataSource) addMetrics(metrics *Metrics) {
 
 	ds.metrics = append(ds.metrics, metrics)
 
+	if ds.hotWindow > 0 {
+		ds.downsample()
+	}
+
 	// Keep only the last maxHistory metrics
 	if len(ds.metrics) > ds.maxHistory {
 		ds.metrics = ds.metrics[len(ds.metrics)-ds.maxHistory:]
 	}
 }
 
+// downsample moves raw samples older than the hot window into rollup buckets.
+// The caller must hold ds.mu.
+func (ds *LocalDataSource) downsample() {
+	cutoff := ds.now().Add(-ds.hotWindow)
+
+	aged := 0
+	for aged < len(ds.metrics) && ds.metrics[aged].Timestamp.Before(cutoff) {
+		bucket := ds.metrics[aged].Timestamp.Truncate(ds.resolution)
+
+		var last *rollup
+		if n := len(ds.rollups); n > 0 {
+			last = ds.rollups[n-1]
+		}
+		if last == nil || !last.bucket.Equal(bucket) {
+			last = &rollup{bucket: bucket}
+			ds.rollups = append(ds.rollups, last)
+		}
+		last.add(ds.metrics[aged])
+		aged++
+	}
+
+	if aged == 0 {
+		return
+	}
+	ds.metrics = append(ds.metrics[:0:0], ds.metrics[aged:]...)
+
+	if len(ds.rollups) > ds.maxHistory {
+		ds.rollups = ds.rollups[len(ds.rollups)-ds.maxHistory:]
+	}
+}
+
+// history returns rolled-up samples followed by raw samples, oldest first.
+// The caller must hold ds.mu.
+func (ds *LocalDataSource) history() []*Metrics {
+	if len(ds.rollups) == 0 {
+		return ds.metrics
+	}
+
+	result := make([]*Metrics, 0, len(ds.rollups)+len(ds.metrics))
+	for _, r := range ds.rollups {
+		result = append(result, r.metrics())
+	}
+	return append(result, ds.metrics...)
+}
+
 // LatencyMeasurer handles latency measurements
 type LatencyMeasurer struct {
 	rand *time.Time
@@ -0,0 +1,185 @@
+package datasource
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// feedSamples adds one sample every interval starting at start, moving the data
+// source clock along with them. Sample i has CPU m*10 + i%2 where m is its minute,
+// so each full minute averages to m*10 + 0.5.
+func feedSamples(ds *LocalDataSource, start time.Time, interval time.Duration, count int) {
+	for i := 0; i < count; i++ {
+		ts := start.Add(time.Duration(i) * interval)
+		minute := float64(ts.Sub(start) / time.Minute)
+		ds.now = func() time.Time { return ts }
+		ds.addMetrics(&Metrics{
+			Timestamp: ts,
+			CPU:       minute*10 + float64(i%2),
+			Memory:    MemoryInfo{Total: 1000, Used: uint64(100 + i%2*100), Percent: 10 + float64(i%2)*10},
+			Latency:   LatencyInfo{HTTPLatency: int64(100 + i%2*100)},
+			Disk:      DiskInfo{Total: 1000, Used: uint64(400 + i%2*200), Percent: 40 + float64(i%2)*20},
+			Network:   NetworkInfo{BytesSent: uint64(1000 + i%2*1000), BytesRecv: uint64(i % 2 * 500)},
+		})
+	}
+}
+
+func TestLocalDataSourceDownsampling(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewLocalDataSource(1000).WithDownsampling(2*time.Minute, time.Minute)
+
+	// 10 minutes of 5-second samples
+	feedSamples(ds, start, 5*time.Second, 120)
+	ctx := context.Background()
+
+	// Everything up to 12:07:55 is rolled into 1-minute buckets (12:07 only partly)
+	if len(ds.rollups) != 8 {
+		t.Fatalf("Expected 8 rollup buckets, got %d", len(ds.rollups))
+	}
+	if len(ds.metrics) != 25 {
+		t.Errorf("Expected 25 raw samples in the hot window, got %d", len(ds.metrics))
+	}
+
+	cpu, err := ds.GetCPUHistory(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cpu) != 8+25 {
+		t.Fatalf("Expected %d CPU points, got %d", 8+25, len(cpu))
+	}
+	for m := 0; m < 7; m++ {
+		if want := float64(m)*10 + 0.5; cpu[m] != want {
+			t.Errorf("Expected minute %d CPU average %v, got %v", m, want, cpu[m])
+		}
+	}
+
+	timestamps, err := ds.GetTimestamps(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !timestamps[0].Equal(start) || !timestamps[1].Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected rollups at 1-minute resolution, got %v and %v", timestamps[0], timestamps[1])
+	}
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i].Before(timestamps[i-1]) {
+			t.Fatalf("Expected timestamps in order, got %v before %v", timestamps[i-1], timestamps[i])
+		}
+	}
+	if last := timestamps[len(timestamps)-1]; !last.Equal(start.Add(119 * 5 * time.Second)) {
+		t.Errorf("Expected latest raw sample at full resolution, got %v", last)
+	}
+
+	history, err := ds.GetMetricsHistory(ctx, start.Add(-time.Second), start.Add(30*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected one rolled-up sample for the first minute, got %d", len(history))
+	}
+	if history[0].Memory.Used != 150 || history[0].Memory.Percent != 15 || history[0].Latency.HTTPLatency != 150 {
+		t.Errorf("Expected averaged memory and latency, got %+v", history[0])
+	}
+	if history[0].Disk.Used != 500 || history[0].Disk.Percent != 50 || history[0].Network.BytesSent != 1500 || history[0].Network.BytesRecv != 250 {
+		t.Errorf("Expected averaged disk and network, got %+v and %+v", history[0].Disk, history[0].Network)
+	}
+}
+
+func TestLocalDataSourceWithoutDownsampling(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewLocalDataSource(100)
+
+	feedSamples(ds, start, 5*time.Second, 120)
+
+	if len(ds.rollups) != 0 {
+		t.Errorf("Expected no rollups, got %d", len(ds.rollups))
+	}
+	cpu, err := ds.GetCPUHistory(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(cpu) != 100 {
+		t.Errorf("Expected the last 100 raw samples, got %d", len(cpu))
+	}
+}
+
+func TestLocalDataSourceHistoryBoundsAreInclusive(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewLocalDataSource(100)
+
+	// Samples at 12:00:00 through 12:01:00, 5 seconds apart
+	feedSamples(ds, start, 5*time.Second, 13)
+
+	end := start.Add(time.Minute)
+	metrics, err := ds.GetMetricsHistory(context.Background(), start, end)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(metrics) != 13 {
+		t.Fatalf("Expected all 13 samples, got %d", len(metrics))
+	}
+	if !metrics[0].Timestamp.Equal(start) || !metrics[12].Timestamp.Equal(end) {
+		t.Errorf("Expected samples at %v and %v, got %v and %v", start, end, metrics[0].Timestamp, metrics[12].Timestamp)
+	}
+}
+
+func TestLocalDataSourceRollupsAreBounded(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewLocalDataSource(5).WithDownsampling(time.Minute, time.Minute)
+
+	// 20 minutes of 30-second samples
+	feedSamples(ds, start, 30*time.Second, 40)
+
+	if len(ds.rollups) != 5 {
+		t.Errorf("Expected rollups capped at 5, got %d", len(ds.rollups))
+	}
+	if oldest := ds.rollups[0].bucket; !oldest.Equal(start.Add(14 * time.Minute)) {
+		t.Errorf("Expected oldest bucket to be dropped first, oldest is %v", oldest)
+	}
+}
+
+func TestLocalDataSourceCollectsDiskAndNetwork(t *testing.T) {
+	ds := NewLocalDataSource(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ds.Start(ctx, 10*time.Millisecond)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		timestamps, _ := ds.GetTimestamps(ctx, time.Hour)
+		if len(timestamps) >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 samples within 5s, got %d", len(timestamps))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	latest, err := ds.GetLatestMetrics(context.Background())
+	if err != nil || latest == nil {
+		t.Fatalf("Expected a latest sample, got %v, %v", latest, err)
+	}
+	if latest.Disk.Total == 0 || latest.Disk.Used > latest.Disk.Total || latest.Disk.Percent < 0 || latest.Disk.Percent > 100 {
+		t.Errorf("Expected disk usage of the root filesystem, got %+v", latest.Disk)
+	}
+
+	timestamps, _ := ds.GetTimestamps(context.Background(), time.Hour)
+	diskHistory, err := ds.GetDiskHistory(context.Background(), time.Hour)
+	if err != nil || len(diskHistory) != len(timestamps) {
+		t.Errorf("Expected one disk point per sample, got %d for %d samples (%v)", len(diskHistory), len(timestamps), err)
+	}
+	network, err := ds.GetNetworkHistory(context.Background(), time.Hour)
+	if err != nil || len(network) != len(timestamps) {
+		t.Fatalf("Expected one network point per sample, got %d for %d samples (%v)", len(network), len(timestamps), err)
+	}
+	// Traffic is counted from the previous sample, so the first sample has none
+	if network[0] != (NetworkInfo{}) {
+		t.Errorf("Expected no traffic in the first sample, got %+v", network[0])
+	}
+}
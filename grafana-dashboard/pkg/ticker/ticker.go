@@ -0,0 +1,86 @@
+// Package ticker runs functions on a fixed interval until a context is cancelled.
+package ticker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Option configures Every
+type Option func(*options)
+
+type options struct {
+	name        string
+	immediate   bool
+	stopOnError bool
+}
+
+// WithName sets the name used when logging errors from fn
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// Immediately runs fn once as soon as Every is called instead of waiting for the first tick
+func Immediately() Option {
+	return func(o *options) {
+		o.immediate = true
+	}
+}
+
+// StopOnError makes Every return the first error from fn. By default errors
+// are logged and the loop keeps running.
+func StopOnError() Option {
+	return func(o *options) {
+		o.stopOnError = true
+	}
+}
+
+// Every calls fn every interval until ctx is cancelled. Runs never overlap: a
+// run that takes longer than interval delays the next one rather than stacking
+// up ticks. Every returns nil once ctx is cancelled, or fn's error when
+// StopOnError is set.
+func Every(ctx context.Context, interval time.Duration, fn func(context.Context) error, opts ...Option) error {
+	o := options{name: "ticker loop"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	run := func() error {
+		err := fn(ctx)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+		if o.stopOnError {
+			return err
+		}
+		logrus.Errorf("%s: %v", o.name, err)
+		return nil
+	}
+
+	if o.immediate {
+		if err := run(); err != nil {
+			return err
+		}
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := run(); err != nil {
+				return err
+			}
+		}
+	}
+}
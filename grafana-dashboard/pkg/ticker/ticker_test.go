@@ -0,0 +1,102 @@
+package ticker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryRunsOnSchedule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	var runs atomic.Int32
+	err := Every(ctx, 10*time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := runs.Load(); got < 3 || got > 6 {
+		t.Errorf("Expected about 5 runs in 55ms at 10ms intervals, got %d", got)
+	}
+}
+
+func TestEveryImmediately(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs atomic.Int32
+	err := Every(ctx, time.Hour, func(context.Context) error {
+		runs.Add(1)
+		cancel()
+		return nil
+	}, Immediately())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("Expected 1 immediate run, got %d", got)
+	}
+}
+
+func TestEveryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- Every(ctx, 5*time.Millisecond, func(context.Context) error { return nil })
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected nil after cancel, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Every to return after cancel")
+	}
+}
+
+func TestEveryErrorPolicy(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		wantErr  error
+		wantRuns int32
+	}{
+		{name: "continue on error", opts: nil, wantErr: nil, wantRuns: 3},
+		{name: "stop on error", opts: []Option{StopOnError()}, wantErr: errBoom, wantRuns: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var runs atomic.Int32
+			err := Every(ctx, time.Millisecond, func(context.Context) error {
+				if runs.Add(1) == 3 {
+					cancel()
+				}
+				return errBoom
+			}, append(tt.opts, WithName("test loop"))...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got := runs.Load(); got != tt.wantRuns {
+				t.Errorf("Expected %d runs, got %d", tt.wantRuns, got)
+			}
+		})
+	}
+}
@@ -35,6 +35,7 @@ class SystemMonitorDashboard {
         this.charts.cpu = echarts.init(document.getElementById('cpu-chart'));
         this.charts.memory = echarts.init(document.getElementById('memory-chart'));
         this.charts.latency = echarts.init(document.getElementById('latency-chart'));
+        this.charts.disk = echarts.init(document.getElementById('disk-chart'));
 
         // Set up basic chart options
         const basicOption = {
@@ -82,6 +83,7 @@ class SystemMonitorDashboard {
         this.charts.cpu.setOption(basicOption);
         this.charts.memory.setOption(basicOption);
         this.charts.latency.setOption(basicOption);
+        this.charts.disk.setOption(basicOption);
 
         // Handle window resize
         window.addEventListener('resize', () => {
@@ -146,6 +148,13 @@ class SystemMonitorDashboard {
                 const latencyData = await latencyResponse.json();
                 this.updateChart('latency', latencyData);
             }
+
+            // Load Disk chart data
+            const diskResponse = await fetch('/api/charts/disk');
+            if (diskResponse.ok) {
+                const diskData = await diskResponse.json();
+                this.updateChart('disk', diskData);
+            }
         } catch (error) {
             console.error('Error loading chart data:', error);
         }
@@ -174,6 +183,7 @@ class SystemMonitorDashboard {
         document.getElementById('cpu-value').textContent = `${metrics.cpu?.toFixed(1)}%`;
         document.getElementById('memory-value').textContent = `${metrics.memory?.percent?.toFixed(1)}%`;
         document.getElementById('latency-value').textContent = `${metrics.latency?.http_latency}ms`;
+        document.getElementById('disk-value').textContent = `${metrics.disk?.percent?.toFixed(1)}%`;
 
         // Update last update time
         document.getElementById('last-update').textContent = new Date().toLocaleTimeString();
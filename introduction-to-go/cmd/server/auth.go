@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"effective-golang/internal/auth"
+	"effective-golang/pkg/utils"
+)
+
+type userIDKey struct{}
+
+// UserIDFromContext returns the ID of the user authenticated by authMiddleware
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// authMiddleware requires a valid session ID in the Authorization header, sent
+// bare as logout expects or as "Bearer <session>", and stores the session's
+// user ID in the request context. Missing, unknown and expired sessions get 401.
+func authMiddleware(authService *auth.AuthService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessionID := strings.TrimSpace(r.Header.Get("Authorization"))
+			if token, ok := strings.CutPrefix(sessionID, "Bearer "); ok {
+				sessionID = strings.TrimSpace(token)
+			}
+			if sessionID == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				utils.ErrorResponse(w, http.StatusUnauthorized, "No session provided")
+				return
+			}
+
+			session, err := authService.ValidateSession(r.Context(), sessionID)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey{}, session.UserID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"effective-golang/internal/audit"
+	"effective-golang/internal/auth"
+	"effective-golang/pkg/apitest"
+	"effective-golang/pkg/utils"
+)
+
+// TestAuthMiddleware tests that only valid sessions reach the handler, with their user ID in the context
+func TestAuthMiddleware(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+
+	user, err := authService.Register(ctx, &auth.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	session, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	loggedOut, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if err := authService.Logout(ctx, loggedOut.ID); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	var gotUserID string
+	handler := authMiddleware(authService)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"unknown session", "not-a-session", http.StatusUnauthorized},
+		{"logged out session", loggedOut.ID, http.StatusUnauthorized},
+		{"bearer without session", "Bearer ", http.StatusUnauthorized},
+		{"bare session", session.ID, http.StatusNoContent},
+		{"bearer session", "Bearer " + session.ID, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID = ""
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/games/active", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if gotUserID != "" {
+					t.Errorf("handler ran for an unauthenticated request")
+				}
+				if rec.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("WWW-Authenticate header missing on 401")
+				}
+				return
+			}
+			if gotUserID != user.ID {
+				t.Errorf("UserIDFromContext() = %q, want %q", gotUserID, user.ID)
+			}
+		})
+	}
+
+	if _, ok := UserIDFromContext(ctx); ok {
+		t.Error("UserIDFromContext() on a bare context reported a user")
+	}
+}
+
+// TestProtectedRoutes tests that game and leaderboard routes need a session while auth and health stay public
+func TestProtectedRoutes(t *testing.T) {
+	app := newTestApplication(t)
+
+	do(t, app, http.MethodGet, "/health", nil, nil).AssertStatus(t, http.StatusOK)
+	registerUser(t, app, "routes")
+	session := login(t, app, "routes")
+
+	for _, path := range []string{"/api/v1/games/active", "/api/v1/leaderboards/missing"} {
+		do(t, app, http.MethodGet, path, nil, nil).AssertStatus(t, http.StatusUnauthorized)
+		do(t, app, http.MethodGet, path, nil, map[string]string{"Authorization": "bogus"}).AssertStatus(t, http.StatusUnauthorized)
+	}
+	do(t, app, http.MethodGet, "/api/v1/games/active", nil, session).AssertStatus(t, http.StatusOK)
+	do(t, app, http.MethodGet, "/api/v1/leaderboards/missing", nil, session).AssertStatus(t, http.StatusNotFound)
+}
+
+// TestLoginLockout tests that a locked out login is answered with 429
+func TestLoginLockout(t *testing.T) {
+	t.Setenv("LOGIN_MAX_ATTEMPTS", "2")
+	app := newTestApplication(t)
+	registerUser(t, app, "lockout")
+
+	wrong := map[string]string{"username": "lockout", "password": "wrong-password"}
+	do(t, app, http.MethodPost, "/api/v1/auth/login", wrong, nil).AssertStatus(t, http.StatusUnauthorized)
+	do(t, app, http.MethodPost, "/api/v1/auth/login", wrong, nil).AssertStatus(t, http.StatusUnauthorized)
+
+	right := map[string]string{"username": "lockout", "password": "password123"}
+	do(t, app, http.MethodPost, "/api/v1/auth/login", right, nil).AssertStatus(t, http.StatusTooManyRequests)
+}
+
+// TestLoginLockoutPerIP tests that the per-IP lockout counts failures for
+// different usernames together, and that rotating X-Forwarded-For does not
+// give a client a fresh count
+func TestLoginLockoutPerIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		forwarded      func(attempt int) string
+	}{
+		{"direct client", "", func(attempt int) string { return fmt.Sprintf("198.51.100.%d", attempt) }},
+		// The proxy appends the address it saw; only the hops left of it change
+		{"behind a trusted proxy", "192.0.2.1", func(attempt int) string { return fmt.Sprintf("198.51.100.%d, 203.0.113.9", attempt) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOGIN_MAX_ATTEMPTS", "2")
+			t.Setenv("TRUSTED_PROXIES", tt.trustedProxies)
+			app := newTestApplication(t)
+			registerUser(t, app, "victim")
+
+			loginAs := func(attempt int, username, password string) *apitest.Response {
+				return do(t, app, http.MethodPost, "/api/v1/auth/login",
+					map[string]string{"username": username, "password": password},
+					map[string]string{"X-Forwarded-For": tt.forwarded(attempt)})
+			}
+
+			// Each guess targets a different user, so only the IP count can trip
+			loginAs(1, "nobody1", "wrong-password").AssertStatus(t, http.StatusUnauthorized)
+			loginAs(2, "nobody2", "wrong-password").AssertStatus(t, http.StatusUnauthorized)
+			loginAs(3, "victim", "password123").AssertStatus(t, http.StatusTooManyRequests)
+		})
+	}
+}
+
+// TestChangeUserPassword tests that users can change only their own password, and only with the current one
+func TestChangeUserPassword(t *testing.T) {
+	app := newTestApplication(t)
+	aliceID := registerUser(t, app, "alice")
+	bobID := registerUser(t, app, "bob")
+	alice := login(t, app, "alice")
+
+	change := func(current, next string) map[string]string {
+		return map[string]string{"current_password": current, "new_password": next}
+	}
+	tests := []struct {
+		name       string
+		userID     string
+		body       interface{}
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"no session", aliceID, change("password123", "newpassword456"), nil, http.StatusUnauthorized},
+		{"someone else's password", bobID, change("password123", "newpassword456"), alice, http.StatusForbidden},
+		{"wrong current password", aliceID, change("wrong-password", "newpassword456"), alice, http.StatusUnauthorized},
+		{"new password too short", aliceID, change("password123", "short"), alice, http.StatusBadRequest},
+		{"malformed body", aliceID, "not an object", alice, http.StatusBadRequest},
+		{"own password", aliceID, change("password123", "newpassword456"), alice, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			do(t, app, http.MethodPut, "/api/v1/users/"+tt.userID+"/password", tt.body, tt.headers).AssertStatus(t, tt.wantStatus)
+		})
+	}
+
+	do(t, app, http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "alice", "password": "password123"}, nil).AssertStatus(t, http.StatusUnauthorized)
+	do(t, app, http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "alice", "password": "newpassword456"}, nil).AssertStatus(t, http.StatusOK)
+	do(t, app, http.MethodPost, "/api/v1/auth/login", map[string]string{"username": "bob", "password": "password123"}, nil).AssertStatus(t, http.StatusOK)
+
+	// Every attempt that reached the service is audited, and the users endpoint is the only one
+	entries, err := app.auditLog.Recent(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	changes := map[bool]int{}
+	for _, entry := range entries {
+		if entry.Action == audit.ActionPasswordChange {
+			changes[entry.Success]++
+		}
+	}
+	if changes[true] != 1 || changes[false] != 2 {
+		t.Errorf("password change audit records = %v, want 1 success and 2 failures", changes)
+	}
+	do(t, app, http.MethodPut, "/api/v1/auth/password", change("newpassword456", "password123"), alice).AssertStatus(t, http.StatusNotFound)
+}
+
+// TestSessionEndpoints tests listing and revoking your own sessions
+func TestSessionEndpoints(t *testing.T) {
+	app := newTestApplication(t)
+	aliceID := registerUser(t, app, "alice")
+	bobID := registerUser(t, app, "bob")
+	alice := login(t, app, "alice")
+	login(t, app, "alice")
+
+	resp := do(t, app, http.MethodGet, "/api/v1/users/"+aliceID+"/sessions", nil, alice)
+	resp.AssertStatus(t, http.StatusOK)
+	var sessions []map[string]interface{}
+	resp.DecodeData(t, &sessions)
+	if len(sessions) != 2 {
+		t.Errorf("listed %d sessions, want 2", len(sessions))
+	}
+
+	do(t, app, http.MethodGet, "/api/v1/users/"+bobID+"/sessions", nil, alice).AssertStatus(t, http.StatusForbidden)
+	do(t, app, http.MethodDelete, "/api/v1/users/"+bobID+"/sessions", nil, alice).AssertStatus(t, http.StatusForbidden)
+
+	do(t, app, http.MethodDelete, "/api/v1/users/"+aliceID+"/sessions", nil, alice).AssertStatus(t, http.StatusOK)
+	do(t, app, http.MethodGet, "/api/v1/users/"+aliceID+"/sessions", nil, alice).AssertStatus(t, http.StatusUnauthorized)
+}
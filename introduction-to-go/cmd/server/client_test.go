@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"effective-golang/pkg/client"
+)
+
+// newTestClient serves the full application over HTTP and returns a client for it
+func newTestClient(t *testing.T) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(newTestApplication(t).server.Handler)
+	t.Cleanup(srv.Close)
+	return client.New(srv.URL, client.WithHTTPClient(srv.Client()))
+}
+
+// TestClientGameFlow tests a game and leaderboard round trip through the client SDK
+func TestClientGameFlow(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	alice, err := c.Register(ctx, "alice", "alice@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Register(alice) error = %v", err)
+	}
+	bob, err := c.Register(ctx, "bob", "bob@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Register(bob) error = %v", err)
+	}
+
+	session, err := c.Login(ctx, "alice", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if session.UserID != alice.ID || c.Session() != session.ID {
+		t.Fatalf("Login() session = %+v, client session %q, want alice's session attached", session, c.Session())
+	}
+
+	if err := c.ChangePassword(ctx, alice.ID, "password123", "newpassword456"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	var apiErr *client.APIError
+	if err := c.ChangePassword(ctx, alice.ID, "password123", "whatever789"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("ChangePassword() with the old password error = %v, want a 401 APIError", err)
+	}
+
+	game, err := c.CreateGame(ctx, alice.ID, bob.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := c.StartGame(ctx, game.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+	if err := c.UpdateScore(ctx, game.ID, alice.ID, 30); err != nil {
+		t.Fatalf("UpdateScore(alice) error = %v", err)
+	}
+	if err := c.UpdateScore(ctx, game.ID, bob.ID, 20); err != nil {
+		t.Fatalf("UpdateScore(bob) error = %v", err)
+	}
+	result, err := c.EndGame(ctx, game.ID)
+	if err != nil {
+		t.Fatalf("EndGame() error = %v", err)
+	}
+	if result.WinnerID != alice.ID || result.WinnerScore != 30 || result.LoserScore != 20 {
+		t.Errorf("EndGame() = %+v, want alice winning 30-20", result)
+	}
+
+	board, err := c.CreateLeaderboard(ctx, client.CreateLeaderboardRequest{Name: "Global", Type: "global", MaxEntries: 10})
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	if err := c.AddScore(ctx, board.ID, alice.ID, 30, nil); err != nil {
+		t.Fatalf("AddScore(alice) error = %v", err)
+	}
+	if err := c.AddScore(ctx, board.ID, bob.ID, 50, map[string]string{"level": "3"}); err != nil {
+		t.Fatalf("AddScore(bob) error = %v", err)
+	}
+
+	page, err := c.GetTopEntries(ctx, board.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	if entries := page.Entries; page.Total != 2 || len(entries) != 2 || entries[0].UserID != bob.ID || entries[0].Metadata["level"] != "3" || entries[1].UserID != alice.ID {
+		t.Errorf("GetTopEntries() = %+v, want bob then alice", entries)
+	}
+
+	rank, err := c.GetUserRank(ctx, board.ID, alice.ID)
+	if err != nil || !rank.Ranked || rank.Rank != 2 {
+		t.Errorf("GetUserRank(alice) = %+v, %v, want rank 2", rank, err)
+	}
+
+	games, err := c.GetUserGames(ctx, alice.ID, 0)
+	if err != nil || len(games) != 1 || games[0].ID != game.ID {
+		t.Errorf("GetUserGames(alice) = %v, %v, want the finished game", games, err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if c.Session() != "" {
+		t.Errorf("Session() = %q after Logout, want empty", c.Session())
+	}
+}
+
+// TestClientErrors tests that non-2xx responses come back as wrapped APIErrors
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	_, err := c.GetActiveGames(ctx)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetActiveGames() without a session error = %v, want a 401 APIError", err)
+	}
+
+	_, err = c.Register(ctx, "x", "not-an-email", "pw")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || len(apiErr.Errors) == 0 {
+		t.Fatalf("Register() with invalid fields error = %v, want a 400 APIError with field errors", err)
+	}
+
+	if _, err := c.Login(ctx, "nobody", "password123"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Login() with unknown user error = %v, want a 401 APIError", err)
+	}
+	if c.Session() != "" {
+		t.Errorf("Session() = %q after a failed Login, want empty", c.Session())
+	}
+}
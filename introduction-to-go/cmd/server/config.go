@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"effective-golang/internal/models"
+)
+
+// redactedValue replaces secret values in Config.Redacted output
+const redactedValue = "[REDACTED]"
+
+// Config is the server's effective configuration, read from the environment.
+// Fields tagged secret:"true" hold credentials and are masked by Redacted.
+type Config struct {
+	Port        string `json:"port"`
+	TLSCertFile string `json:"tls_cert_file"`
+	TLSKeyFile  string `json:"tls_key_file"`
+
+	// Reverse proxies whose X-Forwarded-For is believed when finding the client IP
+	TrustedProxies []netip.Prefix `json:"trusted_proxies"`
+
+	// Admin controls
+	AdminToken      string `json:"admin_token" secret:"true"`
+	MaintenanceMode bool   `json:"maintenance_mode"`
+	AuditLogFile    string `json:"audit_log_file"`
+
+	// Usernames are looked up ignoring case; this resolves names that differ only in case
+	UsernameFallback models.UsernameFallback `json:"username_fallback"`
+
+	// Login lockout after repeated failures per username and client IP
+	LoginMaxAttempts   int           `json:"login_max_attempts"`
+	LoginLockoutWindow time.Duration `json:"login_lockout_window"`
+
+	// Game lifecycle
+	GameArchiveRetention time.Duration `json:"game_archive_retention"`
+	GameMaxDuration      time.Duration `json:"game_max_duration"`
+	GameIdleTimeout      time.Duration `json:"game_idle_timeout"`
+	GameMinWorkers       int           `json:"game_min_workers"`
+
+	// Anti-cheat: scores above allowance + rate * seconds played are flagged
+	ScorePlausibilityRate      float64 `json:"score_plausibility_rate"`
+	ScorePlausibilityAllowance int64   `json:"score_plausibility_allowance"`
+
+	// Leaderboard durability
+	LeaderboardWALFile          string        `json:"leaderboard_wal_file"`
+	LeaderboardSnapshotInterval time.Duration `json:"leaderboard_snapshot_interval"`
+	LeaderboardFlushInterval    time.Duration `json:"leaderboard_flush_interval"`
+	LeaderboardMaxTopEntries    int           `json:"leaderboard_max_top_entries"`
+
+	// Shared Redis cache; empty keeps the in-memory cache
+	RedisURL string `json:"redis_url" secret:"true"`
+
+	// In-memory data is saved here on shutdown and loaded on startup; empty disables it
+	SnapshotFile string `json:"snapshot_file"`
+
+	// Request duration histogram bucket bounds in seconds, served at /metrics
+	MetricsBuckets []float64 `json:"metrics_buckets"`
+
+	// Request log format: "text" lines or "json" objects
+	LogFormat string `json:"log_format"`
+
+	// Cross-origin policy for browser clients
+	CORS CORSConfig `json:"cors"`
+}
+
+// loadConfig reads the server configuration from the environment
+func loadConfig() (*Config, error) {
+	cfg := &Config{
+		Port:            getEnv("PORT", "8080"),
+		TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+		AdminToken:      getEnv("ADMIN_TOKEN", ""),
+		MaintenanceMode: getEnv("MAINTENANCE_MODE", "false") == "true",
+		AuditLogFile:    getEnv("AUDIT_LOG_FILE", ""),
+
+		LeaderboardWALFile: getEnv("LEADERBOARD_WAL_FILE", ""),
+		SnapshotFile:       getEnv("SNAPSHOT_FILE", ""),
+		RedisURL:           getEnv("REDIS_URL", ""),
+	}
+
+	// Usernames differing only in case resolve per USERNAME_FALLBACK: "exact" prefers an exact match, "error" rejects the lookup
+	switch fallback := models.UsernameFallback(getEnv("USERNAME_FALLBACK", string(models.UsernameFallbackExact))); fallback {
+	case models.UsernameFallbackExact, models.UsernameFallbackError:
+		cfg.UsernameFallback = fallback
+	default:
+		return nil, fmt.Errorf("invalid USERNAME_FALLBACK: %q", fallback)
+	}
+
+	// Logins lock out after LOGIN_MAX_ATTEMPTS failures within LOGIN_LOCKOUT_WINDOW; "0" disables the lockout
+	maxAttempts, err := strconv.Atoi(getEnv("LOGIN_MAX_ATTEMPTS", "5"))
+	if err != nil || maxAttempts < 0 {
+		return nil, fmt.Errorf("invalid LOGIN_MAX_ATTEMPTS: %q", getEnv("LOGIN_MAX_ATTEMPTS", "5"))
+	}
+	cfg.LoginMaxAttempts = maxAttempts
+
+	lockoutWindow, err := time.ParseDuration(getEnv("LOGIN_LOCKOUT_WINDOW", "15m"))
+	if err != nil || lockoutWindow <= 0 {
+		return nil, fmt.Errorf("invalid LOGIN_LOCKOUT_WINDOW: %q", getEnv("LOGIN_LOCKOUT_WINDOW", "15m"))
+	}
+	cfg.LoginLockoutWindow = lockoutWindow
+
+	// Ended games are archived after GAME_ARCHIVE_RETENTION; "0" keeps them forever
+	retention, err := time.ParseDuration(getEnv("GAME_ARCHIVE_RETENTION", "24h"))
+	if err != nil || retention < 0 {
+		return nil, fmt.Errorf("invalid GAME_ARCHIVE_RETENTION: %q", getEnv("GAME_ARCHIVE_RETENTION", "24h"))
+	}
+	cfg.GameArchiveRetention = retention
+
+	// Playing games are ended once they exceed GAME_MAX_DURATION; "0" disables the limit
+	maxDuration, err := time.ParseDuration(getEnv("GAME_MAX_DURATION", "0"))
+	if err != nil || maxDuration < 0 {
+		return nil, fmt.Errorf("invalid GAME_MAX_DURATION: %q", getEnv("GAME_MAX_DURATION", "0"))
+	}
+	cfg.GameMaxDuration = maxDuration
+
+	// Playing games with no score update for GAME_IDLE_TIMEOUT are cancelled; "0" keeps them
+	idleTimeout, err := time.ParseDuration(getEnv("GAME_IDLE_TIMEOUT", "0"))
+	if err != nil || idleTimeout < 0 {
+		return nil, fmt.Errorf("invalid GAME_IDLE_TIMEOUT: %q", getEnv("GAME_IDLE_TIMEOUT", "0"))
+	}
+	cfg.GameIdleTimeout = idleTimeout
+
+	// Event workers scale between GAME_MIN_WORKERS and the maximum with queue pressure; "0" keeps the maximum running
+	minWorkers, err := strconv.Atoi(getEnv("GAME_MIN_WORKERS", "0"))
+	if err != nil || minWorkers < 0 {
+		return nil, fmt.Errorf("invalid GAME_MIN_WORKERS: %q", getEnv("GAME_MIN_WORKERS", "0"))
+	}
+	cfg.GameMinWorkers = minWorkers
+
+	// Scores are checked against SCORE_PLAUSIBILITY_RATE points per second played; "0" disables the check
+	rate, err := strconv.ParseFloat(getEnv("SCORE_PLAUSIBILITY_RATE", "0"), 64)
+	if err != nil || rate < 0 {
+		return nil, fmt.Errorf("invalid SCORE_PLAUSIBILITY_RATE: %q", getEnv("SCORE_PLAUSIBILITY_RATE", "0"))
+	}
+	cfg.ScorePlausibilityRate = rate
+
+	allowance, err := strconv.ParseInt(getEnv("SCORE_PLAUSIBILITY_ALLOWANCE", "0"), 10, 64)
+	if err != nil || allowance < 0 {
+		return nil, fmt.Errorf("invalid SCORE_PLAUSIBILITY_ALLOWANCE: %q", getEnv("SCORE_PLAUSIBILITY_ALLOWANCE", "0"))
+	}
+	cfg.ScorePlausibilityAllowance = allowance
+
+	// Leaderboards are snapshotted every LEADERBOARD_SNAPSHOT_INTERVAL when a WAL is configured; "0" disables snapshots
+	snapshotInterval, err := time.ParseDuration(getEnv("LEADERBOARD_SNAPSHOT_INTERVAL", "5m"))
+	if err != nil || snapshotInterval < 0 {
+		return nil, fmt.Errorf("invalid LEADERBOARD_SNAPSHOT_INTERVAL: %q", getEnv("LEADERBOARD_SNAPSHOT_INTERVAL", "5m"))
+	}
+	cfg.LeaderboardSnapshotInterval = snapshotInterval
+
+	// Changed leaderboards are written to the repository every LEADERBOARD_FLUSH_INTERVAL; "0" writes every change
+	flushInterval, err := time.ParseDuration(getEnv("LEADERBOARD_FLUSH_INTERVAL", "0"))
+	if err != nil || flushInterval < 0 {
+		return nil, fmt.Errorf("invalid LEADERBOARD_FLUSH_INTERVAL: %q", getEnv("LEADERBOARD_FLUSH_INTERVAL", "0"))
+	}
+	cfg.LeaderboardFlushInterval = flushInterval
+
+	// Top-entries requests return at most LEADERBOARD_MAX_TOP_ENTRIES entries
+	maxTopEntries, err := strconv.Atoi(getEnv("LEADERBOARD_MAX_TOP_ENTRIES", "1000"))
+	if err != nil || maxTopEntries <= 0 {
+		return nil, fmt.Errorf("invalid LEADERBOARD_MAX_TOP_ENTRIES: %q", getEnv("LEADERBOARD_MAX_TOP_ENTRIES", "1000"))
+	}
+	cfg.LeaderboardMaxTopEntries = maxTopEntries
+
+	// Request durations are bucketed by METRICS_BUCKETS, comma-separated seconds; empty uses the defaults
+	buckets, err := parseBuckets(getEnv("METRICS_BUCKETS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_BUCKETS: %w", err)
+	}
+	cfg.MetricsBuckets = buckets
+
+	// Client IPs come from X-Forwarded-For only when the request arrives from
+	// one of TRUSTED_PROXIES, comma-separated IPs or CIDR ranges
+	proxies, err := parseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+	cfg.TrustedProxies = proxies
+
+	// Requests are logged per LOG_FORMAT: "text" lines, or "json" objects for log aggregators
+	switch format := getEnv("LOG_FORMAT", logFormatText); format {
+	case logFormatText, logFormatJSON:
+		cfg.LogFormat = format
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT: %q", format)
+	}
+
+	// Browsers may call the API from CORS_ALLOWED_ORIGINS, comma-separated; "*"
+	// allows any origin. Unset allows none, so nothing is opened up by default.
+	cfg.CORS = CORSConfig{
+		AllowedOrigins:   splitList(getEnv("CORS_ALLOWED_ORIGINS", "")),
+		AllowedMethods:   splitList(getEnv("CORS_ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS")),
+		AllowedHeaders:   splitList(getEnv("CORS_ALLOWED_HEADERS", "Content-Type, Authorization, X-Request-ID")),
+		AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+		Strict:           getEnv("CORS_STRICT", "false") == "true",
+	}
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, corsAnyOrigin) {
+		return nil, fmt.Errorf("CORS_ALLOW_CREDENTIALS requires CORS_ALLOWED_ORIGINS to list origins, not %q", corsAnyOrigin)
+	}
+
+	// Browsers cache preflight responses for CORS_MAX_AGE seconds; "0" leaves it to the browser
+	maxAge, err := strconv.Atoi(getEnv("CORS_MAX_AGE", "0"))
+	if err != nil || maxAge < 0 {
+		return nil, fmt.Errorf("invalid CORS_MAX_AGE: %q", getEnv("CORS_MAX_AGE", "0"))
+	}
+	cfg.CORS.MaxAge = maxAge
+
+	return cfg, nil
+}
+
+// splitList splits a comma-separated setting, dropping blank items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTrustedProxies parses comma-separated IPs and CIDR ranges; a bare IP
+// trusts that address alone
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, item := range splitList(s) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR range", item)
+		}
+		proxies = append(proxies, prefix.Masked())
+	}
+	return proxies, nil
+}
+
+// Redacted returns the configuration keyed by JSON field name, safe to expose
+// over the admin API. Set secrets are replaced with "[REDACTED]"; unset ones
+// stay empty. Durations are rendered as strings such as "24h0m0s".
+func (c *Config) Redacted() map[string]interface{} {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	out := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		value := v.Field(i).Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+			value = redactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
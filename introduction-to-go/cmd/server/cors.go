@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"effective-golang/pkg/utils"
+)
+
+// corsAnyOrigin in CORSConfig.AllowedOrigins allows every origin
+const corsAnyOrigin = "*"
+
+// CORSConfig is the cross-origin policy for browser clients
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"` // exact origins such as "https://game.example.com", or "*"
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           int      `json:"max_age"` // seconds a browser may cache a preflight; 0 omits the header
+	Strict           bool     `json:"strict"`  // reject requests from other origins with 403
+}
+
+// allowsOrigin reports whether origin is in the allowlist
+func (c CORSConfig) allowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, corsAnyOrigin) || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// corsMiddleware applies cfg to cross-origin requests. An allowed origin is
+// echoed back, or "*" when every origin is allowed without credentials, and
+// preflights are answered directly. Other origins get no CORS headers, so the
+// browser blocks the response, or 403 in strict mode. Requests without an
+// Origin header are not cross-origin and pass through untouched.
+//
+// It must wrap the router rather than be added with Use: mux only runs
+// middleware on matched routes, and no route accepts OPTIONS.
+func corsMiddleware(cfg CORSConfig) func(http.Handler) http.Handler {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	allowOrigin := func(origin string) string {
+		if slices.Contains(cfg.AllowedOrigins, corsAnyOrigin) && !cfg.AllowCredentials {
+			return corsAnyOrigin
+		}
+		return origin
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// Responses differ per origin, so caches must key on it
+			w.Header().Add("Vary", "Origin")
+
+			if !cfg.allowsOrigin(origin) {
+				if cfg.Strict {
+					utils.ErrorResponse(w, http.StatusForbidden, "Origin not allowed")
+					return
+				}
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", allowOrigin(origin))
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				if cfg.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			// Let browser clients read the ID to quote in bug reports
+			w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
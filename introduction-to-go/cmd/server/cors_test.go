@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"effective-golang/pkg/apitest"
+)
+
+// TestCORS tests allowed, disallowed and preflight requests against an allowlist
+func TestCORS(t *testing.T) {
+	const allowed = "https://game.example.com"
+	policy := CORSConfig{
+		AllowedOrigins:   []string{allowed, "https://admin.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	preflight := map[string]string{"Origin": allowed, "Access-Control-Request-Method": "POST"}
+
+	tests := []struct {
+		name            string
+		strict          bool
+		method          string
+		headers         map[string]string
+		wantStatus      int
+		wantAllowOrigin string
+		wantMethods     string
+	}{
+		{"no origin", false, http.MethodGet, nil, http.StatusOK, "", ""},
+		{"allowed origin", false, http.MethodGet, map[string]string{"Origin": allowed}, http.StatusOK, allowed, ""},
+		{"disallowed origin", false, http.MethodGet, map[string]string{"Origin": "https://evil.example.com"}, http.StatusOK, "", ""},
+		{"disallowed origin in strict mode", true, http.MethodGet, map[string]string{"Origin": "https://evil.example.com"}, http.StatusForbidden, "", ""},
+		{"allowed origin in strict mode", true, http.MethodGet, map[string]string{"Origin": allowed}, http.StatusOK, allowed, ""},
+		{"preflight", false, http.MethodOptions, preflight, http.StatusNoContent, allowed, "GET, POST"},
+		{"disallowed preflight", false, http.MethodOptions, map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"}, http.StatusNoContent, "", ""},
+		{"disallowed preflight in strict mode", true, http.MethodOptions, map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "POST"}, http.StatusForbidden, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := policy
+			cfg.Strict = tt.strict
+			resp := apitest.Do(t, corsMiddleware(cfg)(next), apitest.Request{Method: tt.method, Path: "/", Headers: tt.headers})
+			resp.AssertStatus(t, tt.wantStatus)
+
+			if got := resp.Header.Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllowOrigin)
+			}
+			wantCredentials := ""
+			if tt.wantAllowOrigin != "" {
+				wantCredentials = "true"
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Credentials"); got != wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCredentials)
+			}
+			if got := resp.Header.Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if tt.wantMethods != "" {
+				if got := resp.Header.Get("Access-Control-Max-Age"); got != "600" {
+					t.Errorf("Access-Control-Max-Age = %q, want 600", got)
+				}
+				if got := resp.Header.Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization" {
+					t.Errorf("Access-Control-Allow-Headers = %q, want the configured headers", got)
+				}
+			}
+		})
+	}
+}
+
+// TestCORSConfig tests the default policy and its environment configuration through the full application
+func TestCORSConfig(t *testing.T) {
+	preflight := map[string]string{
+		"Origin":                        "https://anywhere.example.com",
+		"Access-Control-Request-Method": "POST",
+	}
+
+	// By default no origin is allowed, though preflights that no route accepts are still answered
+	app := newTestApplication(t)
+	resp := do(t, app, http.MethodOptions, "/api/v1/auth/login", nil, preflight)
+	resp.AssertStatus(t, http.StatusNoContent)
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none by default", got)
+	}
+
+	// "*" allows any origin without credentials
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	app = newTestApplication(t)
+	resp = do(t, app, http.MethodOptions, "/api/v1/auth/login", nil, preflight)
+	resp.AssertStatus(t, http.StatusNoContent)
+	if got := resp.Header.Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want * when every origin is allowed", got)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://game.example.com")
+	t.Setenv("CORS_STRICT", "true")
+	app = newTestApplication(t)
+	do(t, app, http.MethodGet, "/health", nil, map[string]string{"Origin": "https://game.example.com"}).AssertStatus(t, http.StatusOK)
+	do(t, app, http.MethodGet, "/health", nil, map[string]string{"Origin": "https://evil.example.com"}).AssertStatus(t, http.StatusForbidden)
+
+	for env, value := range map[string]string{"CORS_MAX_AGE": "-1", "CORS_ALLOWED_ORIGINS": "*"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+			t.Setenv(env, value)
+			if _, err := loadConfig(); err == nil {
+				t.Errorf("loadConfig() with %s=%q and credentials succeeded, want error", env, value)
+			}
+		})
+	}
+}
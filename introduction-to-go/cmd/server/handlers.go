@@ -2,16 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"effective-golang/internal/audit"
 	"effective-golang/internal/auth"
 	"effective-golang/internal/game"
 	"effective-golang/internal/leaderboard"
 	"effective-golang/internal/models"
 	"effective-golang/pkg/utils"
+	"effective-golang/pkg/validate"
 )
 
 // Auth handlers
@@ -25,6 +29,11 @@ func registerHandler(authService *auth.AuthService) http.HandlerFunc {
 		}
 		
 		user, err := authService.Register(r.Context(), &req)
+		var invalid validate.Errors
+		if errors.As(err, &invalid) {
+			utils.ValidationErrorResponse(w, invalid.Messages())
+			return
+		}
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
@@ -43,6 +52,10 @@ func loginHandler(authService *auth.AuthService) http.HandlerFunc {
 		}
 		
 		session, err := authService.Login(r.Context(), &req)
+		if errors.Is(err, auth.ErrTooManyAttempts) {
+			utils.ErrorResponse(w, http.StatusTooManyRequests, err.Error())
+			return
+		}
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
 			return
@@ -74,8 +87,9 @@ func logoutHandler(authService *auth.AuthService) http.HandlerFunc {
 func createGameHandler(gameService *game.GameService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			Player1ID string `json:"player1_id"`
-			Player2ID string `json:"player2_id"`
+			Player1ID string              `json:"player1_id"`
+			Player2ID string              `json:"player2_id"`
+			Mode      models.GameModeSpec `json:"mode"`
 		}
 		
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -83,7 +97,7 @@ func createGameHandler(gameService *game.GameService) http.HandlerFunc {
 			return
 		}
 		
-		game, err := gameService.CreateGame(r.Context(), req.Player1ID, req.Player2ID)
+		game, err := gameService.CreateGameWithMode(r.Context(), req.Player1ID, req.Player2ID, req.Mode)
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
@@ -146,6 +160,20 @@ func endGameHandler(gameService *game.GameService) http.HandlerFunc {
 	}
 }
 
+func cancelGameHandler(gameService *game.GameService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		gameID := vars["gameID"]
+		
+		if err := gameService.CancelGame(r.Context(), gameID); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, map[string]string{"message": "Game cancelled successfully"})
+	}
+}
+
 func getActiveGamesHandler(gameService *game.GameService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		games, err := gameService.GetActiveGames(r.Context())
@@ -178,9 +206,12 @@ func getGameHandler(gameService *game.GameService) http.HandlerFunc {
 func createLeaderboardHandler(leaderboardSvc *leaderboard.LeaderboardService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
-			Name        string                    `json:"name"`
-			Type        models.LeaderboardType    `json:"type"`
-			MaxEntries  int                       `json:"max_entries"`
+			Name           string                 `json:"name"`
+			Type           models.LeaderboardType `json:"type"`
+			MaxEntries     int                    `json:"max_entries"`
+			SortOrder      models.SortOrder       `json:"sort_order"`       // "desc" (default) or "asc"
+			DecayHalfLife  string                 `json:"decay_half_life"`  // e.g. "24h"; empty for no decay
+			MinUpdateDelta int64                  `json:"min_update_delta"` // 0 emits every score change
 		}
 		
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -188,12 +219,50 @@ func createLeaderboardHandler(leaderboardSvc *leaderboard.LeaderboardService) ht
 			return
 		}
 		
-		leaderboard, err := leaderboardSvc.CreateLeaderboard(r.Context(), req.Name, req.Type, req.MaxEntries)
+		var halfLife time.Duration
+		if req.DecayHalfLife != "" {
+			parsed, err := time.ParseDuration(req.DecayHalfLife)
+			if err != nil || parsed < 0 {
+				utils.ErrorResponse(w, http.StatusBadRequest, "decay_half_life must be a non-negative duration like \"24h\"")
+				return
+			}
+			halfLife = parsed
+		}
+		
+		if req.MinUpdateDelta < 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, leaderboard.ErrInvalidUpdateDelta.Error())
+			return
+		}
+		
+		leaderboard, err := leaderboardSvc.CreateLeaderboardWithOrder(r.Context(), req.Name, req.Type, req.MaxEntries, req.SortOrder)
+		var invalid validate.Errors
+		if errors.As(err, &invalid) {
+			utils.ValidationErrorResponse(w, invalid.Messages())
+			return
+		}
+		if errors.Is(err, models.ErrLeaderboardExists) {
+			utils.ErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		
+		if halfLife > 0 {
+			if err := leaderboardSvc.SetDecay(r.Context(), leaderboard.ID, halfLife); err != nil {
+				utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		
+		if req.MinUpdateDelta > 0 {
+			if err := leaderboardSvc.SetMinUpdateDelta(r.Context(), leaderboard.ID, req.MinUpdateDelta); err != nil {
+				utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		
 		utils.CreatedResponse(w, leaderboard)
 	}
 }
@@ -204,8 +273,9 @@ func addScoreHandler(leaderboardSvc *leaderboard.LeaderboardService) http.Handle
 		leaderboardID := vars["leaderboardID"]
 		
 		var req struct {
-			UserID string `json:"user_id"`
-			Score  int64  `json:"score"`
+			UserID   string            `json:"user_id"`
+			Score    int64             `json:"score"`
+			Metadata map[string]string `json:"metadata"`
 		}
 		
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -213,7 +283,7 @@ func addScoreHandler(leaderboardSvc *leaderboard.LeaderboardService) http.Handle
 			return
 		}
 		
-		if err := leaderboardSvc.AddScore(r.Context(), leaderboardID, req.UserID, req.Score); err != nil {
+		if err := leaderboardSvc.AddScoreWithMetadata(r.Context(), leaderboardID, req.UserID, req.Score, req.Metadata); err != nil {
 			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -235,13 +305,31 @@ func getTopEntriesHandler(leaderboardSvc *leaderboard.LeaderboardService) http.H
 			}
 		}
 		
-		entries, err := leaderboardSvc.GetTopEntries(r.Context(), leaderboardID, count)
+		offset := 0
+		if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+			parsed, err := strconv.Atoi(offsetStr)
+			if err != nil || parsed < 0 {
+				utils.ErrorResponse(w, http.StatusBadRequest, leaderboard.ErrInvalidOffset.Error())
+				return
+			}
+			offset = parsed
+		}
+		
+		includeTies := r.URL.Query().Get("ties") == "true"
+		
+		// Tell the client it got fewer entries than it asked for
+		count, clamped := leaderboardSvc.ClampTopCount(count)
+		if clamped {
+			w.Header().Set("X-Count-Clamped", strconv.Itoa(count))
+		}
+		
+		page, err := leaderboardSvc.GetTopEntries(r.Context(), leaderboardID, offset, count, includeTies)
 		if err != nil {
 			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
 		
-		utils.SuccessResponse(w, entries)
+		utils.SuccessResponse(w, page)
 	}
 }
 
@@ -257,10 +345,33 @@ func getUserRankHandler(leaderboardSvc *leaderboard.LeaderboardService) http.Han
 			return
 		}
 		
-		utils.SuccessResponse(w, map[string]interface{}{
-			"user_id": userID,
-			"rank":    rank,
-		})
+		utils.SuccessResponse(w, rank)
+	}
+}
+
+func getEntriesAroundHandler(leaderboardSvc *leaderboard.LeaderboardService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		leaderboardID := vars["leaderboardID"]
+		userID := vars["userID"]
+		
+		radius := 5 // default
+		if radiusStr := r.URL.Query().Get("radius"); radiusStr != "" {
+			parsed, err := strconv.Atoi(radiusStr)
+			if err != nil || parsed < 0 {
+				utils.ErrorResponse(w, http.StatusBadRequest, leaderboard.ErrInvalidRadius.Error())
+				return
+			}
+			radius = parsed
+		}
+		
+		entries, err := leaderboardSvc.GetEntriesAround(r.Context(), leaderboardID, userID, radius)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, entries)
 	}
 }
 
@@ -310,3 +421,189 @@ func getUserStatsHandler(authService *auth.AuthService) http.HandlerFunc {
 		utils.SuccessResponse(w, stats)
 	}
 }
+
+func getUserGamesHandler(gameService *game.GameService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		
+		limit := game.DefaultUserGamesLimit
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.Atoi(limitStr)
+			if err != nil || parsed <= 0 {
+				utils.ErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = parsed
+		}
+		
+		games, err := gameService.GetUserGames(r.Context(), userID, limit)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, games)
+	}
+}
+
+// selfOnly returns the {userID} route variable when it is the authenticated
+// user, and responds 403 otherwise
+func selfOnly(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID := mux.Vars(r)["userID"]
+	if callerID, _ := UserIDFromContext(r.Context()); callerID != userID {
+		utils.ErrorResponse(w, http.StatusForbidden, "You can only manage your own account")
+		return "", false
+	}
+	return userID, true
+}
+
+func changeUserPasswordHandler(authService *auth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := selfOnly(w, r)
+		if !ok {
+			return
+		}
+		
+		var req struct {
+			CurrentPassword string `json:"current_password"`
+			NewPassword     string `json:"new_password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		
+		err := authService.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+		switch {
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		case err != nil:
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, map[string]string{"message": "Password changed successfully"})
+	}
+}
+
+func listSessionsHandler(authService *auth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := selfOnly(w, r)
+		if !ok {
+			return
+		}
+		
+		sessions, err := authService.ListSessions(r.Context(), userID)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, sessions)
+	}
+}
+
+func revokeSessionsHandler(authService *auth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := selfOnly(w, r)
+		if !ok {
+			return
+		}
+		
+		if err := authService.RevokeAllSessions(r.Context(), userID); err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, map[string]string{"message": "All sessions revoked"})
+	}
+}
+
+// Admin handlers
+
+func setMaintenanceHandler(maintenance *maintenanceMode, auditLog audit.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Enabled bool   `json:"enabled"`
+			Message string `json:"message"`
+		}
+		
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		
+		maintenance.Set(req.Enabled, req.Message)
+		
+		enabled, message := maintenance.Status()
+		auditLog.Log(r.Context(), audit.Entry{
+			Actor:   adminActor,
+			Action:  audit.ActionMaintenance,
+			Success: true,
+			Details: map[string]string{"enabled": strconv.FormatBool(enabled)},
+		})
+		
+		utils.SuccessResponse(w, map[string]interface{}{
+			"enabled": enabled,
+			"message": message,
+		})
+	}
+}
+
+func getStatsHandler(stats func() Stats) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.SuccessResponse(w, stats())
+	}
+}
+
+// getQueueMetricsHandler reports event queue depth, worker availability and
+// counters so operators can see backpressure building
+func getQueueMetricsHandler(gameService *game.GameService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.SuccessResponse(w, gameService.Metrics())
+	}
+}
+
+func getConfigHandler(cfg *Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.SuccessResponse(w, cfg.Redacted())
+	}
+}
+
+func getAuditLogHandler(auditLog audit.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit := 50 // default
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+		if limit > 500 {
+			limit = 500
+		}
+		
+		entries, err := auditLog.Recent(r.Context(), limit)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, entries)
+	}
+}
+
+func deactivateUserHandler(authService *auth.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userID := vars["userID"]
+		
+		if err := authService.DeactivateUser(r.Context(), adminActor, userID); err != nil {
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		
+		utils.SuccessResponse(w, map[string]string{"message": "User deactivated"})
+	}
+}
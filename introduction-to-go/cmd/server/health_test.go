@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"effective-golang/internal/health"
+)
+
+// TestHealthEndpoint tests the aggregated /health response
+func TestHealthEndpoint(t *testing.T) {
+	t.Run("all healthy", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		resp := do(t, app, http.MethodGet, "/health", nil, nil)
+		resp.AssertStatus(t, http.StatusOK)
+		resp.AssertField(t, "status", "healthy")
+		for _, component := range []string{"cache", "users", "games", "leaderboards", "event_queue"} {
+			resp.AssertField(t, "components."+component+".status", "healthy")
+		}
+		resp.AssertField(t, "components.event_queue.critical", false)
+	})
+
+	t.Run("critical component unhealthy", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.health.Register("stub", health.HealthFunc(func(ctx context.Context) error {
+			return errors.New("stub down")
+		}), true)
+
+		resp := do(t, app, http.MethodGet, "/health", nil, nil)
+		resp.AssertStatus(t, http.StatusServiceUnavailable)
+		resp.AssertField(t, "status", "unhealthy")
+		resp.AssertField(t, "components.stub.status", "unhealthy")
+		resp.AssertField(t, "components.stub.error", "stub down")
+		resp.AssertField(t, "components.cache.status", "healthy")
+	})
+
+	t.Run("non-critical component unhealthy", func(t *testing.T) {
+		app := newTestApplication(t)
+		app.health.Register("stub", health.HealthFunc(func(ctx context.Context) error {
+			return errors.New("stub slow")
+		}), false)
+
+		resp := do(t, app, http.MethodGet, "/health", nil, nil)
+		resp.AssertStatus(t, http.StatusOK)
+		resp.AssertField(t, "status", "degraded")
+	})
+}
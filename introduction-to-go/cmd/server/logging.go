@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Request log formats selected by LOG_FORMAT
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// requestIDHeader carries the request ID between clients, proxies and the server
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from clients
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID assigned to the request by the request logger
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
+// requestLogLine is one request in the JSON log format
+type requestLogLine struct {
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id"`
+	Method     string    `json:"method"`
+	URI        string    `json:"uri"`
+	RemoteAddr string    `json:"remote_addr"`
+	UserAgent  string    `json:"user_agent"`
+	Status     int       `json:"status"`
+	Bytes      int64     `json:"bytes"`
+	DurationMS float64   `json:"duration_ms"`
+}
+
+// requestLogger logs every request, as a line of text or a JSON object
+type requestLogger struct {
+	format string
+	out    *log.Logger
+}
+
+// newRequestLogger creates a request logger writing format to out. A nil out
+// uses the standard logger for text, and stderr without a prefix for JSON so
+// each line is a single JSON object.
+func newRequestLogger(format string, out *log.Logger) *requestLogger {
+	if out == nil {
+		out = log.Default()
+		if format == logFormatJSON {
+			out = log.New(os.Stderr, "", 0)
+		}
+	}
+	return &requestLogger{format: format, out: out}
+}
+
+// Middleware assigns each request an ID, reusing a valid X-Request-ID from the
+// client, echoes it in the response and stores it in the request context,
+// then logs the request once it has been served.
+func (l *requestLogger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+			r.Header.Set(requestIDHeader, requestID)
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if l.format != logFormatJSON {
+			l.out.Printf("%s %s %s %v", r.Method, r.RequestURI, r.RemoteAddr, time.Since(start))
+			return
+		}
+
+		line, err := json.Marshal(requestLogLine{
+			Time:       start.UTC(),
+			RequestID:  requestID,
+			Method:     r.Method,
+			URI:        r.RequestURI,
+			RemoteAddr: r.RemoteAddr,
+			UserAgent:  r.UserAgent(),
+			Status:     rec.status,
+			Bytes:      rec.bytes,
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		})
+		if err != nil {
+			l.out.Printf("failed to encode request log: %v", err)
+			return
+		}
+		l.out.Print(string(line))
+	})
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to log
+// and echo back: non-empty, bounded and limited to URL-safe characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit request ID in hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
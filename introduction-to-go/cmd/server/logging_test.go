@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"effective-golang/pkg/apitest"
+)
+
+// TestRequestLoggerJSON tests that JSON log lines capture the status, size and request ID
+func TestRequestLoggerJSON(t *testing.T) {
+	var buf bytes.Buffer
+	var seenID string
+	handler := newRequestLogger(logFormatJSON, log.New(&buf, "", 0)).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID, _ = RequestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	}))
+
+	tests := []struct {
+		name      string
+		requestID string
+	}{
+		{"client request ID", "client-id-123"},
+		{"generated request ID", ""},
+		{"unsafe request ID replaced", "bad id\nwith newline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			headers := map[string]string{"User-Agent": "logging-test"}
+			if tt.requestID != "" {
+				headers[requestIDHeader] = tt.requestID
+			}
+			resp := apitest.Do(t, handler, apitest.Request{Method: http.MethodGet, Path: "/brew?pot=1", Headers: headers})
+			resp.AssertStatus(t, http.StatusTeapot)
+
+			var line requestLogLine
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("log line is not JSON: %v\n%s", err, buf.String())
+			}
+			if line.Status != http.StatusTeapot || line.Bytes != int64(len("short and stout")) {
+				t.Errorf("logged status %d, bytes %d, want %d, %d", line.Status, line.Bytes, http.StatusTeapot, len("short and stout"))
+			}
+			if line.Method != http.MethodGet || line.URI != "/brew?pot=1" || line.UserAgent != "logging-test" {
+				t.Errorf("logged %+v, want the request's method, URI and user agent", line)
+			}
+
+			echoed := resp.Header.Get(requestIDHeader)
+			if tt.requestID == "client-id-123" && echoed != tt.requestID {
+				t.Errorf("%s = %q, want the client's %q", requestIDHeader, echoed, tt.requestID)
+			}
+			if !validRequestID(echoed) {
+				t.Errorf("%s = %q, want a valid request ID", requestIDHeader, echoed)
+			}
+			if line.RequestID != echoed || seenID != echoed {
+				t.Errorf("request ID logged %q, seen by handler %q, echoed %q, want all equal", line.RequestID, seenID, echoed)
+			}
+		})
+	}
+}
+
+// TestRequestLoggerText tests that the default text format is unchanged and still assigns request IDs
+func TestRequestLoggerText(t *testing.T) {
+	app := newTestApplication(t)
+	if app.config.LogFormat != logFormatText {
+		t.Errorf("LogFormat = %q, want %q by default", app.config.LogFormat, logFormatText)
+	}
+
+	var buf bytes.Buffer
+	handler := newRequestLogger(logFormatText, log.New(&buf, "", 0)).Middleware(app.server.Handler)
+	resp := apitest.Do(t, handler, apitest.Request{Method: http.MethodGet, Path: "/api/v1/users/missing/stats"})
+	resp.AssertStatus(t, http.StatusNotFound)
+
+	if got := buf.String(); !strings.HasPrefix(got, "GET /api/v1/users/missing/stats ") || strings.HasPrefix(got, "{") {
+		t.Errorf("text log line = %q, want method, URI, address and duration", got)
+	}
+	if !validRequestID(resp.Header.Get(requestIDHeader)) {
+		t.Errorf("%s = %q, want a generated request ID", requestIDHeader, resp.Header.Get(requestIDHeader))
+	}
+
+	t.Setenv("LOG_FORMAT", "xml")
+	if _, err := loadConfig(); err == nil {
+		t.Error("loadConfig() with LOG_FORMAT=xml succeeded, want error")
+	}
+}
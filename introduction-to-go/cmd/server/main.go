@@ -2,31 +2,60 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"shared/tlsconfig"
 
+	"effective-golang/internal/audit"
 	"effective-golang/internal/auth"
 	"effective-golang/internal/game"
+	"effective-golang/internal/health"
 	"effective-golang/internal/leaderboard"
 	"effective-golang/internal/models"
+	"effective-golang/internal/wal"
 	"effective-golang/pkg/utils"
 )
 
 // Application represents the main application
 type Application struct {
 	server           *http.Server
+	config           *Config
 	authService      *auth.AuthService
 	gameService      *game.GameService
 	leaderboardSvc   *leaderboard.LeaderboardService
+	leaderboardWAL   *wal.Log
 	unitOfWork       models.UnitOfWork
 	
+	// Cache shared by every service; Redis-backed when redisClient is set
+	cacheRepo        models.CacheRepository
+	redisClient      *redis.Client
+	
+	// Admin controls
+	adminToken       string
+	maintenance      *maintenanceMode
+	auditLog         audit.Logger
+	
+	// Component health reported by /health
+	health           *health.Checker
+	
+	// Request durations reported by /metrics
+	metrics          *requestMetrics
+	
 	// Graceful shutdown
 	shutdownCh       chan os.Signal
 	ctx              context.Context
@@ -40,65 +69,168 @@ func NewApplication() (*Application, error) {
 		log.Printf("Warning: .env file not found: %v", err)
 	}
 	
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	
 	// Initialize repositories (in real app, these would be database implementations)
 	// For this learning project, we'll use in-memory implementations
 	unitOfWork := utils.NewInMemoryUnitOfWork()
+	if repo, ok := unitOfWork.UserRepository().(usernameFallbackSetter); ok {
+		repo.SetUsernameFallback(cfg.UsernameFallback)
+	}
+	
+	// Data survives restarts when SNAPSHOT_FILE is set: it is loaded now and saved on shutdown
+	if store, ok := unitOfWork.(snapshotStore); ok && cfg.SnapshotFile != "" {
+		if err := store.LoadSnapshot(cfg.SnapshotFile); err == nil {
+			log.Printf("Loaded snapshot from %s", cfg.SnapshotFile)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			cancel()
+			return nil, err
+		}
+		store.SetSnapshotPath(cfg.SnapshotFile)
+	}
+	
+	// The cache is shared with other instances through Redis when REDIS_URL is set
+	cacheRepo := unitOfWork.CacheRepository()
+	var redisClient *redis.Client
+	if cfg.RedisURL != "" {
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		redisClient = redis.NewClient(redisOpts)
+		cacheRepo = utils.NewRedisCacheRepository(redisClient)
+	}
+	
+	// Audit log for security-sensitive actions; file-backed when AUDIT_LOG_FILE is set
+	auditLog, err := newAuditLogger(cfg.AuditLogFile)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 	
 	// Initialize services
 	authService := auth.NewAuthService(
 		unitOfWork.UserRepository(),
-		unitOfWork.CacheRepository(),
+		cacheRepo,
+		auth.WithAuditLogger(auditLog),
+		auth.WithLoginRateLimit(cfg.LoginMaxAttempts, cfg.LoginLockoutWindow),
 	)
 	
+	gameOpts := []game.GameServiceOption{
+		game.WithArchiving(cfg.GameArchiveRetention, 10*time.Minute),
+		game.WithAutoEnd(cfg.GameMaxDuration, time.Minute),
+		game.WithActiveGameReconciliation(),
+		game.WithScorePlausibility(cfg.ScorePlausibilityRate, cfg.ScorePlausibilityAllowance),
+	}
+	if cfg.GameIdleTimeout > 0 {
+		gameOpts = append(gameOpts, game.WithIdleTimeout(cfg.GameIdleTimeout, time.Minute))
+	}
+	if cfg.GameMinWorkers > 0 {
+		// Grow once a fifth of the queue is waiting
+		gameOpts = append(gameOpts, game.WithWorkerAutoscaling(cfg.GameMinWorkers, 20, time.Second))
+	}
+	
 	gameService := game.NewGameService(
 		unitOfWork.GameRepository(),
 		unitOfWork.UserRepository(),
 		unitOfWork.LeaderboardRepository(),
-		unitOfWork.CacheRepository(),
+		cacheRepo,
 		10, // max workers
 		100, // queue size
+		gameOpts...,
 	)
 	
+	leaderboardOpts := []leaderboard.LeaderboardServiceOption{
+		leaderboard.WithMaxTopEntries(cfg.LeaderboardMaxTopEntries),
+		leaderboard.WithLeaderboardReconciliation(),
+	}
+	
+	// Leaderboard writes go through a write-ahead log when LEADERBOARD_WAL_FILE is set
+	var leaderboardWAL *wal.Log
+	if cfg.LeaderboardWALFile != "" {
+		leaderboardWAL, err = wal.Open(cfg.LeaderboardWALFile)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		leaderboardOpts = append(leaderboardOpts, leaderboard.WithWAL(leaderboardWAL, cfg.LeaderboardSnapshotInterval))
+	}
+	if cfg.LeaderboardFlushInterval > 0 {
+		leaderboardOpts = append(leaderboardOpts, leaderboard.WithPeriodicFlush(cfg.LeaderboardFlushInterval))
+	}
+	
 	leaderboardSvc := leaderboard.NewLeaderboardService(
 		unitOfWork.LeaderboardRepository(),
 		unitOfWork.UserRepository(),
-		unitOfWork.CacheRepository(),
+		cacheRepo,
 		3600, // cache TTL in seconds
+		leaderboardOpts...,
 	)
 	
+	replayed, err := leaderboardSvc.Recover(ctx)
+	if err != nil {
+		leaderboardSvc.Close()
+		cancel()
+		return nil, fmt.Errorf("failed to recover leaderboards: %w", err)
+	}
+	if leaderboardWAL != nil {
+		log.Printf("Recovered leaderboards from %s (%d log records replayed)", cfg.LeaderboardWALFile, replayed)
+	}
+	
+	app := &Application{
+		config:         cfg,
+		authService:    authService,
+		gameService:    gameService,
+		leaderboardSvc: leaderboardSvc,
+		leaderboardWAL: leaderboardWAL,
+		unitOfWork:     unitOfWork,
+		cacheRepo:      cacheRepo,
+		redisClient:    redisClient,
+		adminToken:     cfg.AdminToken,
+		maintenance:    newMaintenanceMode(cfg.MaintenanceMode),
+		auditLog:       auditLog,
+		health:         newHealthChecker(cacheRepo, authService, gameService, leaderboardSvc),
+		metrics:        newRequestMetrics(cfg.MetricsBuckets),
+		shutdownCh:     make(chan os.Signal, 1),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+	
 	// Create router
 	router := mux.NewRouter()
 	
 	// Setup middleware
-	router.Use(loggingMiddleware)
-	router.Use(corsMiddleware)
+	router.Use(newRequestLogger(cfg.LogFormat, nil).Middleware)
+	router.Use(clientIPMiddleware(cfg.TrustedProxies))
+	router.Use(app.metrics.Middleware)
 	
 	// Setup routes
-	setupRoutes(router, authService, gameService, leaderboardSvc)
+	app.setupRoutes(router)
 	
-	// Create HTTP server
-	port := getEnv("PORT", "8080")
-	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
+	// Create HTTP server. CORS wraps the router so preflight requests are
+	// answered before route matching.
+	app.server = &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      corsMiddleware(cfg.CORS)(router),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 	
-	app := &Application{
-		server:         server,
-		authService:    authService,
-		gameService:    gameService,
-		leaderboardSvc: leaderboardSvc,
-		unitOfWork:     unitOfWork,
-		shutdownCh:     make(chan os.Signal, 1),
-		ctx:            ctx,
-		cancel:         cancel,
+	// Serve HTTPS when a certificate pair is configured
+	tlsConfig, err := loadTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
+	app.server.TLSConfig = tlsConfig
 	
 	// Setup graceful shutdown
 	signal.Notify(app.shutdownCh, syscall.SIGINT, syscall.SIGTERM)
@@ -108,11 +240,19 @@ func NewApplication() (*Application, error) {
 
 // Start starts the application
 func (app *Application) Start() error {
-	log.Printf("Starting server on port %s", app.server.Addr)
+	log.Printf("Starting server on port %s (TLS: %v)", app.server.Addr, app.server.TLSConfig != nil)
+	
+	// Reset weekly and monthly leaderboards as their periods end
+	app.leaderboardSvc.StartResetScheduler(app.ctx)
 	
 	// Start server in a goroutine
 	go func() {
-		if err := app.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		ln, err := net.Listen("tcp", app.server.Addr)
+		if err != nil {
+			log.Printf("Server error: %v", err)
+			return
+		}
+		if err := app.serve(ln); err != nil && err != http.ErrServerClosed {
 			log.Printf("Server error: %v", err)
 		}
 	}()
@@ -124,6 +264,15 @@ func (app *Application) Start() error {
 	return app.Shutdown()
 }
 
+// serve accepts connections on ln, over TLS when a certificate is configured
+func (app *Application) serve(ln net.Listener) error {
+	if app.server.TLSConfig != nil {
+		// Certificates come from TLSConfig.GetCertificate
+		return app.server.ServeTLS(ln, "", "")
+	}
+	return app.server.Serve(ln)
+}
+
 // Shutdown gracefully shuts down the application
 func (app *Application) Shutdown() error {
 	log.Println("Shutting down application...")
@@ -147,106 +296,259 @@ func (app *Application) Shutdown() error {
 	
 	app.leaderboardSvc.Close()
 	
+	// Close the leaderboard write-ahead log, if any
+	if app.leaderboardWAL != nil {
+		if err := app.leaderboardWAL.Close(); err != nil {
+			log.Printf("Leaderboard WAL shutdown error: %v", err)
+		}
+	}
+	
 	// Close unit of work
 	if err := app.unitOfWork.Close(); err != nil {
 		log.Printf("Unit of work shutdown error: %v", err)
 	}
 	
+	// Close the Redis connection, if any
+	if app.redisClient != nil {
+		if err := app.redisClient.Close(); err != nil {
+			log.Printf("Redis shutdown error: %v", err)
+		}
+	}
+	
+	// Close the audit log file, if any
+	if closer, ok := app.auditLog.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Audit log shutdown error: %v", err)
+		}
+	}
+	
 	log.Println("Application shutdown complete")
 	return nil
 }
 
 // setupRoutes sets up all application routes
-func setupRoutes(
-	router *mux.Router,
-	authService *auth.AuthService,
-	gameService *game.GameService,
-	leaderboardSvc *leaderboard.LeaderboardService,
-) {
+func (app *Application) setupRoutes(router *mux.Router) {
+	authService := app.authService
+	gameService := app.gameService
+	leaderboardSvc := app.leaderboardSvc
+	
 	// Health check
-	router.HandleFunc("/health", healthHandler).Methods("GET")
+	router.HandleFunc("/health", healthHandler(app.health)).Methods("GET")
+	
+	// Prometheus scrape endpoint
+	router.HandleFunc("/metrics", metricsHandler(app.metrics)).Methods("GET")
 	
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 	
+	// Service stats for dashboards
+	api.HandleFunc("/stats", getStatsHandler(app.Stats)).Methods("GET")
+	
 	// Auth routes
 	auth := api.PathPrefix("/auth").Subrouter()
 	auth.HandleFunc("/register", registerHandler(authService)).Methods("POST")
 	auth.HandleFunc("/login", loginHandler(authService)).Methods("POST")
 	auth.HandleFunc("/logout", logoutHandler(authService)).Methods("POST")
 	
+	// Admin routes
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(adminMiddleware(app.adminToken))
+	admin.HandleFunc("/maintenance", setMaintenanceHandler(app.maintenance, app.auditLog)).Methods("POST")
+	admin.HandleFunc("/audit", getAuditLogHandler(app.auditLog)).Methods("GET")
+	admin.HandleFunc("/config", getConfigHandler(app.config)).Methods("GET")
+	admin.HandleFunc("/queue", getQueueMetricsHandler(app.gameService)).Methods("GET")
+	admin.HandleFunc("/users/{userID}/deactivate", deactivateUserHandler(authService)).Methods("POST")
+	
 	// Game routes
 	games := api.PathPrefix("/games").Subrouter()
+	games.Use(authMiddleware(authService))
+	games.Use(maintenanceMiddleware(app.maintenance))
 	games.HandleFunc("", createGameHandler(gameService)).Methods("POST")
 	games.HandleFunc("/{gameID}/start", startGameHandler(gameService)).Methods("POST")
 	games.HandleFunc("/{gameID}/score", updateScoreHandler(gameService)).Methods("PUT")
 	games.HandleFunc("/{gameID}/end", endGameHandler(gameService)).Methods("POST")
+	games.HandleFunc("/{gameID}/cancel", cancelGameHandler(gameService)).Methods("POST")
 	games.HandleFunc("/active", getActiveGamesHandler(gameService)).Methods("GET")
 	games.HandleFunc("/{gameID}", getGameHandler(gameService)).Methods("GET")
 	
 	// Leaderboard routes
 	leaderboards := api.PathPrefix("/leaderboards").Subrouter()
+	leaderboards.Use(authMiddleware(authService))
+	leaderboards.Use(maintenanceMiddleware(app.maintenance))
 	leaderboards.HandleFunc("", createLeaderboardHandler(leaderboardSvc)).Methods("POST")
 	leaderboards.HandleFunc("/{leaderboardID}/scores", addScoreHandler(leaderboardSvc)).Methods("POST")
 	leaderboards.HandleFunc("/{leaderboardID}/top", getTopEntriesHandler(leaderboardSvc)).Methods("GET")
 	leaderboards.HandleFunc("/{leaderboardID}/rank/{userID}", getUserRankHandler(leaderboardSvc)).Methods("GET")
+	leaderboards.HandleFunc("/{leaderboardID}/around/{userID}", getEntriesAroundHandler(leaderboardSvc)).Methods("GET")
 	leaderboards.HandleFunc("/{leaderboardID}/stats", getLeaderboardStatsHandler(leaderboardSvc)).Methods("GET")
+	leaderboards.HandleFunc("/{leaderboardID}/stream", streamLeaderboardHandler(leaderboardSvc)).Methods("GET")
 	leaderboards.HandleFunc("/{leaderboardID}", getLeaderboardHandler(leaderboardSvc)).Methods("GET")
 	
 	// User routes
 	users := api.PathPrefix("/users").Subrouter()
 	users.HandleFunc("/{userID}/stats", getUserStatsHandler(authService)).Methods("GET")
+	users.HandleFunc("/{userID}/games", getUserGamesHandler(gameService)).Methods("GET")
+	users.Handle("/{userID}/password", authMiddleware(authService)(changeUserPasswordHandler(authService))).Methods("PUT")
+	users.Handle("/{userID}/sessions", authMiddleware(authService)(listSessionsHandler(authService))).Methods("GET")
+	users.Handle("/{userID}/sessions", authMiddleware(authService)(revokeSessionsHandler(authService))).Methods("DELETE")
 }
 
 // Middleware functions
 
-// loggingMiddleware logs all HTTP requests
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		
-		// Call next handler
-		next.ServeHTTP(w, r)
-		
-		// Log request
-		log.Printf(
-			"%s %s %s %v",
-			r.Method,
-			r.RequestURI,
-			r.RemoteAddr,
-			time.Since(start),
-		)
-	})
+// clientIPMiddleware stores the client IP in the request context for audit
+// records and login rate limiting
+func clientIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := audit.WithClientIP(r.Context(), clientIP(r, trustedProxies))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-// corsMiddleware adds CORS headers
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// Handler functions
+
+// healthHandler reports the health of every component, responding 503 when a
+// critical component is unhealthy
+func healthHandler(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Check(r.Context())
 		
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		status := http.StatusOK
+		if report.Status == health.StatusUnhealthy {
+			status = http.StatusServiceUnavailable
 		}
 		
-		next.ServeHTTP(w, r)
-	})
+		utils.JSONResponse(w, status, map[string]interface{}{
+			"status":     report.Status,
+			"components": report.Components,
+			"timestamp":  report.Timestamp,
+			"version":    "1.0.0",
+		})
+	}
 }
 
-// Handler functions
+// Helper functions
 
-// healthHandler handles health check requests
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now(),
-		"version":   "1.0.0",
-	})
+// snapshotStore is implemented by units of work that can save their data to a
+// file on Close and load it back
+type snapshotStore interface {
+	LoadSnapshot(path string) error
+	SetSnapshotPath(path string)
 }
 
-// Helper functions
+// usernameFallbackSetter is implemented by user repositories whose handling of
+// usernames that differ only in case can be configured
+type usernameFallbackSetter interface {
+	SetUsernameFallback(fallback models.UsernameFallback)
+}
+
+// newHealthChecker registers the components behind /health. A full event queue
+// only drops events, so it degrades health instead of failing it.
+func newHealthChecker(cache models.CacheRepository, authService *auth.AuthService, gameService *game.GameService, leaderboardSvc *leaderboard.LeaderboardService) *health.Checker {
+	checker := health.NewChecker(2 * time.Second)
+	checker.Register("cache", health.HealthFunc(func(ctx context.Context) error {
+		return cacheHealth(ctx, cache)
+	}), true)
+	checker.Register("users", authService, true)
+	checker.Register("games", gameService, true)
+	checker.Register("leaderboards", leaderboardSvc, true)
+	checker.Register("event_queue", health.HealthFunc(gameService.QueueHealth), false)
+	return checker
+}
+
+// cacheHealth writes and reads back a probe key to check the cache is reachable
+func cacheHealth(ctx context.Context, cache models.CacheRepository) error {
+	const probeKey = "health:probe"
+	
+	if err := cache.Set(ctx, probeKey, time.Now().Unix(), 60); err != nil {
+		return fmt.Errorf("cache write: %w", err)
+	}
+	exists, err := cache.Exists(ctx, probeKey)
+	if err != nil {
+		return fmt.Errorf("cache read: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("cache read: probe key missing after write")
+	}
+	return nil
+}
+
+// clientIP returns the IP of the connecting peer. When that peer is a trusted
+// proxy, X-Forwarded-For is walked from the right past further trusted proxies
+// to the first address they vouch for; anything left of it is client-supplied
+// and could be forged.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host, trustedProxies) {
+		return host
+	}
+	
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(hop); err != nil {
+			// A malformed hop was not added by a proxy we trust
+			return host
+		}
+		if !isTrustedProxy(hop, trustedProxies) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+// isTrustedProxy reports whether ip is in one of the trusted proxy ranges
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTLSConfig returns a TLS config that reloads the certificate pair on change,
+// or nil when neither file is set
+func loadTLSConfig(certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	
+	reloader, err := tlsconfig.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return tlsconfig.ServerConfig(reloader), nil
+}
+
+// newAuditLogger creates a file-backed audit logger, or an in-memory one when path is empty
+func newAuditLogger(path string) (audit.Logger, error) {
+	if path == "" {
+		return audit.NewMemoryLogger(1000), nil
+	}
+	
+	logger, err := audit.NewFileLogger(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit logger: %w", err)
+	}
+	return logger, nil
+}
 
 // getEnv gets an environment variable with a default value
 func getEnv(key, defaultValue string) string {
@@ -0,0 +1,638 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"effective-golang/internal/game"
+	"effective-golang/pkg/apitest"
+)
+
+const testAdminToken = "test-admin-token"
+
+// adminHeaders authenticates requests to the admin API
+var adminHeaders = map[string]string{"X-Admin-Token": testAdminToken}
+
+// newTestApplication builds the full application with routes and in-memory storage
+func newTestApplication(t *testing.T) *Application {
+	t.Helper()
+
+	t.Setenv("ADMIN_TOKEN", testAdminToken)
+	t.Setenv("MAINTENANCE_MODE", "false")
+
+	app, err := NewApplication()
+	if err != nil {
+		t.Fatalf("NewApplication() error = %v", err)
+	}
+	t.Cleanup(func() {
+		app.gameService.Close()
+		app.leaderboardSvc.Close()
+	})
+
+	return app
+}
+
+// do sends a request through the application's router
+func do(t *testing.T, app *Application, method, path string, body interface{}, headers map[string]string) *apitest.Response {
+	t.Helper()
+
+	return apitest.Do(t, app.server.Handler, apitest.Request{Method: method, Path: path, Body: body, Headers: headers})
+}
+
+// createdID asserts a 201 response and returns the id of the created resource
+func createdID(t *testing.T, resp *apitest.Response) string {
+	t.Helper()
+
+	resp.AssertStatus(t, http.StatusCreated)
+	var data struct {
+		ID string `json:"id"`
+	}
+	resp.DecodeData(t, &data)
+	return data.ID
+}
+
+// registerUser registers a user through the API and returns its ID
+func registerUser(t *testing.T, app *Application, username string) string {
+	t.Helper()
+
+	return createdID(t, do(t, app, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username": username,
+		"email":    username + "@example.com",
+		"password": "password123",
+	}, nil))
+}
+
+// login logs a registered user in through the API and returns headers that
+// authenticate requests with the new session
+func login(t *testing.T, app *Application, username string) map[string]string {
+	t.Helper()
+
+	resp := do(t, app, http.MethodPost, "/api/v1/auth/login", map[string]string{"username": username, "password": "password123"}, nil)
+	resp.AssertStatus(t, http.StatusOK)
+	var session struct {
+		ID string `json:"id"`
+	}
+	resp.DecodeData(t, &session)
+	return map[string]string{"Authorization": session.ID}
+}
+
+func setMaintenance(t *testing.T, app *Application, enabled bool) {
+	t.Helper()
+
+	resp := do(t, app, http.MethodPost, "/api/v1/admin/maintenance", map[string]interface{}{"enabled": enabled}, adminHeaders)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertField(t, "data.enabled", enabled)
+}
+
+// TestMaintenanceMode tests that writes are rejected and reads still served during maintenance
+func TestMaintenanceMode(t *testing.T) {
+	app := newTestApplication(t)
+
+	player1 := registerUser(t, app, "player1")
+	player2 := registerUser(t, app, "player2")
+	session := login(t, app, "player1")
+	createGame := map[string]string{"player1_id": player1, "player2_id": player2}
+
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Global",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+
+	setMaintenance(t, app, true)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       interface{}
+		wantStatus int
+	}{
+		{"create game", http.MethodPost, "/api/v1/games", createGame, http.StatusServiceUnavailable},
+		{"update score", http.MethodPut, "/api/v1/games/some-game/score", map[string]interface{}{"player_id": player1, "score": 10}, http.StatusServiceUnavailable},
+		{"add score", http.MethodPost, "/api/v1/leaderboards/" + leaderboardID + "/scores", map[string]interface{}{"user_id": player1, "score": 10}, http.StatusServiceUnavailable},
+		{"health", http.MethodGet, "/health", nil, http.StatusOK},
+		{"active games", http.MethodGet, "/api/v1/games/active", nil, http.StatusOK},
+		{"get leaderboard", http.MethodGet, "/api/v1/leaderboards/" + leaderboardID, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, tt.method, tt.path, tt.body, session)
+			resp.AssertStatus(t, tt.wantStatus)
+			if tt.wantStatus == http.StatusServiceUnavailable {
+				resp.AssertField(t, "message", defaultMaintenanceMessage)
+			}
+		})
+	}
+
+	setMaintenance(t, app, false)
+
+	resp := do(t, app, http.MethodPost, "/api/v1/games", createGame, session)
+	resp.AssertStatus(t, http.StatusCreated)
+	resp.AssertField(t, "data.state", "waiting")
+}
+
+// TestAdminMiddleware tests that admin routes require the configured token
+func TestAdminMiddleware(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "missing token", headers: nil, want: http.StatusUnauthorized},
+		{name: "wrong token", headers: map[string]string{"X-Admin-Token": "nope"}, want: http.StatusUnauthorized},
+		{name: "valid token", headers: adminHeaders, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodPost, "/api/v1/admin/maintenance", map[string]bool{"enabled": false}, tt.headers)
+			resp.AssertStatus(t, tt.want)
+		})
+	}
+}
+
+// TestAdminAuditLog tests that logins and admin actions can be read back from the audit endpoint
+func TestAdminAuditLog(t *testing.T) {
+	// Requests come from apitest's 192.0.2.1, acting as the load balancer
+	t.Setenv("TRUSTED_PROXIES", "192.0.2.1, 10.0.0.0/8")
+	app := newTestApplication(t)
+
+	registerUser(t, app, "player1")
+	resp := apitest.Do(t, app.server.Handler, apitest.Request{
+		Method:  http.MethodPost,
+		Path:    "/api/v1/auth/login",
+		Body:    map[string]string{"username": "player1", "password": "password123"},
+		Headers: map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.1"},
+	})
+	resp.AssertStatus(t, http.StatusOK)
+
+	setMaintenance(t, app, false)
+
+	do(t, app, http.MethodGet, "/api/v1/admin/audit", nil, nil).AssertStatus(t, http.StatusUnauthorized)
+
+	resp = do(t, app, http.MethodGet, "/api/v1/admin/audit?limit=2", nil, adminHeaders)
+	resp.AssertStatus(t, http.StatusOK)
+
+	var entries []struct {
+		Actor    string `json:"actor"`
+		Action   string `json:"action"`
+		ClientIP string `json:"client_ip"`
+	}
+	resp.DecodeData(t, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("audit entries = %d, want 2", len(entries))
+	}
+	if entries[0].Action != "admin.maintenance" || entries[0].Actor != "admin" {
+		t.Errorf("entries[0] = %+v, want admin maintenance record", entries[0])
+	}
+	if entries[1].Action != "auth.login" || entries[1].Actor != "player1" || entries[1].ClientIP != "198.51.100.1" {
+		t.Errorf("entries[1] = %+v, want player1 login from 198.51.100.1", entries[1])
+	}
+}
+
+// TestClientIP tests that X-Forwarded-For is only believed as far as trusted proxies vouch for it
+func TestClientIP(t *testing.T) {
+	proxies, err := parseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("parseTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"forged header from an untrusted peer", "203.0.113.7:5000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "192.0.2.1:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"forged hop left of the proxy's", "192.0.2.1:443", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "192.0.2.1:443", []string{"198.51.100.1, 10.0.0.5", "10.1.1.1"}, "198.51.100.1"},
+		{"only trusted hops", "192.0.2.1:443", []string{"10.0.0.5"}, "10.0.0.5"},
+		{"malformed hop", "192.0.2.1:443", []string{"not-an-ip"}, "192.0.2.1"},
+		{"trusted proxy without header", "192.0.2.1:443", nil, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := clientIP(r, proxies); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseTrustedProxies("10.0.0.0/8, proxy.internal"); err == nil {
+		t.Error("parseTrustedProxies() with a hostname succeeded, want error")
+	}
+}
+
+// TestAdminConfig tests that the effective configuration is served with secrets redacted
+func TestAdminConfig(t *testing.T) {
+	t.Setenv("GAME_MAX_DURATION", "90m")
+	t.Setenv("GAME_IDLE_TIMEOUT", "15m")
+	app := newTestApplication(t)
+
+	do(t, app, http.MethodGet, "/api/v1/admin/config", nil, nil).AssertStatus(t, http.StatusUnauthorized)
+
+	resp := do(t, app, http.MethodGet, "/api/v1/admin/config", nil, adminHeaders)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertField(t, "data.admin_token", "[REDACTED]")
+	resp.AssertField(t, "data.game_max_duration", "1h30m0s")
+	resp.AssertField(t, "data.game_idle_timeout", "15m0s")
+	resp.AssertField(t, "data.maintenance_mode", false)
+	resp.AssertField(t, "data.tls_cert_file", "")
+	if port, ok := resp.Field(t, "data.port"); !ok || port == "" {
+		t.Errorf("data.port = %v, want the configured port", port)
+	}
+	if strings.Contains(string(resp.Body), testAdminToken) {
+		t.Errorf("config response leaks the admin token: %s", resp.Body)
+	}
+}
+
+// TestGameIdleTimeout tests that GAME_IDLE_TIMEOUT turns on cancelling idle games
+func TestGameIdleTimeout(t *testing.T) {
+	t.Setenv("GAME_IDLE_TIMEOUT", "1ms")
+	app := newTestApplication(t)
+
+	player1 := registerUser(t, app, "player1")
+	player2 := registerUser(t, app, "player2")
+	session := login(t, app, "player1")
+	gameID := createdID(t, do(t, app, http.MethodPost, "/api/v1/games", map[string]string{"player1_id": player1, "player2_id": player2}, session))
+	do(t, app, http.MethodPost, "/api/v1/games/"+gameID+"/start", nil, session).AssertStatus(t, http.StatusOK)
+
+	time.Sleep(5 * time.Millisecond)
+	if n, err := app.gameService.CancelIdleGames(context.Background()); err != nil || n != 1 {
+		t.Fatalf("CancelIdleGames() = %v, %v, want the idle game cancelled", n, err)
+	}
+	do(t, app, http.MethodGet, "/api/v1/games/"+gameID, nil, session).AssertField(t, "data.state", "cancelled")
+
+	t.Setenv("GAME_IDLE_TIMEOUT", "-1m")
+	if _, err := loadConfig(); err == nil {
+		t.Error("loadConfig() with a negative GAME_IDLE_TIMEOUT succeeded, want error")
+	}
+}
+
+// TestAdminQueue tests that the queue metrics require the admin token and count events from game traffic
+func TestAdminQueue(t *testing.T) {
+	app := newTestApplication(t)
+
+	do(t, app, http.MethodGet, "/api/v1/admin/queue", nil, nil).AssertStatus(t, http.StatusUnauthorized)
+
+	resp := do(t, app, http.MethodGet, "/api/v1/admin/queue", nil, adminHeaders)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertField(t, "data.processed", 0)
+	resp.AssertField(t, "data.queue_capacity", 100)
+	resp.AssertField(t, "data.max_workers", 10)
+	resp.AssertField(t, "data.draining", false)
+
+	player1 := registerUser(t, app, "player1")
+	player2 := registerUser(t, app, "player2")
+	session := login(t, app, "player1")
+	gameID := createdID(t, do(t, app, http.MethodPost, "/api/v1/games", map[string]string{"player1_id": player1, "player2_id": player2}, session))
+	do(t, app, http.MethodPost, "/api/v1/games/"+gameID+"/start", nil, session).AssertStatus(t, http.StatusOK)
+
+	const updates = 20
+	for i := 1; i <= updates; i++ {
+		do(t, app, http.MethodPut, "/api/v1/games/"+gameID+"/score", map[string]interface{}{"player_id": player1, "score": i}, session).AssertStatus(t, http.StatusOK)
+	}
+
+	var metrics game.QueueMetrics
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp = do(t, app, http.MethodGet, "/api/v1/admin/queue", nil, adminHeaders)
+		resp.AssertStatus(t, http.StatusOK)
+		resp.DecodeData(t, &metrics)
+		if (metrics.Processed == metrics.Queued && metrics.IdleWorkers == metrics.Workers) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The start event plus one per score update
+	if metrics.Queued+metrics.Dropped != updates+1 || metrics.Processed != metrics.Queued {
+		t.Errorf("metrics = %+v, want %d events queued or dropped and every queued event processed", metrics, updates+1)
+	}
+	if metrics.ActiveGames != 1 || metrics.QueueDepth != 0 || metrics.IdleWorkers != metrics.Workers {
+		t.Errorf("metrics = %+v, want one active game and an idle, empty queue", metrics)
+	}
+}
+
+// TestStats tests that the aggregated stats reflect games, sessions and leaderboards created through the services
+func TestStats(t *testing.T) {
+	app := newTestApplication(t)
+
+	player1 := registerUser(t, app, "player1")
+	player2 := registerUser(t, app, "player2")
+	session := login(t, app, "player1")
+	login(t, app, "player2")
+
+	createdID(t, do(t, app, http.MethodPost, "/api/v1/games", map[string]string{"player1_id": player1, "player2_id": player2}, session))
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Global",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+	if _, err := app.leaderboardSvc.SubscribeToUpdates(leaderboardID); err != nil {
+		t.Fatalf("SubscribeToUpdates() error = %v", err)
+	}
+
+	resp := do(t, app, http.MethodGet, "/api/v1/stats", nil, nil)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertField(t, "data.games.active_games", 1)
+	resp.AssertField(t, "data.auth.active_sessions", 2)
+	resp.AssertField(t, "data.leaderboards.leaderboards", 1)
+	resp.AssertField(t, "data.leaderboards.subscribers", 1)
+	resp.AssertField(t, "data.leaderboards.webhooks", 0)
+	if entries, ok := resp.Field(t, "data.cache.entries"); !ok || entries.(float64) < 2 {
+		t.Errorf("data.cache.entries = %v, want at least the 2 sessions", entries)
+	}
+}
+
+// TestUserRank tests that ranked users, unranked users and empty boards are told apart
+func TestUserRank(t *testing.T) {
+	app := newTestApplication(t)
+
+	ranked := registerUser(t, app, "ranked")
+	unranked := registerUser(t, app, "unranked")
+	session := login(t, app, "ranked")
+	newBoard := func(name string) string {
+		return createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+			"name":        name,
+			"type":        "global",
+			"max_entries": 10,
+		}, session))
+	}
+	populated := newBoard("Populated")
+	empty := newBoard("Empty")
+
+	resp := do(t, app, http.MethodPost, "/api/v1/leaderboards/"+populated+"/scores", map[string]interface{}{"user_id": ranked, "score": 50}, session)
+	resp.AssertStatus(t, http.StatusOK)
+
+	tests := []struct {
+		name        string
+		leaderboard string
+		userID      string
+		wantRanked  bool
+		wantStatus  string
+	}{
+		{"ranked user", populated, ranked, true, "ranked"},
+		{"unranked user", populated, unranked, false, "unranked"},
+		{"empty board", empty, ranked, false, "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, "/api/v1/leaderboards/"+tt.leaderboard+"/rank/"+tt.userID, nil, session)
+			resp.AssertStatus(t, http.StatusOK)
+			resp.AssertField(t, "data.user_id", tt.userID)
+			resp.AssertField(t, "data.ranked", tt.wantRanked)
+			resp.AssertField(t, "data.status", tt.wantStatus)
+			if tt.wantRanked {
+				resp.AssertField(t, "data.rank", 1)
+			} else if rank, ok := resp.Field(t, "data.rank"); ok {
+				t.Errorf("data.rank = %v, want no rank", rank)
+			}
+		})
+	}
+
+	resp = do(t, app, http.MethodGet, "/api/v1/leaderboards/missing/rank/"+ranked, nil, session)
+	resp.AssertStatus(t, http.StatusNotFound)
+}
+
+// TestTopEntriesClamped tests that top-entries counts above the configured maximum are clamped and flagged
+func TestTopEntriesClamped(t *testing.T) {
+	t.Setenv("LEADERBOARD_MAX_TOP_ENTRIES", "2")
+	app := newTestApplication(t)
+
+	userIDs := []string{registerUser(t, app, "first"), registerUser(t, app, "second"), registerUser(t, app, "third")}
+	session := login(t, app, "first")
+
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Capped",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+	for i, userID := range userIDs {
+		resp := do(t, app, http.MethodPost, "/api/v1/leaderboards/"+leaderboardID+"/scores", map[string]interface{}{"user_id": userID, "score": 10 * (i + 1)}, session)
+		resp.AssertStatus(t, http.StatusOK)
+	}
+
+	tests := []struct {
+		name        string
+		count       string
+		wantEntries int
+		wantClamped string
+	}{
+		{"within the cap", "2", 2, ""},
+		{"above the cap", "1000000", 2, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, "/api/v1/leaderboards/"+leaderboardID+"/top?count="+tt.count, nil, session)
+			resp.AssertStatus(t, http.StatusOK)
+
+			var page struct {
+				Entries []map[string]interface{} `json:"entries"`
+			}
+			resp.DecodeData(t, &page)
+			if len(page.Entries) != tt.wantEntries {
+				t.Errorf("got %d entries, want %d", len(page.Entries), tt.wantEntries)
+			}
+			if got := resp.Header.Get("X-Count-Clamped"); got != tt.wantClamped {
+				t.Errorf("X-Count-Clamped = %q, want %q", got, tt.wantClamped)
+			}
+		})
+	}
+}
+
+// TestTopEntriesOffset tests the top-entries page metadata and its offset parameter
+func TestTopEntriesOffset(t *testing.T) {
+	app := newTestApplication(t)
+
+	userIDs := []string{registerUser(t, app, "first"), registerUser(t, app, "second"), registerUser(t, app, "third")}
+	session := login(t, app, "first")
+
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Paged",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+	for i, userID := range userIDs {
+		resp := do(t, app, http.MethodPost, "/api/v1/leaderboards/"+leaderboardID+"/scores", map[string]interface{}{"user_id": userID, "score": 10 * (i + 1)}, session)
+		resp.AssertStatus(t, http.StatusOK)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantUsers  []string
+		wantNext   int
+	}{
+		{"first page", "?count=2", http.StatusOK, []string{userIDs[2], userIDs[1]}, 2},
+		{"second page", "?count=2&offset=2", http.StatusOK, []string{userIDs[0]}, 3},
+		{"past the end", "?count=2&offset=3", http.StatusOK, []string{}, 3},
+		{"negative offset", "?offset=-1", http.StatusBadRequest, nil, 0},
+		{"invalid offset", "?offset=two", http.StatusBadRequest, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, "/api/v1/leaderboards/"+leaderboardID+"/top"+tt.query, nil, session)
+			resp.AssertStatus(t, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				Entries []struct {
+					UserID string `json:"user_id"`
+				} `json:"entries"`
+				Total      int `json:"total"`
+				NextOffset int `json:"next_offset"`
+			}
+			resp.DecodeData(t, &page)
+			if page.Total != len(userIDs) {
+				t.Errorf("total = %d, want %d", page.Total, len(userIDs))
+			}
+			if page.NextOffset != tt.wantNext {
+				t.Errorf("next_offset = %d, want %d", page.NextOffset, tt.wantNext)
+			}
+			if len(page.Entries) != len(tt.wantUsers) {
+				t.Fatalf("got %d entries, want %d", len(page.Entries), len(tt.wantUsers))
+			}
+			for i, entry := range page.Entries {
+				if entry.UserID != tt.wantUsers[i] {
+					t.Errorf("entry %d = %s, want %s", i, entry.UserID, tt.wantUsers[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEntriesAround tests the neighbouring entries endpoint and its radius parameter
+func TestEntriesAround(t *testing.T) {
+	app := newTestApplication(t)
+
+	userIDs := []string{registerUser(t, app, "first"), registerUser(t, app, "second"), registerUser(t, app, "third")}
+	session := login(t, app, "first")
+
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Neighbours",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+	for i, userID := range userIDs {
+		resp := do(t, app, http.MethodPost, "/api/v1/leaderboards/"+leaderboardID+"/scores", map[string]interface{}{"user_id": userID, "score": 10 * (i + 1)}, session)
+		resp.AssertStatus(t, http.StatusOK)
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		query       string
+		wantStatus  int
+		wantEntries int
+	}{
+		{"default radius", userIDs[1], "", http.StatusOK, 3},
+		{"top of the board", userIDs[2], "?radius=1", http.StatusOK, 2},
+		{"zero radius", userIDs[1], "?radius=0", http.StatusOK, 1},
+		{"negative radius", userIDs[1], "?radius=-1", http.StatusBadRequest, 0},
+		{"user not on the board", "nobody", "", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, "/api/v1/leaderboards/"+leaderboardID+"/around/"+tt.userID+tt.query, nil, session)
+			resp.AssertStatus(t, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var entries []map[string]interface{}
+			resp.DecodeData(t, &entries)
+			if len(entries) != tt.wantEntries {
+				t.Errorf("got %d entries, want %d", len(entries), tt.wantEntries)
+			}
+		})
+	}
+}
+
+// TestUserGames tests the game history endpoint's limit handling and an empty history
+func TestUserGames(t *testing.T) {
+	app := newTestApplication(t)
+
+	player1 := registerUser(t, app, "player1")
+	player2 := registerUser(t, app, "player2")
+	newcomer := registerUser(t, app, "newcomer")
+	session := login(t, app, "player1")
+	for i := 0; i < 3; i++ {
+		createdID(t, do(t, app, http.MethodPost, "/api/v1/games", map[string]string{"player1_id": player1, "player2_id": player2}, session))
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		query      string
+		wantStatus int
+		wantGames  int
+	}{
+		{"default limit", player1, "", http.StatusOK, 3},
+		{"limit", player2, "?limit=2", http.StatusOK, 2},
+		{"limit above the cap", player1, "?limit=1000", http.StatusOK, 3},
+		{"empty history", newcomer, "", http.StatusOK, 0},
+		{"zero limit", player1, "?limit=0", http.StatusBadRequest, 0},
+		{"non-numeric limit", player1, "?limit=all", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := do(t, app, http.MethodGet, "/api/v1/users/"+tt.userID+"/games"+tt.query, nil, nil)
+			resp.AssertStatus(t, tt.wantStatus)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var games []map[string]interface{}
+			resp.DecodeData(t, &games)
+			if games == nil || len(games) != tt.wantGames {
+				t.Errorf("got %v games, want %d", games, tt.wantGames)
+			}
+		})
+	}
+}
+
+// TestValidationErrorResponses tests that invalid requests report every failing field
+func TestValidationErrorResponses(t *testing.T) {
+	app := newTestApplication(t)
+
+	resp := do(t, app, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username": "al",
+		"email":    "not-an-email",
+		"password": "password123",
+	}, nil)
+	resp.AssertStatus(t, http.StatusBadRequest)
+	resp.AssertField(t, "message", "Validation failed")
+	resp.AssertField(t, "errors.username", "must be at least 3 characters")
+	resp.AssertField(t, "errors.email", "must be a valid email address")
+	if _, ok := resp.Field(t, "errors.password"); ok {
+		t.Error("errors.password reported for a valid password")
+	}
+
+	registerUser(t, app, "validator")
+	resp = do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "",
+		"type":        "daily",
+		"max_entries": 10,
+	}, login(t, app, "validator"))
+	resp.AssertStatus(t, http.StatusBadRequest)
+	resp.AssertField(t, "errors.name", "is required")
+	resp.AssertField(t, "errors.type", "must be one of global, weekly, monthly, seasonal")
+}
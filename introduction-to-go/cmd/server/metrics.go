@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultMetricsBuckets are the request duration bucket bounds in seconds,
+// matching the Prometheus client defaults
+var defaultMetricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// requestDurationMetric is the histogram name exposed at /metrics
+const requestDurationMetric = "http_request_duration_seconds"
+
+// routeKey identifies one histogram series. Route is the route template, not
+// the raw path, so IDs in the URL do not create a series each.
+type routeKey struct {
+	Method      string
+	Route       string
+	StatusClass string
+}
+
+// histogram counts observations per bucket. counts[i] holds observations up
+// to buckets[i]; the last slot is the +Inf bucket.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// requestMetrics records request durations per route for /metrics
+type requestMetrics struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[routeKey]*histogram
+}
+
+// newRequestMetrics creates request metrics with the given bucket bounds in
+// seconds; nil uses the defaults
+func newRequestMetrics(buckets []float64) *requestMetrics {
+	if len(buckets) == 0 {
+		buckets = defaultMetricsBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &requestMetrics{
+		buckets: buckets,
+		series:  make(map[routeKey]*histogram),
+	}
+}
+
+// Observe records one request duration for key
+func (m *requestMetrics) Observe(key routeKey, d time.Duration) {
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(m.buckets, seconds)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.series[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets)+1)}
+		m.series[key] = h
+	}
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// WriteTo writes every series in the Prometheus text exposition format
+func (m *requestMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]routeKey, 0, len(m.series))
+	snapshot := make(map[routeKey]histogram, len(m.series))
+	for key, h := range m.series {
+		keys = append(keys, key)
+		snapshot[key] = histogram{counts: append([]uint64(nil), h.counts...), sum: h.sum, count: h.count}
+	}
+	m.mu.Unlock()
+
+	// Stable output keeps scrapes diffable
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.StatusClass < b.StatusClass
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s Duration of HTTP requests by route.\n", requestDurationMetric)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", requestDurationMetric)
+	for _, key := range keys {
+		h := snapshot[key]
+		labels := fmt.Sprintf(`method="%s",route="%s",status="%s"`, escapeLabel(key.Method), escapeLabel(key.Route), key.StatusClass)
+
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", requestDurationMetric, labels, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", requestDurationMetric, labels, h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", requestDurationMetric, labels, formatFloat(h.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", requestDurationMetric, labels, h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Middleware times every routed request. It runs after route matching, so
+// requests that match no route are not recorded.
+func (m *requestMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		m.Observe(routeKey{
+			Method:      r.Method,
+			Route:       route,
+			StatusClass: statusClass(rec.status),
+		}, time.Since(start))
+	})
+}
+
+// metricsHandler serves the recorded metrics in the Prometheus text format
+func metricsHandler(m *requestMetrics) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	}
+}
+
+// statusRecorder remembers the status code a handler responded with and how
+// many body bytes it wrote
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Hijack hands the connection over for protocol upgrades such as WebSockets
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", r.ResponseWriter)
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// statusClass groups a status code as "2xx", "4xx" and so on
+func statusClass(code int) string {
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// parseBuckets parses comma-separated bucket bounds in seconds
+func parseBuckets(s string) ([]float64, error) {
+	var buckets []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		bound, err := strconv.ParseFloat(field, 64)
+		if err != nil || bound <= 0 {
+			return nil, fmt.Errorf("invalid bucket %q", field)
+		}
+		buckets = append(buckets, bound)
+	}
+	return buckets, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// scrapeMetrics fetches /metrics and returns its lines
+func scrapeMetrics(t *testing.T, app *Application) []string {
+	t.Helper()
+
+	resp := do(t, app, http.MethodGet, "/metrics", nil, nil)
+	resp.AssertStatus(t, http.StatusOK)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	return strings.Split(strings.TrimSpace(string(resp.Body)), "\n")
+}
+
+// assertSample checks that a sample line with the given name and labels exists with value
+func assertSample(t *testing.T, lines []string, sample, value string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if name, got, ok := strings.Cut(line, "} "); ok && name+"}" == sample {
+			if got != value {
+				t.Errorf("%s = %s, want %s", sample, got, value)
+			}
+			return
+		}
+	}
+	t.Errorf("sample %s missing from /metrics:\n%s", sample, strings.Join(lines, "\n"))
+}
+
+// TestRequestMetrics tests that requests are recorded per route template and status class
+func TestRequestMetrics(t *testing.T) {
+	t.Setenv("METRICS_BUCKETS", "0.5,0.1,10")
+	app := newTestApplication(t)
+
+	playerID := registerUser(t, app, "metrics")
+	do(t, app, http.MethodGet, "/api/v1/users/"+playerID+"/stats", nil, nil).AssertStatus(t, http.StatusOK)
+	do(t, app, http.MethodGet, "/api/v1/users/missing-1/stats", nil, nil).AssertStatus(t, http.StatusNotFound)
+	do(t, app, http.MethodGet, "/api/v1/users/missing-2/stats", nil, nil).AssertStatus(t, http.StatusNotFound)
+
+	lines := scrapeMetrics(t, app)
+
+	stats := `method="GET",route="/api/v1/users/{userID}/stats"`
+	register := `method="POST",route="/api/v1/auth/register",status="2xx"`
+
+	assertSample(t, lines, `http_request_duration_seconds_count{`+stats+`,status="2xx"}`, "1")
+	assertSample(t, lines, `http_request_duration_seconds_count{`+stats+`,status="4xx"}`, "2")
+	assertSample(t, lines, `http_request_duration_seconds_bucket{`+stats+`,status="4xx",le="+Inf"}`, "2")
+	assertSample(t, lines, `http_request_duration_seconds_bucket{`+stats+`,status="4xx",le="10"}`, "2")
+	assertSample(t, lines, `http_request_duration_seconds_count{`+register+`}`, "1")
+
+	// Buckets come from METRICS_BUCKETS, sorted
+	var bounds []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, `http_request_duration_seconds_bucket{`+register) {
+			_, le, _ := strings.Cut(line, `le="`)
+			bound, _, _ := strings.Cut(le, `"`)
+			bounds = append(bounds, bound)
+		}
+	}
+	if got := strings.Join(bounds, ","); got != "0.1,0.5,10,+Inf" {
+		t.Errorf("bucket bounds = %s, want 0.1,0.5,10,+Inf", got)
+	}
+
+	// Raw IDs never become labels
+	for _, line := range lines {
+		if strings.Contains(line, playerID) || strings.Contains(line, "missing-1") {
+			t.Errorf("raw path leaked into labels: %s", line)
+		}
+	}
+}
+
+// TestRequestMetricsInvalidBuckets tests that malformed bucket configuration is rejected
+func TestRequestMetricsInvalidBuckets(t *testing.T) {
+	for _, buckets := range []string{"0.1,abc", "-1", "0"} {
+		t.Setenv("METRICS_BUCKETS", buckets)
+		if _, err := loadConfig(); err == nil {
+			t.Errorf("loadConfig() with METRICS_BUCKETS=%q succeeded, want error", buckets)
+		}
+	}
+}
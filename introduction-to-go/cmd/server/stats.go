@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/game"
+	"effective-golang/internal/leaderboard"
+)
+
+// Stats is a point-in-time snapshot of every service, served at /api/v1/stats
+type Stats struct {
+	Games        game.GameStats           `json:"games"`
+	Leaderboards leaderboard.ServiceStats `json:"leaderboards"`
+	Auth         auth.AuthStats           `json:"auth"`
+	Cache        CacheStats               `json:"cache"`
+	Timestamp    time.Time                `json:"timestamp"`
+}
+
+// CacheStats reports cache usage. Entries is -1 when the cache cannot report its size.
+type CacheStats struct {
+	Entries int `json:"entries"`
+}
+
+// sizedCache is implemented by caches that can report how many entries they hold
+type sizedCache interface {
+	Len() int
+}
+
+// Stats gathers a snapshot from every service. Each service only takes its
+// own lock long enough to count, so it is safe to call while serving traffic.
+func (app *Application) Stats() Stats {
+	cache := CacheStats{Entries: -1}
+	if sized, ok := app.cacheRepo.(sizedCache); ok {
+		cache.Entries = sized.Len()
+	}
+
+	return Stats{
+		Games:        app.gameService.Stats(),
+		Leaderboards: app.leaderboardSvc.Stats(),
+		Auth:         app.authService.Stats(),
+		Cache:        cache,
+		Timestamp:    time.Now(),
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"effective-golang/internal/leaderboard"
+	"effective-golang/pkg/utils"
+)
+
+// Leaderboard stream keepalive
+const (
+	// streamWriteWait is how long a single write to a client may take
+	streamWriteWait = 10 * time.Second
+	// streamPongWait is how long a client may go without answering a ping
+	streamPongWait = 60 * time.Second
+	// streamPingPeriod must be shorter than streamPongWait so a pong can arrive in time
+	streamPingPeriod = streamPongWait * 9 / 10
+)
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// streamLeaderboardHandler upgrades to a WebSocket and sends each update to
+// the leaderboard as a JSON text message until the client goes away or the
+// feed closes. Each connection holds its own subscription, closed on return,
+// so one client leaving does not cut off the others.
+func streamLeaderboardHandler(leaderboardSvc *leaderboard.LeaderboardService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		leaderboardID := mux.Vars(r)["leaderboardID"]
+
+		// Subscribe before upgrading so an unknown leaderboard gets a plain 404
+		sub, err := leaderboardSvc.Subscribe(leaderboardID, leaderboard.DefaultSubscription)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		defer sub.Close()
+
+		conn, err := streamUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already answered the client
+			return
+		}
+		defer conn.Close()
+
+		// Clients send nothing but pongs and close frames; reading is still
+		// needed to process them and to notice when the client goes away
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			conn.SetReadLimit(512)
+			conn.SetReadDeadline(time.Now().Add(streamPongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+			})
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(streamPingPeriod)
+		defer ping.Stop()
+
+		for {
+			select {
+			case update, ok := <-sub.C():
+				conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+				if !ok {
+					conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "leaderboard feed closed"))
+					return
+				}
+				if err := conn.WriteJSON(update); err != nil {
+					logStreamError(leaderboardID, err)
+					return
+				}
+
+			case <-ping.C:
+				conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					logStreamError(leaderboardID, err)
+					return
+				}
+
+			case <-gone:
+				return
+			}
+		}
+	}
+}
+
+// logStreamError logs a failed write unless the client simply disconnected
+func logStreamError(leaderboardID string, err error) {
+	if errors.Is(err, websocket.ErrCloseSent) || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+		return
+	}
+	log.Printf("Leaderboard stream for %s failed: %v", leaderboardID, err)
+}
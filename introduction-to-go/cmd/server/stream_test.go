@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"effective-golang/internal/leaderboard"
+)
+
+// TestStreamLeaderboard tests that score updates reach a WebSocket client and
+// that disconnecting releases its subscription
+func TestStreamLeaderboard(t *testing.T) {
+	app := newTestApplication(t)
+	server := httptest.NewServer(app.server.Handler)
+	t.Cleanup(server.Close)
+
+	userID := registerUser(t, app, "streamer")
+	session := login(t, app, "streamer")
+	leaderboardID := createdID(t, do(t, app, http.MethodPost, "/api/v1/leaderboards", map[string]interface{}{
+		"name":        "Live",
+		"type":        "global",
+		"max_entries": 10,
+	}, session))
+
+	streamURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/leaderboards/" + leaderboardID + "/stream"
+	header := http.Header{"Authorization": {session["Authorization"]}}
+
+	if _, resp, err := websocket.DefaultDialer.Dial(streamURL, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Dial() without a session = %v, want 401", err)
+	}
+	missingURL := strings.Replace(streamURL, leaderboardID, "missing", 1)
+	if _, resp, err := websocket.DefaultDialer.Dial(missingURL, header); err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Dial() to an unknown leaderboard = %v, want 404", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(streamURL, header)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	waitForSubscribers(t, app, leaderboardID, 1)
+
+	resp := do(t, app, http.MethodPost, "/api/v1/leaderboards/"+leaderboardID+"/scores", map[string]interface{}{"user_id": userID, "score": 42}, session)
+	resp.AssertStatus(t, http.StatusOK)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var update leaderboard.LeaderboardUpdate
+	if err := conn.ReadJSON(&update); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	if update.LeaderboardID != leaderboardID || update.UserID != userID || update.NewRank != 1 {
+		t.Errorf("update = %+v, want %s ranked 1 on %s", update, userID, leaderboardID)
+	}
+
+	conn.Close()
+	waitForSubscribers(t, app, leaderboardID, 0)
+}
+
+// waitForSubscribers waits for a leaderboard's update feed to reach want subscribers
+func waitForSubscribers(t *testing.T, app *Application, leaderboardID string, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats, err := app.leaderboardSvc.UpdateStats(leaderboardID)
+		if err != nil {
+			t.Fatalf("UpdateStats() error = %v", err)
+		}
+		if stats.Subscribers == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("subscribers = %d, want %d", stats.Subscribers, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
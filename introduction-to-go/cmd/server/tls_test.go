@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shared/tlsconfig"
+)
+
+// TestServeTLS tests that the server speaks HTTPS when a certificate pair is configured
+func TestServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := tlsconfig.WriteSelfSignedCert(certFile, keyFile, "effective-golang dev", "127.0.0.1"); err != nil {
+		t.Fatalf("WriteSelfSignedCert() error = %v", err)
+	}
+	t.Setenv("TLS_CERT_FILE", certFile)
+	t.Setenv("TLS_KEY_FILE", keyFile)
+
+	app := newTestApplication(t)
+	if app.server.TLSConfig == nil {
+		t.Fatal("TLSConfig = nil, want TLS enabled")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go app.serve(ln)
+	t.Cleanup(func() { app.server.Close() })
+
+	pemBytes, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pemBytes)
+
+	httpsClient := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	resp, err := httpsClient.Get("https://" + ln.Addr().String() + "/health")
+	if err != nil {
+		t.Fatalf("HTTPS GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("HTTPS GET status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("HTTPS GET negotiated %+v, want TLS 1.2 or newer", resp.TLS)
+	}
+
+	// Plain HTTP to the TLS port is refused
+	plainClient := &http.Client{Timeout: 5 * time.Second}
+	resp, err = plainClient.Get("http://" + ln.Addr().String() + "/health")
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("plain HTTP GET status = %d, want failure", resp.StatusCode)
+		}
+	}
+}
+
+// TestLoadTLSConfigRequiresBothFiles tests that a lone cert or key file is a configuration error
+func TestLoadTLSConfigRequiresBothFiles(t *testing.T) {
+	if cfg, err := loadTLSConfig("", ""); cfg != nil || err != nil {
+		t.Errorf("loadTLSConfig(\"\", \"\") = %v, %v, want nil, nil", cfg, err)
+	}
+	if _, err := loadTLSConfig("cert.pem", ""); err == nil {
+		t.Error("loadTLSConfig() with only a cert expected error but got none")
+	}
+}
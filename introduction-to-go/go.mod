@@ -4,10 +4,17 @@ go 1.24
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.4.2
 	github.com/joho/godotenv v1.5.1
+	github.com/redis/go-redis/v9 v9.7.0
+	shared v0.0.0
 )
 
 require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/heroiclabs/nakama-common v1.32.0 // indirect
 	google.golang.org/protobuf v1.34.1 // indirect
 )
+
+replace shared => ../shared
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileLogger appends audit entries to a file as JSON lines
+type FileLogger struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileLogger opens (or creates) an append-only audit log file
+func NewFileLogger(path string) (*FileLogger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	
+	return &FileLogger{path: path, file: file}, nil
+}
+
+// Log appends an entry to the file
+func (l *FileLogger) Log(ctx context.Context, entry Entry) error {
+	data, err := json.Marshal(prepare(ctx, entry))
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Recent reads the file and returns up to limit of the most recent entries, newest first
+func (l *FileLogger) Recent(ctx context.Context, limit int) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	
+	return newestFirst(entries, limit), nil
+}
+
+// Close closes the underlying file
+func (l *FileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	return l.file.Close()
+}
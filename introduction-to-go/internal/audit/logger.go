@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	ActionLogin          = "auth.login"
+	ActionLogout         = "auth.logout"
+	ActionPasswordChange = "auth.password_change"
+	ActionDeactivate     = "user.deactivate"
+	ActionMaintenance    = "admin.maintenance"
+)
+
+// redacted replaces secret values in entry details
+const redacted = "[REDACTED]"
+
+// Entry is a single append-only audit record
+type Entry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Actor     string            `json:"actor"`
+	Action    string            `json:"action"`
+	Target    string            `json:"target,omitempty"`
+	ClientIP  string            `json:"client_ip,omitempty"`
+	Success   bool              `json:"success"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Logger records security-sensitive actions
+type Logger interface {
+	// Log appends an entry to the audit log
+	Log(ctx context.Context, entry Entry) error
+	
+	// Recent returns up to limit of the most recent entries, newest first
+	Recent(ctx context.Context, limit int) ([]Entry, error)
+}
+
+// prepare fills in defaults and redacts secrets before an entry is stored
+func prepare(ctx context.Context, entry Entry) Entry {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.ClientIP == "" {
+		entry.ClientIP = ClientIPFromContext(ctx)
+	}
+	entry.Details = Redact(entry.Details)
+	return entry
+}
+
+// Redact returns a copy of details with values of secret-looking keys replaced
+func Redact(details map[string]string) map[string]string {
+	if len(details) == 0 {
+		return nil
+	}
+	
+	result := make(map[string]string, len(details))
+	for key, value := range details {
+		if isSecretKey(key) {
+			value = redacted
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// isSecretKey reports whether a detail key holds a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range []string{"password", "token", "secret", "session", "authorization"} {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithClientIP returns a context carrying the client IP for audit entries
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// ClientIPFromContext returns the client IP stored by WithClientIP, if any
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
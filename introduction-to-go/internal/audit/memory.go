@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryLogger keeps the most recent audit entries in memory
+type MemoryLogger struct {
+	mu       sync.RWMutex
+	entries  []Entry
+	capacity int
+}
+
+// NewMemoryLogger creates an in-memory audit logger holding up to capacity entries
+func NewMemoryLogger(capacity int) *MemoryLogger {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &MemoryLogger{
+		entries:  make([]Entry, 0, capacity),
+		capacity: capacity,
+	}
+}
+
+// Log appends an entry, dropping the oldest once capacity is reached
+func (l *MemoryLogger) Log(ctx context.Context, entry Entry) error {
+	entry = prepare(ctx, entry)
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	if len(l.entries) >= l.capacity {
+		l.entries = append(l.entries[:0], l.entries[1:]...)
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+// Recent returns up to limit of the most recent entries, newest first
+func (l *MemoryLogger) Recent(ctx context.Context, limit int) ([]Entry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	
+	return newestFirst(l.entries, limit), nil
+}
+
+// newestFirst returns the last limit entries in reverse order
+func newestFirst(entries []Entry, limit int) []Entry {
+	if limit <= 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+	
+	result := make([]Entry, 0, limit)
+	for i := len(entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, entries[i])
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"effective-golang/internal/audit"
+	"effective-golang/internal/models"
+)
+
+// Login rate limiting defaults
+const (
+	DefaultLoginMaxAttempts   = 5
+	DefaultLoginLockoutWindow = 15 * time.Minute
+)
+
+// ErrTooManyAttempts is returned by Login while a username or client IP is
+// locked out after repeated failures
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// WithLoginRateLimit locks a username, and the client IP the attempt came from,
+// out of Login once they rack up maxAttempts failed attempts within window.
+// The window starts at the first failure, and the lockout lifts when it ends.
+// A maxAttempts of 0 or less disables rate limiting.
+func WithLoginRateLimit(maxAttempts int, window time.Duration) AuthServiceOption {
+	return func(s *AuthService) {
+		s.loginMaxAttempts = maxAttempts
+		s.loginLockoutWindow = window
+	}
+}
+
+// loginAttemptKeys are the cache keys counting failed logins for username and
+// for the client IP in ctx, when there is one. That IP must not come from
+// headers the client controls, or rotating them resets the per-IP count.
+func loginAttemptKeys(ctx context.Context, username string) []string {
+	keys := []string{"login_failures:user:" + strings.ToLower(username)}
+	if ip := audit.ClientIPFromContext(ctx); ip != "" {
+		keys = append(keys, "login_failures:ip:"+ip)
+	}
+	return keys
+}
+
+// checkLoginAttempts returns ErrTooManyAttempts when any of keys has reached
+// the failure limit. Cache errors let the attempt through.
+func (s *AuthService) checkLoginAttempts(ctx context.Context, keys []string) error {
+	if s.loginMaxAttempts <= 0 {
+		return nil
+	}
+
+	for _, key := range keys {
+		var failures int64
+		if err := s.cacheRepo.Get(ctx, key, &failures); err != nil {
+			if !errors.Is(err, models.ErrCacheMiss) {
+				log.Printf("Failed to read login attempts for %s: %v", key, err)
+			}
+			continue
+		}
+		if failures >= int64(s.loginMaxAttempts) {
+			return fmt.Errorf("%w: try again in up to %s", ErrTooManyAttempts, s.loginLockoutWindow)
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against keys, starting the window
+// on the first failure
+func (s *AuthService) recordLoginFailure(ctx context.Context, keys []string) {
+	if s.loginMaxAttempts <= 0 {
+		return
+	}
+
+	ttl := max(int(s.loginLockoutWindow/time.Second), 1)
+	for _, key := range keys {
+		failures, err := s.cacheRepo.Increment(ctx, key, 1)
+		if err != nil {
+			log.Printf("Failed to count login failure for %s: %v", key, err)
+			continue
+		}
+		if failures == 1 {
+			if err := s.cacheRepo.Expire(ctx, key, ttl); err != nil {
+				log.Printf("Failed to start login lockout window for %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// resetLoginFailures clears the username's failure count after a successful
+// login. The client IP keeps its count, so logging in to one account does not
+// clear failures racked up guessing others from the same address.
+func (s *AuthService) resetLoginFailures(ctx context.Context, keys []string) {
+	if s.loginMaxAttempts <= 0 {
+		return
+	}
+
+	if err := s.cacheRepo.Delete(ctx, keys[0]); err != nil {
+		log.Printf("Failed to reset login attempts for %s: %v", keys[0], err)
+	}
+}
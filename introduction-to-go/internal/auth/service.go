@@ -4,16 +4,43 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
+	"effective-golang/internal/audit"
 	"effective-golang/internal/models"
+	"effective-golang/pkg/validate"
 )
 
 // AuthService handles authentication and authorization logic
 type AuthService struct {
 	userRepo models.UserRepository
 	cacheRepo models.CacheRepository
+	auditLog audit.Logger
+	
+	// Expiry of every session this service knows is live, for Stats
+	sessions      map[string]time.Time
+	sessionsMutex sync.Mutex
+	
+	// Serializes updates to the per-user session index in the cache
+	sessionIndexMu sync.Mutex
+	
+	// Failed logins allowed per username and client IP within the window
+	loginMaxAttempts   int
+	loginLockoutWindow time.Duration
+}
+
+// AuthServiceOption configures optional AuthService behaviour
+type AuthServiceOption func(*AuthService)
+
+// WithAuditLogger records logins, logouts, password changes and deactivations
+func WithAuditLogger(logger audit.Logger) AuthServiceOption {
+	return func(s *AuthService) {
+		s.auditLog = logger
+	}
 }
 
 // Session represents a user session
@@ -33,9 +60,14 @@ type LoginRequest struct {
 
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username string `json:"username" validate:"required,min=3,max=20"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// AuthStats is a point-in-time snapshot of the auth service
+type AuthStats struct {
+	ActiveSessions int `json:"active_sessions"`
 }
 
 // Custom errors for authentication
@@ -44,21 +76,36 @@ var (
 	ErrSessionExpired     = fmt.Errorf("session expired")
 	ErrSessionNotFound    = fmt.Errorf("session not found")
 	ErrUserAlreadyExists  = fmt.Errorf("user already exists")
+	ErrAccountDeactivated = fmt.Errorf("account is deactivated")
 )
 
 // NewAuthService creates a new authentication service
-func NewAuthService(userRepo models.UserRepository, cacheRepo models.CacheRepository) *AuthService {
-	return &AuthService{
+func NewAuthService(userRepo models.UserRepository, cacheRepo models.CacheRepository, opts ...AuthServiceOption) *AuthService {
+	s := &AuthService{
 		userRepo:  userRepo,
 		cacheRepo: cacheRepo,
+		sessions:  make(map[string]time.Time),
+		
+		loginMaxAttempts:   DefaultLoginMaxAttempts,
+		loginLockoutWindow: DefaultLoginLockoutWindow,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Register creates a new user account
 func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*models.User, error) {
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	
 	// Check if user already exists
+	// Lookups ignore case, so "Alice" is taken once "alice" is; an ambiguous
+	// match means several users already hold the name in some case
 	existingUser, err := s.userRepo.GetByUsername(ctx, req.Username)
-	if err == nil && existingUser != nil {
+	if (err == nil && existingUser != nil) || errors.Is(err, models.ErrAmbiguousUsername) {
 		return nil, fmt.Errorf("username already taken: %w", ErrUserAlreadyExists)
 	}
 	
@@ -98,21 +145,39 @@ func (s *AuthService) Register(ctx context.Context, req *RegisterRequest) (*mode
 
 // Login authenticates a user and creates a session
 func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*Session, error) {
+	// Locked out usernames and IPs are turned away before the password is checked
+	attemptKeys := loginAttemptKeys(ctx, req.Username)
+	if err := s.checkLoginAttempts(ctx, attemptKeys); err != nil {
+		s.record(ctx, audit.Entry{Actor: req.Username, Action: audit.ActionLogin, Details: map[string]string{"reason": "too many attempts"}})
+		return nil, err
+	}
+	
 	// Get user by username
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
+	if errors.Is(err, models.ErrAmbiguousUsername) {
+		s.recordLoginFailure(ctx, attemptKeys)
+		s.record(ctx, audit.Entry{Actor: req.Username, Action: audit.ActionLogin, Details: map[string]string{"reason": "ambiguous username"}})
+		return nil, fmt.Errorf("authentication failed: %w", ErrInvalidCredentials)
+	}
 	if err != nil {
+		s.recordLoginFailure(ctx, attemptKeys)
+		s.record(ctx, audit.Entry{Actor: req.Username, Action: audit.ActionLogin, Details: map[string]string{"reason": "unknown user"}})
 		return nil, fmt.Errorf("authentication failed: %w", ErrInvalidCredentials)
 	}
 	
 	// Check if user is active
 	if !user.IsActive {
-		return nil, fmt.Errorf("account is deactivated")
+		s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionLogin, Target: user.ID, Details: map[string]string{"reason": "deactivated"}})
+		return nil, ErrAccountDeactivated
 	}
 	
 	// Verify password (in real app, use bcrypt.CompareHashAndPassword)
-	if user.Password != req.Password {
+	if !user.CheckPassword(req.Password) {
+		s.recordLoginFailure(ctx, attemptKeys)
+		s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionLogin, Target: user.ID, Details: map[string]string{"reason": "invalid password"}})
 		return nil, fmt.Errorf("authentication failed: %w", ErrInvalidCredentials)
 	}
+	s.resetLoginFailures(ctx, attemptKeys)
 	
 	// Create session
 	session, err := s.createSession(ctx, user)
@@ -120,20 +185,96 @@ func (s *AuthService) Login(ctx context.Context, req *LoginRequest) (*Session, e
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 	
+	s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionLogin, Target: user.ID, Success: true})
+	
 	return session, nil
 }
 
 // Logout invalidates a user session
 func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
+	// Look up who is logging out for the audit log; an unknown session is still removed
+	actor := "unknown"
+	target := ""
+	if session, err := s.ValidateSession(ctx, sessionID); err == nil {
+		actor = session.Username
+		target = session.UserID
+	}
+	
 	// Remove session from cache
 	cacheKey := fmt.Sprintf("session:%s", sessionID)
 	if err := s.cacheRepo.Delete(ctx, cacheKey); err != nil {
 		return fmt.Errorf("failed to remove session: %w", err)
 	}
+	s.untrackSession(sessionID)
+	
+	// Sessions that had already expired are pruned from the index by ListSessions
+	if target != "" {
+		if err := s.unindexSession(ctx, target, sessionID); err != nil {
+			return fmt.Errorf("failed to remove session: %w", err)
+		}
+	}
+	
+	s.record(ctx, audit.Entry{Actor: actor, Action: audit.ActionLogout, Target: target, Success: true})
+	
+	return nil
+}
+
+// ChangePassword replaces a user's password after verifying the current one
+func (s *AuthService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	
+	if !user.CheckPassword(currentPassword) {
+		s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionPasswordChange, Target: user.ID, Details: map[string]string{"reason": "invalid current password"}})
+		return fmt.Errorf("password change failed: %w", ErrInvalidCredentials)
+	}
+	
+	if err := user.SetPassword(newPassword); err != nil {
+		s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionPasswordChange, Target: user.ID, Details: map[string]string{"reason": "invalid new password"}})
+		return fmt.Errorf("password change failed: %w", err)
+	}
+	
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	
+	s.record(ctx, audit.Entry{Actor: user.Username, Action: audit.ActionPasswordChange, Target: user.ID, Success: true})
 	
 	return nil
 }
 
+// DeactivateUser disables a user account; actor identifies who requested it
+func (s *AuthService) DeactivateUser(ctx context.Context, actor, userID string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	
+	user.Deactivate()
+	
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	
+	s.record(ctx, audit.Entry{Actor: actor, Action: audit.ActionDeactivate, Target: user.ID, Success: true})
+	
+	return nil
+}
+
+// record writes an audit entry if an audit logger is configured. Audit
+// failures never fail the action being audited.
+func (s *AuthService) record(ctx context.Context, entry audit.Entry) {
+	if s.auditLog == nil {
+		return
+	}
+	
+	if err := s.auditLog.Log(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for %s: %v", entry.Action, err)
+	}
+}
+
 // ValidateSession validates a session and returns user information
 func (s *AuthService) ValidateSession(ctx context.Context, sessionID string) (*Session, error) {
 	cacheKey := fmt.Sprintf("session:%s", sessionID)
@@ -147,6 +288,7 @@ func (s *AuthService) ValidateSession(ctx context.Context, sessionID string) (*S
 	if time.Now().After(session.ExpiresAt) {
 		// Clean up expired session
 		s.cacheRepo.Delete(ctx, cacheKey)
+		s.untrackSession(sessionID)
 		return nil, fmt.Errorf("session validation failed: %w", ErrSessionExpired)
 	}
 	
@@ -180,7 +322,13 @@ func (s *AuthService) RefreshSession(ctx context.Context, sessionID string) (*Se
 	
 	// Update session in cache
 	cacheKey := fmt.Sprintf("session:%s", sessionID)
-	if err := s.cacheRepo.Set(ctx, cacheKey, session, 86400); err != nil {
+	if err := s.cacheRepo.Set(ctx, cacheKey, session, sessionTTL); err != nil {
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+	s.trackSession(session)
+	
+	// Keep the user's index alive as long as the session
+	if err := s.indexSession(ctx, session); err != nil {
 		return nil, fmt.Errorf("failed to refresh session: %w", err)
 	}
 	
@@ -205,13 +353,50 @@ func (s *AuthService) createSession(ctx context.Context, user *models.User) (*Se
 	
 	// Store session in cache
 	cacheKey := fmt.Sprintf("session:%s", sessionID)
-	if err := s.cacheRepo.Set(ctx, cacheKey, session, 86400); err != nil {
+	if err := s.cacheRepo.Set(ctx, cacheKey, session, sessionTTL); err != nil {
 		return nil, fmt.Errorf("failed to store session: %w", err)
 	}
 	
+	// Index it under the user so their sessions can be listed and revoked together
+	if err := s.indexSession(ctx, session); err != nil {
+		s.cacheRepo.Delete(ctx, cacheKey)
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
+	s.trackSession(session)
+	
 	return session, nil
 }
 
+// trackSession records a live session and its expiry
+func (s *AuthService) trackSession(session *Session) {
+	s.sessionsMutex.Lock()
+	s.sessions[session.ID] = session.ExpiresAt
+	s.sessionsMutex.Unlock()
+}
+
+// untrackSession forgets a session that was removed or found expired
+func (s *AuthService) untrackSession(sessionID string) {
+	s.sessionsMutex.Lock()
+	delete(s.sessions, sessionID)
+	s.sessionsMutex.Unlock()
+}
+
+// Stats returns a snapshot of the auth service. Sessions that have expired
+// are not counted, and are forgotten as they are found.
+func (s *AuthService) Stats() AuthStats {
+	now := time.Now()
+	
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+	
+	for id, expiresAt := range s.sessions {
+		if now.After(expiresAt) {
+			delete(s.sessions, id)
+		}
+	}
+	return AuthStats{ActiveSessions: len(s.sessions)}
+}
+
 // generateSessionID generates a random session ID
 func generateSessionID() (string, error) {
 	bytes := make([]byte, 32)
@@ -221,6 +406,14 @@ func generateSessionID() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
+// Health reports whether the user repository is responding
+func (s *AuthService) Health(ctx context.Context) error {
+	if _, err := s.userRepo.List(ctx, 0, 1); err != nil {
+		return fmt.Errorf("user repository: %w", err)
+	}
+	return nil
+}
+
 // GetUserStats retrieves user statistics
 func (s *AuthService) GetUserStats(ctx context.Context, userID string) (*models.UserStats, error) {
 	stats, err := s.userRepo.GetStats(ctx, userID)
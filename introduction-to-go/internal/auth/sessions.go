@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"effective-golang/internal/audit"
+	"effective-golang/internal/models"
+)
+
+// sessionTTL is how long sessions, and the per-user index of them, stay cached
+const sessionTTL = 86400
+
+// userSessionsKey is the cache key listing a user's session IDs
+func userSessionsKey(userID string) string {
+	return fmt.Sprintf("user_sessions:%s", userID)
+}
+
+// loadSessionIndex returns the session IDs indexed for a user. The caller
+// holds sessionIndexMu.
+func (s *AuthService) loadSessionIndex(ctx context.Context, userID string) ([]string, error) {
+	var ids []string
+	if err := s.cacheRepo.Get(ctx, userSessionsKey(userID), &ids); err != nil && !errors.Is(err, models.ErrCacheMiss) {
+		return nil, fmt.Errorf("failed to load sessions for user %s: %w", userID, err)
+	}
+	return ids, nil
+}
+
+// saveSessionIndex stores a user's session IDs, dropping the index once it is
+// empty. The caller holds sessionIndexMu.
+func (s *AuthService) saveSessionIndex(ctx context.Context, userID string, ids []string) error {
+	if len(ids) == 0 {
+		return s.cacheRepo.Delete(ctx, userSessionsKey(userID))
+	}
+	return s.cacheRepo.Set(ctx, userSessionsKey(userID), ids, sessionTTL)
+}
+
+// indexSession adds a session to its user's index, or renews the index's
+// expiry when the session is already in it
+func (s *AuthService) indexSession(ctx context.Context, session *Session) error {
+	s.sessionIndexMu.Lock()
+	defer s.sessionIndexMu.Unlock()
+
+	ids, err := s.loadSessionIndex(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ids, session.ID) {
+		ids = append(ids, session.ID)
+	}
+	return s.saveSessionIndex(ctx, session.UserID, ids)
+}
+
+// unindexSession removes a session from its user's index
+func (s *AuthService) unindexSession(ctx context.Context, userID, sessionID string) error {
+	s.sessionIndexMu.Lock()
+	defer s.sessionIndexMu.Unlock()
+
+	ids, err := s.loadSessionIndex(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.saveSessionIndex(ctx, userID, slices.DeleteFunc(ids, func(id string) bool { return id == sessionID }))
+}
+
+// ListSessions returns a user's live sessions, oldest first. Sessions that
+// have expired are dropped from the index as they are found.
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]*Session, error) {
+	s.sessionIndexMu.Lock()
+	defer s.sessionIndexMu.Unlock()
+
+	ids, err := s.loadSessionIndex(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	live := ids[:0]
+	for _, id := range ids {
+		session, err := s.ValidateSession(ctx, id)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+		live = append(live, id)
+	}
+	if len(live) != len(ids) {
+		if err := s.saveSessionIndex(ctx, userID, live); err != nil {
+			return nil, fmt.Errorf("failed to prune sessions for user %s: %w", userID, err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeAllSessions logs a user out everywhere by removing every session in
+// their index, then the index itself
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID string) error {
+	s.sessionIndexMu.Lock()
+	defer s.sessionIndexMu.Unlock()
+
+	ids, err := s.loadSessionIndex(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		if err := s.cacheRepo.Delete(ctx, fmt.Sprintf("session:%s", id)); err != nil {
+			// Keep the sessions not yet removed indexed so a retry finds them
+			if saveErr := s.saveSessionIndex(ctx, userID, ids[i:]); saveErr != nil {
+				err = errors.Join(err, saveErr)
+			}
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+		s.untrackSession(id)
+	}
+	if err := s.saveSessionIndex(ctx, userID, nil); err != nil {
+		return fmt.Errorf("failed to clear sessions for user %s: %w", userID, err)
+	}
+
+	actor := userID
+	if user, err := s.userRepo.GetByID(ctx, userID); err == nil {
+		actor = user.Username
+	}
+	s.record(ctx, audit.Entry{Actor: actor, Action: audit.ActionLogout, Target: userID, Success: true, Details: map[string]string{"sessions": fmt.Sprint(len(ids))}})
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"effective-golang/internal/models"
+)
+
+// UsernameConflict is a group of users whose usernames differ only in case
+type UsernameConflict struct {
+	Username string         `json:"username"` // lower-cased form shared by the group
+	Users    []*models.User `json:"users"`    // oldest first
+}
+
+// DedupeUsernames reports every group of users whose usernames collide once
+// lookups ignore case, so they can be renamed before the old exact-case
+// behaviour is relied on anywhere. It only reports; nothing is changed. It
+// loads every user, so it is meant for one-off migrations rather than requests.
+func DedupeUsernames(ctx context.Context, userRepo models.UserRepository) ([]UsernameConflict, error) {
+	users, err := userRepo.List(ctx, 0, math.MaxInt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	groups := make(map[string][]*models.User)
+	for _, user := range users {
+		key := strings.ToLower(user.Username)
+		groups[key] = append(groups[key], user)
+	}
+
+	var conflicts []UsernameConflict
+	for key, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			if !group[i].CreatedAt.Equal(group[j].CreatedAt) {
+				return group[i].CreatedAt.Before(group[j].CreatedAt)
+			}
+			return group[i].ID < group[j].ID
+		})
+		conflicts = append(conflicts, UsernameConflict{Username: key, Users: group})
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Username < conflicts[j].Username
+	})
+
+	return conflicts, nil
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"time"
+)
+
+// Autoscaling hysteresis: the queue has to stay busy or idle for several
+// checks in a row before the worker count moves, so a single spike or lull
+// does not make it flap
+const (
+	scaleUpChecks   = 2
+	scaleDownChecks = 3
+)
+
+// WithWorkerAutoscaling starts the event processor with minWorkers workers
+// instead of the maxWorkers passed to NewGameService. The queue is checked
+// every interval: while it holds at least highWater events a worker is added,
+// up to maxWorkers, and while it is empty one is removed, down to minWorkers.
+func WithWorkerAutoscaling(minWorkers, highWater int, interval time.Duration) GameServiceOption {
+	return func(s *GameService) {
+		s.minWorkers = minWorkers
+		s.highWater = highWater
+		s.scaleInterval = interval
+	}
+}
+
+// autoscaling reports whether the worker count follows queue pressure
+func (ep *EventProcessor) autoscaling() bool {
+	return ep.gameSvc.scaleInterval > 0
+}
+
+// initialWorkers is the worker count the processor starts with
+func (ep *EventProcessor) initialWorkers() int {
+	if !ep.autoscaling() {
+		return cap(ep.workers)
+	}
+	return min(max(ep.gameSvc.minWorkers, 1), cap(ep.workers))
+}
+
+// runAutoscaler adds and retires workers according to queue pressure until
+// the processor stops. Workers are the tokens in ep.workers, so adding one
+// puts a token back and retiring one takes a free token out of circulation.
+func (ep *EventProcessor) runAutoscaler() {
+	defer ep.wg.Done()
+
+	minWorkers := ep.initialWorkers()
+	ticker := time.NewTicker(ep.gameSvc.scaleInterval)
+	defer ticker.Stop()
+
+	busy, idle := 0, 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-ep.ctx.Done():
+			return
+		}
+
+		switch queued := len(ep.queue); {
+		case queued >= ep.gameSvc.highWater:
+			busy, idle = busy+1, 0
+		case queued == 0:
+			busy, idle = 0, idle+1
+		default:
+			busy, idle = 0, 0
+		}
+
+		workers := int(ep.workerCount.Load())
+		switch {
+		case busy >= scaleUpChecks && workers < cap(ep.workers):
+			// Fewer tokens than the channel holds are in circulation, so this cannot block
+			ep.workers <- struct{}{}
+			ep.workerCount.Add(1)
+
+		case idle >= scaleDownChecks && workers > minWorkers:
+			select {
+			case <-ep.workers:
+				ep.workerCount.Add(-1)
+			default:
+				// Every worker is still busy; retire one on a later check
+			}
+		}
+	}
+}
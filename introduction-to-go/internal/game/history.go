@@ -0,0 +1,81 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"effective-golang/internal/models"
+)
+
+const (
+	// DefaultUserGamesLimit is how many games GetUserGames returns when no limit is given
+	DefaultUserGamesLimit = 20
+
+	// MaxUserGamesLimit caps the limit GetUserGames accepts
+	MaxUserGamesLimit = 100
+
+	// userGamesCacheTTL is how long, in seconds, a user's game history is cached
+	userGamesCacheTTL = 300
+)
+
+// GetUserGames retrieves a user's most recent games, newest first. A limit of
+// 0 or less returns DefaultUserGamesLimit games, and limits above
+// MaxUserGamesLimit are lowered to it. Results are cached until one of the
+// user's games changes.
+func (s *GameService) GetUserGames(ctx context.Context, userID string, limit int) ([]*models.Game, error) {
+	limit = clampUserGamesLimit(limit)
+
+	// Try to get from cache first, under the user's current history version
+	version, versionErr := models.CacheVersion(ctx, s.cacheRepo, userGamesVersionKey(userID))
+	cacheKey := fmt.Sprintf("user:%s:games:%s:%d", userID, version, limit)
+	var games []*models.Game
+	if versionErr == nil {
+		if err := s.cacheRepo.Get(ctx, cacheKey, &games); err == nil {
+			return games, nil
+		}
+	}
+
+	// Get from database
+	games, err := s.gameRepo.GetUserGames(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user games: %w", err)
+	}
+	if games == nil {
+		games = []*models.Game{}
+	}
+
+	// Cache an encoded copy, so later changes to the live games are not
+	// picked up by the cache. If a game change bumped the version since it was
+	// read, the copy lands under the old version and is never read.
+	if data, err := json.Marshal(games); err == nil && versionErr == nil {
+		s.cacheRepo.Set(ctx, cacheKey, json.RawMessage(data), userGamesCacheTTL)
+	}
+
+	return games, nil
+}
+
+// clampUserGamesLimit applies the GetUserGames default and maximum to a requested limit
+func clampUserGamesLimit(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultUserGamesLimit
+	case limit > MaxUserGamesLimit:
+		return MaxUserGamesLimit
+	default:
+		return limit
+	}
+}
+
+// userGamesVersionKey returns the cache key of a user's game history version; see models.CacheVersion
+func userGamesVersionKey(userID string) string {
+	return fmt.Sprintf("user:%s:games:version", userID)
+}
+
+// invalidateUserGames drops the cached game histories of both players in a
+// game, on every instance sharing the cache
+func (s *GameService) invalidateUserGames(ctx context.Context, game *models.Game) {
+	for _, userID := range []string{game.Player1ID, game.Player2ID} {
+		models.BumpCacheVersion(ctx, s.cacheRepo, userGamesVersionKey(userID))
+	}
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"effective-golang/internal/models"
+)
+
+// modeOf returns the mode a game was created with. Modes are validated when
+// the game is created, so a mode that no longer builds (say, one registered
+// by a previous run) falls back to classic.
+func modeOf(game *models.Game) models.GameMode {
+	mode, err := models.NewGameMode(game.Mode)
+	if err != nil {
+		mode, _ = models.NewGameMode(models.GameModeSpec{Name: models.GameModeClassic})
+	}
+	return mode
+}
+
+// endIfOver ends a game whose mode says it is over. A concurrent update may
+// have ended it first, which is not an error.
+func (s *GameService) endIfOver(ctx context.Context, game *models.Game) {
+	if !modeOf(game).ShouldEnd(game) {
+		return
+	}
+	if _, err := s.EndGame(ctx, game.ID); err != nil && !errors.Is(err, models.ErrGameNotStarted) {
+		log.Printf("Failed to end game %s for mode %s: %v", game.ID, game.Mode.Name, err)
+	}
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"effective-golang/internal/models"
+)
+
+// Notifier receives game events that need someone's attention, such as
+// suspicious scores
+type Notifier interface {
+	Notify(ctx context.Context, event *GameEvent) error
+}
+
+// NotifierFunc adapts a plain function to the Notifier interface
+type NotifierFunc func(ctx context.Context, event *GameEvent) error
+
+// Notify calls f(ctx, event)
+func (f NotifierFunc) Notify(ctx context.Context, event *GameEvent) error {
+	return f(ctx, event)
+}
+
+// SuspiciousScore is the Data of a suspicious_score event
+type SuspiciousScore struct {
+	GameID      string        `json:"game_id"`
+	PlayerID    string        `json:"player_id"`
+	Score       int64         `json:"score"`
+	ExpectedMax int64         `json:"expected_max"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
+
+// WithScorePlausibility flags scores above what a game's duration makes
+// plausible: allowance points plus ratePerSecond for every second played.
+// Implausible scores are still accepted, but the game is marked suspicious
+// and a suspicious_score event is sent to the notifier for review.
+func WithScorePlausibility(ratePerSecond float64, allowance int64) GameServiceOption {
+	return func(s *GameService) {
+		s.plausibleRate = ratePerSecond
+		s.plausibleAllowance = allowance
+	}
+}
+
+// WithNotifier routes events that need attention to n
+func WithNotifier(n Notifier) GameServiceOption {
+	return func(s *GameService) {
+		s.notifier = n
+	}
+}
+
+// ExpectedMaxScore returns the highest score a player could plausibly have
+// reached in game by now, or -1 when plausibility checks are not configured
+func (s *GameService) ExpectedMaxScore(game *models.Game) int64 {
+	return s.expectedMaxScore(game.PlayingFor(s.now()))
+}
+
+// expectedMaxScore is the plausible score ceiling after playing for elapsed
+func (s *GameService) expectedMaxScore(elapsed time.Duration) int64 {
+	if s.plausibleRate <= 0 {
+		return -1
+	}
+	return s.plausibleAllowance + int64(s.plausibleRate*elapsed.Seconds())
+}
+
+// checkPlausible flags game and queues a suspicious_score event when score is
+// above the expected maximum. It never rejects the score.
+func (s *GameService) checkPlausible(ctx context.Context, game *models.Game, playerID string, score int64) {
+	elapsed := game.PlayingFor(s.now())
+	expected := s.expectedMaxScore(elapsed)
+	if expected < 0 || score <= expected {
+		return
+	}
+
+	game.FlagSuspicious()
+	s.enqueue(ctx, &GameEvent{
+		GameID:    game.ID,
+		PlayerID:  playerID,
+		EventType: "suspicious_score",
+		Score:     score,
+		Data: &SuspiciousScore{
+			GameID:      game.ID,
+			PlayerID:    playerID,
+			Score:       score,
+			ExpectedMax: expected,
+			Elapsed:     elapsed,
+		},
+		Timestamp: time.Now(),
+	})
+}
+
+// handleSuspiciousScore passes suspicious score events to the notifier
+func (ep *EventProcessor) handleSuspiciousScore(ctx context.Context, event *GameEvent) {
+	suspicious, _ := event.Data.(*SuspiciousScore)
+	if suspicious != nil {
+		log.Printf("Suspicious score in game %s: player %s scored %d, expected at most %d after %v",
+			suspicious.GameID, suspicious.PlayerID, suspicious.Score, suspicious.ExpectedMax, suspicious.Elapsed)
+	}
+
+	if ep.gameSvc.notifier == nil {
+		return
+	}
+	if err := ep.gameSvc.notifier.Notify(ctx, event); err != nil {
+		log.Printf("Failed to notify suspicious score in game %s: %v", event.GameID, err)
+	}
+}
@@ -2,11 +2,15 @@ package game
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"effective-golang/internal/models"
+	"effective-golang/pkg/validate"
 )
 
 // GameService handles game logic and concurrent operations
@@ -23,18 +27,139 @@ type GameService struct {
 	
 	// Game state management
 	activeGames     map[string]*models.Game
+	lastActivity    map[string]time.Time
 	gameMutex       sync.RWMutex
 	
 	// Configuration
 	maxWorkers      int
 	queueSize       int
+	enqueueTimeout  time.Duration
+	retention       time.Duration
+	archiveInterval time.Duration
+	maxDuration     time.Duration
+	autoEndInterval time.Duration
+	idleTimeout     time.Duration
+	idleInterval    time.Duration
+	reconcile       bool
+	monotonic       bool
+	now             func() time.Time
+	
+	// Worker autoscaling
+	minWorkers    int
+	highWater     int
+	scaleInterval time.Duration
+	
+	// Anti-cheat score plausibility
+	plausibleRate      float64
+	plausibleAllowance int64
+	notifier           Notifier
+	
+	// Event queue counters
+	eventsQueued    atomic.Uint64
+	eventsProcessed atomic.Uint64
+	eventsDropped   atomic.Uint64
+}
+
+// GameServiceOption configures optional GameService behaviour
+type GameServiceOption func(*GameService)
+
+// WithEnqueueTimeout makes game operations wait up to timeout for room in a
+// full event queue instead of dropping the event immediately
+func WithEnqueueTimeout(timeout time.Duration) GameServiceOption {
+	return func(s *GameService) {
+		s.enqueueTimeout = timeout
+	}
+}
+
+// WithArchiving moves finished and cancelled games out of the game repository
+// once they have been over for longer than retention. A background sweep runs
+// every interval; only a compact summary of each archived game is kept.
+func WithArchiving(retention, interval time.Duration) GameServiceOption {
+	return func(s *GameService) {
+		s.retention = retention
+		s.archiveInterval = interval
+	}
+}
+
+// WithAutoEnd ends playing games that have been running for longer than
+// maxDuration, and games whose mode says they are over. A background sweep
+// runs every interval; a maxDuration of 0 only ends games by their mode.
+func WithAutoEnd(maxDuration, interval time.Duration) GameServiceOption {
+	return func(s *GameService) {
+		s.maxDuration = maxDuration
+		s.autoEndInterval = interval
+	}
+}
+
+// WithIdleTimeout cancels playing games with no score updates for longer than
+// timeout, counting from when the game started. A background sweep runs every
+// interval and removes cancelled games from the active set.
+func WithIdleTimeout(timeout, interval time.Duration) GameServiceOption {
+	return func(s *GameService) {
+		s.idleTimeout = timeout
+		s.idleInterval = interval
+	}
+}
+
+// WithActiveGameReconciliation loads games that are still playing from the game
+// repository into the active set on startup, so games that outlived a restart
+// are tracked and covered by the auto-end sweep again
+func WithActiveGameReconciliation() GameServiceOption {
+	return func(s *GameService) {
+		s.reconcile = true
+	}
+}
+
+// WithMonotonicScores rejects negative score increments so scores only grow
+func WithMonotonicScores() GameServiceOption {
+	return func(s *GameService) {
+		s.monotonic = true
+	}
+}
+
+// WithClock replaces time.Now for game timestamps and archiving, mainly for tests
+func WithClock(now func() time.Time) GameServiceOption {
+	return func(s *GameService) {
+		s.now = now
+	}
+}
+
+// EventStats reports event queue usage
+type EventStats struct {
+	Queued        uint64 `json:"queued"`
+	Processed     uint64 `json:"processed"`
+	Dropped       uint64 `json:"dropped"`
+	QueueLength   int    `json:"queue_length"`
+	QueueCapacity int    `json:"queue_capacity"`
+}
+
+// GameStats is a point-in-time snapshot of the game service
+type GameStats struct {
+	ActiveGames int        `json:"active_games"`
+	Workers     int        `json:"workers"`
+	Events      EventStats `json:"events"`
+}
+
+// QueueMetrics is a point-in-time snapshot of the event queue and worker
+// pool, for spotting backpressure
+type QueueMetrics struct {
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Workers       int    `json:"workers"`      // workers in circulation
+	IdleWorkers   int    `json:"idle_workers"` // workers free to take an event
+	MaxWorkers    int    `json:"max_workers"`
+	Queued        uint64 `json:"queued"`
+	Processed     uint64 `json:"processed"`
+	Dropped       uint64 `json:"dropped"`
+	ActiveGames   int    `json:"active_games"`
+	Draining      bool   `json:"draining"` // closed; no new events, queued ones still run
 }
 
 // GameEvent represents a game event to be processed
 type GameEvent struct {
-	GameID    string
+	GameID    string `validate:"required"`
 	PlayerID  string
-	EventType string
+	EventType string `validate:"required"`
 	Score     int64
 	Data      interface{}
 	Timestamp time.Time
@@ -49,6 +174,7 @@ type GameResult struct {
 	LoserScore  int64
 	Duration   time.Duration
 	IsTie      bool
+	Suspicious bool
 }
 
 // EventProcessor handles game event processing
@@ -59,6 +185,17 @@ type EventProcessor struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
+	
+	// stopped is set by Stop; senders hold stopMutex for reading while they
+	// queue, so no event gets in after the queue is drained
+	stopMutex sync.RWMutex
+	stopped   bool
+	
+	// workerCount is how many workers are in circulation; it changes only with autoscaling
+	workerCount atomic.Int32
+	
+	// busyWorkers is how many workers are processing an event
+	busyWorkers atomic.Int32
 }
 
 // Custom errors for game operations
@@ -68,6 +205,9 @@ var (
 	ErrInvalidPlayer    = fmt.Errorf("invalid player")
 	ErrGameNotStarted   = fmt.Errorf("game not started")
 	ErrEventQueueFull   = fmt.Errorf("event queue is full")
+	ErrInvalidEvent     = fmt.Errorf("invalid game event")
+	ErrNegativeDelta    = fmt.Errorf("negative score increment not allowed")
+	ErrServiceClosed    = fmt.Errorf("game service is closed")
 )
 
 // NewGameService creates a new game service
@@ -77,6 +217,7 @@ func NewGameService(
 	leaderboardRepo models.LeaderboardRepository,
 	cacheRepo models.CacheRepository,
 	maxWorkers, queueSize int,
+	opts ...GameServiceOption,
 ) *GameService {
 	ctx, cancel := context.WithCancel(context.Background())
 	
@@ -86,8 +227,13 @@ func NewGameService(
 		leaderboardRepo: leaderboardRepo,
 		cacheRepo:       cacheRepo,
 		activeGames:     make(map[string]*models.Game),
+		lastActivity:    make(map[string]time.Time),
 		maxWorkers:      maxWorkers,
 		queueSize:       queueSize,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(svc)
 	}
 	
 	// Initialize event processor
@@ -101,14 +247,57 @@ func NewGameService(
 		cancel:  cancel,
 	}
 	
+	// Pick up games left playing by a previous run
+	if svc.reconcile {
+		if n, err := svc.reconcileActiveGames(ctx); err != nil {
+			log.Printf("Active game reconciliation failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Reconciled %d active games from the repository", n)
+		}
+	}
+	
 	// Start event processor
 	svc.eventProcessor.Start()
 	
+	// Start archive sweep; it stops with the event processor
+	if svc.retention > 0 && svc.archiveInterval > 0 {
+		svc.eventProcessor.wg.Add(1)
+		go svc.runArchiver(ctx)
+	}
+	
+	// Start auto-end sweep; it stops with the event processor
+	if svc.autoEndInterval > 0 {
+		svc.eventProcessor.wg.Add(1)
+		go svc.runAutoEnder(ctx)
+	}
+	
+	// Start idle sweep; it stops with the event processor
+	if svc.idleTimeout > 0 && svc.idleInterval > 0 {
+		svc.eventProcessor.wg.Add(1)
+		go svc.runIdleSweeper(ctx)
+	}
+	
 	return svc
 }
 
-// CreateGame creates a new game between two players
+// CreateGame creates a new classic game between two players, which only ends
+// when a player ends it
 func (s *GameService) CreateGame(ctx context.Context, player1ID, player2ID string) (*models.Game, error) {
+	return s.CreateGameWithMode(ctx, player1ID, player2ID, models.GameModeSpec{Name: models.GameModeClassic})
+}
+
+// CreateGameWithMode creates a new game between two players that also ends
+// when its mode's end condition is met; see models.GameMode. Score updates
+// check target conditions straight away, while timed modes are ended by the
+// auto-end sweep.
+func (s *GameService) CreateGameWithMode(ctx context.Context, player1ID, player2ID string, mode models.GameModeSpec) (*models.Game, error) {
+	if mode.Name == "" {
+		mode.Name = models.GameModeClassic
+	}
+	if _, err := models.NewGameMode(mode); err != nil {
+		return nil, err
+	}
+	
 	// Validate players exist
 	_, err := s.userRepo.GetByID(ctx, player1ID)
 	if err != nil {
@@ -125,11 +314,14 @@ func (s *GameService) CreateGame(ctx context.Context, player1ID, player2ID strin
 	if err != nil {
 		return nil, fmt.Errorf("failed to create game: %w", err)
 	}
+	game.Mode = mode
+	game.SetClock(s.now)
 	
 	// Save to database
 	if err := s.gameRepo.Create(ctx, game); err != nil {
 		return nil, fmt.Errorf("failed to save game: %w", err)
 	}
+	s.invalidateUserGames(ctx, game)
 	
 	// Add to active games
 	s.gameMutex.Lock()
@@ -149,14 +341,16 @@ func (s *GameService) StartGame(ctx context.Context, gameID string) error {
 	if err := game.Start(); err != nil {
 		return fmt.Errorf("failed to start game: %w", err)
 	}
+	s.touch(gameID)
 	
 	// Update in database
 	if err := s.gameRepo.Update(ctx, game); err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
+	s.invalidateUserGames(ctx, game)
 	
 	// Process game start event
-	s.QueueEvent(&GameEvent{
+	s.enqueue(ctx, &GameEvent{
 		GameID:    gameID,
 		EventType: "game_started",
 		Timestamp: time.Now(),
@@ -175,14 +369,56 @@ func (s *GameService) UpdateScore(ctx context.Context, gameID, playerID string,
 	if err := game.UpdateScore(playerID, score); err != nil {
 		return fmt.Errorf("failed to update score: %w", err)
 	}
+	s.touch(gameID)
+	s.checkPlausible(ctx, game, playerID, score)
 	
 	// Update in database
 	if err := s.gameRepo.Update(ctx, game); err != nil {
 		return fmt.Errorf("failed to update game: %w", err)
 	}
+	s.invalidateUserGames(ctx, game)
 	
 	// Queue score update event
-	s.QueueEvent(&GameEvent{
+	s.enqueue(ctx, &GameEvent{
+		GameID:    gameID,
+		PlayerID:  playerID,
+		EventType: "score_updated",
+		Score:     score,
+		Timestamp: time.Now(),
+	})
+	
+	s.endIfOver(ctx, game)
+	
+	return nil
+}
+
+// IncrementScore atomically adds delta to a player's score, so concurrent
+// increments are never lost to a read-modify-write race
+func (s *GameService) IncrementScore(ctx context.Context, gameID, playerID string, delta int64) error {
+	if s.monotonic && delta < 0 {
+		return ErrNegativeDelta
+	}
+	
+	game, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return fmt.Errorf("failed to get game: %w", err)
+	}
+	
+	score, err := game.IncrementScore(playerID, delta)
+	if err != nil {
+		return fmt.Errorf("failed to increment score: %w", err)
+	}
+	s.touch(gameID)
+	s.checkPlausible(ctx, game, playerID, score)
+	
+	// Update in database
+	if err := s.gameRepo.Update(ctx, game); err != nil {
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+	s.invalidateUserGames(ctx, game)
+	
+	// Queue score update event with the new total
+	s.enqueue(ctx, &GameEvent{
 		GameID:    gameID,
 		PlayerID:  playerID,
 		EventType: "score_updated",
@@ -190,6 +426,8 @@ func (s *GameService) UpdateScore(ctx context.Context, gameID, playerID string,
 		Timestamp: time.Now(),
 	})
 	
+	s.endIfOver(ctx, game)
+	
 	return nil
 }
 
@@ -200,7 +438,7 @@ func (s *GameService) EndGame(ctx context.Context, gameID string) (*GameResult,
 		return nil, fmt.Errorf("failed to get game: %w", err)
 	}
 	
-	if err := game.End(); err != nil {
+	if err := game.EndAt(s.now()); err != nil {
 		return nil, fmt.Errorf("failed to end game: %w", err)
 	}
 	
@@ -208,10 +446,12 @@ func (s *GameService) EndGame(ctx context.Context, gameID string) (*GameResult,
 	if err := s.gameRepo.Update(ctx, game); err != nil {
 		return nil, fmt.Errorf("failed to update game: %w", err)
 	}
+	s.invalidateUserGames(ctx, game)
 	
 	// Remove from active games
 	s.gameMutex.Lock()
 	delete(s.activeGames, gameID)
+	delete(s.lastActivity, gameID)
 	s.gameMutex.Unlock()
 	
 	// Create game result
@@ -221,6 +461,7 @@ func (s *GameService) EndGame(ctx context.Context, gameID string) (*GameResult,
 		LoserScore:  game.Score2,
 		Duration:    game.GetDuration(),
 		IsTie:       game.GetWinner() == "",
+		Suspicious:  game.IsSuspicious(),
 	}
 	
 	if !result.IsTie {
@@ -233,7 +474,7 @@ func (s *GameService) EndGame(ctx context.Context, gameID string) (*GameResult,
 	}
 	
 	// Queue game end event
-	s.QueueEvent(&GameEvent{
+	s.enqueue(ctx, &GameEvent{
 		GameID:    gameID,
 		EventType: "game_ended",
 		Data:      result,
@@ -243,6 +484,39 @@ func (s *GameService) EndGame(ctx context.Context, gameID string) (*GameResult,
 	return result, nil
 }
 
+// CancelGame cancels a waiting or playing game without recording any results
+func (s *GameService) CancelGame(ctx context.Context, gameID string) error {
+	game, err := s.getGame(ctx, gameID)
+	if err != nil {
+		return fmt.Errorf("failed to get game: %w", err)
+	}
+	
+	if err := game.CancelAt(s.now()); err != nil {
+		return fmt.Errorf("failed to cancel game: %w", err)
+	}
+	
+	// Update in database
+	if err := s.gameRepo.Update(ctx, game); err != nil {
+		return fmt.Errorf("failed to update game: %w", err)
+	}
+	s.invalidateUserGames(ctx, game)
+	
+	// Remove from active games
+	s.gameMutex.Lock()
+	delete(s.activeGames, gameID)
+	delete(s.lastActivity, gameID)
+	s.gameMutex.Unlock()
+	
+	// Queue game cancel event (no stats or leaderboard updates)
+	s.enqueue(ctx, &GameEvent{
+		GameID:    gameID,
+		EventType: "game_cancelled",
+		Timestamp: time.Now(),
+	})
+	
+	return nil
+}
+
 // GetActiveGames returns all active games
 func (s *GameService) GetActiveGames(ctx context.Context) ([]*models.Game, error) {
 	s.gameMutex.RLock()
@@ -261,16 +535,346 @@ func (s *GameService) GetGame(ctx context.Context, gameID string) (*models.Game,
 	return s.getGame(ctx, gameID)
 }
 
-// QueueEvent queues a game event for processing
+// GetArchivedGame returns the summary of a game that has been archived
+func (s *GameService) GetArchivedGame(ctx context.Context, gameID string) (*models.GameSummary, error) {
+	return s.gameRepo.GetSummary(ctx, gameID)
+}
+
+// ArchiveEndedGames archives every finished or cancelled game that ended more
+// than the retention period ago and returns how many were archived. It does
+// nothing when archiving is not configured.
+func (s *GameService) ArchiveEndedGames(ctx context.Context) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	
+	now := s.now()
+	games, err := s.gameRepo.GetEndedGames(ctx, now.Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list ended games: %w", err)
+	}
+	
+	archived := 0
+	for _, game := range games {
+		events, err := s.gameRepo.GetGameEvents(ctx, game.ID)
+		if err != nil {
+			return archived, fmt.Errorf("failed to get events for game %s: %w", game.ID, err)
+		}
+		
+		if err := s.gameRepo.Archive(ctx, game.Summarize(len(events), now)); err != nil {
+			return archived, fmt.Errorf("failed to archive game %s: %w", game.ID, err)
+		}
+		s.invalidateUserGames(ctx, game)
+		archived++
+	}
+	
+	return archived, nil
+}
+
+// runArchiver periodically archives ended games until ctx is cancelled
+func (s *GameService) runArchiver(ctx context.Context) {
+	defer s.eventProcessor.wg.Done()
+	
+	ticker := time.NewTicker(s.archiveInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			if n, err := s.ArchiveEndedGames(ctx); err != nil {
+				log.Printf("Game archive sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Archived %d ended games", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// EndTimedOutGames ends every active game that has been playing for longer
+// than the configured maximum duration, or whose mode says it is over, and
+// returns how many were ended. Games ended by another request during the
+// sweep are skipped; any other failure is returned once the sweep is done.
+func (s *GameService) EndTimedOutGames(ctx context.Context) (int, error) {
+	now := s.now()
+	var expired []string
+	s.gameMutex.RLock()
+	for id, game := range s.activeGames {
+		startedAt, playing := game.PlayingSince()
+		timedOut := s.maxDuration > 0 && playing && !startedAt.IsZero() && now.Sub(startedAt) > s.maxDuration
+		if timedOut || modeOf(game).ShouldEnd(game) {
+			expired = append(expired, id)
+		}
+	}
+	s.gameMutex.RUnlock()
+	
+	ended := 0
+	var errs []error
+	for _, id := range expired {
+		if _, err := s.EndGame(ctx, id); err != nil {
+			if !endedConcurrently(err) {
+				errs = append(errs, fmt.Errorf("failed to end game %s: %w", id, err))
+			}
+			continue
+		}
+		ended++
+	}
+	
+	return ended, errors.Join(errs...)
+}
+
+// endedConcurrently reports whether err means another request ended or
+// cancelled a game after a sweep picked it
+func endedConcurrently(err error) bool {
+	return errors.Is(err, models.ErrGameNotStarted) ||
+		errors.Is(err, models.ErrGameAlreadyEnded) ||
+		errors.Is(err, models.ErrGameNotFound)
+}
+
+// runAutoEnder periodically ends timed-out and finished games until ctx is cancelled
+func (s *GameService) runAutoEnder(ctx context.Context) {
+	defer s.eventProcessor.wg.Done()
+	
+	ticker := time.NewTicker(s.autoEndInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			if n, err := s.EndTimedOutGames(ctx); err != nil {
+				log.Printf("Game auto-end sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Auto-ended %d games", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CancelIdleGames cancels every playing game whose last score update, or its
+// start if it has none, is older than the configured idle timeout, and returns
+// how many were cancelled. Games ended by another request during the sweep
+// are skipped; any other failure is returned once the sweep is done.
+func (s *GameService) CancelIdleGames(ctx context.Context) (int, error) {
+	if s.idleTimeout <= 0 {
+		return 0, nil
+	}
+	
+	now := s.now()
+	var idle []string
+	s.gameMutex.RLock()
+	for id, game := range s.activeGames {
+		startedAt, playing := game.PlayingSince()
+		if !playing {
+			continue
+		}
+		last, ok := s.lastActivity[id]
+		if !ok {
+			last = startedAt
+		}
+		if !last.IsZero() && now.Sub(last) > s.idleTimeout {
+			idle = append(idle, id)
+		}
+	}
+	s.gameMutex.RUnlock()
+	
+	cancelled := 0
+	var errs []error
+	for _, id := range idle {
+		if err := s.CancelGame(ctx, id); err != nil {
+			if !endedConcurrently(err) {
+				errs = append(errs, fmt.Errorf("failed to cancel game %s: %w", id, err))
+			}
+			continue
+		}
+		cancelled++
+	}
+	
+	return cancelled, errors.Join(errs...)
+}
+
+// runIdleSweeper periodically cancels idle games until ctx is cancelled
+func (s *GameService) runIdleSweeper(ctx context.Context) {
+	defer s.eventProcessor.wg.Done()
+	
+	ticker := time.NewTicker(s.idleInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			if n, err := s.CancelIdleGames(ctx); err != nil {
+				log.Printf("Game idle sweep failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Cancelled %d idle games", n)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// touch records activity on a game, resetting its idle timer
+func (s *GameService) touch(gameID string) {
+	s.gameMutex.Lock()
+	s.lastActivity[gameID] = s.now()
+	s.gameMutex.Unlock()
+}
+
+// reconcileActiveGames adds every playing game in the repository to the active set
+func (s *GameService) reconcileActiveGames(ctx context.Context) (int, error) {
+	games, err := s.gameRepo.GetActiveGames(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active games: %w", err)
+	}
+	
+	s.gameMutex.Lock()
+	defer s.gameMutex.Unlock()
+	
+	added := 0
+	for _, game := range games {
+		if _, exists := s.activeGames[game.ID]; !exists {
+			game.SetClock(s.now)
+			s.activeGames[game.ID] = game
+			added++
+		}
+	}
+	
+	return added, nil
+}
+
+// QueueEvent queues a game event for processing. If the queue is full the
+// event is dropped, counted and ErrEventQueueFull is returned; use
+// QueueEventWithContext to wait for room instead.
 func (s *GameService) QueueEvent(event *GameEvent) error {
+	if err := validate.Struct(event); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	
+	s.eventProcessor.stopMutex.RLock()
+	defer s.eventProcessor.stopMutex.RUnlock()
+	if s.eventProcessor.stopped {
+		s.eventsDropped.Add(1)
+		return ErrServiceClosed
+	}
+	
 	select {
 	case s.eventQueue <- event:
+		s.eventsQueued.Add(1)
 		return nil
 	default:
+		s.eventsDropped.Add(1)
 		return ErrEventQueueFull
 	}
 }
 
+// QueueEventWithContext queues a game event, waiting for room in the queue
+// until ctx is done. An event that never gets in is counted as dropped.
+func (s *GameService) QueueEventWithContext(ctx context.Context, event *GameEvent) error {
+	if err := validate.Struct(event); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidEvent, err)
+	}
+	
+	s.eventProcessor.stopMutex.RLock()
+	defer s.eventProcessor.stopMutex.RUnlock()
+	if s.eventProcessor.stopped {
+		s.eventsDropped.Add(1)
+		return ErrServiceClosed
+	}
+	
+	select {
+	case s.eventQueue <- event:
+		s.eventsQueued.Add(1)
+		return nil
+	case <-ctx.Done():
+		s.eventsDropped.Add(1)
+		return fmt.Errorf("%w: %v", ErrEventQueueFull, ctx.Err())
+	}
+}
+
+// EventStats returns how many events were queued, processed and dropped, and
+// the current queue usage
+func (s *GameService) EventStats() EventStats {
+	return EventStats{
+		Queued:        s.eventsQueued.Load(),
+		Processed:     s.eventsProcessed.Load(),
+		Dropped:       s.eventsDropped.Load(),
+		QueueLength:   len(s.eventQueue),
+		QueueCapacity: cap(s.eventQueue),
+	}
+}
+
+// Stats returns a snapshot of the active games, event workers and event queue
+func (s *GameService) Stats() GameStats {
+	s.gameMutex.RLock()
+	activeGames := len(s.activeGames)
+	s.gameMutex.RUnlock()
+	
+	return GameStats{
+		ActiveGames: activeGames,
+		Workers:     int(s.eventProcessor.workerCount.Load()),
+		Events:      s.EventStats(),
+	}
+}
+
+// Metrics returns a snapshot of the event queue, the worker pool and the
+// active games
+func (s *GameService) Metrics() QueueMetrics {
+	ep := s.eventProcessor
+	ep.stopMutex.RLock()
+	draining := ep.stopped
+	ep.stopMutex.RUnlock()
+	
+	stats := s.Stats()
+	return QueueMetrics{
+		QueueDepth:    stats.Events.QueueLength,
+		QueueCapacity: stats.Events.QueueCapacity,
+		Workers:       stats.Workers,
+		IdleWorkers:   max(stats.Workers-int(ep.busyWorkers.Load()), 0),
+		MaxWorkers:    cap(ep.workers),
+		Queued:        stats.Events.Queued,
+		Processed:     stats.Events.Processed,
+		Dropped:       stats.Events.Dropped,
+		ActiveGames:   stats.ActiveGames,
+		Draining:      draining,
+	}
+}
+
+// enqueue queues an event after the game state has been saved. A full queue
+// does not fail the operation; the dropped event is logged instead.
+func (s *GameService) enqueue(ctx context.Context, event *GameEvent) {
+	var err error
+	if s.enqueueTimeout > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, s.enqueueTimeout)
+		err = s.QueueEventWithContext(waitCtx, event)
+		cancel()
+	} else {
+		err = s.QueueEvent(event)
+	}
+	
+	if err != nil {
+		log.Printf("Dropped %s event for game %s: %v", event.EventType, event.GameID, err)
+	}
+}
+
+// Health reports whether the game repository is responding
+func (s *GameService) Health(ctx context.Context) error {
+	if _, err := s.gameRepo.GetActiveGames(ctx); err != nil {
+		return fmt.Errorf("game repository: %w", err)
+	}
+	return nil
+}
+
+// QueueHealth reports an error while the event queue is full and new events are being dropped
+func (s *GameService) QueueHealth(ctx context.Context) error {
+	if length, capacity := len(s.eventQueue), cap(s.eventQueue); length >= capacity {
+		return fmt.Errorf("%w: %d of %d slots used", ErrEventQueueFull, length, capacity)
+	}
+	return nil
+}
+
 // getGame retrieves a game from cache or database
 func (s *GameService) getGame(ctx context.Context, gameID string) (*models.Game, error) {
 	// Try to get from active games first
@@ -297,7 +901,8 @@ func (s *GameService) getGame(ctx context.Context, gameID string) (*models.Game,
 	return game, nil
 }
 
-// Close shuts down the game service
+// Close shuts down the game service. Events already queued are still
+// processed before it returns; new events are rejected with ErrServiceClosed.
 func (s *GameService) Close() error {
 	s.eventProcessor.Stop()
 	return nil
@@ -307,30 +912,70 @@ func (s *GameService) Close() error {
 
 // Start starts the event processor
 func (ep *EventProcessor) Start() {
-	for i := 0; i < cap(ep.workers); i++ {
+	workers := ep.initialWorkers()
+	for i := 0; i < workers; i++ {
 		ep.workers <- struct{}{}
 	}
+	ep.workerCount.Store(int32(workers))
 	
 	ep.wg.Add(1)
 	go ep.processEvents()
+	
+	if ep.autoscaling() {
+		ep.wg.Add(1)
+		go ep.runAutoscaler()
+	}
 }
 
-// Stop stops the event processor
+// Stop stops accepting new events, processes the events left in the queue,
+// and waits for every event being processed and every background sweep to finish
 func (ep *EventProcessor) Stop() {
+	// Waits for senders already blocked on a full queue to get their events in
+	ep.stopMutex.Lock()
+	ep.stopped = true
+	ep.stopMutex.Unlock()
+	
 	ep.cancel()
 	ep.wg.Wait()
 }
 
-// processEvents processes events from the queue
+// processEvents processes events from the queue. A worker is acquired before
+// an event is dequeued, so when every worker is busy events stay in the queue
+// and a full queue pushes back on QueueEvent. Once stopped it drains the queue.
 func (ep *EventProcessor) processEvents() {
 	defer ep.wg.Done()
 	
 	for {
+		// Acquire worker
+		select {
+		case <-ep.workers:
+		case <-ep.ctx.Done():
+			ep.drain()
+			return
+		}
+		
 		select {
 		case event := <-ep.queue:
 			ep.wg.Add(1)
 			go ep.processEvent(event)
 		case <-ep.ctx.Done():
+			ep.workers <- struct{}{}
+			ep.drain()
+			return
+		}
+	}
+}
+
+// drain hands every event still in the queue to a worker, waiting for workers
+// to free up as needed. It is only called once no new events can be queued.
+func (ep *EventProcessor) drain() {
+	for {
+		select {
+		case event := <-ep.queue:
+			<-ep.workers
+			ep.wg.Add(1)
+			go ep.processEvent(event)
+		default:
 			return
 		}
 	}
@@ -340,9 +985,11 @@ func (ep *EventProcessor) processEvents() {
 func (ep *EventProcessor) processEvent(event *GameEvent) {
 	defer ep.wg.Done()
 	
-	// Acquire worker
-	<-ep.workers
+	// Release the worker acquired by processEvents
 	defer func() { ep.workers <- struct{}{} }()
+	ep.busyWorkers.Add(1)
+	defer ep.busyWorkers.Add(-1)
+	defer ep.gameSvc.eventsProcessed.Add(1)
 	
 	ctx := context.Background()
 	
@@ -353,6 +1000,10 @@ func (ep *EventProcessor) processEvent(event *GameEvent) {
 		ep.handleScoreUpdated(ctx, event)
 	case "game_ended":
 		ep.handleGameEnded(ctx, event)
+	case "game_cancelled":
+		ep.handleGameCancelled(ctx, event)
+	case "suspicious_score":
+		ep.handleSuspiciousScore(ctx, event)
 	default:
 		// Log unknown event type
 		fmt.Printf("Unknown event type: %s\n", event.EventType)
@@ -391,6 +1042,13 @@ func (ep *EventProcessor) handleGameEnded(ctx context.Context, event *GameEvent)
 	ep.gameSvc.cacheRepo.Delete(ctx, cacheKey)
 }
 
+// handleGameCancelled handles game cancel events
+func (ep *EventProcessor) handleGameCancelled(ctx context.Context, event *GameEvent) {
+	// Clean up cached game state
+	cacheKey := fmt.Sprintf("game:%s", event.GameID)
+	ep.gameSvc.cacheRepo.Delete(ctx, cacheKey)
+}
+
 // updateUserStats updates user statistics after a game
 func (ep *EventProcessor) updateUserStats(ctx context.Context, result *GameResult) {
 	// Update winner stats
@@ -426,6 +1084,7 @@ func (ep *EventProcessor) updateLeaderboards(ctx context.Context, result *GameRe
 					Username:  user.Username,
 					Score:     result.WinnerScore,
 					UpdatedAt: time.Now(),
+					Metadata:  map[string]string{"game_id": result.GameID},
 				})
 			}
 		}
@@ -0,0 +1,148 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Healther is implemented by components that can report whether they are working.
+// Health returns nil when the component is healthy.
+type Healther interface {
+	Health(ctx context.Context) error
+}
+
+// HealthFunc adapts a function to the Healther interface
+type HealthFunc func(ctx context.Context) error
+
+// Health calls f(ctx)
+func (f HealthFunc) Health(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Status is the health of a component or of the whole system
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"  // a non-critical component is unhealthy
+	StatusUnhealthy Status = "unhealthy" // a critical component is unhealthy
+)
+
+// ComponentReport is the result of checking one component
+type ComponentReport struct {
+	Status    Status `json:"status"`
+	Critical  bool   `json:"critical"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report is the aggregated health of every registered component
+type Report struct {
+	Status     Status                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+	Timestamp  time.Time                  `json:"timestamp"`
+}
+
+// Checker aggregates the health of registered components
+type Checker struct {
+	timeout    time.Duration
+	mu         sync.RWMutex
+	components map[string]component
+}
+
+type component struct {
+	healther Healther
+	critical bool
+}
+
+// NewChecker creates a checker that gives each component up to timeout to respond
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout:    timeout,
+		components: make(map[string]component),
+	}
+}
+
+// Register adds a component under name. If a critical component is unhealthy the
+// overall status is unhealthy; a failing non-critical component only degrades it.
+func (c *Checker) Register(name string, h Healther, critical bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.components[name] = component{healther: h, critical: critical}
+}
+
+// Check runs every component check concurrently and rolls the results up
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.components))
+	for name := range c.components {
+		names = append(names, name)
+	}
+	components := make(map[string]component, len(c.components))
+	for name, comp := range c.components {
+		components[name] = comp
+	}
+	c.mu.RUnlock()
+	sort.Strings(names)
+
+	reports := make([]ComponentReport, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, comp component) {
+			defer wg.Done()
+			reports[i] = c.checkComponent(ctx, comp)
+		}(i, components[name])
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:     StatusHealthy,
+		Components: make(map[string]ComponentReport, len(names)),
+		Timestamp:  time.Now(),
+	}
+	for i, name := range names {
+		report.Components[name] = reports[i]
+		if reports[i].Status == StatusHealthy {
+			continue
+		}
+		if reports[i].Critical {
+			report.Status = StatusUnhealthy
+		} else if report.Status == StatusHealthy {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// checkComponent runs one check, treating a check that outlives the timeout as unhealthy
+func (c *Checker) checkComponent(ctx context.Context, comp component) ComponentReport {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() { errCh <- comp.healther.Health(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	report := ComponentReport{
+		Status:    StatusHealthy,
+		Critical:  comp.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		report.Status = StatusUnhealthy
+		report.Error = err.Error()
+	}
+	return report
+}
@@ -0,0 +1,59 @@
+package leaderboard
+
+import (
+	"log"
+)
+
+// UpdateCallback is called with every leaderboard update. Callbacks run
+// synchronously on the goroutine that made the change, so they should be
+// quick; a panicking callback is recovered and logged.
+type UpdateCallback func(update *LeaderboardUpdate)
+
+// registeredCallback wraps a callback so it can be found again to unregister it
+type registeredCallback struct {
+	fn UpdateCallback
+}
+
+// OnUpdate registers fn to be called with every update on every leaderboard,
+// alongside the subscriber channels and webhooks. It returns a function that
+// unregisters fn.
+func (s *LeaderboardService) OnUpdate(fn UpdateCallback) (unregister func()) {
+	cb := &registeredCallback{fn: fn}
+
+	s.callbackMutex.Lock()
+	s.callbacks = append(s.callbacks, cb)
+	s.callbackMutex.Unlock()
+
+	return func() {
+		s.callbackMutex.Lock()
+		defer s.callbackMutex.Unlock()
+
+		for i, registered := range s.callbacks {
+			if registered == cb {
+				s.callbacks = append(s.callbacks[:i:i], s.callbacks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// runCallbacks calls every registered callback with update
+func (s *LeaderboardService) runCallbacks(update *LeaderboardUpdate) {
+	s.callbackMutex.RLock()
+	callbacks := s.callbacks
+	s.callbackMutex.RUnlock()
+
+	for _, cb := range callbacks {
+		runCallback(cb.fn, update)
+	}
+}
+
+// runCallback calls fn, recovering a panic so the remaining callbacks still run
+func runCallback(fn UpdateCallback, update *LeaderboardUpdate) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Leaderboard update callback panicked on %s for %s: %v", update.Type, update.LeaderboardID, r)
+		}
+	}()
+	fn(update)
+}
@@ -0,0 +1,259 @@
+package leaderboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"effective-golang/internal/models"
+	"effective-golang/internal/wal"
+)
+
+// Write-ahead log operations
+const (
+	walOpCreate         = "create"
+	walOpScore          = "score"
+	walOpScores         = "scores"
+	walOpRemove         = "remove"
+	walOpReset          = "reset"
+	walOpDecay          = "decay"
+	walOpMinUpdateDelta = "min_update_delta"
+)
+
+// walRecord is one leaderboard write as stored in the write-ahead log
+type walRecord struct {
+	Op             string                     `json:"op"`
+	LeaderboardID  string                     `json:"leaderboard_id"`
+	Name           string                     `json:"name,omitempty"`
+	Type           models.LeaderboardType     `json:"type,omitempty"`
+	MaxEntries     int                        `json:"max_entries,omitempty"`
+	SortOrder      models.SortOrder           `json:"sort_order,omitempty"`
+	UserID         string                     `json:"user_id,omitempty"`
+	Username       string                     `json:"username,omitempty"`
+	Score          int64                      `json:"score,omitempty"`
+	Metadata       map[string]string          `json:"metadata,omitempty"`
+	UpdatedAt      time.Time                  `json:"updated_at,omitzero"` // when a score was set, for decay
+	Entries        []*models.LeaderboardEntry `json:"entries,omitempty"`   // a batch of scores
+	HalfLife       time.Duration              `json:"half_life,omitempty"`
+	MinUpdateDelta int64                      `json:"min_update_delta,omitempty"`
+}
+
+// snapshotLeaderboard is the full state of one leaderboard in a snapshot
+type snapshotLeaderboard struct {
+	ID             string                    `json:"id"`
+	Name           string                    `json:"name"`
+	Type           models.LeaderboardType    `json:"type"`
+	MaxEntries     int                       `json:"max_entries"`
+	SortOrder      models.SortOrder          `json:"sort_order,omitempty"`
+	HalfLife       time.Duration             `json:"half_life,omitempty"`
+	MinUpdateDelta int64                     `json:"min_update_delta,omitempty"`
+	Entries        []models.LeaderboardEntry `json:"entries"`
+}
+
+// WithWAL makes leaderboard writes durable: every create, score, removal and
+// setting change is appended to walLog before it is applied, and Recover
+// rebuilds the leaderboards from it after a restart. When snapshotInterval is
+// positive the full state is snapshotted that often and the log truncated.
+func WithWAL(walLog *wal.Log, snapshotInterval time.Duration) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.wal = walLog
+		s.snapshotInterval = snapshotInterval
+	}
+}
+
+// logged appends rec to the write-ahead log, if one is configured, and then
+// runs apply. Both happen under the WAL lock so a snapshot never falls
+// between a record being logged and applied.
+func (s *LeaderboardService) logged(rec walRecord, apply func() error) error {
+	if s.wal == nil {
+		return apply()
+	}
+
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	if err := s.wal.Append(rec); err != nil {
+		return err
+	}
+	return apply()
+}
+
+// Recover rebuilds leaderboards from the latest snapshot and the write-ahead
+// log and returns how many log records were replayed, including writes that
+// were rejected when first made and are skipped again. Call it once at
+// startup, before serving requests. It does nothing without a WAL.
+func (s *LeaderboardService) Recover(ctx context.Context) (int, error) {
+	if s.wal == nil {
+		return 0, nil
+	}
+
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	var snapshot []snapshotLeaderboard
+	if _, err := s.wal.ReadSnapshot(&snapshot); err != nil {
+		return 0, err
+	}
+	for _, lb := range snapshot {
+		if err := s.restore(ctx, lb); err != nil {
+			return 0, fmt.Errorf("failed to restore leaderboard %s: %w", lb.ID, err)
+		}
+	}
+
+	return s.wal.Replay(func(raw json.RawMessage) error {
+		var rec walRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return err
+		}
+		if err := s.apply(ctx, rec); err != nil && !rejectedWrite(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// rejectedWrite reports whether err is the repository refusing a write, such
+// as a score for a missing or full leaderboard. Records are logged before they
+// are applied, so one refused live is still in the log; replay refuses it the
+// same way and skips it.
+func rejectedWrite(err error) bool {
+	return errors.Is(err, models.ErrLeaderboardNotFound) ||
+		errors.Is(err, models.ErrLeaderboardFull) ||
+		errors.Is(err, models.ErrInvalidScore) ||
+		errors.Is(err, models.ErrUserNotFoundInLeaderboard)
+}
+
+// Snapshot writes the state of every leaderboard to the WAL snapshot and
+// truncates the log. It does nothing without a WAL.
+func (s *LeaderboardService) Snapshot(ctx context.Context) error {
+	if s.wal == nil {
+		return nil
+	}
+
+	s.walMutex.Lock()
+	defer s.walMutex.Unlock()
+
+	leaderboards, err := s.leaderboardRepo.List(ctx, 0, math.MaxInt32)
+	if err != nil {
+		return fmt.Errorf("failed to list leaderboards: %w", err)
+	}
+
+	snapshot := make([]snapshotLeaderboard, 0, len(leaderboards))
+	for _, lb := range leaderboards {
+		entries, _ := lb.GetTopEntries(0, math.MaxInt32, false)
+		snapshot = append(snapshot, snapshotLeaderboard{
+			ID:             lb.ID,
+			Name:           lb.Name,
+			Type:           lb.Type,
+			MaxEntries:     lb.MaxEntries,
+			SortOrder:      lb.SortOrder,
+			HalfLife:       lb.HalfLife,
+			MinUpdateDelta: lb.GetMinUpdateDelta(),
+			Entries:        entries,
+		})
+	}
+
+	return s.wal.WriteSnapshot(snapshot)
+}
+
+// restore recreates one leaderboard from a snapshot
+func (s *LeaderboardService) restore(ctx context.Context, lb snapshotLeaderboard) error {
+	records := []walRecord{
+		{Op: walOpCreate, LeaderboardID: lb.ID, Name: lb.Name, Type: lb.Type, MaxEntries: lb.MaxEntries, SortOrder: lb.SortOrder},
+		{Op: walOpDecay, LeaderboardID: lb.ID, HalfLife: lb.HalfLife},
+		{Op: walOpMinUpdateDelta, LeaderboardID: lb.ID, MinUpdateDelta: lb.MinUpdateDelta},
+	}
+	for _, entry := range lb.Entries {
+		records = append(records, walRecord{Op: walOpScore, LeaderboardID: lb.ID, UserID: entry.UserID, Username: entry.Username, Score: entry.Score, Metadata: entry.Metadata, UpdatedAt: entry.UpdatedAt})
+	}
+
+	for _, rec := range records {
+		if err := s.apply(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply performs a logged write without logging it again
+func (s *LeaderboardService) apply(ctx context.Context, rec walRecord) error {
+	if rec.Op != walOpCreate {
+		// Cached leaderboards are copies, so drop them once the board changes
+		defer s.invalidateCache(ctx, rec.LeaderboardID)
+	}
+
+	switch rec.Op {
+	case walOpCreate:
+		leaderboard := models.NewLeaderboardWithOrder(rec.Name, rec.Type, rec.MaxEntries, rec.SortOrder)
+		leaderboard.ID = rec.LeaderboardID
+		if err := s.leaderboardRepo.Create(ctx, leaderboard); err != nil {
+			if errors.Is(err, models.ErrLeaderboardExists) {
+				return nil
+			}
+			return err
+		}
+		s.register(ctx, leaderboard)
+		return nil
+
+	case walOpScore:
+		return s.leaderboardRepo.AddEntry(ctx, rec.LeaderboardID, &models.LeaderboardEntry{
+			UserID:    rec.UserID,
+			Username:  rec.Username,
+			Score:     rec.Score,
+			Metadata:  rec.Metadata,
+			UpdatedAt: rec.UpdatedAt,
+		})
+
+	case walOpScores:
+		return s.leaderboardRepo.AddEntries(ctx, rec.LeaderboardID, rec.Entries)
+
+	case walOpRemove:
+		return s.leaderboardRepo.RemoveEntry(ctx, rec.LeaderboardID, rec.UserID)
+
+	case walOpReset:
+		// The archive was stored by the repository when the reset happened
+		leaderboard, err := s.leaderboardRepo.GetByID(ctx, rec.LeaderboardID)
+		if err != nil {
+			return err
+		}
+		leaderboard.Clear()
+		return s.leaderboardRepo.Update(ctx, leaderboard)
+
+	case walOpDecay, walOpMinUpdateDelta:
+		leaderboard, err := s.leaderboardRepo.GetByID(ctx, rec.LeaderboardID)
+		if err != nil {
+			return err
+		}
+		if rec.Op == walOpDecay {
+			leaderboard.SetDecay(rec.HalfLife)
+		} else {
+			leaderboard.SetMinUpdateDelta(rec.MinUpdateDelta)
+		}
+		return s.leaderboardRepo.Update(ctx, leaderboard)
+	}
+
+	return fmt.Errorf("unknown log operation %q", rec.Op)
+}
+
+// runSnapshots snapshots the leaderboards every snapshotInterval until the service is closed
+func (s *LeaderboardService) runSnapshots() {
+	defer s.background.Done()
+
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Snapshot(context.Background()); err != nil {
+				log.Printf("Leaderboard snapshot failed: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"effective-golang/internal/models"
+)
+
+// WithPeriodicFlush keeps leaderboards in memory and writes changed ones to
+// the repository every interval instead of on every score. This trades a
+// bounded window of data loss (one interval, unless a write-ahead log is also
+// configured) for far fewer repository writes. Close flushes what is left.
+func WithPeriodicFlush(interval time.Duration) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.flushInterval = interval
+	}
+}
+
+// Flush writes every leaderboard changed since the last flush to the
+// repository. It does nothing without periodic flushing.
+func (s *LeaderboardService) Flush(ctx context.Context) error {
+	if s.writeBehind == nil {
+		return nil
+	}
+	return s.writeBehind.flush(ctx)
+}
+
+// runFlusher flushes changed leaderboards every flushInterval until the service is closed
+func (s *LeaderboardService) runFlusher() {
+	defer s.background.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(context.Background()); err != nil {
+				log.Printf("Leaderboard flush failed: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// writeBehindRepository holds the authoritative copy of every leaderboard
+// that has been touched in memory. Entry changes only mark a board dirty;
+// flush writes dirty boards to the underlying repository. Settings changes
+// through Update are rare and written through straight away.
+type writeBehindRepository struct {
+	models.LeaderboardRepository
+
+	mu     sync.Mutex
+	boards map[string]*models.Leaderboard
+	dirty  map[string]struct{}
+}
+
+func newWriteBehindRepository(repo models.LeaderboardRepository) *writeBehindRepository {
+	return &writeBehindRepository{
+		LeaderboardRepository: repo,
+		boards:                make(map[string]*models.Leaderboard),
+		dirty:                 make(map[string]struct{}),
+	}
+}
+
+// board returns the in-memory copy of a leaderboard, loading it on first use
+func (r *writeBehindRepository) board(ctx context.Context, id string) (*models.Leaderboard, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if board, ok := r.boards[id]; ok {
+		return board, nil
+	}
+	stored, err := r.LeaderboardRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Work on a copy so the stored board only changes when it is flushed
+	board := stored.Clone()
+	r.boards[id] = board
+	return board, nil
+}
+
+// loaded returns the in-memory copy of lb if there is one, else lb itself
+func (r *writeBehindRepository) loaded(lb *models.Leaderboard) *models.Leaderboard {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if board, ok := r.boards[lb.ID]; ok {
+		return board
+	}
+	return lb
+}
+
+func (r *writeBehindRepository) markDirty(id string) {
+	r.mu.Lock()
+	r.dirty[id] = struct{}{}
+	r.mu.Unlock()
+}
+
+func (r *writeBehindRepository) GetByID(ctx context.Context, id string) (*models.Leaderboard, error) {
+	return r.board(ctx, id)
+}
+
+func (r *writeBehindRepository) GetByName(ctx context.Context, name string) (*models.Leaderboard, error) {
+	lb, err := r.LeaderboardRepository.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.loaded(lb), nil
+}
+
+func (r *writeBehindRepository) List(ctx context.Context, offset, limit int) ([]*models.Leaderboard, error) {
+	leaderboards, err := r.LeaderboardRepository.List(ctx, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i, lb := range leaderboards {
+		leaderboards[i] = r.loaded(lb)
+	}
+	return leaderboards, nil
+}
+
+func (r *writeBehindRepository) GetByType(ctx context.Context, leaderboardType models.LeaderboardType) ([]*models.Leaderboard, error) {
+	leaderboards, err := r.LeaderboardRepository.GetByType(ctx, leaderboardType)
+	if err != nil {
+		return nil, err
+	}
+	for i, lb := range leaderboards {
+		leaderboards[i] = r.loaded(lb)
+	}
+	return leaderboards, nil
+}
+
+func (r *writeBehindRepository) Update(ctx context.Context, leaderboard *models.Leaderboard) error {
+	if err := r.LeaderboardRepository.Update(ctx, leaderboard.Clone()); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.boards[leaderboard.ID] = leaderboard
+	delete(r.dirty, leaderboard.ID)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *writeBehindRepository) Delete(ctx context.Context, id string) error {
+	if err := r.LeaderboardRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	delete(r.boards, id)
+	delete(r.dirty, id)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *writeBehindRepository) AddEntry(ctx context.Context, leaderboardID string, entry *models.LeaderboardEntry) error {
+	board, err := r.board(ctx, leaderboardID)
+	if err != nil {
+		return err
+	}
+	if err := board.AddEntryAt(entry.UserID, entry.Username, entry.Score, entry.Metadata, entry.UpdatedAt); err != nil {
+		return err
+	}
+	r.markDirty(leaderboardID)
+	return nil
+}
+
+func (r *writeBehindRepository) AddEntries(ctx context.Context, leaderboardID string, entries []*models.LeaderboardEntry) error {
+	board, err := r.board(ctx, leaderboardID)
+	if err != nil {
+		return err
+	}
+	values := make([]models.LeaderboardEntry, len(entries))
+	for i, entry := range entries {
+		values[i] = *entry
+	}
+	if err := board.AddEntries(values); err != nil {
+		return err
+	}
+	r.markDirty(leaderboardID)
+	return nil
+}
+
+func (r *writeBehindRepository) RemoveEntry(ctx context.Context, leaderboardID, userID string) error {
+	board, err := r.board(ctx, leaderboardID)
+	if err != nil {
+		return err
+	}
+	if err := board.RemoveUser(userID); err != nil {
+		return err
+	}
+	r.markDirty(leaderboardID)
+	return nil
+}
+
+func (r *writeBehindRepository) GetTopEntries(ctx context.Context, leaderboardID string, offset, count int, includeTies bool) ([]*models.LeaderboardEntry, int, error) {
+	board, err := r.board(ctx, leaderboardID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries, total := board.GetTopEntries(offset, count, includeTies)
+	result := make([]*models.LeaderboardEntry, len(entries))
+	for i := range entries {
+		result[i] = &entries[i]
+	}
+	return result, total, nil
+}
+
+func (r *writeBehindRepository) GetUserRank(ctx context.Context, leaderboardID, userID string) (int, error) {
+	board, err := r.board(ctx, leaderboardID)
+	if err != nil {
+		return 0, err
+	}
+	return board.GetUserRank(userID)
+}
+
+// flush writes a copy of every dirty board to the underlying repository. A
+// board that fails to write stays dirty and is retried on the next flush.
+func (r *writeBehindRepository) flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := make(map[string]*models.Leaderboard, len(r.dirty))
+	for id := range r.dirty {
+		pending[id] = r.boards[id].Clone()
+	}
+	r.dirty = make(map[string]struct{})
+	r.mu.Unlock()
+
+	var firstErr error
+	for id, board := range pending {
+		if err := r.LeaderboardRepository.Update(ctx, board); err != nil {
+			r.markDirty(id)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to flush leaderboard %s: %w", id, err)
+			}
+		}
+	}
+	return firstErr
+}
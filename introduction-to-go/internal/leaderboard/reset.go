@@ -0,0 +1,183 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"effective-golang/internal/models"
+)
+
+// DefaultResetCheckInterval is how often the reset scheduler looks for
+// leaderboards whose period has ended, unless WithResetCheckInterval sets another
+const DefaultResetCheckInterval = time.Minute
+
+// resetTypes are the leaderboard types the reset scheduler resets
+var resetTypes = []models.LeaderboardType{models.LeaderboardTypeWeekly, models.LeaderboardTypeMonthly}
+
+// WithClock replaces time.Now for period boundaries, mainly for tests
+func WithClock(now func() time.Time) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.now = now
+	}
+}
+
+// WithResetCheckInterval sets how often the reset scheduler looks for ended
+// periods. An interval of 0 or less keeps DefaultResetCheckInterval.
+func WithResetCheckInterval(interval time.Duration) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		if interval > 0 {
+			s.resetInterval = interval
+		}
+	}
+}
+
+// StartResetScheduler resets weekly and monthly leaderboards as their periods
+// end, every reset check interval, until ctx is cancelled or the service is
+// closed. See ResetEndedPeriods.
+func (s *LeaderboardService) StartResetScheduler(ctx context.Context) {
+	s.background.Add(1)
+	go s.runResetScheduler(ctx)
+}
+
+func (s *LeaderboardService) runResetScheduler(ctx context.Context) {
+	defer s.background.Done()
+
+	ticker := time.NewTicker(s.resetInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if n, err := s.ResetEndedPeriods(ctx); err != nil {
+				log.Printf("Leaderboard reset failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Reset %d leaderboards for a new period", n)
+			}
+		case <-ctx.Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// ResetEndedPeriods archives and clears every weekly leaderboard that has
+// crossed Monday 00:00, and every monthly one that has crossed the 1st of the
+// month, since it was created or last reset, and returns how many were reset.
+// Boards the service did not create are tracked from the first time they are
+// checked, and reset at the next boundary after that.
+func (s *LeaderboardService) ResetEndedPeriods(ctx context.Context) (int, error) {
+	now := s.now()
+
+	reset := 0
+	for _, leaderboardType := range resetTypes {
+		leaderboards, err := s.leaderboardRepo.GetByType(ctx, leaderboardType)
+		if err != nil {
+			return reset, fmt.Errorf("failed to list %s leaderboards: %w", leaderboardType, err)
+		}
+
+		for _, leaderboard := range leaderboards {
+			periodStart, _ := leaderboardType.PeriodStart(now)
+			lastStart, tracked := s.trackPeriod(leaderboard.ID, periodStart)
+			if !tracked || !periodStart.After(lastStart) {
+				continue
+			}
+
+			if err := s.resetLeaderboard(ctx, leaderboard, lastStart, periodStart); err != nil {
+				return reset, fmt.Errorf("failed to reset leaderboard %s: %w", leaderboard.ID, err)
+			}
+			s.resetMutex.Lock()
+			s.periodStarts[leaderboard.ID] = periodStart
+			s.resetMutex.Unlock()
+			reset++
+		}
+	}
+
+	return reset, nil
+}
+
+// trackPeriod returns the start of the period a leaderboard is in. A board
+// seen for the first time starts tracking at periodStart and reports false.
+func (s *LeaderboardService) trackPeriod(leaderboardID string, periodStart time.Time) (time.Time, bool) {
+	s.resetMutex.Lock()
+	defer s.resetMutex.Unlock()
+
+	lastStart, tracked := s.periodStarts[leaderboardID]
+	if !tracked {
+		s.periodStarts[leaderboardID] = periodStart
+	}
+	return lastStart, tracked
+}
+
+// startPeriod starts tracking a newly created periodic leaderboard from the
+// period it was created in
+func (s *LeaderboardService) startPeriod(leaderboard *models.Leaderboard) {
+	if periodStart, ok := leaderboard.Type.PeriodStart(s.now()); ok {
+		s.trackPeriod(leaderboard.ID, periodStart)
+	}
+}
+
+// resetLeaderboard archives a leaderboard's standings for the period from
+// periodStart to periodEnd and clears it. If the archive cannot be written the
+// entries are put back, so the next check tries again.
+func (s *LeaderboardService) resetLeaderboard(ctx context.Context, leaderboard *models.Leaderboard, periodStart, periodEnd time.Time) error {
+	archive := &models.LeaderboardArchive{
+		LeaderboardID: leaderboard.ID,
+		Name:          leaderboard.Name,
+		Type:          leaderboard.Type,
+		PeriodStart:   periodStart,
+		PeriodEnd:     periodEnd,
+		ArchivedAt:    s.now(),
+	}
+
+	reset := walRecord{Op: walOpReset, LeaderboardID: leaderboard.ID}
+	err := s.logged(reset, func() error {
+		archive.Entries = leaderboard.Clear()
+		if err := s.leaderboardRepo.Archive(ctx, archive); err != nil {
+			if restoreErr := leaderboard.AddEntries(archive.Entries); restoreErr != nil {
+				log.Printf("Failed to restore leaderboard %s after a failed archive: %v", leaderboard.ID, restoreErr)
+			}
+			return fmt.Errorf("failed to archive: %w", err)
+		}
+		return s.leaderboardRepo.Update(ctx, leaderboard)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateCache(ctx, leaderboard.ID)
+	s.forgetEmitted(leaderboard.ID)
+
+	s.sendUpdate(&LeaderboardUpdate{
+		LeaderboardID: leaderboard.ID,
+		Type:          "leaderboard_reset",
+		Timestamp:     time.Now(),
+	})
+
+	return nil
+}
+
+// forgetEmitted drops the last emitted scores for a leaderboard's users, so
+// their first scores of a new period are always emitted
+func (s *LeaderboardService) forgetEmitted(leaderboardID string) {
+	s.emittedMutex.Lock()
+	defer s.emittedMutex.Unlock()
+
+	for key := range s.emittedScores {
+		if strings.HasPrefix(key, leaderboardID+":") {
+			delete(s.emittedScores, key)
+		}
+	}
+}
+
+// GetArchives retrieves the archived periods of a leaderboard, oldest first
+func (s *LeaderboardService) GetArchives(ctx context.Context, leaderboardID string) ([]*models.LeaderboardArchive, error) {
+	archives, err := s.leaderboardRepo.GetArchives(ctx, leaderboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archives: %w", err)
+	}
+	return archives, nil
+}
@@ -2,11 +2,21 @@ package leaderboard
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"effective-golang/internal/models"
+	"effective-golang/internal/wal"
+	"effective-golang/pkg/broadcast"
+	"effective-golang/pkg/validate"
 )
 
 // LeaderboardService handles leaderboard operations and caching
@@ -16,12 +26,52 @@ type LeaderboardService struct {
 	cacheRepo       models.CacheRepository
 	
 	// Cache for leaderboard data
-	cacheMutex      sync.RWMutex
 	cacheTTL        int
 	
+	// Largest count GetTopEntries returns, whatever the caller asks for
+	maxTopEntries   int
+	
+	// Register leaderboards already in the repository on startup
+	reconcile       bool
+	
 	// Real-time updates
-	updateChannels  map[string]chan *LeaderboardUpdate
+	feeds           map[string]*broadcast.Broadcaster[*LeaderboardUpdate]
+	webhooks        map[string][]*webhook
 	channelMutex    sync.RWMutex
+	boards          atomic.Int64
+	
+	// Last score emitted per leaderboard and user, for update coalescing
+	emittedScores   map[string]int64
+	emittedMutex    sync.Mutex
+	
+	// In-process update callbacks
+	callbacks       []*registeredCallback
+	callbackMutex   sync.RWMutex
+	
+	// Webhook delivery
+	webhookClient   *http.Client
+	webhookAttempts int
+	webhookBackoff  time.Duration
+	
+	// Write-ahead log for durability; nil when disabled
+	wal              *wal.Log
+	walMutex         sync.Mutex
+	snapshotInterval time.Duration
+	
+	// Write-behind persistence; nil when every change is written through
+	writeBehind      *writeBehindRepository
+	flushInterval    time.Duration
+	
+	// Periodic resets: the period each weekly or monthly board is in
+	now              func() time.Time
+	resetInterval    time.Duration
+	periodStarts     map[string]time.Time
+	resetMutex       sync.Mutex
+	
+	// Background loops (snapshots, flushes, resets) stop when stop is closed
+	stop             chan struct{}
+	background       sync.WaitGroup
+	closeOnce        sync.Once
 }
 
 // LeaderboardUpdate represents a leaderboard update
@@ -33,6 +83,17 @@ type LeaderboardUpdate struct {
 	NewRank       int                       `json:"new_rank,omitempty"`
 	OldRank       int                       `json:"old_rank,omitempty"`
 	Timestamp     time.Time                 `json:"timestamp"`
+	
+	// Gap is set on the first update a subscriber receives after its buffer
+	// overflowed; earlier updates were lost, so it should re-fetch the board
+	Gap           bool                      `json:"gap,omitempty"`
+}
+
+// markGap returns a copy of update flagged as following dropped updates
+func markGap(update *LeaderboardUpdate) *LeaderboardUpdate {
+	marked := *update
+	marked.Gap = true
+	return &marked
 }
 
 // LeaderboardStats represents aggregated leaderboard statistics
@@ -41,16 +102,104 @@ type LeaderboardStats struct {
 	AverageScore   float64 `json:"average_score"`
 	HighestScore   int64   `json:"highest_score"`
 	LowestScore    int64   `json:"lowest_score"`
+	BestScore      int64   `json:"best_score"`  // the highest score, or the lowest on an ascending board
+	WorstScore     int64   `json:"worst_score"` // the opposite end from BestScore
 	ScoreRange     int64   `json:"score_range"`
+	Median         float64 `json:"median"`
+	StdDev         float64 `json:"std_dev"` // population standard deviation
 	LastUpdated    time.Time `json:"last_updated"`
 }
 
+// RankStatus says where a user stands on a leaderboard
+type RankStatus string
+
+const (
+	// RankStatusRanked means the user holds a place on the board
+	RankStatusRanked RankStatus = "ranked"
+	// RankStatusUnranked means the board has entries but none for the user,
+	// either because they never scored or fell below the cutoff
+	RankStatusUnranked RankStatus = "unranked"
+	// RankStatusEmpty means nobody is on the board yet
+	RankStatusEmpty RankStatus = "empty"
+)
+
+// UserRank is a user's standing on a leaderboard. Rank is only set when
+// Ranked is true.
+type UserRank struct {
+	UserID string     `json:"user_id"`
+	Ranked bool       `json:"ranked"`
+	Rank   int        `json:"rank,omitempty"`
+	Status RankStatus `json:"status"`
+}
+
+// TopEntriesPage is one page of a leaderboard's ranked entries. Total counts
+// every entry on the board, so callers can tell when they reached the end.
+// NextOffset is where the next page starts; with ties included a page can hold
+// more than Limit entries, so it is not always Offset+Limit.
+type TopEntriesPage struct {
+	Entries    []models.LeaderboardEntry `json:"entries"`
+	Total      int                       `json:"total"`
+	Offset     int                       `json:"offset"`
+	Limit      int                       `json:"limit"`
+	NextOffset int                       `json:"next_offset"`
+}
+
+// ServiceStats is a point-in-time snapshot of the leaderboard service
+type ServiceStats struct {
+	Leaderboards   int    `json:"leaderboards"`
+	Subscribers    int    `json:"subscribers"` // includes webhooks
+	Webhooks       int    `json:"webhooks"`
+	DroppedUpdates uint64 `json:"dropped_updates"`
+}
+
+// DefaultSubscription is used by SubscribeToUpdates: a 100-update buffer that
+// drops new updates while the subscriber is behind, flagging the next update
+// it does receive with Gap
+var DefaultSubscription = broadcast.Options{Buffer: 100, Policy: broadcast.DropNewest, MarkGaps: true}
+
+// MaxLeaderboardNameLength is the longest name CreateLeaderboard accepts
+const MaxLeaderboardNameLength = 100
+
+// leaderboardTypes are the types CreateLeaderboard accepts
+var leaderboardTypes = []string{
+	string(models.LeaderboardTypeGlobal),
+	string(models.LeaderboardTypeWeekly),
+	string(models.LeaderboardTypeMonthly),
+	string(models.LeaderboardTypeSeasonal),
+}
+
+// sortOrders are the orders CreateLeaderboardWithOrder accepts
+var sortOrders = []string{
+	string(models.SortOrderDesc),
+	string(models.SortOrderAsc),
+}
+
+// DefaultMaxTopEntries is the most entries GetTopEntries returns unless
+// WithMaxTopEntries sets another limit
+const DefaultMaxTopEntries = 1000
+
+// WithMaxTopEntries caps how many entries GetTopEntries returns; larger
+// counts are clamped. A max of 0 or less keeps DefaultMaxTopEntries.
+func WithMaxTopEntries(max int) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		if max > 0 {
+			s.maxTopEntries = max
+		}
+	}
+}
+
 // Custom errors for leaderboard operations
 var (
 	ErrLeaderboardNotFound = fmt.Errorf("leaderboard not found")
 	ErrInvalidScore        = fmt.Errorf("invalid score")
 	ErrUserNotFound        = fmt.Errorf("user not found")
 	ErrCacheMiss           = fmt.Errorf("cache miss")
+	ErrInvalidMaxEntries   = fmt.Errorf("max entries must be 0 (unlimited) or positive")
+	ErrInvalidRadius       = fmt.Errorf("radius must not be negative")
+	ErrInvalidOffset       = fmt.Errorf("offset must not be negative")
+	ErrLeaderboardExists   = models.ErrLeaderboardExists
+	ErrInvalidHalfLife     = fmt.Errorf("decay half-life must be 0 (no decay) or positive")
+	ErrInvalidUpdateDelta  = fmt.Errorf("minimum update delta must be 0 (every change) or positive")
 )
 
 // NewLeaderboardService creates a new leaderboard service
@@ -59,53 +208,175 @@ func NewLeaderboardService(
 	userRepo models.UserRepository,
 	cacheRepo models.CacheRepository,
 	cacheTTL int,
+	opts ...LeaderboardServiceOption,
 ) *LeaderboardService {
-	return &LeaderboardService{
+	svc := &LeaderboardService{
 		leaderboardRepo: leaderboardRepo,
 		userRepo:        userRepo,
 		cacheRepo:       cacheRepo,
 		cacheTTL:        cacheTTL,
-		updateChannels:  make(map[string]chan *LeaderboardUpdate),
+		maxTopEntries:   DefaultMaxTopEntries,
+		feeds:           make(map[string]*broadcast.Broadcaster[*LeaderboardUpdate]),
+		webhooks:        make(map[string][]*webhook),
+		emittedScores:   make(map[string]int64),
+		now:             time.Now,
+		resetInterval:   DefaultResetCheckInterval,
+		periodStarts:    make(map[string]time.Time),
+		webhookClient:   &http.Client{Timeout: 10 * time.Second},
+		webhookAttempts: 5,
+		webhookBackoff:  time.Second,
+		stop:            make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	
+	if svc.flushInterval > 0 {
+		svc.writeBehind = newWriteBehindRepository(svc.leaderboardRepo)
+		svc.leaderboardRepo = svc.writeBehind
+		svc.background.Add(1)
+		go svc.runFlusher()
+	}
+	if svc.wal != nil && svc.snapshotInterval > 0 {
+		svc.background.Add(1)
+		go svc.runSnapshots()
+	}
+	
+	// Pick up leaderboards loaded into the repository before the service started
+	if svc.reconcile {
+		if n, err := svc.reconcileLeaderboards(context.Background()); err != nil {
+			log.Printf("Leaderboard reconciliation failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Reconciled %d leaderboards from the repository", n)
+		}
 	}
+	return svc
 }
 
-// CreateLeaderboard creates a new leaderboard
+// WithLeaderboardReconciliation registers the leaderboards already in the
+// repository on startup, such as ones loaded from a snapshot, so they get
+// update feeds and period resets like leaderboards created through the service
+func WithLeaderboardReconciliation() LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.reconcile = true
+	}
+}
+
+// reconcileLeaderboards registers every leaderboard in the repository that has no update feed yet
+func (s *LeaderboardService) reconcileLeaderboards(ctx context.Context) (int, error) {
+	leaderboards, err := s.leaderboardRepo.List(ctx, 0, math.MaxInt32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list leaderboards: %w", err)
+	}
+	
+	added := 0
+	for _, leaderboard := range leaderboards {
+		s.channelMutex.RLock()
+		_, exists := s.feeds[leaderboard.ID]
+		s.channelMutex.RUnlock()
+		if !exists {
+			s.register(ctx, leaderboard)
+			added++
+		}
+	}
+	
+	return added, nil
+}
+
+// CreateLeaderboard creates a new leaderboard that ranks the highest score
+// first. A maxEntries of 0 creates an unlimited leaderboard. Names are unique:
+// when several requests race to create the same name, the repository lets
+// exactly one through and the rest get ErrLeaderboardExists.
 func (s *LeaderboardService) CreateLeaderboard(
 	ctx context.Context,
 	name string,
 	leaderboardType models.LeaderboardType,
 	maxEntries int,
 ) (*models.Leaderboard, error) {
-	// Check if leaderboard already exists
-	existing, err := s.leaderboardRepo.GetByName(ctx, name)
-	if err == nil && existing != nil {
-		return nil, fmt.Errorf("leaderboard already exists: %w", ErrLeaderboardNotFound)
+	return s.CreateLeaderboardWithOrder(ctx, name, leaderboardType, maxEntries, models.SortOrderDesc)
+}
+
+// CreateLeaderboardWithOrder is CreateLeaderboard with the direction scores
+// are ranked in. With models.SortOrderAsc the lowest score ranks first and a
+// full board evicts its highest score. An empty order means models.SortOrderDesc.
+func (s *LeaderboardService) CreateLeaderboardWithOrder(
+	ctx context.Context,
+	name string,
+	leaderboardType models.LeaderboardType,
+	maxEntries int,
+	order models.SortOrder,
+) (*models.Leaderboard, error) {
+	if order == "" {
+		order = models.SortOrderDesc
+	}
+	
+	var errs validate.Errors
+	errs.Check("name", name, validate.Required(), validate.MaxLen(MaxLeaderboardNameLength))
+	errs.Check("type", leaderboardType, validate.OneOf(leaderboardTypes...))
+	errs.Check("max_entries", maxEntries, func(any) error {
+		if maxEntries < 0 {
+			return ErrInvalidMaxEntries
+		}
+		return nil
+	})
+	errs.Check("sort_order", order, validate.OneOf(sortOrders...))
+	if err := errs.Err(); err != nil {
+		return nil, err
 	}
 	
 	// Create new leaderboard
-	leaderboard := models.NewLeaderboard(name, leaderboardType, maxEntries)
+	leaderboard := models.NewLeaderboardWithOrder(name, leaderboardType, maxEntries, order)
 	
-	// Save to database
-	if err := s.leaderboardRepo.Create(ctx, leaderboard); err != nil {
+	// Save to database; the repository enforces name uniqueness atomically
+	created := walRecord{Op: walOpCreate, LeaderboardID: leaderboard.ID, Name: name, Type: leaderboardType, MaxEntries: maxEntries, SortOrder: order}
+	if err := s.logged(created, func() error { return s.leaderboardRepo.Create(ctx, leaderboard) }); err != nil {
+		if errors.Is(err, models.ErrLeaderboardExists) {
+			return nil, fmt.Errorf("leaderboard %q: %w", name, ErrLeaderboardExists)
+		}
 		return nil, fmt.Errorf("failed to create leaderboard: %w", err)
 	}
 	
+	s.register(ctx, leaderboard)
+	
+	return leaderboard, nil
+}
+
+// register caches a newly created leaderboard and opens its update feed
+func (s *LeaderboardService) register(ctx context.Context, leaderboard *models.Leaderboard) {
 	// Initialize cache
 	s.cacheLeaderboard(ctx, leaderboard)
 	
-	// Create update channel
+	// Create update feed
 	s.channelMutex.Lock()
-	s.updateChannels[leaderboard.ID] = make(chan *LeaderboardUpdate, 100)
+	feed := broadcast.New[*LeaderboardUpdate]()
+	feed.SetGapMarker(markGap)
+	s.feeds[leaderboard.ID] = feed
 	s.channelMutex.Unlock()
 	
-	return leaderboard, nil
+	s.boards.Add(1)
+	s.startPeriod(leaderboard)
 }
 
-// AddScore adds or updates a score in a leaderboard
+// AddScore adds or updates a score in a leaderboard. The score is always
+// stored; the real-time update is skipped when the leaderboard has a minimum
+// update delta, the rank is unchanged and the score has not moved more than
+// the delta since the last update emitted for this user.
 func (s *LeaderboardService) AddScore(
 	ctx context.Context,
 	leaderboardID, userID string,
 	score int64,
+) error {
+	return s.AddScoreWithMetadata(ctx, leaderboardID, userID, score, nil)
+}
+
+// AddScoreWithMetadata is AddScore with metadata, such as the game that
+// produced the score, attached to the user's entry. Nil metadata keeps what
+// the entry already has.
+func (s *LeaderboardService) AddScoreWithMetadata(
+	ctx context.Context,
+	leaderboardID, userID string,
+	score int64,
+	metadata map[string]string,
 ) error {
 	if score < 0 {
 		return ErrInvalidScore
@@ -126,9 +397,11 @@ func (s *LeaderboardService) AddScore(
 		Username:  user.Username,
 		Score:     score,
 		UpdatedAt: time.Now(),
+		Metadata:  metadata,
 	}
 	
-	if err := s.leaderboardRepo.AddEntry(ctx, leaderboardID, entry); err != nil {
+	scored := walRecord{Op: walOpScore, LeaderboardID: leaderboardID, UserID: userID, Username: user.Username, Score: score, Metadata: metadata, UpdatedAt: entry.UpdatedAt}
+	if err := s.logged(scored, func() error { return s.leaderboardRepo.AddEntry(ctx, leaderboardID, entry) }); err != nil {
 		return fmt.Errorf("failed to add entry: %w", err)
 	}
 	
@@ -141,6 +414,10 @@ func (s *LeaderboardService) AddScore(
 	// Invalidate cache
 	s.invalidateCache(ctx, leaderboardID)
 	
+	if !s.shouldEmit(ctx, leaderboardID, userID, score, oldRank != newRank) {
+		return nil
+	}
+	
 	// Send real-time update
 	s.sendUpdate(&LeaderboardUpdate{
 		LeaderboardID: leaderboardID,
@@ -154,61 +431,253 @@ func (s *LeaderboardService) AddScore(
 	return nil
 }
 
-// GetTopEntries retrieves top entries from a leaderboard
+// AddScores sets several users' scores on a leaderboard at once, such as
+// everyone's result at the end of a round. Every score and user is checked
+// before anything is written, the scores are applied together, in user ID
+// order, and if any fails none are kept. The cache is invalidated once and a
+// single batch_updated update carries the users' new entries.
+func (s *LeaderboardService) AddScores(ctx context.Context, leaderboardID string, scores map[string]int64) error {
+	if len(scores) == 0 {
+		return nil
+	}
+	
+	userIDs := make([]string, 0, len(scores))
+	for userID, score := range scores {
+		if score < 0 {
+			return fmt.Errorf("user %s: %w", userID, ErrInvalidScore)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	
+	entries := make([]*models.LeaderboardEntry, len(userIDs))
+	for i, userID := range userIDs {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("user %s not found: %w", userID, err)
+		}
+		entries[i] = &models.LeaderboardEntry{
+			UserID:    userID,
+			Username:  user.Username,
+			Score:     scores[userID],
+			UpdatedAt: time.Now(),
+		}
+	}
+	
+	scored := walRecord{Op: walOpScores, LeaderboardID: leaderboardID, Entries: entries}
+	if err := s.logged(scored, func() error { return s.leaderboardRepo.AddEntries(ctx, leaderboardID, entries) }); err != nil {
+		return fmt.Errorf("failed to add entries: %w", err)
+	}
+	
+	s.invalidateCache(ctx, leaderboardID)
+	
+	leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	updated := make([]models.LeaderboardEntry, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if entry, err := leaderboard.GetUserEntry(userID); err == nil {
+			updated = append(updated, *entry)
+		}
+	}
+	
+	// Subscribers see every score in the batch, so later coalescing measures from them
+	s.emittedMutex.Lock()
+	for userID, score := range scores {
+		s.emittedScores[leaderboardID+":"+userID] = score
+	}
+	s.emittedMutex.Unlock()
+	
+	s.sendUpdate(&LeaderboardUpdate{
+		LeaderboardID: leaderboardID,
+		Type:          "batch_updated",
+		Entries:       updated,
+		Timestamp:     time.Now(),
+	})
+	
+	return nil
+}
+
+// RemoveEntry removes a user's entry from a leaderboard
+func (s *LeaderboardService) RemoveEntry(ctx context.Context, leaderboardID, userID string) error {
+	oldRank, err := s.leaderboardRepo.GetUserRank(ctx, leaderboardID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user rank: %w", err)
+	}
+	
+	removed := walRecord{Op: walOpRemove, LeaderboardID: leaderboardID, UserID: userID}
+	if err := s.logged(removed, func() error { return s.leaderboardRepo.RemoveEntry(ctx, leaderboardID, userID) }); err != nil {
+		return fmt.Errorf("failed to remove entry: %w", err)
+	}
+	
+	s.invalidateCache(ctx, leaderboardID)
+	
+	s.sendUpdate(&LeaderboardUpdate{
+		LeaderboardID: leaderboardID,
+		Type:          "entry_removed",
+		UserID:        userID,
+		OldRank:       oldRank,
+		Timestamp:     time.Now(),
+	})
+	
+	return nil
+}
+
+// GetTopEntries retrieves a page of up to count ranked entries starting at
+// offset. With includeTies set, every entry tied with the last one is returned
+// as well, so the next page starts at the page's NextOffset rather than
+// offset+count. A count above the service maximum is clamped; see ClampTopCount.
 func (s *LeaderboardService) GetTopEntries(
 	ctx context.Context,
 	leaderboardID string,
-	count int,
-) ([]models.LeaderboardEntry, error) {
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("leaderboard:%s:top:%d", leaderboardID, count)
-	var entries []models.LeaderboardEntry
+	offset, count int,
+	includeTies bool,
+) (*TopEntriesPage, error) {
+	if offset < 0 {
+		return nil, ErrInvalidOffset
+	}
+	count, _ = s.ClampTopCount(count)
+	
+	// Try to get from cache first, under the leaderboard's current cache version
+	version, versionErr := models.CacheVersion(ctx, s.cacheRepo, cacheVersionKey(leaderboardID))
+	cacheKey := topEntriesCacheKey(leaderboardID, version, offset, count, includeTies)
+	var page TopEntriesPage
 	
-	if err := s.cacheRepo.Get(ctx, cacheKey, &entries); err == nil {
-		return entries, nil
+	if versionErr == nil {
+		if err := s.cacheRepo.Get(ctx, cacheKey, &page); err == nil {
+			return &page, nil
+		}
 	}
 	
 	// Get from database
-	repoEntries, err := s.leaderboardRepo.GetTopEntries(ctx, leaderboardID, count)
+	repoEntries, total, err := s.leaderboardRepo.GetTopEntries(ctx, leaderboardID, offset, count, includeTies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get top entries: %w", err)
 	}
 	
 	// Convert to value slice for caching
-	entryValues := make([]models.LeaderboardEntry, len(repoEntries))
+	page = TopEntriesPage{
+		Entries:    make([]models.LeaderboardEntry, len(repoEntries)),
+		Total:      total,
+		Offset:     offset,
+		Limit:      count,
+		NextOffset: offset + len(repoEntries),
+	}
 	for i, entry := range repoEntries {
-		entryValues[i] = *entry
+		page.Entries[i] = *entry
 	}
 	
-	// Cache the result
-	s.cacheRepo.Set(ctx, cacheKey, entryValues, s.cacheTTL)
+	// Cache the result. If a write bumped the version since it was read, the
+	// page lands under the old version and is never read.
+	if versionErr == nil {
+		s.cacheRepo.Set(ctx, cacheKey, page, s.cacheTTL)
+	}
 	
-	return entryValues, nil
+	return &page, nil
+}
+
+// ClampTopCount limits a requested top-entries count to the service maximum,
+// reporting whether it had to be lowered
+func (s *LeaderboardService) ClampTopCount(count int) (int, bool) {
+	if count > s.maxTopEntries {
+		return s.maxTopEntries, true
+	}
+	return count, false
 }
 
-// GetUserRank retrieves a user's rank in a leaderboard
+// GetUserRank retrieves a user's standing in a leaderboard. A user without an
+// entry is not an error: the result says whether they are unranked or the
+// board is empty. Only a missing leaderboard returns an error.
 func (s *LeaderboardService) GetUserRank(
 	ctx context.Context,
 	leaderboardID, userID string,
-) (int, error) {
-	// Try to get from cache first
-	cacheKey := fmt.Sprintf("leaderboard:%s:rank:%s", leaderboardID, userID)
+) (*UserRank, error) {
+	// Try to get from cache first, under the leaderboard's current cache
+	// version, so a score change or removal drops the cached rank
+	version, versionErr := models.CacheVersion(ctx, s.cacheRepo, cacheVersionKey(leaderboardID))
+	cacheKey := fmt.Sprintf("leaderboard:%s:%s:rank:%s", leaderboardID, version, userID)
 	var rank int
 	
-	if err := s.cacheRepo.Get(ctx, cacheKey, &rank); err == nil {
-		return rank, nil
+	if versionErr == nil {
+		if err := s.cacheRepo.Get(ctx, cacheKey, &rank); err == nil {
+			return &UserRank{UserID: userID, Ranked: true, Rank: rank, Status: RankStatusRanked}, nil
+		}
 	}
 	
 	// Get from database
 	rank, err := s.leaderboardRepo.GetUserRank(ctx, leaderboardID, userID)
+	if errors.Is(err, models.ErrUserNotFoundInLeaderboard) {
+		return s.unrankedUser(ctx, leaderboardID, userID)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get user rank: %w", err)
+		return nil, fmt.Errorf("failed to get user rank: %w", err)
 	}
 	
 	// Cache the result
-	s.cacheRepo.Set(ctx, cacheKey, rank, s.cacheTTL)
+	if versionErr == nil {
+		s.cacheRepo.Set(ctx, cacheKey, rank, s.cacheTTL)
+	}
+	
+	return &UserRank{UserID: userID, Ranked: true, Rank: rank, Status: RankStatusRanked}, nil
+}
+
+// unrankedUser tells an empty leaderboard apart from a user missing from a populated one
+func (s *LeaderboardService) unrankedUser(ctx context.Context, leaderboardID, userID string) (*UserRank, error) {
+	_, total, err := s.leaderboardRepo.GetTopEntries(ctx, leaderboardID, 0, 0, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top entries: %w", err)
+	}
+	
+	status := RankStatusUnranked
+	if total == 0 {
+		status = RankStatusEmpty
+	}
+	return &UserRank{UserID: userID, Status: status}, nil
+}
+
+// GetEntriesAround retrieves a user's entry with up to radius entries ranked
+// either side of it, so a player can see who is just ahead and behind. The
+// window is cut short at the top and bottom of the board, and a radius above
+// the service maximum is clamped. A user without an entry gets
+// models.ErrUserNotFoundInLeaderboard.
+func (s *LeaderboardService) GetEntriesAround(
+	ctx context.Context,
+	leaderboardID, userID string,
+	radius int,
+) ([]models.LeaderboardEntry, error) {
+	if radius < 0 {
+		return nil, ErrInvalidRadius
+	}
+	radius = min(radius, s.maxTopEntries)
+	
+	leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	
+	entries, err := leaderboard.GetEntriesAround(userID, radius)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries around user %s: %w", userID, err)
+	}
+	return entries, nil
+}
+
+// GetUserPercentile returns the percentage of the players on a leaderboard
+// that the user ranks strictly ahead of, from 0 to 100. Players tied with the
+// user do not count, and the user counts towards the total, so a board's sole
+// player is at 0. A user without an entry gets models.ErrUserNotFoundInLeaderboard.
+func (s *LeaderboardService) GetUserPercentile(ctx context.Context, leaderboardID, userID string) (float64, error) {
+	leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
 	
-	return rank, nil
+	percentile, err := leaderboard.Percentile(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get percentile for user %s: %w", userID, err)
+	}
+	return percentile, nil
 }
 
 // GetLeaderboard retrieves a complete leaderboard
@@ -233,7 +702,88 @@ func (s *LeaderboardService) GetLeaderboard(
 	// Cache the result
 	s.cacheLeaderboard(ctx, repoLeaderboard)
 	
-	return repoLeaderboard, nil
+	// The repository hands out its live instance; callers get their own copy
+	return repoLeaderboard.Clone(), nil
+}
+
+// SetDecay sets how fast scores on a leaderboard decay; see models.Leaderboard.SetDecay.
+// A halfLife of 0 turns decay off.
+func (s *LeaderboardService) SetDecay(ctx context.Context, leaderboardID string, halfLife time.Duration) error {
+	if halfLife < 0 {
+		return ErrInvalidHalfLife
+	}
+	
+	leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	
+	decay := walRecord{Op: walOpDecay, LeaderboardID: leaderboardID, HalfLife: halfLife}
+	err = s.logged(decay, func() error {
+		leaderboard.SetDecay(halfLife)
+		return s.leaderboardRepo.Update(ctx, leaderboard)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update leaderboard: %w", err)
+	}
+	
+	// Rankings change with decay
+	s.invalidateCache(ctx, leaderboardID)
+	
+	return nil
+}
+
+// SetMinUpdateDelta sets the minimum score change that emits a real-time
+// update for a leaderboard. A delta of 0 emits an update for every change.
+func (s *LeaderboardService) SetMinUpdateDelta(ctx context.Context, leaderboardID string, delta int64) error {
+	if delta < 0 {
+		return ErrInvalidUpdateDelta
+	}
+	
+	leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	
+	threshold := walRecord{Op: walOpMinUpdateDelta, LeaderboardID: leaderboardID, MinUpdateDelta: delta}
+	err = s.logged(threshold, func() error {
+		leaderboard.SetMinUpdateDelta(delta)
+		return s.leaderboardRepo.Update(ctx, leaderboard)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update leaderboard: %w", err)
+	}
+	
+	// Cached copies still carry the old threshold
+	s.invalidateCache(ctx, leaderboardID)
+	
+	return nil
+}
+
+// shouldEmit reports whether a score change is worth a real-time update and,
+// if so, records score as the last one emitted for the user
+func (s *LeaderboardService) shouldEmit(ctx context.Context, leaderboardID, userID string, score int64, rankChanged bool) bool {
+	var delta int64
+	if leaderboard, err := s.leaderboardRepo.GetByID(ctx, leaderboardID); err == nil {
+		delta = leaderboard.GetMinUpdateDelta()
+	}
+	
+	key := leaderboardID + ":" + userID
+	
+	s.emittedMutex.Lock()
+	defer s.emittedMutex.Unlock()
+	
+	last, emitted := s.emittedScores[key]
+	change := score - last
+	if change < 0 {
+		change = -change
+	}
+	if delta > 0 && emitted && !rankChanged && change <= delta {
+		return false
+	}
+	
+	s.emittedScores[key] = score
+	return true
 }
 
 // GetStats retrieves leaderboard statistics
@@ -264,25 +814,50 @@ func (s *LeaderboardService) GetStats(
 	return &stats, nil
 }
 
-// SubscribeToUpdates subscribes to real-time leaderboard updates
+// SubscribeToUpdates subscribes to real-time leaderboard updates with the
+// DefaultSubscription options
 func (s *LeaderboardService) SubscribeToUpdates(leaderboardID string) (<-chan *LeaderboardUpdate, error) {
+	sub, err := s.Subscribe(leaderboardID, DefaultSubscription)
+	if err != nil {
+		return nil, err
+	}
+	return sub.C(), nil
+}
+
+// Subscribe subscribes to real-time leaderboard updates with its own buffer
+// and backpressure policy. Close the subscription to stop receiving updates.
+func (s *LeaderboardService) Subscribe(leaderboardID string, opts broadcast.Options) (*broadcast.Subscription[*LeaderboardUpdate], error) {
 	s.channelMutex.RLock()
-	channel, exists := s.updateChannels[leaderboardID]
+	feed, exists := s.feeds[leaderboardID]
 	s.channelMutex.RUnlock()
 	
 	if !exists {
 		return nil, fmt.Errorf("leaderboard not found: %w", ErrLeaderboardNotFound)
 	}
 	
-	return channel, nil
+	return feed.Subscribe(opts), nil
+}
+
+// UpdateStats reports a leaderboard's update subscribers and how many updates
+// were published and dropped because a subscriber's buffer was full
+func (s *LeaderboardService) UpdateStats(leaderboardID string) (broadcast.Stats, error) {
+	s.channelMutex.RLock()
+	feed, exists := s.feeds[leaderboardID]
+	s.channelMutex.RUnlock()
+	
+	if !exists {
+		return broadcast.Stats{}, fmt.Errorf("leaderboard not found: %w", ErrLeaderboardNotFound)
+	}
+	return feed.Stats(), nil
 }
 
-// UnsubscribeFromUpdates unsubscribes from real-time updates
+// UnsubscribeFromUpdates closes every subscription to a leaderboard and stops
+// publishing its updates
 func (s *LeaderboardService) UnsubscribeFromUpdates(leaderboardID string) {
 	s.channelMutex.Lock()
-	if channel, exists := s.updateChannels[leaderboardID]; exists {
-		close(channel)
-		delete(s.updateChannels, leaderboardID)
+	if feed, exists := s.feeds[leaderboardID]; exists {
+		feed.Close()
+		delete(s.feeds, leaderboardID)
 	}
 	s.channelMutex.Unlock()
 }
@@ -312,7 +887,9 @@ func (s *LeaderboardService) RefreshLeaderboard(ctx context.Context, leaderboard
 // cacheLeaderboard caches leaderboard data
 func (s *LeaderboardService) cacheLeaderboard(ctx context.Context, leaderboard *models.Leaderboard) {
 	cacheKey := fmt.Sprintf("leaderboard:%s", leaderboard.ID)
-	s.cacheRepo.Set(ctx, cacheKey, leaderboard, s.cacheTTL)
+	// Cache a copy: an in-process cache may hold on to the value, and it must
+	// not change, or be read without its lock, as the live board is updated
+	s.cacheRepo.Set(ctx, cacheKey, leaderboard.Clone(), s.cacheTTL)
 }
 
 // invalidateCache invalidates cached data for a leaderboard
@@ -325,27 +902,34 @@ func (s *LeaderboardService) invalidateCache(ctx context.Context, leaderboardID
 	statsKey := fmt.Sprintf("leaderboard:%s:stats", leaderboardID)
 	s.cacheRepo.Delete(ctx, statsKey)
 	
-	// Remove top entries cache (pattern matching would be better in real implementation)
-	for i := 1; i <= 100; i++ {
-		topKey := fmt.Sprintf("leaderboard:%s:top:%d", leaderboardID, i)
-		s.cacheRepo.Delete(ctx, topKey)
+	// Drop every cached top entries query and user rank, on every instance sharing the cache
+	models.BumpCacheVersion(ctx, s.cacheRepo, cacheVersionKey(leaderboardID))
+}
+
+// cacheVersionKey returns the cache key of a leaderboard's cache version; see models.CacheVersion
+func cacheVersionKey(leaderboardID string) string {
+	return fmt.Sprintf("leaderboard:%s:version", leaderboardID)
+}
+
+// topEntriesCacheKey returns the cache key for a top entries query under a cache version
+func topEntriesCacheKey(leaderboardID, version string, offset, count int, includeTies bool) string {
+	if includeTies {
+		return fmt.Sprintf("leaderboard:%s:%s:top:%d:%d:ties", leaderboardID, version, offset, count)
 	}
+	return fmt.Sprintf("leaderboard:%s:%s:top:%d:%d", leaderboardID, version, offset, count)
 }
 
-// sendUpdate sends a real-time update to subscribers
+// sendUpdate publishes a real-time update to subscribers and webhooks and runs the update callbacks
 func (s *LeaderboardService) sendUpdate(update *LeaderboardUpdate) {
 	s.channelMutex.RLock()
-	channel, exists := s.updateChannels[update.LeaderboardID]
+	feed, exists := s.feeds[update.LeaderboardID]
 	s.channelMutex.RUnlock()
 	
 	if exists {
-		select {
-		case channel <- update:
-			// Update sent successfully
-		default:
-			// Channel is full, skip update
-		}
+		feed.Publish(update)
 	}
+	
+	s.runCallbacks(update)
 }
 
 // calculateStats calculates leaderboard statistics
@@ -361,20 +945,47 @@ func (s *LeaderboardService) calculateStats(leaderboard *models.Leaderboard) Lea
 		}
 	}
 	
+	// Entries are in rank order, which is ascending on some boards and decayed
+	// on others, so the raw extremes need a scan
 	var totalScore int64
 	highestScore := leaderboard.Entries[0].Score
-	lowestScore := leaderboard.Entries[len(leaderboard.Entries)-1].Score
+	lowestScore := leaderboard.Entries[0].Score
+	scores := make([]int64, len(leaderboard.Entries))
 	
-	for _, entry := range leaderboard.Entries {
+	for i, entry := range leaderboard.Entries {
 		totalScore += entry.Score
+		highestScore = max(highestScore, entry.Score)
+		lowestScore = min(lowestScore, entry.Score)
+		scores[i] = entry.Score
+	}
+	
+	mean := float64(totalScore) / float64(len(scores))
+	var squares float64
+	for _, score := range scores {
+		squares += (float64(score) - mean) * (float64(score) - mean)
+	}
+	
+	slices.Sort(scores)
+	median := float64(scores[len(scores)/2])
+	if len(scores)%2 == 0 {
+		median = (float64(scores[len(scores)/2-1]) + median) / 2
+	}
+	
+	bestScore, worstScore := highestScore, lowestScore
+	if leaderboard.SortOrder == models.SortOrderAsc {
+		bestScore, worstScore = lowestScore, highestScore
 	}
 	
 	return LeaderboardStats{
 		TotalUsers:   len(leaderboard.Entries),
-		AverageScore: float64(totalScore) / float64(len(leaderboard.Entries)),
+		AverageScore: mean,
 		HighestScore: highestScore,
 		LowestScore:  lowestScore,
+		BestScore:    bestScore,
+		WorstScore:   worstScore,
 		ScoreRange:   highestScore - lowestScore,
+		Median:       median,
+		StdDev:       math.Sqrt(squares / float64(len(scores))),
 		LastUpdated:  leaderboard.UpdatedAt,
 	}
 }
@@ -397,6 +1008,9 @@ func (s *LeaderboardService) GetLeaderboardsByType(
 	if err != nil {
 		return nil, fmt.Errorf("failed to get leaderboards: %w", err)
 	}
+	for i, leaderboard := range leaderboards {
+		leaderboards[i] = leaderboard.Clone()
+	}
 	
 	// Cache the result
 	s.cacheRepo.Set(ctx, cacheKey, leaderboards, s.cacheTTL)
@@ -404,13 +1018,57 @@ func (s *LeaderboardService) GetLeaderboardsByType(
 	return leaderboards, nil
 }
 
-// Close closes the leaderboard service
+// Stats returns a snapshot of the leaderboards, update subscriptions and
+// webhooks managed by the service
+func (s *LeaderboardService) Stats() ServiceStats {
+	s.channelMutex.RLock()
+	defer s.channelMutex.RUnlock()
+	
+	stats := ServiceStats{Leaderboards: int(s.boards.Load())}
+	for _, feed := range s.feeds {
+		feedStats := feed.Stats()
+		stats.Subscribers += feedStats.Subscribers
+		stats.DroppedUpdates += feedStats.Dropped
+	}
+	for _, hooks := range s.webhooks {
+		stats.Webhooks += len(hooks)
+	}
+	return stats
+}
+
+// Health reports whether the leaderboard repository is responding
+func (s *LeaderboardService) Health(ctx context.Context) error {
+	if _, err := s.leaderboardRepo.GetByType(ctx, models.LeaderboardTypeGlobal); err != nil {
+		return fmt.Errorf("leaderboard repository: %w", err)
+	}
+	return nil
+}
+
+// Close closes the leaderboard service, stops all webhooks and snapshots and
+// flushes any unwritten changes. It does not close the write-ahead log, which
+// belongs to the caller.
 func (s *LeaderboardService) Close() {
+	s.closeOnce.Do(func() { close(s.stop) })
+	s.background.Wait()
+	
+	if err := s.Flush(context.Background()); err != nil {
+		log.Printf("Final leaderboard flush failed: %v", err)
+	}
+	
 	s.channelMutex.Lock()
-	defer s.channelMutex.Unlock()
+	for _, feed := range s.feeds {
+		feed.Close()
+	}
+	s.feeds = make(map[string]*broadcast.Broadcaster[*LeaderboardUpdate])
 	
-	for _, channel := range s.updateChannels {
-		close(channel)
+	webhooks := s.webhooks
+	s.webhooks = make(map[string][]*webhook)
+	s.channelMutex.Unlock()
+	
+	for _, hooks := range webhooks {
+		for _, hook := range hooks {
+			hook.cancel()
+			<-hook.done
+		}
 	}
-	s.updateChannels = make(map[string]chan *LeaderboardUpdate)
 }
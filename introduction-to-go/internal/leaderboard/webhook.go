@@ -0,0 +1,187 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"effective-golang/pkg/broadcast"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+// keyed with the secret given to RegisterWebhook
+const WebhookSignatureHeader = "X-Leaderboard-Signature"
+
+// ErrInvalidWebhookURL is returned for webhook URLs that are not absolute http(s) URLs
+var ErrInvalidWebhookURL = fmt.Errorf("webhook url must be an absolute http or https url")
+
+// LeaderboardServiceOption configures optional LeaderboardService behaviour
+type LeaderboardServiceOption func(*LeaderboardService)
+
+// WithWebhookRetry sets how many times a webhook delivery is attempted and the
+// backoff before the first retry; the backoff doubles after every failure
+func WithWebhookRetry(attempts int, backoff time.Duration) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.webhookAttempts = attempts
+		s.webhookBackoff = backoff
+	}
+}
+
+// WithWebhookClient replaces the HTTP client used for webhook deliveries
+func WithWebhookClient(client *http.Client) LeaderboardServiceOption {
+	return func(s *LeaderboardService) {
+		s.webhookClient = client
+	}
+}
+
+// webhookSubscription buffers updates for a webhook; when the endpoint falls
+// behind, new updates are dropped rather than holding up other subscribers
+var webhookSubscription = broadcast.Options{Buffer: 100, Policy: broadcast.DropNewest}
+
+// webhook pushes a leaderboard's updates to an HTTP endpoint
+type webhook struct {
+	url     string
+	secret  []byte
+	updates *broadcast.Subscription[*LeaderboardUpdate]
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// RegisterWebhook POSTs every update of a leaderboard to url as JSON, signed
+// with secret in the X-Leaderboard-Signature header. Failed deliveries are
+// retried with exponential backoff and dropped once the attempts run out.
+// Calling the returned cancel func stops delivery and waits for any in-flight
+// request to finish.
+func (s *LeaderboardService) RegisterWebhook(leaderboardID, rawURL, secret string) (func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hook := &webhook{
+		url:    rawURL,
+		secret: []byte(secret),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	s.channelMutex.Lock()
+	feed, exists := s.feeds[leaderboardID]
+	if !exists {
+		s.channelMutex.Unlock()
+		cancel()
+		return nil, fmt.Errorf("leaderboard not found: %w", ErrLeaderboardNotFound)
+	}
+	hook.updates = feed.Subscribe(webhookSubscription)
+	s.webhooks[leaderboardID] = append(s.webhooks[leaderboardID], hook)
+	s.channelMutex.Unlock()
+
+	go s.runWebhook(hook)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.removeWebhook(leaderboardID, hook)
+			hook.updates.Close()
+			hook.cancel()
+			<-hook.done
+		})
+	}, nil
+}
+
+// removeWebhook stops routing updates to hook
+func (s *LeaderboardService) removeWebhook(leaderboardID string, hook *webhook) {
+	s.channelMutex.Lock()
+	defer s.channelMutex.Unlock()
+
+	hooks := s.webhooks[leaderboardID]
+	for i, h := range hooks {
+		if h == hook {
+			s.webhooks[leaderboardID] = append(hooks[:i:i], hooks[i+1:]...)
+			break
+		}
+	}
+	if len(s.webhooks[leaderboardID]) == 0 {
+		delete(s.webhooks, leaderboardID)
+	}
+}
+
+// runWebhook delivers queued updates until the webhook is cancelled or its
+// leaderboard's feed is closed
+func (s *LeaderboardService) runWebhook(hook *webhook) {
+	defer close(hook.done)
+
+	for {
+		select {
+		case update, ok := <-hook.updates.C():
+			if !ok {
+				return
+			}
+			if err := s.deliver(hook, update); err != nil && hook.ctx.Err() == nil {
+				log.Printf("Giving up on webhook delivery to %s: %v", hook.url, err)
+			}
+		case <-hook.ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver POSTs one update, retrying with exponential backoff
+func (s *LeaderboardService) deliver(hook *webhook, update *LeaderboardUpdate) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("failed to encode update: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, hook.secret)
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	backoff := s.webhookBackoff
+	for attempt := 1; ; attempt++ {
+		err = s.post(hook, body, signature)
+		if err == nil || attempt >= s.webhookAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-hook.ctx.Done():
+			return hook.ctx.Err()
+		}
+	}
+}
+
+// post makes a single delivery attempt; any non-2xx response is a failure
+func (s *LeaderboardService) post(hook *webhook, body []byte, signature string) error {
+	req, err := http.NewRequestWithContext(hook.ctx, http.MethodPost, hook.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, signature)
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
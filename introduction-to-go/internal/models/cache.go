@@ -0,0 +1,55 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+// cacheVersionTTL is how long, in seconds, a cache version lives. Losing one
+// only costs cache misses: the data cached under it is never read again.
+const cacheVersionTTL = 24 * 60 * 60
+
+// CacheVersion returns the version stored under key, creating one if there is
+// none. Cached data that must be dropped together, such as every page of a
+// leaderboard, carries the version in its keys, and BumpCacheVersion drops it
+// all by moving to a new version. The version lives in the cache rather than
+// in one server's memory, so every instance sharing the cache sees the bump.
+func CacheVersion(ctx context.Context, cache CacheRepository, key string) (string, error) {
+	var version string
+	err := cache.Get(ctx, key, &version)
+	if err == nil {
+		return version, nil
+	}
+	if !errors.Is(err, ErrCacheMiss) {
+		return "", err
+	}
+
+	version = newCacheVersion()
+	stored, err := cache.SetNX(ctx, key, version, cacheVersionTTL)
+	if err != nil {
+		return "", err
+	}
+	if !stored {
+		// Another caller created the version first; use theirs
+		if err := cache.Get(ctx, key, &version); err != nil {
+			return "", err
+		}
+	}
+	return version, nil
+}
+
+// BumpCacheVersion replaces the version under key, so data cached under the
+// old one is no longer read and expires with its own TTL
+func BumpCacheVersion(ctx context.Context, cache CacheRepository, key string) error {
+	return cache.Set(ctx, key, newCacheVersion(), cacheVersionTTL)
+}
+
+// newCacheVersion returns a random version, so a version that expired and was
+// created again can never bring back data cached under the old one
+func newCacheVersion() string {
+	raw := make([]byte, 8)
+	_, _ = rand.Read(raw)
+	return hex.EncodeToString(raw)
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"math"
 	"sync"
 	"time"
 )
@@ -29,6 +32,15 @@ type Game struct {
 	FinishedAt  *time.Time `json:"finished_at,omitempty" db:"finished_at"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	
+	// Set when a submitted score exceeded what the game's duration makes plausible
+	Suspicious  bool      `json:"suspicious,omitempty" db:"suspicious"`
+	
+	// Mode decides when the game ends besides players ending it; see GameMode
+	Mode        GameModeSpec `json:"mode" db:"mode"`
+	
+	// Clock used for start times and timed modes; time.Now when nil
+	now func() time.Time
+	
 	// Thread-safe access to game state
 	mu sync.RWMutex
 }
@@ -44,12 +56,29 @@ type GameEvent struct {
 	Data      string    `json:"data" db:"data"` // JSON string for additional data
 }
 
+// GameSummary is the compact record kept for a game once it has been archived
+// and its full record and events removed from hot storage
+type GameSummary struct {
+	ID         string        `json:"id" db:"id"`
+	Player1ID  string        `json:"player1_id" db:"player1_id"`
+	Player2ID  string        `json:"player2_id" db:"player2_id"`
+	State      GameState     `json:"state" db:"state"`
+	Score1     int64         `json:"score1" db:"score1"`
+	Score2     int64         `json:"score2" db:"score2"`
+	WinnerID   string        `json:"winner_id,omitempty" db:"winner_id"`
+	Duration   time.Duration `json:"duration" db:"duration"`
+	EventCount int           `json:"event_count" db:"event_count"`
+	FinishedAt time.Time     `json:"finished_at" db:"finished_at"`
+	ArchivedAt time.Time     `json:"archived_at" db:"archived_at"`
+}
+
 // Custom errors for game operations
 var (
 	ErrGameNotFound     = errors.New("game not found")
 	ErrGameAlreadyEnded = errors.New("game already ended")
 	ErrInvalidPlayer    = errors.New("invalid player")
 	ErrGameNotStarted   = errors.New("game not started")
+	ErrScoreOverflow    = errors.New("score would overflow")
 )
 
 // NewGame creates a new game between two players
@@ -71,6 +100,7 @@ func NewGame(player1ID, player2ID string) (*Game, error) {
 		Score1:    0,
 		Score2:    0,
 		CreatedAt: now,
+		Mode:      GameModeSpec{Name: GameModeClassic},
 	}, nil
 }
 
@@ -84,10 +114,26 @@ func (g *Game) Start() error {
 	}
 	
 	g.State = GameStatePlaying
-	g.StartedAt = time.Now()
+	g.StartedAt = g.clock()
 	return nil
 }
 
+// SetClock replaces time.Now for the start time and timed modes, mainly for tests
+func (g *Game) SetClock(now func() time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	g.now = now
+}
+
+// clock returns the current time from the injected clock, if any. Callers hold g.mu.
+func (g *Game) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
 // UpdateScore updates the score for a player
 func (g *Game) UpdateScore(playerID string, score int64) error {
 	g.mu.Lock()
@@ -109,8 +155,40 @@ func (g *Game) UpdateScore(playerID string, score int64) error {
 	return nil
 }
 
+// IncrementScore adds delta to a player's score and returns the new score
+func (g *Game) IncrementScore(playerID string, delta int64) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	if g.State != GameStatePlaying {
+		return 0, ErrGameNotStarted
+	}
+	
+	var score *int64
+	switch playerID {
+	case g.Player1ID:
+		score = &g.Score1
+	case g.Player2ID:
+		score = &g.Score2
+	default:
+		return 0, ErrInvalidPlayer
+	}
+	
+	if (delta > 0 && *score > math.MaxInt64-delta) || (delta < 0 && *score < math.MinInt64-delta) {
+		return 0, ErrScoreOverflow
+	}
+	
+	*score += delta
+	return *score, nil
+}
+
 // End finishes the game and determines the winner
 func (g *Game) End() error {
+	return g.EndAt(time.Now())
+}
+
+// EndAt finishes the game at the given time and determines the winner
+func (g *Game) EndAt(now time.Time) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	
@@ -119,7 +197,6 @@ func (g *Game) End() error {
 	}
 	
 	g.State = GameStateFinished
-	now := time.Now()
 	g.FinishedAt = &now
 	
 	// Determine winner
@@ -135,15 +212,19 @@ func (g *Game) End() error {
 
 // Cancel cancels the game
 func (g *Game) Cancel() error {
+	return g.CancelAt(time.Now())
+}
+
+// CancelAt cancels the game at the given time
+func (g *Game) CancelAt(now time.Time) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 	
-	if g.State == GameStateFinished {
+	if g.State == GameStateFinished || g.State == GameStateCancelled {
 		return ErrGameAlreadyEnded
 	}
 	
 	g.State = GameStateCancelled
-	now := time.Now()
 	g.FinishedAt = &now
 	return nil
 }
@@ -159,6 +240,53 @@ func (g *Game) GetWinner() string {
 	return ""
 }
 
+// PlayingFor returns how long the game has been running at now, or 0 if it
+// has not started
+func (g *Game) PlayingFor(now time.Time) time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	
+	if g.StartedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(g.StartedAt)
+}
+
+// IsPlaying reports whether the game has started and not yet ended
+func (g *Game) IsPlaying() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	
+	return g.State == GameStatePlaying
+}
+
+// PlayingSince returns when the game started, and false if it is not playing
+func (g *Game) PlayingSince() (time.Time, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	
+	if g.State != GameStatePlaying {
+		return time.Time{}, false
+	}
+	return g.StartedAt, true
+}
+
+// FlagSuspicious marks the game as having an implausible score
+func (g *Game) FlagSuspicious() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	
+	g.Suspicious = true
+}
+
+// IsSuspicious reports whether the game has been flagged for an implausible score
+func (g *Game) IsSuspicious() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	
+	return g.Suspicious
+}
+
 // IsPlayer checks if the given user ID is a player in this game
 func (g *Game) IsPlayer(userID string) bool {
 	g.mu.RLock()
@@ -214,7 +342,37 @@ func (g *Game) GetScore(playerID string) (int64, error) {
 	}
 }
 
+// Summarize builds the compact archive record for a finished or cancelled game
+func (g *Game) Summarize(eventCount int, archivedAt time.Time) *GameSummary {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	
+	summary := &GameSummary{
+		ID:         g.ID,
+		Player1ID:  g.Player1ID,
+		Player2ID:  g.Player2ID,
+		State:      g.State,
+		Score1:     g.Score1,
+		Score2:     g.Score2,
+		EventCount: eventCount,
+		ArchivedAt: archivedAt,
+	}
+	if g.WinnerID != nil {
+		summary.WinnerID = *g.WinnerID
+	}
+	if g.FinishedAt != nil {
+		summary.FinishedAt = *g.FinishedAt
+		if !g.StartedAt.IsZero() {
+			summary.Duration = g.FinishedAt.Sub(g.StartedAt)
+		}
+	}
+	return summary
+}
+
 // Helper function to generate game ID
 func generateGameID() string {
-	return "game_" + time.Now().Format("20060102150405")
+	// Random suffix keeps IDs unique when several games are created in the same second
+	raw := make([]byte, 4)
+	_, _ = rand.Read(raw)
+	return "game_" + time.Now().Format("20060102150405") + "_" + hex.EncodeToString(raw)
 }
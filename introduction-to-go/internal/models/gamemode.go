@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Built-in game mode names
+const (
+	GameModeClassic     = "classic"      // ends only when a player ends it
+	GameModeTimed       = "timed"        // ends once it has been playing for Duration
+	GameModeTargetScore = "target-score" // ends once a player reaches Target
+	GameModeBestOf      = "best-of"      // scores count rounds won; ends once a player wins a majority of Rounds
+)
+
+// Game mode errors
+var (
+	ErrUnknownGameMode = errors.New("unknown game mode")
+	ErrInvalidGameMode = errors.New("invalid game mode settings")
+)
+
+// GameMode decides when a playing game is over, on top of players ending it
+// themselves. Implementations must be safe for concurrent use.
+type GameMode interface {
+	// ShouldEnd reports whether the game has met the mode's end condition
+	ShouldEnd(game *Game) bool
+}
+
+// GameModeSpec selects a game's mode and its settings. Only the settings the
+// mode uses need to be set.
+type GameModeSpec struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration,omitempty"` // timed
+	Target   int64         `json:"target,omitempty"`   // target-score
+	Rounds   int           `json:"rounds,omitempty"`   // best-of
+}
+
+// GameModeFactory builds a mode from its spec, rejecting invalid settings
+type GameModeFactory func(spec GameModeSpec) (GameMode, error)
+
+var (
+	gameModesMu sync.RWMutex
+	gameModes   = map[string]GameModeFactory{
+		GameModeClassic:     func(GameModeSpec) (GameMode, error) { return classicMode{}, nil },
+		GameModeTimed:       newTimedMode,
+		GameModeTargetScore: newTargetScoreMode,
+		GameModeBestOf:      newBestOfMode,
+	}
+)
+
+// RegisterGameMode adds a game mode, or replaces the one registered under name
+func RegisterGameMode(name string, factory GameModeFactory) {
+	gameModesMu.Lock()
+	defer gameModesMu.Unlock()
+	gameModes[name] = factory
+}
+
+// NewGameMode builds the mode a spec selects. An empty name is classic.
+func NewGameMode(spec GameModeSpec) (GameMode, error) {
+	if spec.Name == "" {
+		spec.Name = GameModeClassic
+	}
+
+	gameModesMu.RLock()
+	factory, ok := gameModes[spec.Name]
+	gameModesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownGameMode, spec.Name)
+	}
+	return factory(spec)
+}
+
+type classicMode struct{}
+
+func (classicMode) ShouldEnd(*Game) bool { return false }
+
+// timedMode ends a game once it has been playing for duration
+type timedMode struct {
+	duration time.Duration
+}
+
+func newTimedMode(spec GameModeSpec) (GameMode, error) {
+	if spec.Duration <= 0 {
+		return nil, fmt.Errorf("%w: timed mode needs a positive duration", ErrInvalidGameMode)
+	}
+	return timedMode{duration: spec.Duration}, nil
+}
+
+func (m timedMode) ShouldEnd(game *Game) bool {
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+
+	return game.State == GameStatePlaying && game.clock().Sub(game.StartedAt) >= m.duration
+}
+
+// targetScoreMode ends a game once either player reaches target
+type targetScoreMode struct {
+	target int64
+}
+
+func newTargetScoreMode(spec GameModeSpec) (GameMode, error) {
+	if spec.Target <= 0 {
+		return nil, fmt.Errorf("%w: target-score mode needs a positive target", ErrInvalidGameMode)
+	}
+	return targetScoreMode{target: spec.Target}, nil
+}
+
+func (m targetScoreMode) ShouldEnd(game *Game) bool {
+	game.mu.RLock()
+	defer game.mu.RUnlock()
+
+	return game.State == GameStatePlaying && (game.Score1 >= m.target || game.Score2 >= m.target)
+}
+
+// newBestOfMode treats scores as rounds won, so the game is over once a
+// player has won more than half of the rounds
+func newBestOfMode(spec GameModeSpec) (GameMode, error) {
+	if spec.Rounds <= 0 {
+		return nil, fmt.Errorf("%w: best-of mode needs a positive number of rounds", ErrInvalidGameMode)
+	}
+	return targetScoreMode{target: int64(spec.Rounds/2 + 1)}, nil
+}
@@ -1,10 +1,17 @@
 package models
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"maps"
+	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // LeaderboardType represents different types of leaderboards
@@ -17,6 +24,31 @@ const (
 	LeaderboardTypeSeasonal  LeaderboardType = "seasonal"
 )
 
+// SortOrder is the direction a leaderboard ranks scores in
+type SortOrder string
+
+const (
+	// SortOrderDesc ranks the highest score first, the default
+	SortOrderDesc SortOrder = "desc"
+	// SortOrderAsc ranks the lowest score first, for things like race times
+	SortOrderAsc SortOrder = "asc"
+)
+
+// PeriodStart returns when the period containing now began for leaderboards
+// that reset: Monday 00:00 for weekly boards and the 1st of the month for
+// monthly ones, in now's location. Other types never reset and report false.
+func (t LeaderboardType) PeriodStart(now time.Time) (time.Time, bool) {
+	year, month, day := now.Date()
+	switch t {
+	case LeaderboardTypeWeekly:
+		sinceMonday := (int(now.Weekday()) + 6) % 7
+		return time.Date(year, month, day-sinceMonday, 0, 0, 0, 0, now.Location()), true
+	case LeaderboardTypeMonthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location()), true
+	}
+	return time.Time{}, false
+}
+
 // LeaderboardEntry represents a single entry in the leaderboard
 type LeaderboardEntry struct {
 	UserID    string    `json:"user_id" db:"user_id"`
@@ -24,6 +56,14 @@ type LeaderboardEntry struct {
 	Score     int64     `json:"score" db:"score"`
 	Rank      int       `json:"rank" db:"rank"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	
+	// EffectiveScore is the decayed score entries are ranked by on boards with a
+	// half-life; Score keeps the raw value for display
+	EffectiveScore float64 `json:"effective_score,omitempty" db:"-"`
+	
+	// Metadata is optional context for the score, such as the game that
+	// produced it or the player's region. It plays no part in ranking.
+	Metadata map[string]string `json:"metadata,omitempty" db:"metadata"`
 }
 
 // Leaderboard represents a leaderboard with entries
@@ -32,20 +72,40 @@ type Leaderboard struct {
 	Name        string           `json:"name" db:"name"`
 	Type        LeaderboardType  `json:"type" db:"type"`
 	Entries     []LeaderboardEntry `json:"entries" db:"entries"`
-	MaxEntries  int              `json:"max_entries" db:"max_entries"`
+	MaxEntries  int              `json:"max_entries" db:"max_entries"` // <= 0 means unlimited
+	SortOrder   SortOrder        `json:"sort_order" db:"sort_order"`
+	HalfLife    time.Duration    `json:"half_life,omitempty" db:"half_life"` // > 0 enables score decay
+	MinUpdateDelta int64         `json:"min_update_delta,omitempty" db:"min_update_delta"` // > 0 coalesces small score updates
 	CreatedAt   time.Time        `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time        `json:"updated_at" db:"updated_at"`
 	
+	// Clock used for entry ages; time.Now when nil
+	now func() time.Time
+	
 	// Thread-safe access to leaderboard data
 	mu sync.RWMutex
 }
 
+// LeaderboardArchive is the final standings of one leaderboard period, kept
+// when a periodic leaderboard is reset
+type LeaderboardArchive struct {
+	LeaderboardID string             `json:"leaderboard_id" db:"leaderboard_id"`
+	Name          string             `json:"name" db:"name"`
+	Type          LeaderboardType    `json:"type" db:"type"`
+	PeriodStart   time.Time          `json:"period_start" db:"period_start"`
+	PeriodEnd     time.Time          `json:"period_end" db:"period_end"`
+	Entries       []LeaderboardEntry `json:"entries" db:"entries"`
+	ArchivedAt    time.Time          `json:"archived_at" db:"archived_at"`
+}
+
 // LeaderboardStats contains statistics about the leaderboard
 type LeaderboardStats struct {
 	TotalEntries    int     `json:"total_entries"`
 	AverageScore    float64 `json:"average_score"`
 	HighestScore    int64   `json:"highest_score"`
 	LowestScore     int64   `json:"lowest_score"`
+	BestScore       int64   `json:"best_score"`  // the highest score, or the lowest on an ascending board
+	WorstScore      int64   `json:"worst_score"` // the opposite end from BestScore
 	LastUpdated     time.Time `json:"last_updated"`
 }
 
@@ -55,10 +115,22 @@ var (
 	ErrInvalidScore        = errors.New("invalid score")
 	ErrUserNotFoundInLeaderboard = errors.New("user not found in leaderboard")
 	ErrLeaderboardFull     = errors.New("leaderboard is full")
+	ErrLeaderboardExists   = errors.New("leaderboard already exists")
 )
 
-// NewLeaderboard creates a new leaderboard
+// NewLeaderboard creates a new leaderboard that ranks the highest score first.
+// A maxEntries of 0 (or less) means the leaderboard is unlimited and never
+// evicts entries.
 func NewLeaderboard(name string, leaderboardType LeaderboardType, maxEntries int) *Leaderboard {
+	return NewLeaderboardWithOrder(name, leaderboardType, maxEntries, SortOrderDesc)
+}
+
+// NewLeaderboardWithOrder is NewLeaderboard with the direction scores are
+// ranked in; an empty order means SortOrderDesc
+func NewLeaderboardWithOrder(name string, leaderboardType LeaderboardType, maxEntries int, order SortOrder) *Leaderboard {
+	if order == "" {
+		order = SortOrderDesc
+	}
 	now := time.Now()
 	return &Leaderboard{
 		ID:         generateLeaderboardID(),
@@ -66,13 +138,117 @@ func NewLeaderboard(name string, leaderboardType LeaderboardType, maxEntries int
 		Type:       leaderboardType,
 		Entries:    make([]LeaderboardEntry, 0),
 		MaxEntries: maxEntries,
+		SortOrder:  order,
 		CreatedAt:  now,
 		UpdatedAt:  now,
 	}
 }
 
-// AddEntry adds or updates an entry in the leaderboard
+// Clone returns a deep copy of the leaderboard that shares no state with it
+func (l *Leaderboard) Clone() *Leaderboard {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	
+	entries := make([]LeaderboardEntry, len(l.Entries))
+	copy(entries, l.Entries)
+	for i := range entries {
+		entries[i].Metadata = maps.Clone(entries[i].Metadata)
+	}
+	return &Leaderboard{
+		ID:             l.ID,
+		Name:           l.Name,
+		Type:           l.Type,
+		Entries:        entries,
+		MaxEntries:     l.MaxEntries,
+		SortOrder:      l.SortOrder,
+		HalfLife:       l.HalfLife,
+		MinUpdateDelta: l.MinUpdateDelta,
+		CreatedAt:      l.CreatedAt,
+		UpdatedAt:      l.UpdatedAt,
+		now:            l.now,
+	}
+}
+
+// SetDecay makes scores decay over time so recent activity outranks stale high
+// scores. An entry's effective score is score * 0.5^(age/halfLife), where age is
+// the time since the entry was last updated; it halves every halfLife. Ranking
+// uses the effective score, computed at read time, while Score keeps the raw
+// value. A halfLife of 0 turns decay off.
+func (l *Leaderboard) SetDecay(halfLife time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	l.HalfLife = halfLife
+	l.sortAndUpdateRanks()
+}
+
+// SetMinUpdateDelta sets how far a user's score must move before a real-time
+// update is emitted for it; rank changes are always emitted. 0 emits every change.
+func (l *Leaderboard) SetMinUpdateDelta(delta int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	l.MinUpdateDelta = delta
+}
+
+// GetMinUpdateDelta returns the minimum score change that emits an update
+func (l *Leaderboard) GetMinUpdateDelta() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	
+	return l.MinUpdateDelta
+}
+
+// SetClock replaces time.Now for entry timestamps and decay, mainly for tests
+func (l *Leaderboard) SetClock(now func() time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	l.now = now
+}
+
+// clock returns the current time from the injected clock, if any
+func (l *Leaderboard) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// SanitizeUsername makes a username safe to store in leaderboard entries and show
+// in downstream displays. Control and invisible formatting characters (such as
+// newlines or right-to-left overrides) are removed, runs of whitespace collapse to
+// a single space and the result is trimmed. Escaping for a specific output format,
+// like Slack mrkdwn, is left to the code rendering it.
+func SanitizeUsername(username string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, username)
+	
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// AddEntry adds or updates an entry in the leaderboard. The username is
+// sanitized with SanitizeUsername; if nothing is left, the user ID is shown instead.
 func (l *Leaderboard) AddEntry(userID, username string, score int64) error {
+	return l.AddEntryWithMetadata(userID, username, score, nil)
+}
+
+// AddEntryWithMetadata is AddEntry with metadata attached to the entry. A copy
+// of metadata is stored; nil leaves an existing entry's metadata unchanged.
+func (l *Leaderboard) AddEntryWithMetadata(userID, username string, score int64, metadata map[string]string) error {
+	return l.AddEntryAt(userID, username, score, metadata, time.Time{})
+}
+
+// AddEntryAt is AddEntryWithMetadata with the time the score was set, so a
+// restored entry keeps its age for decay. A zero updatedAt means now.
+func (l *Leaderboard) AddEntryAt(userID, username string, score int64, metadata map[string]string, updatedAt time.Time) error {
 	if score < 0 {
 		return ErrInvalidScore
 	}
@@ -80,27 +256,80 @@ func (l *Leaderboard) AddEntry(userID, username string, score int64) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	
+	return l.addEntry(userID, username, score, metadata, updatedAt)
+}
+
+// AddEntries adds or updates several entries as one change, in order, under a
+// single lock. Either every entry is applied or, when one fails, none are and
+// the board is left as it was. Each entry's UpdatedAt is kept, or now if zero.
+func (l *Leaderboard) AddEntries(entries []LeaderboardEntry) error {
+	for _, entry := range entries {
+		if entry.Score < 0 {
+			return fmt.Errorf("user %s: %w", entry.UserID, ErrInvalidScore)
+		}
+	}
+	
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	// Metadata maps are replaced, never modified, so copying the entries is
+	// enough to roll back to
+	saved := make([]LeaderboardEntry, len(l.Entries))
+	copy(saved, l.Entries)
+	savedUpdatedAt := l.UpdatedAt
+	
+	for _, entry := range entries {
+		if err := l.addEntry(entry.UserID, entry.Username, entry.Score, entry.Metadata, entry.UpdatedAt); err != nil {
+			l.Entries = saved
+			l.UpdatedAt = savedUpdatedAt
+			return fmt.Errorf("user %s: %w", entry.UserID, err)
+		}
+	}
+	return nil
+}
+
+// addEntry adds or updates one entry, set at updatedAt or now if it is zero.
+// The caller holds l.mu for writing.
+func (l *Leaderboard) addEntry(userID, username string, score int64, metadata map[string]string, updatedAt time.Time) error {
+	if username = SanitizeUsername(username); username == "" {
+		username = userID
+	}
+	if updatedAt.IsZero() {
+		updatedAt = l.clock()
+	}
+	
 	// Check if user already exists
 	for i, entry := range l.Entries {
 		if entry.UserID == userID {
 			// Update existing entry
 			l.Entries[i].Score = score
 			l.Entries[i].Username = username
-			l.Entries[i].UpdatedAt = time.Now()
+			l.Entries[i].UpdatedAt = updatedAt
+			if metadata != nil {
+				l.Entries[i].Metadata = maps.Clone(metadata)
+			}
 			l.sortAndUpdateRanks()
 			l.UpdatedAt = time.Now()
 			return nil
 		}
 	}
 	
-	// Add new entry
-	if len(l.Entries) >= l.MaxEntries {
-		// Check if new score is higher than lowest score
-		if len(l.Entries) > 0 && score <= l.Entries[len(l.Entries)-1].Score {
+	// Add new entry, evicting the worst-ranked entry if the board is capped and full
+	if l.MaxEntries > 0 && len(l.Entries) >= l.MaxEntries {
+		// Check if new score outranks the worst one; a restored entry may have decayed already.
+		// On a decaying board the stored order is from the last write, so re-rank as of now
+		// first, or the entry compared against and evicted may no longer be the worst.
+		candidate := float64(score)
+		if l.HalfLife > 0 {
+			now := l.clock()
+			l.rankEntries(l.Entries, now)
+			candidate = l.decayedScore(LeaderboardEntry{Score: score, UpdatedAt: updatedAt}, now)
+		}
+		if len(l.Entries) > 0 && !l.outranks(candidate, l.rankScore(l.Entries[len(l.Entries)-1])) {
 			return ErrLeaderboardFull
 		}
 		
-		// Remove lowest score entry
+		// Remove worst-ranked entry
 		l.Entries = l.Entries[:len(l.Entries)-1]
 	}
 	
@@ -108,7 +337,8 @@ func (l *Leaderboard) AddEntry(userID, username string, score int64) error {
 		UserID:    userID,
 		Username:  username,
 		Score:     score,
-		UpdatedAt: time.Now(),
+		UpdatedAt: updatedAt,
+		Metadata:  maps.Clone(metadata),
 	}
 	
 	l.Entries = append(l.Entries, newEntry)
@@ -123,7 +353,7 @@ func (l *Leaderboard) GetUserRank(userID string) (int, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	
-	for _, entry := range l.Entries {
+	for _, entry := range l.ranked() {
 		if entry.UserID == userID {
 			return entry.Rank, nil
 		}
@@ -132,22 +362,31 @@ func (l *Leaderboard) GetUserRank(userID string) (int, error) {
 	return 0, ErrUserNotFoundInLeaderboard
 }
 
-// GetTopEntries returns the top N entries from the leaderboard
-func (l *Leaderboard) GetTopEntries(count int) []LeaderboardEntry {
+// GetTopEntries returns up to count ranked entries starting at offset, along
+// with the total number of entries on the board. When includeTies is set,
+// entries tied with the last one returned are included too, so the result can
+// be longer than count; the next page then starts at offset+len(entries).
+func (l *Leaderboard) GetTopEntries(offset, count int, includeTies bool) ([]LeaderboardEntry, int) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	
-	if count <= 0 {
-		return []LeaderboardEntry{}
+	entries := l.ranked()
+	total := len(entries)
+	if count <= 0 || offset < 0 || offset >= total {
+		return []LeaderboardEntry{}, total
 	}
 	
-	if count > len(l.Entries) {
-		count = len(l.Entries)
+	end := offset + min(count, total-offset)
+	if includeTies {
+		cutoff := l.rankScore(entries[end-1])
+		for end < total && l.rankScore(entries[end]) == cutoff {
+			end++
+		}
 	}
 	
-	result := make([]LeaderboardEntry, count)
-	copy(result, l.Entries[:count])
-	return result
+	result := make([]LeaderboardEntry, end-offset)
+	copy(result, entries[offset:end])
+	return result, total
 }
 
 // GetUserEntry returns the entry for a specific user
@@ -155,7 +394,7 @@ func (l *Leaderboard) GetUserEntry(userID string) (*LeaderboardEntry, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	
-	for _, entry := range l.Entries {
+	for _, entry := range l.ranked() {
 		if entry.UserID == userID {
 			return &entry, nil
 		}
@@ -164,6 +403,53 @@ func (l *Leaderboard) GetUserEntry(userID string) (*LeaderboardEntry, error) {
 	return nil, ErrUserNotFoundInLeaderboard
 }
 
+// GetEntriesAround returns the user's entry with up to radius entries ranked
+// either side of it, in rank order. Near the top or bottom of the board the
+// window is cut short rather than shifted.
+func (l *Leaderboard) GetEntriesAround(userID string, radius int) ([]LeaderboardEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	
+	radius = max(radius, 0)
+	entries := l.ranked()
+	for i, entry := range entries {
+		if entry.UserID != userID {
+			continue
+		}
+		start := max(i-radius, 0)
+		end := min(i+radius+1, len(entries))
+		
+		result := make([]LeaderboardEntry, end-start)
+		copy(result, entries[start:end])
+		return result, nil
+	}
+	
+	return nil, ErrUserNotFoundInLeaderboard
+}
+
+// Percentile returns the percentage of entries that the user ranks strictly
+// ahead of, counting the user's own entry in the total
+func (l *Leaderboard) Percentile(userID string) (float64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	
+	entries := l.ranked()
+	for _, entry := range entries {
+		if entry.UserID != userID {
+			continue
+		}
+		behind := 0
+		for _, other := range entries {
+			if other.Rank > entry.Rank {
+				behind++
+			}
+		}
+		return float64(behind) / float64(len(entries)) * 100, nil
+	}
+	
+	return 0, ErrUserNotFoundInLeaderboard
+}
+
 // GetStats returns statistics about the leaderboard
 func (l *Leaderboard) GetStats() *LeaderboardStats {
 	l.mu.RLock()
@@ -179,12 +465,24 @@ func (l *Leaderboard) GetStats() *LeaderboardStats {
 		}
 	}
 	
+	// Entries are ordered by effective score, so raw extremes need a scan when decay is on
 	var totalScore int64
 	highestScore := l.Entries[0].Score
-	lowestScore := l.Entries[len(l.Entries)-1].Score
+	lowestScore := l.Entries[0].Score
 	
 	for _, entry := range l.Entries {
 		totalScore += entry.Score
+		if entry.Score > highestScore {
+			highestScore = entry.Score
+		}
+		if entry.Score < lowestScore {
+			lowestScore = entry.Score
+		}
+	}
+	
+	bestScore, worstScore := highestScore, lowestScore
+	if l.SortOrder == SortOrderAsc {
+		bestScore, worstScore = lowestScore, highestScore
 	}
 	
 	return &LeaderboardStats{
@@ -192,17 +490,22 @@ func (l *Leaderboard) GetStats() *LeaderboardStats {
 		AverageScore: float64(totalScore) / float64(len(l.Entries)),
 		HighestScore: highestScore,
 		LowestScore:  lowestScore,
+		BestScore:    bestScore,
+		WorstScore:   worstScore,
 		LastUpdated:  l.UpdatedAt,
 	}
 }
 
-// Clear removes all entries from the leaderboard
-func (l *Leaderboard) Clear() {
+// Clear removes all entries from the leaderboard and returns them in rank order
+func (l *Leaderboard) Clear() []LeaderboardEntry {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	
+	// The old slice is dropped, so it can be handed out as is
+	removed := l.ranked()
 	l.Entries = make([]LeaderboardEntry, 0)
 	l.UpdatedAt = time.Now()
+	return removed
 }
 
 // RemoveUser removes a user from the leaderboard
@@ -223,20 +526,84 @@ func (l *Leaderboard) RemoveUser(userID string) error {
 	return ErrUserNotFoundInLeaderboard
 }
 
-// sortAndUpdateRanks sorts entries by score (descending) and updates ranks
+// sortAndUpdateRanks sorts entries by score in the board's order and updates ranks
+// using standard competition ranking: tied scores share a rank and the next
+// rank skips ahead (1, 2, 2, 4)
 func (l *Leaderboard) sortAndUpdateRanks() {
-	// Sort by score in descending order
-	sort.Slice(l.Entries, func(i, j int) bool {
-		return l.Entries[i].Score > l.Entries[j].Score
+	l.rankEntries(l.Entries, l.clock())
+}
+
+// ranked returns the entries in rank order. With decay on, effective scores
+// change as time passes, so a freshly ranked copy is returned; otherwise the
+// stored order is already current.
+func (l *Leaderboard) ranked() []LeaderboardEntry {
+	if l.HalfLife <= 0 {
+		return l.Entries
+	}
+	
+	entries := make([]LeaderboardEntry, len(l.Entries))
+	copy(entries, l.Entries)
+	l.rankEntries(entries, l.clock())
+	return entries
+}
+
+// rankEntries computes effective scores as of now, sorts entries by them in
+// the board's order (keeping earlier entries first on ties) and assigns ranks
+func (l *Leaderboard) rankEntries(entries []LeaderboardEntry, now time.Time) {
+	if l.HalfLife > 0 {
+		for i := range entries {
+			entries[i].EffectiveScore = l.decayedScore(entries[i], now)
+		}
+	} else {
+		for i := range entries {
+			entries[i].EffectiveScore = 0
+		}
+	}
+	
+	sort.SliceStable(entries, func(i, j int) bool {
+		return l.outranks(l.rankScore(entries[i]), l.rankScore(entries[j]))
 	})
 	
-	// Update ranks
-	for i := range l.Entries {
-		l.Entries[i].Rank = i + 1
+	for i := range entries {
+		if i > 0 && l.rankScore(entries[i]) == l.rankScore(entries[i-1]) {
+			entries[i].Rank = entries[i-1].Rank
+			continue
+		}
+		entries[i].Rank = i + 1
+	}
+}
+
+// rankScore is the score an entry is ranked by: its effective score on a
+// decaying board, its raw score otherwise
+func (l *Leaderboard) rankScore(entry LeaderboardEntry) float64 {
+	if l.HalfLife > 0 {
+		return entry.EffectiveScore
+	}
+	return float64(entry.Score)
+}
+
+// outranks reports whether score a ranks strictly ahead of score b in the
+// board's sort order
+func (l *Leaderboard) outranks(a, b float64) bool {
+	if l.SortOrder == SortOrderAsc {
+		return a < b
+	}
+	return a > b
+}
+
+// decayedScore returns the entry's score halved for every HalfLife since it was updated
+func (l *Leaderboard) decayedScore(entry LeaderboardEntry, now time.Time) float64 {
+	age := now.Sub(entry.UpdatedAt)
+	if age < 0 {
+		age = 0
 	}
+	return float64(entry.Score) * math.Pow(0.5, float64(age)/float64(l.HalfLife))
 }
 
 // Helper function to generate leaderboard ID
 func generateLeaderboardID() string {
-	return "lb_" + time.Now().Format("20060102150405")
+	// Random suffix keeps IDs unique when several leaderboards are created in the same second
+	raw := make([]byte, 4)
+	_, _ = rand.Read(raw)
+	return "lb_" + time.Now().Format("20060102150405") + "_" + hex.EncodeToString(raw)
 }
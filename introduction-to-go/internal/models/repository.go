@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // Repository interfaces demonstrate the repository pattern
@@ -28,7 +29,7 @@ type UserRepository interface {
 	// Delete removes a user
 	Delete(ctx context.Context, id string) error
 	
-	// List retrieves users with pagination
+	// List retrieves users with pagination, oldest first
 	List(ctx context.Context, offset, limit int) ([]*User, error)
 	
 	// GetStats retrieves user statistics
@@ -52,10 +53,10 @@ type GameRepository interface {
 	// Delete removes a game
 	Delete(ctx context.Context, id string) error
 	
-	// GetUserGames retrieves games for a specific user
+	// GetUserGames retrieves up to limit games for a specific user, newest first
 	GetUserGames(ctx context.Context, userID string, limit int) ([]*Game, error)
 	
-	// GetActiveGames retrieves active games
+	// GetActiveGames retrieves active games, oldest first
 	GetActiveGames(ctx context.Context) ([]*Game, error)
 	
 	// AddEvent adds a game event
@@ -63,11 +64,20 @@ type GameRepository interface {
 	
 	// GetGameEvents retrieves events for a game
 	GetGameEvents(ctx context.Context, gameID string) ([]*GameEvent, error)
+	
+	// GetEndedGames retrieves finished or cancelled games that ended before the given time
+	GetEndedGames(ctx context.Context, before time.Time) ([]*Game, error)
+	
+	// Archive stores a game summary and removes the full game and its events
+	Archive(ctx context.Context, summary *GameSummary) error
+	
+	// GetSummary retrieves the summary of an archived game
+	GetSummary(ctx context.Context, id string) (*GameSummary, error)
 }
 
 // LeaderboardRepository defines operations for leaderboard data access
 type LeaderboardRepository interface {
-	// Create creates a new leaderboard
+	// Create creates a new leaderboard, failing with ErrLeaderboardExists if the name is taken
 	Create(ctx context.Context, leaderboard *Leaderboard) error
 	
 	// GetByID retrieves a leaderboard by ID
@@ -82,7 +92,7 @@ type LeaderboardRepository interface {
 	// Delete removes a leaderboard
 	Delete(ctx context.Context, id string) error
 	
-	// List retrieves leaderboards with pagination
+	// List retrieves leaderboards with pagination, oldest first
 	List(ctx context.Context, offset, limit int) ([]*Leaderboard, error)
 	
 	// GetByType retrieves leaderboards by type
@@ -91,14 +101,26 @@ type LeaderboardRepository interface {
 	// AddEntry adds an entry to a leaderboard
 	AddEntry(ctx context.Context, leaderboardID string, entry *LeaderboardEntry) error
 	
+	// AddEntries adds several entries to a leaderboard as one change: if any
+	// fails, none are added
+	AddEntries(ctx context.Context, leaderboardID string, entries []*LeaderboardEntry) error
+	
 	// RemoveEntry removes an entry from a leaderboard
 	RemoveEntry(ctx context.Context, leaderboardID, userID string) error
 	
-	// GetTopEntries retrieves top entries from a leaderboard
-	GetTopEntries(ctx context.Context, leaderboardID string, count int) ([]*LeaderboardEntry, error)
+	// GetTopEntries retrieves a page of ranked entries starting at offset,
+	// optionally including ties with the last entry, and the total number of
+	// entries on the leaderboard
+	GetTopEntries(ctx context.Context, leaderboardID string, offset, count int, includeTies bool) ([]*LeaderboardEntry, int, error)
 	
 	// GetUserRank retrieves a user's rank in a leaderboard
 	GetUserRank(ctx context.Context, leaderboardID, userID string) (int, error)
+	
+	// Archive stores the final standings of a leaderboard period
+	Archive(ctx context.Context, archive *LeaderboardArchive) error
+	
+	// GetArchives retrieves a leaderboard's archived periods, oldest first
+	GetArchives(ctx context.Context, leaderboardID string) ([]*LeaderboardArchive, error)
 }
 
 // Custom errors for cache operations
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -36,8 +37,43 @@ var (
 	ErrInvalidEmail      = errors.New("invalid email")
 	ErrInvalidPassword   = errors.New("password too short")
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrAmbiguousUsername = errors.New("username matches several users")
 )
 
+// UsernameFallback decides what a case-insensitive username lookup does when
+// several users differ only in the case of their username, as can happen with
+// accounts created before lookups ignored case
+type UsernameFallback string
+
+const (
+	// UsernameFallbackExact returns the user whose username matches exactly,
+	// and ErrAmbiguousUsername when none does
+	UsernameFallbackExact UsernameFallback = "exact"
+	// UsernameFallbackError always returns ErrAmbiguousUsername
+	UsernameFallbackError UsernameFallback = "error"
+)
+
+// MatchUsername picks the user a lookup for username resolves to among the
+// users whose usernames equal it ignoring case. Repositories use it so every
+// implementation resolves collisions the same way.
+func MatchUsername(candidates []*User, username string, fallback UsernameFallback) (*User, error) {
+	switch len(candidates) {
+	case 0:
+		return nil, ErrUserNotFound
+	case 1:
+		return candidates[0], nil
+	}
+	
+	if fallback != UsernameFallbackError {
+		for _, user := range candidates {
+			if user.Username == username {
+				return user, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %q has %d case-insensitive matches", ErrAmbiguousUsername, username, len(candidates))
+}
+
 // NewUser creates a new user with validation
 func NewUser(username, email, password string) (*User, error) {
 	if err := validateUsername(username); err != nil {
@@ -64,6 +100,28 @@ func NewUser(username, email, password string) (*User, error) {
 	}, nil
 }
 
+// SetPassword validates and replaces the user's password
+func (u *User) SetPassword(password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+	
+	u.Password = hashPassword(password)
+	u.UpdatedAt = time.Now()
+	return nil
+}
+
+// CheckPassword reports whether password matches the user's password
+func (u *User) CheckPassword(password string) bool {
+	return u.Password == hashPassword(password)
+}
+
+// Deactivate disables the account so it can no longer log in
+func (u *User) Deactivate() {
+	u.IsActive = false
+	u.UpdatedAt = time.Now()
+}
+
 // GetWinRate calculates and returns the user's win rate
 func (u *UserStats) GetWinRate() float64 {
 	if u.TotalGames == 0 {
@@ -0,0 +1,175 @@
+// Package wal provides an append-only write-ahead log of JSON records with
+// snapshot support, so in-memory state can be rebuilt after a crash.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxRecordSize bounds a single log line when replaying
+const maxRecordSize = 1 << 20
+
+// Log is an append-only file of JSON records, one per line. Every append is
+// synced to disk before it returns. A snapshot of the full state can be
+// written next to the log (at path + ".snapshot"); doing so truncates the
+// log, since the snapshot already covers every record in it.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Open opens the log at path, creating it if needed
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	return &Log{path: path, file: file}, nil
+}
+
+// Append writes record as a JSON line and syncs it to disk
+func (l *Log) Append(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode log record: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(data); err != nil {
+		return fmt.Errorf("failed to append log record: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+	return nil
+}
+
+// Replay calls fn with every record in the log, oldest first, and returns how
+// many were replayed. A final line without a trailing newline is a write that
+// was cut short by a crash; it is skipped and truncated away so later appends
+// start on a clean line.
+func (l *Log) Replay(fn func(record json.RawMessage) error) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to rewind log: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(l.file, maxRecordSize)
+	replayed := 0
+	var offset int64
+	for {
+		line, err := reader.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return replayed, fmt.Errorf("log record %d exceeds %d bytes", replayed+1, maxRecordSize)
+		}
+		if err == io.EOF {
+			if len(line) > 0 {
+				if err := l.file.Truncate(offset); err != nil {
+					return replayed, fmt.Errorf("failed to drop torn log record: %w", err)
+				}
+			}
+			return replayed, nil
+		}
+		if err != nil {
+			return replayed, fmt.Errorf("failed to read log: %w", err)
+		}
+		offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(json.RawMessage(line)); err != nil {
+			return replayed, fmt.Errorf("failed to replay log record %d: %w", replayed+1, err)
+		}
+		replayed++
+	}
+}
+
+// WriteSnapshot atomically replaces the snapshot with state and then
+// truncates the log. The caller must make sure no records are appended
+// between capturing state and this call returning.
+func (l *Log) WriteSnapshot(state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tmp := l.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, l.snapshotPath()); err != nil {
+		return fmt.Errorf("failed to install snapshot: %w", err)
+	}
+
+	// Only drop the log once the snapshot covering it is safely on disk
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+	return nil
+}
+
+// ReadSnapshot decodes the latest snapshot into state. It reports false when
+// no snapshot has been written yet.
+func (l *Log) ReadSnapshot(state interface{}) (bool, error) {
+	data, err := os.ReadFile(l.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return false, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return true, nil
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// snapshotPath is where the snapshot for this log lives
+func (l *Log) snapshotPath() string {
+	return l.path + ".snapshot"
+}
+
+// writeFileSync writes data to path and syncs it before closing
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
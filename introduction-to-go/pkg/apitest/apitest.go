@@ -0,0 +1,146 @@
+// Package apitest provides a small harness for table-driven HTTP handler tests.
+// It sends a request through a handler (usually the full router) and decodes
+// the standard response envelope written by pkg/utils.
+package apitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Request describes a request to send through a handler
+type Request struct {
+	Method  string
+	Path    string
+	Body    interface{} // encoded as JSON unless it is a string or []byte
+	Headers map[string]string
+}
+
+// Envelope mirrors the JSON envelope written by the utils response helpers
+type Envelope struct {
+	Success bool              `json:"success"`
+	Error   bool              `json:"error"`
+	Message string            `json:"message"`
+	Data    json.RawMessage   `json:"data"`
+	Errors  map[string]string `json:"errors"`
+}
+
+// Response is the result of a request sent with Do
+type Response struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	Envelope Envelope
+}
+
+// Do sends req through handler and returns the recorded response. The body is
+// decoded into the envelope when it is JSON; non-JSON bodies are left raw.
+func Do(t testing.TB, handler http.Handler, req Request) *Response {
+	t.Helper()
+	
+	var body bytes.Buffer
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body.WriteString(b)
+	case []byte:
+		body.Write(b)
+	default:
+		if err := json.NewEncoder(&body).Encode(b); err != nil {
+			t.Fatalf("apitest: encode request body: %v", err)
+		}
+	}
+	
+	r := httptest.NewRequest(req.Method, req.Path, &body)
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range req.Headers {
+		r.Header.Set(k, v)
+	}
+	
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	
+	resp := &Response{
+		Status: rec.Code,
+		Header: rec.Header(),
+		Body:   rec.Body.Bytes(),
+	}
+	if strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		if err := json.Unmarshal(resp.Body, &resp.Envelope); err != nil {
+			t.Fatalf("apitest: decode response envelope: %v\n%s", err, resp.Body)
+		}
+	}
+	
+	return resp
+}
+
+// AssertStatus fails the test if the response status is not want
+func (r *Response) AssertStatus(t testing.TB, want int) {
+	t.Helper()
+	
+	if r.Status != want {
+		t.Errorf("status = %d, want %d\nbody: %s", r.Status, want, r.Body)
+	}
+}
+
+// AssertField fails the test if the JSON value at path does not equal want.
+// Path is a dot-separated list of object keys, e.g. "data.state" or "message".
+func (r *Response) AssertField(t testing.TB, path string, want interface{}) {
+	t.Helper()
+	
+	got, ok := r.Field(t, path)
+	if !ok {
+		t.Errorf("field %q missing from response\nbody: %s", path, r.Body)
+		return
+	}
+	
+	// Round-trip want through JSON so e.g. int and float64 compare equal
+	raw, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("apitest: encode expected value for %q: %v", path, err)
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		t.Fatalf("apitest: decode expected value for %q: %v", path, err)
+	}
+	
+	if !reflect.DeepEqual(got, normalized) {
+		t.Errorf("field %q = %v, want %v", path, got, normalized)
+	}
+}
+
+// Field returns the JSON value at path and whether it exists
+func (r *Response) Field(t testing.TB, path string) (interface{}, bool) {
+	t.Helper()
+	
+	var value interface{}
+	if err := json.Unmarshal(r.Body, &value); err != nil {
+		t.Fatalf("apitest: response is not JSON: %v\n%s", err, r.Body)
+	}
+	
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	
+	return value, true
+}
+
+// DecodeData decodes the envelope's data field into v
+func (r *Response) DecodeData(t testing.TB, v interface{}) {
+	t.Helper()
+	
+	if err := json.Unmarshal(r.Envelope.Data, v); err != nil {
+		t.Fatalf("apitest: decode data: %v\n%s", err, r.Body)
+	}
+}
@@ -0,0 +1,245 @@
+// Package broadcast fans messages out to any number of subscribers, each with
+// its own buffer and a policy for what happens when that buffer is full. A
+// slow subscriber never holds up the others for longer than its own policy
+// allows, and every message it misses is counted.
+package broadcast
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy decides what Publish does when a subscriber's buffer is full
+type Policy int
+
+const (
+	// DropNewest discards the message being published
+	DropNewest Policy = iota
+	// DropOldest discards the oldest buffered message to make room, so the
+	// subscriber always sees the latest Buffer messages
+	DropOldest
+	// BlockWithTimeout waits up to Options.Timeout for room and drops the
+	// message if none frees up
+	BlockWithTimeout
+)
+
+// String returns the policy name
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case BlockWithTimeout:
+		return "block-with-timeout"
+	}
+	return "unknown"
+}
+
+// Options configures one subscription
+type Options struct {
+	// Buffer is how many messages can wait for the subscriber; at least 1
+	Buffer int
+	// Policy applies when the buffer is full
+	Policy Policy
+	// Timeout bounds the wait under BlockWithTimeout
+	Timeout time.Duration
+	// MarkGaps passes the first message delivered after a drop through the
+	// broadcaster's gap marker, so the subscriber knows it missed something
+	MarkGaps bool
+}
+
+// Stats reports a broadcaster's subscribers and message counts
+type Stats struct {
+	Subscribers int    `json:"subscribers"`
+	Published   uint64 `json:"published"`
+	Dropped     uint64 `json:"dropped"`
+}
+
+// Broadcaster delivers every published message to all current subscribers
+type Broadcaster[T any] struct {
+	mu     sync.RWMutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+	mark   func(T) T
+
+	published atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// Subscription receives messages from a Broadcaster until it is closed
+type Subscription[T any] struct {
+	ch      chan T
+	opts    Options
+	owner   *Broadcaster[T]
+	sendMu  sync.Mutex // serializes deliveries so drop-oldest stays ordered
+	gap     bool       // a message was dropped since the last delivery; guarded by sendMu
+	dropped atomic.Uint64
+}
+
+// New creates a broadcaster with no subscribers
+func New[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{subs: make(map[*Subscription[T]]struct{})}
+}
+
+// SetGapMarker sets how a message is marked for subscribers with
+// Options.MarkGaps when it is the first they receive after a drop. mark must
+// return a marked copy rather than change msg, which other subscribers share.
+func (b *Broadcaster[T]) SetGapMarker(mark func(msg T) T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mark = mark
+}
+
+// Subscribe adds a subscriber. Its channel is closed when the subscription or
+// the broadcaster is closed; subscribing to a closed broadcaster returns a
+// subscription whose channel is already closed.
+func (b *Broadcaster[T]) Subscribe(opts Options) *Subscription[T] {
+	if opts.Buffer < 1 {
+		opts.Buffer = 1
+	}
+	sub := &Subscription[T]{ch: make(chan T, opts.Buffer), opts: opts, owner: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers msg to every subscriber according to its policy and
+// returns how many subscribers had to drop a message to keep up
+func (b *Broadcaster[T]) Publish(msg T) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return 0
+	}
+	b.published.Add(1)
+
+	dropped := 0
+	for sub := range b.subs {
+		if sub.deliver(msg, b.mark) {
+			dropped++
+		}
+	}
+	b.dropped.Add(uint64(dropped))
+	return dropped
+}
+
+// Stats returns the current subscriber count and message totals
+func (b *Broadcaster[T]) Stats() Stats {
+	b.mu.RLock()
+	subscribers := len(b.subs)
+	b.mu.RUnlock()
+
+	return Stats{
+		Subscribers: subscribers,
+		Published:   b.published.Load(),
+		Dropped:     b.dropped.Load(),
+	}
+}
+
+// Close closes every subscription; later publishes are ignored
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+	}
+	b.subs = nil
+}
+
+// C returns the channel messages are delivered on
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Dropped returns how many messages this subscriber missed
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes the channel. It is safe to call more than once.
+func (s *Subscription[T]) Close() {
+	b := s.owner
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// deliver applies the subscription's policy and reports whether a message
+// was dropped. Callers hold the broadcaster's read lock, so the channel cannot
+// be closed underneath it.
+func (s *Subscription[T]) deliver(msg T, mark func(T) T) bool {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	marked := false
+	markGap := func() {
+		if !marked && s.opts.MarkGaps && mark != nil {
+			msg = mark(msg)
+			marked = true
+		}
+	}
+	if s.gap {
+		markGap()
+	}
+
+	select {
+	case s.ch <- msg:
+		s.gap = false
+		return false
+	default:
+	}
+
+	switch s.opts.Policy {
+	case DropOldest:
+		// Evict the oldest message, unless the subscriber made room meanwhile
+		evicted := false
+		select {
+		case <-s.ch:
+			evicted = true
+			// msg now follows a message the subscriber will never see
+			markGap()
+		default:
+		}
+		// There is room now and sendMu keeps other publishers out, so this cannot block
+		s.ch <- msg
+		s.gap = false
+		if !evicted {
+			return false
+		}
+		s.dropped.Add(1)
+		return true
+
+	case BlockWithTimeout:
+		timer := time.NewTimer(s.opts.Timeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- msg:
+			s.gap = false
+			return false
+		case <-timer.C:
+		}
+	}
+
+	s.gap = true
+	s.dropped.Add(1)
+	return true
+}
@@ -0,0 +1,349 @@
+// Package client is a typed Go client for the game server's HTTP API. It
+// covers the player-facing routes under /api/v1; the admin API and the
+// leaderboard stream are left to plain HTTP and WebSocket clients.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// APIError is returned for any response outside the 2xx range. It carries
+// the message from the server's error envelope.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Errors     map[string]string // per-field messages for validation failures
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("api error: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("api error: %d %s", e.StatusCode, e.Message)
+}
+
+// Client calls the game server API. Log in, or set a session with
+// SetSession, before calling the routes that need authentication. A Client is
+// safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	sessionID string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with hc instead of http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Session returns the session ID attached to requests, if any
+func (c *Client) Session() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sessionID
+}
+
+// SetSession attaches sessionID to every following request. An empty ID
+// sends requests unauthenticated.
+func (c *Client) SetSession(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = sessionID
+}
+
+// Auth
+
+// Register creates a user account
+func (c *Client) Register(ctx context.Context, username, email, password string) (*User, error) {
+	var user User
+	body := map[string]string{"username": username, "email": email, "password": password}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/auth/register", body, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login starts a session and attaches it to the client's following requests
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	var session Session
+	body := map[string]string{"username": username, "password": password}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/auth/login", body, &session); err != nil {
+		return nil, err
+	}
+	c.SetSession(session.ID)
+	return &session, nil
+}
+
+// Logout ends the client's session
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, "/api/v1/auth/logout", nil, nil); err != nil {
+		return err
+	}
+	c.SetSession("")
+	return nil
+}
+
+// ChangePassword changes the password of the logged-in user, userID
+func (c *Client) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	body := map[string]string{"current_password": currentPassword, "new_password": newPassword}
+	return c.do(ctx, http.MethodPut, "/api/v1/users/"+url.PathEscape(userID)+"/password", body, nil)
+}
+
+// Games
+
+// CreateGame creates a classic game between two players
+func (c *Client) CreateGame(ctx context.Context, player1ID, player2ID string) (*Game, error) {
+	return c.CreateGameWithMode(ctx, player1ID, player2ID, GameModeSpec{})
+}
+
+// CreateGameWithMode creates a game between two players with the given mode
+func (c *Client) CreateGameWithMode(ctx context.Context, player1ID, player2ID string, mode GameModeSpec) (*Game, error) {
+	var game Game
+	body := map[string]interface{}{"player1_id": player1ID, "player2_id": player2ID, "mode": mode}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/games", body, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// StartGame starts a game
+func (c *Client) StartGame(ctx context.Context, gameID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/games/"+url.PathEscape(gameID)+"/start", nil, nil)
+}
+
+// UpdateScore sets a player's score in a game
+func (c *Client) UpdateScore(ctx context.Context, gameID, playerID string, score int64) error {
+	body := map[string]interface{}{"player_id": playerID, "score": score}
+	return c.do(ctx, http.MethodPut, "/api/v1/games/"+url.PathEscape(gameID)+"/score", body, nil)
+}
+
+// EndGame ends a game and returns its result
+func (c *Client) EndGame(ctx context.Context, gameID string) (*GameResult, error) {
+	var result GameResult
+	if err := c.do(ctx, http.MethodPost, "/api/v1/games/"+url.PathEscape(gameID)+"/end", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelGame cancels a game
+func (c *Client) CancelGame(ctx context.Context, gameID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/games/"+url.PathEscape(gameID)+"/cancel", nil, nil)
+}
+
+// GetActiveGames lists the games in progress
+func (c *Client) GetActiveGames(ctx context.Context) ([]*Game, error) {
+	var games []*Game
+	if err := c.do(ctx, http.MethodGet, "/api/v1/games/active", nil, &games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// GetGame retrieves a game
+func (c *Client) GetGame(ctx context.Context, gameID string) (*Game, error) {
+	var game Game
+	if err := c.do(ctx, http.MethodGet, "/api/v1/games/"+url.PathEscape(gameID), nil, &game); err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// Leaderboards
+
+// CreateLeaderboard creates a leaderboard
+func (c *Client) CreateLeaderboard(ctx context.Context, req CreateLeaderboardRequest) (*Leaderboard, error) {
+	var leaderboard Leaderboard
+	if err := c.do(ctx, http.MethodPost, "/api/v1/leaderboards", req, &leaderboard); err != nil {
+		return nil, err
+	}
+	return &leaderboard, nil
+}
+
+// AddScore records a score for a user on a leaderboard. metadata may be nil.
+func (c *Client) AddScore(ctx context.Context, leaderboardID, userID string, score int64, metadata map[string]string) error {
+	body := map[string]interface{}{"user_id": userID, "score": score, "metadata": metadata}
+	return c.do(ctx, http.MethodPost, "/api/v1/leaderboards/"+url.PathEscape(leaderboardID)+"/scores", body, nil)
+}
+
+// GetTopEntries retrieves a page of up to count ranked entries of a
+// leaderboard, starting at offset. With includeTies set, every entry tied with
+// the last one is returned as well; request the next page from the page's
+// NextOffset, not offset+count.
+func (c *Client) GetTopEntries(ctx context.Context, leaderboardID string, offset, count int, includeTies bool) (*TopEntriesPage, error) {
+	query := url.Values{"offset": {strconv.Itoa(offset)}, "count": {strconv.Itoa(count)}}
+	if includeTies {
+		query.Set("ties", "true")
+	}
+
+	var page TopEntriesPage
+	path := "/api/v1/leaderboards/" + url.PathEscape(leaderboardID) + "/top?" + query.Encode()
+	if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetUserRank retrieves a user's standing on a leaderboard
+func (c *Client) GetUserRank(ctx context.Context, leaderboardID, userID string) (*UserRank, error) {
+	var rank UserRank
+	path := "/api/v1/leaderboards/" + url.PathEscape(leaderboardID) + "/rank/" + url.PathEscape(userID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &rank); err != nil {
+		return nil, err
+	}
+	return &rank, nil
+}
+
+// GetEntriesAround retrieves a user's entry with up to radius entries either side of it
+func (c *Client) GetEntriesAround(ctx context.Context, leaderboardID, userID string, radius int) ([]LeaderboardEntry, error) {
+	var entries []LeaderboardEntry
+	path := "/api/v1/leaderboards/" + url.PathEscape(leaderboardID) + "/around/" + url.PathEscape(userID) +
+		"?radius=" + strconv.Itoa(radius)
+	if err := c.do(ctx, http.MethodGet, path, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetLeaderboardStats retrieves aggregated statistics for a leaderboard
+func (c *Client) GetLeaderboardStats(ctx context.Context, leaderboardID string) (*LeaderboardStats, error) {
+	var stats LeaderboardStats
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboards/"+url.PathEscape(leaderboardID)+"/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetLeaderboard retrieves a complete leaderboard
+func (c *Client) GetLeaderboard(ctx context.Context, leaderboardID string) (*Leaderboard, error) {
+	var leaderboard Leaderboard
+	if err := c.do(ctx, http.MethodGet, "/api/v1/leaderboards/"+url.PathEscape(leaderboardID), nil, &leaderboard); err != nil {
+		return nil, err
+	}
+	return &leaderboard, nil
+}
+
+// Users
+
+// GetUserStats retrieves a user's game statistics
+func (c *Client) GetUserStats(ctx context.Context, userID string) (*UserStats, error) {
+	var stats UserStats
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(userID)+"/stats", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetUserGames retrieves up to limit of a user's games, newest first. A limit
+// of 0 uses the server default.
+func (c *Client) GetUserGames(ctx context.Context, userID string, limit int) ([]*Game, error) {
+	path := "/api/v1/users/" + url.PathEscape(userID) + "/games"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+
+	var games []*Game
+	if err := c.do(ctx, http.MethodGet, path, nil, &games); err != nil {
+		return nil, err
+	}
+	return games, nil
+}
+
+// ListSessions lists the logged-in user's live sessions
+func (c *Client) ListSessions(ctx context.Context, userID string) ([]*Session, error) {
+	var sessions []*Session
+	if err := c.do(ctx, http.MethodGet, "/api/v1/users/"+url.PathEscape(userID)+"/sessions", nil, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSessions ends every session of the logged-in user, including the client's own
+func (c *Client) RevokeSessions(ctx context.Context, userID string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/users/"+url.PathEscape(userID)+"/sessions", nil, nil)
+}
+
+// envelope mirrors the JSON envelope written by the utils response helpers
+type envelope struct {
+	Data    json.RawMessage   `json:"data"`
+	Message string            `json:"message"`
+	Errors  map[string]string `json:"errors"`
+}
+
+// do sends a request with body encoded as JSON and decodes the envelope's
+// data into out, which may be nil to discard it
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%s %s: failed to encode request: %w", method, path, err)
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sessionID := c.Session(); sessionID != "" {
+		req.Header.Set("Authorization", sessionID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	decodeErr := json.NewDecoder(resp.Body).Decode(&env)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %w", method, path, &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    env.Message,
+			Errors:     env.Errors,
+		})
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", method, path, decodeErr)
+	}
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return fmt.Errorf("%s %s: failed to decode response data: %w", method, path, err)
+		}
+	}
+	return nil
+}
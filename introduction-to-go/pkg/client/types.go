@@ -0,0 +1,37 @@
+package client
+
+import (
+	"effective-golang/internal/auth"
+	"effective-golang/internal/game"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+)
+
+// The API returns the server's own types. They are aliased here so programs
+// outside this module, which cannot import internal packages, can still name
+// them.
+type (
+	User             = models.User
+	UserStats        = models.UserStats
+	Session          = auth.Session
+	Game             = models.Game
+	GameModeSpec     = models.GameModeSpec
+	GameResult       = game.GameResult
+	Leaderboard      = models.Leaderboard
+	LeaderboardType  = models.LeaderboardType
+	LeaderboardEntry = models.LeaderboardEntry
+	SortOrder        = models.SortOrder
+	LeaderboardStats = leaderboard.LeaderboardStats
+	UserRank         = leaderboard.UserRank
+	TopEntriesPage   = leaderboard.TopEntriesPage
+)
+
+// CreateLeaderboardRequest describes a leaderboard to create
+type CreateLeaderboardRequest struct {
+	Name           string          `json:"name"`
+	Type           LeaderboardType `json:"type"`
+	MaxEntries     int             `json:"max_entries"`
+	SortOrder      SortOrder       `json:"sort_order,omitempty"`       // "desc" (default) or "asc"
+	DecayHalfLife  string          `json:"decay_half_life,omitempty"`  // e.g. "24h"; empty for no decay
+	MinUpdateDelta int64           `json:"min_update_delta,omitempty"` // 0 emits every score change
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,24 +19,38 @@ type InMemoryUnitOfWork struct {
 	leaderboardRepo *InMemoryLeaderboardRepository
 	cacheRepo       *InMemoryCacheRepository
 	txManager       *InMemoryTransactionManager
+	
+	// Close saves a snapshot here when set; see SetSnapshotPath
+	snapshotPath    string
+	
+	// stopJanitor stops the cache janitor started by NewInMemoryUnitOfWork
+	stopJanitor     context.CancelFunc
 }
 
+// DefaultCacheJanitorInterval is how often NewInMemoryUnitOfWork evicts
+// expired cache entries
+const DefaultCacheJanitorInterval = time.Minute
+
 // NewInMemoryUnitOfWork creates a new in-memory unit of work
 func NewInMemoryUnitOfWork() models.UnitOfWork {
 	userRepo := &InMemoryUserRepository{
 		users:  make(map[string]*models.User),
 		stats:  make(map[string]*models.UserStats),
 		mutex:  sync.RWMutex{},
+		
+		usernameFallback: models.UsernameFallbackExact,
 	}
 	
 	gameRepo := &InMemoryGameRepository{
-		games:  make(map[string]*models.Game),
-		events: make(map[string][]*models.GameEvent),
-		mutex:  sync.RWMutex{},
+		games:     make(map[string]*models.Game),
+		events:    make(map[string][]*models.GameEvent),
+		summaries: make(map[string]*models.GameSummary),
+		mutex:     sync.RWMutex{},
 	}
 	
 	leaderboardRepo := &InMemoryLeaderboardRepository{
 		leaderboards: make(map[string]*models.Leaderboard),
+		archives:     make(map[string][]*models.LeaderboardArchive),
 		mutex:        sync.RWMutex{},
 	}
 	
@@ -57,6 +73,10 @@ func NewInMemoryUnitOfWork() models.UnitOfWork {
 	
 	txManager.unitOfWork = unitOfWork
 	
+	ctx, cancel := context.WithCancel(context.Background())
+	unitOfWork.stopJanitor = cancel
+	cacheRepo.StartJanitor(ctx, DefaultCacheJanitorInterval)
+	
 	return unitOfWork
 }
 
@@ -85,8 +105,15 @@ func (uow *InMemoryUnitOfWork) Begin(ctx context.Context) (models.Transaction, e
 	return uow.txManager.Begin(ctx)
 }
 
+// Close stops the cache janitor and saves a snapshot when a snapshot path is set
 func (uow *InMemoryUnitOfWork) Close() error {
-	return nil
+	uow.stopJanitor()
+	uow.cacheRepo.janitors.Wait()
+	
+	if uow.snapshotPath == "" {
+		return nil
+	}
+	return uow.SaveSnapshot(uow.snapshotPath)
 }
 
 // InMemoryUserRepository implements UserRepository with in-memory storage
@@ -94,6 +121,8 @@ type InMemoryUserRepository struct {
 	users map[string]*models.User
 	stats map[string]*models.UserStats
 	mutex sync.RWMutex
+	
+	usernameFallback models.UsernameFallback
 }
 
 func (r *InMemoryUserRepository) Create(ctx context.Context, user *models.User) error {
@@ -115,16 +144,28 @@ func (r *InMemoryUserRepository) GetByID(ctx context.Context, id string) (*model
 	return user, nil
 }
 
+// GetByUsername looks a user up ignoring case. Users whose usernames differ
+// only in case are resolved by the repository's UsernameFallback.
 func (r *InMemoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
+	var candidates []*models.User
 	for _, user := range r.users {
-		if user.Username == username {
-			return user, nil
+		if strings.EqualFold(user.Username, username) {
+			candidates = append(candidates, user)
 		}
 	}
-	return nil, models.ErrUserNotFound
+	return models.MatchUsername(candidates, username, r.usernameFallback)
+}
+
+// SetUsernameFallback sets how lookups resolve usernames that differ only in
+// case; the default is models.UsernameFallbackExact
+func (r *InMemoryUserRepository) SetUsernameFallback(fallback models.UsernameFallback) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.usernameFallback = fallback
 }
 
 func (r *InMemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
@@ -172,6 +213,14 @@ func (r *InMemoryUserRepository) List(ctx context.Context, offset, limit int) ([
 		users = append(users, user)
 	}
 	
+	// Oldest first, so offsets select the same users on every call
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	
 	// Simple pagination
 	if offset >= len(users) {
 		return []*models.User{}, nil
@@ -206,9 +255,10 @@ func (r *InMemoryUserRepository) UpdateStats(ctx context.Context, stats *models.
 
 // InMemoryGameRepository implements GameRepository with in-memory storage
 type InMemoryGameRepository struct {
-	games  map[string]*models.Game
-	events map[string][]*models.GameEvent
-	mutex  sync.RWMutex
+	games     map[string]*models.Game
+	events    map[string][]*models.GameEvent
+	summaries map[string]*models.GameSummary
+	mutex     sync.RWMutex
 }
 
 func (r *InMemoryGameRepository) Create(ctx context.Context, game *models.Game) error {
@@ -267,6 +317,14 @@ func (r *InMemoryGameRepository) GetUserGames(ctx context.Context, userID string
 		}
 	}
 	
+	// Newest first, so a limit keeps the most recent games
+	sort.Slice(games, func(i, j int) bool {
+		if !games[i].CreatedAt.Equal(games[j].CreatedAt) {
+			return games[i].CreatedAt.After(games[j].CreatedAt)
+		}
+		return games[i].ID < games[j].ID
+	})
+	
 	if limit > 0 && len(games) > limit {
 		games = games[:limit]
 	}
@@ -285,6 +343,13 @@ func (r *InMemoryGameRepository) GetActiveGames(ctx context.Context) ([]*models.
 		}
 	}
 	
+	sort.Slice(games, func(i, j int) bool {
+		if !games[i].CreatedAt.Equal(games[j].CreatedAt) {
+			return games[i].CreatedAt.Before(games[j].CreatedAt)
+		}
+		return games[i].ID < games[j].ID
+	})
+	
 	return games, nil
 }
 
@@ -308,9 +373,50 @@ func (r *InMemoryGameRepository) GetGameEvents(ctx context.Context, gameID strin
 	return events, nil
 }
 
+func (r *InMemoryGameRepository) GetEndedGames(ctx context.Context, before time.Time) ([]*models.Game, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	var games []*models.Game
+	for _, game := range r.games {
+		ended := game.State == models.GameStateFinished || game.State == models.GameStateCancelled
+		if ended && game.FinishedAt != nil && game.FinishedAt.Before(before) {
+			games = append(games, game)
+		}
+	}
+	
+	return games, nil
+}
+
+func (r *InMemoryGameRepository) Archive(ctx context.Context, summary *models.GameSummary) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if _, exists := r.games[summary.ID]; !exists {
+		return models.ErrGameNotFound
+	}
+	
+	r.summaries[summary.ID] = summary
+	delete(r.games, summary.ID)
+	delete(r.events, summary.ID)
+	return nil
+}
+
+func (r *InMemoryGameRepository) GetSummary(ctx context.Context, id string) (*models.GameSummary, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	summary, exists := r.summaries[id]
+	if !exists {
+		return nil, models.ErrGameNotFound
+	}
+	return summary, nil
+}
+
 // InMemoryLeaderboardRepository implements LeaderboardRepository with in-memory storage
 type InMemoryLeaderboardRepository struct {
 	leaderboards map[string]*models.Leaderboard
+	archives     map[string][]*models.LeaderboardArchive
 	mutex        sync.RWMutex
 }
 
@@ -318,6 +424,13 @@ func (r *InMemoryLeaderboardRepository) Create(ctx context.Context, leaderboard
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
+	// Names are unique; checking under the write lock makes check-and-insert atomic
+	for _, existing := range r.leaderboards {
+		if existing.Name == leaderboard.Name {
+			return models.ErrLeaderboardExists
+		}
+	}
+	
 	r.leaderboards[leaderboard.ID] = leaderboard
 	return nil
 }
@@ -366,6 +479,7 @@ func (r *InMemoryLeaderboardRepository) Delete(ctx context.Context, id string) e
 	}
 	
 	delete(r.leaderboards, id)
+	delete(r.archives, id)
 	return nil
 }
 
@@ -378,6 +492,14 @@ func (r *InMemoryLeaderboardRepository) List(ctx context.Context, offset, limit
 		leaderboards = append(leaderboards, leaderboard)
 	}
 	
+	// Oldest first, so offsets select the same leaderboards on every call
+	sort.Slice(leaderboards, func(i, j int) bool {
+		if !leaderboards[i].CreatedAt.Equal(leaderboards[j].CreatedAt) {
+			return leaderboards[i].CreatedAt.Before(leaderboards[j].CreatedAt)
+		}
+		return leaderboards[i].ID < leaderboards[j].ID
+	})
+	
 	if offset >= len(leaderboards) {
 		return []*models.Leaderboard{}, nil
 	}
@@ -413,7 +535,23 @@ func (r *InMemoryLeaderboardRepository) AddEntry(ctx context.Context, leaderboar
 		return models.ErrLeaderboardNotFound
 	}
 	
-	return leaderboard.AddEntry(entry.UserID, entry.Username, entry.Score)
+	return leaderboard.AddEntryAt(entry.UserID, entry.Username, entry.Score, entry.Metadata, entry.UpdatedAt)
+}
+
+func (r *InMemoryLeaderboardRepository) AddEntries(ctx context.Context, leaderboardID string, entries []*models.LeaderboardEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	leaderboard, exists := r.leaderboards[leaderboardID]
+	if !exists {
+		return models.ErrLeaderboardNotFound
+	}
+	
+	values := make([]models.LeaderboardEntry, len(entries))
+	for i, entry := range entries {
+		values[i] = *entry
+	}
+	return leaderboard.AddEntries(values)
 }
 
 func (r *InMemoryLeaderboardRepository) RemoveEntry(ctx context.Context, leaderboardID, userID string) error {
@@ -428,21 +566,21 @@ func (r *InMemoryLeaderboardRepository) RemoveEntry(ctx context.Context, leaderb
 	return leaderboard.RemoveUser(userID)
 }
 
-func (r *InMemoryLeaderboardRepository) GetTopEntries(ctx context.Context, leaderboardID string, count int) ([]*models.LeaderboardEntry, error) {
+func (r *InMemoryLeaderboardRepository) GetTopEntries(ctx context.Context, leaderboardID string, offset, count int, includeTies bool) ([]*models.LeaderboardEntry, int, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
 	leaderboard, exists := r.leaderboards[leaderboardID]
 	if !exists {
-		return nil, models.ErrLeaderboardNotFound
+		return nil, 0, models.ErrLeaderboardNotFound
 	}
 	
-	entries := leaderboard.GetTopEntries(count)
+	entries, total := leaderboard.GetTopEntries(offset, count, includeTies)
 	result := make([]*models.LeaderboardEntry, len(entries))
 	for i := range entries {
 		result[i] = &entries[i]
 	}
-	return result, nil
+	return result, total, nil
 }
 
 func (r *InMemoryLeaderboardRepository) GetUserRank(ctx context.Context, leaderboardID, userID string) (int, error) {
@@ -457,10 +595,38 @@ func (r *InMemoryLeaderboardRepository) GetUserRank(ctx context.Context, leaderb
 	return leaderboard.GetUserRank(userID)
 }
 
+func (r *InMemoryLeaderboardRepository) Archive(ctx context.Context, archive *models.LeaderboardArchive) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if _, exists := r.leaderboards[archive.LeaderboardID]; !exists {
+		return models.ErrLeaderboardNotFound
+	}
+	
+	r.archives[archive.LeaderboardID] = append(r.archives[archive.LeaderboardID], archive)
+	return nil
+}
+
+func (r *InMemoryLeaderboardRepository) GetArchives(ctx context.Context, leaderboardID string) ([]*models.LeaderboardArchive, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	if _, exists := r.leaderboards[leaderboardID]; !exists {
+		return nil, models.ErrLeaderboardNotFound
+	}
+	
+	archives := make([]*models.LeaderboardArchive, len(r.archives[leaderboardID]))
+	copy(archives, r.archives[leaderboardID])
+	return archives, nil
+}
+
 // InMemoryCacheRepository implements CacheRepository with in-memory storage
 type InMemoryCacheRepository struct {
 	data  map[string]*cacheEntry
 	mutex sync.RWMutex
+	
+	// janitors tracks the goroutines started by StartJanitor
+	janitors sync.WaitGroup
 }
 
 type cacheEntry struct {
@@ -468,6 +634,52 @@ type cacheEntry struct {
 	expiration time.Time
 }
 
+// Len returns the number of entries in the cache, including expired entries
+// that have not been evicted yet
+func (r *InMemoryCacheRepository) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return len(r.data)
+}
+
+// StartJanitor evicts expired entries every interval until ctx is done, so
+// keys that are never read again do not stay in memory forever
+func (r *InMemoryCacheRepository) StartJanitor(ctx context.Context, interval time.Duration) {
+	r.janitors.Add(1)
+	go func() {
+		defer r.janitors.Done()
+		
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.EvictExpired()
+			}
+		}
+	}()
+}
+
+// EvictExpired deletes every expired entry and returns how many were deleted
+func (r *InMemoryCacheRepository) EvictExpired() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	now := time.Now()
+	evicted := 0
+	for key, entry := range r.data {
+		if now.After(entry.expiration) {
+			delete(r.data, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
 func (r *InMemoryCacheRepository) Set(ctx context.Context, key string, value interface{}, ttl int) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -484,13 +696,10 @@ func (r *InMemoryCacheRepository) Get(ctx context.Context, key string, dest inte
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
+	// An expired entry is a miss. It is left for the janitor to delete: only
+	// the read lock is held here, and concurrent reads must not write the map.
 	entry, exists := r.data[key]
-	if !exists {
-		return models.ErrCacheMiss
-	}
-	
-	if time.Now().After(entry.expiration) {
-		delete(r.data, key)
+	if !exists || time.Now().After(entry.expiration) {
 		return models.ErrCacheMiss
 	}
 	
@@ -515,24 +724,17 @@ func (r *InMemoryCacheRepository) Exists(ctx context.Context, key string) (bool,
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
+	// Like Get, leave expired entries for the janitor
 	entry, exists := r.data[key]
-	if !exists {
-		return false, nil
-	}
-	
-	if time.Now().After(entry.expiration) {
-		delete(r.data, key)
-		return false, nil
-	}
-	
-	return true, nil
+	return exists && !time.Now().After(entry.expiration), nil
 }
 
 func (r *InMemoryCacheRepository) SetNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
-	if _, exists := r.data[key]; exists {
+	// An expired entry no longer holds the key
+	if entry, exists := r.data[key]; exists && !time.Now().After(entry.expiration) {
 		return false, nil
 	}
 	
@@ -576,8 +778,9 @@ func (r *InMemoryCacheRepository) Expire(ctx context.Context, key string, ttl in
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
+	// An expired entry is gone, even if the janitor has not deleted it yet
 	entry, exists := r.data[key]
-	if !exists {
+	if !exists || time.Now().After(entry.expiration) {
 		return models.ErrCacheMiss
 	}
 	
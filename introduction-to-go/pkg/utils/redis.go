@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"effective-golang/internal/models"
+)
+
+// RedisCacheRepository implements CacheRepository on Redis, so every server
+// instance shares one cache. Values are stored JSON-encoded; counters made by
+// Increment are plain Redis integers, which read back as JSON numbers.
+type RedisCacheRepository struct {
+	client redis.UniversalClient
+}
+
+// NewRedisCacheRepository creates a cache repository backed by client. The
+// caller owns the client and closes it once the repository is no longer used.
+func NewRedisCacheRepository(client redis.UniversalClient) *RedisCacheRepository {
+	return &RedisCacheRepository{client: client}
+}
+
+// Set stores value under key for ttl seconds; a ttl of 0 or less never expires
+func (r *RedisCacheRepository) Set(ctx context.Context, key string, value interface{}, ttl int) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	return r.client.Set(ctx, key, data, ttlDuration(ttl)).Err()
+}
+
+// Get decodes the value under key into dest, or returns models.ErrCacheMiss
+func (r *RedisCacheRepository) Get(ctx context.Context, key string, dest interface{}) error {
+	data, err := r.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return models.ErrCacheMiss
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// Delete removes key; a missing key is not an error
+func (r *RedisCacheRepository) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, key).Err()
+}
+
+// Exists reports whether key holds a value that has not expired
+func (r *RedisCacheRepository) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetNX stores value under key with SET NX, reporting whether it was stored
+func (r *RedisCacheRepository) SetNX(ctx context.Context, key string, value interface{}, ttl int) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode cache value: %w", err)
+	}
+	return r.client.SetNX(ctx, key, data, ttlDuration(ttl)).Result()
+}
+
+// Increment adds value to the counter under key with INCRBY. Unlike the
+// in-memory cache, a new counter does not expire until Expire is called.
+func (r *RedisCacheRepository) Increment(ctx context.Context, key string, value int64) (int64, error) {
+	return r.client.IncrBy(ctx, key, value).Result()
+}
+
+// Expire sets the ttl of key in seconds, or returns models.ErrCacheMiss when it does not exist
+func (r *RedisCacheRepository) Expire(ctx context.Context, key string, ttl int) error {
+	ok, err := r.client.Expire(ctx, key, time.Duration(ttl)*time.Second).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.ErrCacheMiss
+	}
+	return nil
+}
+
+// ttlDuration converts a ttl in seconds to a Redis expiration; 0 means none
+func ttlDuration(ttl int) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Duration(ttl) * time.Second
+}
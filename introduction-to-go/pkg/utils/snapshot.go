@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"effective-golang/internal/models"
+)
+
+// snapshot is the on-disk form of an InMemoryUnitOfWork. The cache is not
+// included; it is rebuilt as the data is used.
+type snapshot struct {
+	Users               []snapshotUser                          `json:"users"`
+	UserStats           []*models.UserStats                     `json:"user_stats"`
+	Games               []*models.Game                          `json:"games"`
+	GameEvents          map[string][]*models.GameEvent          `json:"game_events"`
+	GameSummaries       []*models.GameSummary                   `json:"game_summaries"`
+	Leaderboards        []*models.Leaderboard                   `json:"leaderboards"`
+	LeaderboardArchives map[string][]*models.LeaderboardArchive `json:"leaderboard_archives"`
+}
+
+// snapshotUser keeps the password hash, which models.User leaves out of JSON
+type snapshotUser struct {
+	*models.User
+	Password string `json:"password"`
+}
+
+// SetSnapshotPath makes Close save a snapshot to path; an empty path saves nothing
+func (uow *InMemoryUnitOfWork) SetSnapshotPath(path string) {
+	uow.snapshotPath = path
+}
+
+// SaveSnapshot writes every user, game and leaderboard to path as JSON. The
+// file is replaced atomically, so a failed save leaves the previous snapshot.
+// Games are written as they are, so call it once game writes have stopped.
+func (uow *InMemoryUnitOfWork) SaveSnapshot(path string) error {
+	var snap snapshot
+
+	uow.userRepo.mutex.RLock()
+	for _, user := range uow.userRepo.users {
+		snap.Users = append(snap.Users, snapshotUser{User: user, Password: user.Password})
+	}
+	for _, stats := range uow.userRepo.stats {
+		snap.UserStats = append(snap.UserStats, stats)
+	}
+	uow.userRepo.mutex.RUnlock()
+
+	uow.gameRepo.mutex.RLock()
+	snap.GameEvents = make(map[string][]*models.GameEvent, len(uow.gameRepo.events))
+	for id, game := range uow.gameRepo.games {
+		snap.Games = append(snap.Games, game)
+		snap.GameEvents[id] = append([]*models.GameEvent(nil), uow.gameRepo.events[id]...)
+	}
+	for _, summary := range uow.gameRepo.summaries {
+		snap.GameSummaries = append(snap.GameSummaries, summary)
+	}
+	uow.gameRepo.mutex.RUnlock()
+
+	// Leaderboards embed a mutex, so each is copied under its own lock
+	uow.leaderboardRepo.mutex.RLock()
+	for _, leaderboard := range uow.leaderboardRepo.leaderboards {
+		snap.Leaderboards = append(snap.Leaderboards, leaderboard.Clone())
+	}
+	snap.LeaderboardArchives = make(map[string][]*models.LeaderboardArchive, len(uow.leaderboardRepo.archives))
+	for id, archives := range uow.leaderboardRepo.archives {
+		snap.LeaderboardArchives[id] = append([]*models.LeaderboardArchive(nil), archives...)
+	}
+	uow.leaderboardRepo.mutex.RUnlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to install snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot replaces every user, game and leaderboard with those saved to
+// path by SaveSnapshot. A missing file returns an error wrapping os.ErrNotExist
+// and leaves the unit of work unchanged.
+func (uow *InMemoryUnitOfWork) LoadSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	users := make(map[string]*models.User, len(snap.Users))
+	for _, saved := range snap.Users {
+		if saved.User == nil {
+			continue
+		}
+		saved.User.Password = saved.Password
+		users[saved.ID] = saved.User
+	}
+	stats := make(map[string]*models.UserStats, len(snap.UserStats))
+	for _, s := range snap.UserStats {
+		stats[s.UserID] = s
+	}
+
+	games := make(map[string]*models.Game, len(snap.Games))
+	events := make(map[string][]*models.GameEvent, len(snap.Games))
+	for _, game := range snap.Games {
+		games[game.ID] = game
+		events[game.ID] = append(make([]*models.GameEvent, 0), snap.GameEvents[game.ID]...)
+	}
+	summaries := make(map[string]*models.GameSummary, len(snap.GameSummaries))
+	for _, summary := range snap.GameSummaries {
+		summaries[summary.ID] = summary
+	}
+
+	leaderboards := make(map[string]*models.Leaderboard, len(snap.Leaderboards))
+	for _, leaderboard := range snap.Leaderboards {
+		leaderboards[leaderboard.ID] = leaderboard
+	}
+	archives := snap.LeaderboardArchives
+	if archives == nil {
+		archives = make(map[string][]*models.LeaderboardArchive)
+	}
+
+	uow.userRepo.mutex.Lock()
+	uow.userRepo.users, uow.userRepo.stats = users, stats
+	uow.userRepo.mutex.Unlock()
+
+	uow.gameRepo.mutex.Lock()
+	uow.gameRepo.games, uow.gameRepo.events, uow.gameRepo.summaries = games, events, summaries
+	uow.gameRepo.mutex.Unlock()
+
+	uow.leaderboardRepo.mutex.Lock()
+	uow.leaderboardRepo.leaderboards, uow.leaderboardRepo.archives = leaderboards, archives
+	uow.leaderboardRepo.mutex.Unlock()
+
+	return nil
+}
@@ -0,0 +1,434 @@
+// Package validate checks request values against small composable rules and
+// reports every failing field at once, rather than stopping at the first.
+//
+// Rules can be attached to struct fields with a `validate` tag:
+//
+//	type RegisterRequest struct {
+//		Username string `json:"username" validate:"required,min=3,max=20"`
+//		Email    string `json:"email" validate:"required,email"`
+//	}
+//
+// declared for a struct with a Ruleset, or checked one value at a time with
+// Errors.Check.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrValidation matches every validation failure reported by this package
+var ErrValidation = errors.New("validation failed")
+
+// Rule checks a single value and returns an error describing why it is
+// invalid, or nil. Messages read after the field name, as in "is required".
+type Rule func(value any) error
+
+// FieldError is the first rule a field failed
+type FieldError struct {
+	Field string `json:"field"`
+	Err   error  `json:"-"`
+}
+
+// Error returns "field: message"
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the rule's error, so callers can match sentinel errors
+// returned by their own rules
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON reports the field and its message
+func (e FieldError) MarshalJSON() ([]byte, error) {
+	return []byte(`{"field":` + strconv.Quote(e.Field) + `,"message":` + strconv.Quote(e.Err.Error()) + `}`), nil
+}
+
+// Errors collects failures across fields, in the order they were checked
+type Errors []FieldError
+
+// Error joins every field's failure
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is matches ErrValidation
+func (e Errors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Unwrap returns the individual field errors
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, fe := range e {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Field returns the failure recorded for field, if any
+func (e Errors) Field(field string) (FieldError, bool) {
+	for _, fe := range e {
+		if fe.Field == field {
+			return fe, true
+		}
+	}
+	return FieldError{}, false
+}
+
+// Messages maps each failing field to its message
+func (e Errors) Messages() map[string]string {
+	msgs := make(map[string]string, len(e))
+	for _, fe := range e {
+		msgs[fe.Field] = fe.Err.Error()
+	}
+	return msgs
+}
+
+// Check runs rules against value in order and records the first failure
+// under field
+func (e *Errors) Check(field string, value any, rules ...Rule) {
+	if err := Value(value, rules...); err != nil {
+		*e = append(*e, FieldError{Field: field, Err: err})
+	}
+}
+
+// Err returns the collected failures as an error, or nil if there are none
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Value runs rules against value in order and returns the first failure
+func Value(value any, rules ...Rule) error {
+	for _, rule := range rules {
+		if err := rule(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Required rejects zero values. Strings of only whitespace count as empty.
+func Required() Rule {
+	return func(value any) error {
+		v := indirect(value)
+		if !v.IsValid() || v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+			return errors.New("is required")
+		}
+		return nil
+	}
+}
+
+// MinLen rejects strings, slices and maps shorter than n. Strings are
+// measured in characters, not bytes.
+func MinLen(n int) Rule {
+	return func(value any) error {
+		length, unit, err := lengthOf(value)
+		if err != nil {
+			return err
+		}
+		if length < n {
+			return fmt.Errorf("must be at least %d %s", n, unit)
+		}
+		return nil
+	}
+}
+
+// MaxLen rejects strings, slices and maps longer than n
+func MaxLen(n int) Rule {
+	return func(value any) error {
+		length, unit, err := lengthOf(value)
+		if err != nil {
+			return err
+		}
+		if length > n {
+			return fmt.Errorf("must be at most %d %s", n, unit)
+		}
+		return nil
+	}
+}
+
+// Min rejects numbers below min
+func Min(min float64) Rule {
+	return func(value any) error {
+		n, err := numberOf(value)
+		if err != nil {
+			return err
+		}
+		if n < min {
+			return fmt.Errorf("must be at least %s", formatNumber(min))
+		}
+		return nil
+	}
+}
+
+// Max rejects numbers above max
+func Max(max float64) Rule {
+	return func(value any) error {
+		n, err := numberOf(value)
+		if err != nil {
+			return err
+		}
+		if n > max {
+			return fmt.Errorf("must be at most %s", formatNumber(max))
+		}
+		return nil
+	}
+}
+
+// Range rejects numbers outside [min, max]
+func Range(min, max float64) Rule {
+	return func(value any) error {
+		n, err := numberOf(value)
+		if err != nil {
+			return err
+		}
+		if n < min || n > max {
+			return fmt.Errorf("must be between %s and %s", formatNumber(min), formatNumber(max))
+		}
+		return nil
+	}
+}
+
+// OneOf rejects strings, including named string types, that are not one of allowed
+func OneOf(allowed ...string) Rule {
+	return func(value any) error {
+		s, err := stringOf(value)
+		if err != nil {
+			return err
+		}
+		for _, a := range allowed {
+			if s == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// Email rejects strings that are not a bare address like "user@example.com"
+func Email() Rule {
+	return func(value any) error {
+		s, err := stringOf(value)
+		if err != nil {
+			return err
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return errors.New("must be a valid email address")
+		}
+		return nil
+	}
+}
+
+// URL rejects strings that are not absolute http or https URLs
+func URL() Rule {
+	return func(value any) error {
+		s, err := stringOf(value)
+		if err != nil {
+			return err
+		}
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("must be an absolute http or https URL")
+		}
+		return nil
+	}
+}
+
+// Ruleset declares the rules for a struct's fields, keyed by Go field name
+type Ruleset map[string][]Rule
+
+// Validate checks v, a struct or pointer to one, against the ruleset. Fields
+// are reported by their JSON name, in declaration order.
+func (rs Ruleset) Validate(v any) error {
+	sv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	seen := 0
+	var errs Errors
+	for i := 0; i < sv.NumField(); i++ {
+		field := sv.Type().Field(i)
+		rules, ok := rs[field.Name]
+		if !ok {
+			continue
+		}
+		seen++
+		errs.Check(fieldName(field), sv.Field(i).Interface(), rules...)
+	}
+	if seen != len(rs) {
+		return fmt.Errorf("validate: ruleset names a field %s does not have", sv.Type())
+	}
+	return errs.Err()
+}
+
+// Struct checks v, a struct or pointer to one, against its fields' `validate`
+// tags. A tag is a comma-separated list of:
+//
+//	required       Required()
+//	min=N, max=N   MinLen/MaxLen on strings, slices and maps; Min/Max on numbers
+//	range=LO:HI    Range(LO, HI)
+//	oneof=A B C    OneOf("A", "B", "C")
+//	email, url     Email(), URL()
+//
+// Fields are reported by their JSON name, in declaration order.
+func Struct(v any) error {
+	sv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	var errs Errors
+	for i := 0; i < sv.NumField(); i++ {
+		field := sv.Type().Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if !ok || !field.IsExported() {
+			continue
+		}
+		rules, err := parseTag(tag, field.Type)
+		if err != nil {
+			return fmt.Errorf("validate: %s.%s: %w", sv.Type(), field.Name, err)
+		}
+		errs.Check(fieldName(field), sv.Field(i).Interface(), rules...)
+	}
+	return errs.Err()
+}
+
+// parseTag turns a `validate` tag into rules for a field of type t
+func parseTag(tag string, t reflect.Type) ([]Rule, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	numeric := isNumber(t.Kind())
+
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "":
+		case "required":
+			rules = append(rules, Required())
+		case "email":
+			rules = append(rules, Email())
+		case "url":
+			rules = append(rules, URL())
+		case "oneof":
+			rules = append(rules, OneOf(strings.Fields(arg)...))
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", name, arg)
+			}
+			switch {
+			case numeric && name == "min":
+				rules = append(rules, Min(n))
+			case numeric:
+				rules = append(rules, Max(n))
+			case name == "min":
+				rules = append(rules, MinLen(int(n)))
+			default:
+				rules = append(rules, MaxLen(int(n)))
+			}
+		case "range":
+			lo, hi, ok := strings.Cut(arg, ":")
+			min, errLo := strconv.ParseFloat(lo, 64)
+			max, errHi := strconv.ParseFloat(hi, 64)
+			if !ok || errLo != nil || errHi != nil {
+				return nil, fmt.Errorf("invalid range %q, want LO:HI", arg)
+			}
+			rules = append(rules, Range(min, max))
+		default:
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return rules, nil
+}
+
+// fieldName is the field's JSON name, or its Go name without one
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func structValue(v any) (reflect.Value, error) {
+	sv := indirect(v)
+	if sv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("validate: want a struct, got %T", v)
+	}
+	return sv, nil
+}
+
+// indirect follows pointers to the value they point at
+func indirect(value any) reflect.Value {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// lengthOf measures value and names the unit it is measured in
+func lengthOf(value any) (int, string, error) {
+	v := indirect(value)
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), "characters", nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), "items", nil
+	}
+	return 0, "", fmt.Errorf("has no length (%s)", v.Kind())
+}
+
+func numberOf(value any) (float64, error) {
+	v := indirect(value)
+	switch {
+	case v.CanInt():
+		return float64(v.Int()), nil
+	case v.CanUint():
+		return float64(v.Uint()), nil
+	case v.CanFloat():
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("must be a number")
+}
+
+func stringOf(value any) (string, error) {
+	v := indirect(value)
+	if v.Kind() != reflect.String {
+		return "", fmt.Errorf("must be a string")
+	}
+	return v.String(), nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
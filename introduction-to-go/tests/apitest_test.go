@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"effective-golang/pkg/apitest"
+	"effective-golang/pkg/utils"
+)
+
+// TestAPITestHarness tests the handler harness against trivial success and error handlers
+func TestAPITestHarness(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		utils.SuccessResponse(w, map[string]interface{}{
+			"id":     "abc",
+			"count":  3,
+			"method": r.Method,
+			"token":  r.Header.Get("X-Token"),
+		})
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		utils.ErrorResponse(w, http.StatusNotFound, "not here")
+	})
+	mux.HandleFunc("/invalid", func(w http.ResponseWriter, r *http.Request) {
+		utils.ValidationErrorResponse(w, map[string]string{"name": "required"})
+	})
+
+	tests := []struct {
+		name       string
+		req        apitest.Request
+		wantStatus int
+		wantFields map[string]interface{}
+	}{
+		{
+			name:       "success envelope",
+			req:        apitest.Request{Method: http.MethodPost, Path: "/ok", Body: map[string]string{"a": "b"}, Headers: map[string]string{"X-Token": "t1"}},
+			wantStatus: http.StatusOK,
+			wantFields: map[string]interface{}{"success": true, "data.id": "abc", "data.count": 3, "data.method": "POST", "data.token": "t1"},
+		},
+		{
+			name:       "error envelope",
+			req:        apitest.Request{Method: http.MethodGet, Path: "/fail"},
+			wantStatus: http.StatusNotFound,
+			wantFields: map[string]interface{}{"error": true, "message": "not here"},
+		},
+		{
+			name:       "validation envelope",
+			req:        apitest.Request{Method: http.MethodPost, Path: "/invalid", Body: "{}"},
+			wantStatus: http.StatusBadRequest,
+			wantFields: map[string]interface{}{"errors.name": "required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := apitest.Do(t, mux, tt.req)
+			resp.AssertStatus(t, tt.wantStatus)
+			for path, want := range tt.wantFields {
+				resp.AssertField(t, path, want)
+			}
+		})
+	}
+
+	resp := apitest.Do(t, mux, apitest.Request{Method: http.MethodGet, Path: "/ok"})
+	if !resp.Envelope.Success || resp.Envelope.Error {
+		t.Errorf("Envelope = %+v, want success", resp.Envelope)
+	}
+	var data struct {
+		ID string `json:"id"`
+	}
+	resp.DecodeData(t, &data)
+	if data.ID != "abc" {
+		t.Errorf("DecodeData() id = %v, want %v", data.ID, "abc")
+	}
+	if _, ok := resp.Field(t, "data.missing"); ok {
+		t.Errorf("Field(data.missing) found, want missing")
+	}
+
+	fail := apitest.Do(t, mux, apitest.Request{Method: http.MethodGet, Path: "/fail"})
+	if !fail.Envelope.Error || fail.Envelope.Message != "not here" {
+		t.Errorf("Envelope = %+v, want error with message", fail.Envelope)
+	}
+}
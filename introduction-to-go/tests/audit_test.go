@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"effective-golang/internal/audit"
+	"effective-golang/internal/auth"
+	"effective-golang/pkg/utils"
+)
+
+// TestAuthServiceAudit tests that logins and password changes produce audit records
+func TestAuthServiceAudit(t *testing.T) {
+	ctx := audit.WithClientIP(context.Background(), "203.0.113.7")
+	uow := utils.NewInMemoryUnitOfWork()
+	auditLog := audit.NewMemoryLogger(100)
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository(), auth.WithAuditLogger(auditLog))
+
+	user, err := authService.Register(ctx, &auth.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "wrong-password"}); err == nil {
+		t.Fatalf("Login() with wrong password expected error but got none")
+	}
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if err := authService.ChangePassword(ctx, user.ID, "password123", "newpassword456"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+
+	entries, err := auditLog.Recent(ctx, 10)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+
+	want := []struct {
+		action  string
+		success bool
+	}{
+		{audit.ActionPasswordChange, true},
+		{audit.ActionLogin, true},
+		{audit.ActionLogin, false},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Recent() len = %v, want %v: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Action != w.action || e.Success != w.success {
+			t.Errorf("entry[%d] = %s success=%v, want %s success=%v", i, e.Action, e.Success, w.action, w.success)
+		}
+		if e.Actor != "alice" || e.Target != user.ID {
+			t.Errorf("entry[%d] actor/target = %s/%s, want alice/%s", i, e.Actor, e.Target, user.ID)
+		}
+		if e.ClientIP != "203.0.113.7" {
+			t.Errorf("entry[%d] client IP = %v, want %v", i, e.ClientIP, "203.0.113.7")
+		}
+		if e.Timestamp.IsZero() {
+			t.Errorf("entry[%d] timestamp not set", i)
+		}
+	}
+
+	// The new password works and the old one no longer does
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"}); err == nil {
+		t.Errorf("Login() with old password expected error but got none")
+	}
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "newpassword456"}); err != nil {
+		t.Errorf("Login() with new password error = %v", err)
+	}
+}
+
+// TestAuditRedaction tests that secret-looking detail keys are redacted
+func TestAuditRedaction(t *testing.T) {
+	ctx := context.Background()
+	fileLog, err := audit.NewFileLogger(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("NewFileLogger() error = %v", err)
+	}
+	t.Cleanup(func() { fileLog.Close() })
+
+	loggers := map[string]audit.Logger{
+		"memory": audit.NewMemoryLogger(10),
+		"file":   fileLog,
+	}
+
+	for name, logger := range loggers {
+		t.Run(name, func(t *testing.T) {
+			err := logger.Log(ctx, audit.Entry{
+				Actor:  "admin",
+				Action: audit.ActionMaintenance,
+				Details: map[string]string{
+					"new_password":  "hunter22",
+					"X-Admin-Token": "secret",
+					"enabled":       "true",
+				},
+			})
+			if err != nil {
+				t.Fatalf("Log() error = %v", err)
+			}
+
+			entries, err := logger.Recent(ctx, 1)
+			if err != nil {
+				t.Fatalf("Recent() error = %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("Recent() len = %v, want 1", len(entries))
+			}
+
+			details := entries[0].Details
+			if details["new_password"] != "[REDACTED]" || details["X-Admin-Token"] != "[REDACTED]" {
+				t.Errorf("Details = %v, want secrets redacted", details)
+			}
+			if details["enabled"] != "true" {
+				t.Errorf("Details[enabled] = %v, want %v", details["enabled"], "true")
+			}
+		})
+	}
+}
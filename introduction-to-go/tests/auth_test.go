@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/internal/audit"
+	"effective-golang/internal/auth"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// newAuthFixture returns an auth service with alice registered
+func newAuthFixture(t *testing.T, opts ...auth.AuthServiceOption) *auth.AuthService {
+	t.Helper()
+
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository(), opts...)
+	if _, err := authService.Register(context.Background(), &auth.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return authService
+}
+
+// TestLoginRateLimit tests that repeated failures lock a username out until the window ends
+func TestLoginRateLimit(t *testing.T) {
+	ctx := context.Background()
+	authService := newAuthFixture(t, auth.WithLoginRateLimit(3, time.Second))
+
+	for i := 0; i < 3; i++ {
+		_, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "wrong-password"})
+		if !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login() attempt %d error = %v, want %v", i+1, err, auth.ErrInvalidCredentials)
+		}
+	}
+
+	// Locked out: even the right password is not checked, and case does not dodge the limit
+	for _, username := range []string{"alice", "ALICE"} {
+		if _, err := authService.Login(ctx, &auth.LoginRequest{Username: username, Password: "password123"}); !errors.Is(err, auth.ErrTooManyAttempts) {
+			t.Errorf("Login(%s) while locked out error = %v, want %v", username, err, auth.ErrTooManyAttempts)
+		}
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Errorf("Login() after the window error = %v", err)
+	}
+}
+
+// TestLoginRateLimitReset tests that a successful login clears the username's failures
+func TestLoginRateLimitReset(t *testing.T) {
+	ctx := context.Background()
+	authService := newAuthFixture(t, auth.WithLoginRateLimit(3, time.Minute))
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 2; i++ {
+			if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "wrong-password"}); !errors.Is(err, auth.ErrInvalidCredentials) {
+				t.Fatalf("Login() round %d error = %v, want %v", round, err, auth.ErrInvalidCredentials)
+			}
+		}
+		if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+			t.Fatalf("Login() round %d error = %v, want the counter reset by the previous success", round, err)
+		}
+	}
+}
+
+// TestLoginRateLimitPerIP tests that failures across usernames from one IP lock that IP out
+func TestLoginRateLimitPerIP(t *testing.T) {
+	attacker := audit.WithClientIP(context.Background(), "203.0.113.7")
+	other := audit.WithClientIP(context.Background(), "198.51.100.1")
+	auditLog := audit.NewMemoryLogger(100)
+	authService := newAuthFixture(t, auth.WithLoginRateLimit(3, time.Minute), auth.WithAuditLogger(auditLog))
+
+	for _, username := range []string{"bob", "carol", "dave"} {
+		if _, err := authService.Login(attacker, &auth.LoginRequest{Username: username, Password: "guess"}); !errors.Is(err, auth.ErrInvalidCredentials) {
+			t.Fatalf("Login(%s) error = %v, want %v", username, err, auth.ErrInvalidCredentials)
+		}
+	}
+
+	if _, err := authService.Login(attacker, &auth.LoginRequest{Username: "alice", Password: "password123"}); !errors.Is(err, auth.ErrTooManyAttempts) {
+		t.Errorf("Login() from the locked out IP error = %v, want %v", err, auth.ErrTooManyAttempts)
+	}
+	if _, err := authService.Login(other, &auth.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Errorf("Login() from another IP error = %v", err)
+	}
+
+	entries, err := auditLog.Recent(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(entries) != 2 || entries[1].Details["reason"] != "too many attempts" {
+		t.Errorf("Recent() = %+v, want the lockout recorded", entries)
+	}
+}
+
+// TestLoginRateLimitDisabled tests that a limit of 0 never locks anyone out
+func TestLoginRateLimitDisabled(t *testing.T) {
+	ctx := context.Background()
+	authService := newAuthFixture(t, auth.WithLoginRateLimit(0, time.Minute))
+
+	for i := 0; i < auth.DefaultLoginMaxAttempts*2; i++ {
+		authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "wrong-password"})
+	}
+	if _, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"}); err != nil {
+		t.Errorf("Login() with rate limiting disabled error = %v", err)
+	}
+}
+
+// TestChangePassword tests that a password change needs the current password and a valid new one
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	user, err := authService.Register(ctx, &auth.RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registeredAt := user.UpdatedAt
+
+	if err := authService.ChangePassword(ctx, user.ID, "wrong-password", "newpassword456"); !errors.Is(err, auth.ErrInvalidCredentials) {
+		t.Errorf("ChangePassword() with the wrong current password error = %v, want %v", err, auth.ErrInvalidCredentials)
+	}
+	if err := authService.ChangePassword(ctx, user.ID, "password123", "short"); !errors.Is(err, models.ErrInvalidPassword) {
+		t.Errorf("ChangePassword() to a short password error = %v, want %v", err, models.ErrInvalidPassword)
+	}
+	if err := authService.ChangePassword(ctx, "missing", "password123", "newpassword456"); !errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("ChangePassword() for a missing user error = %v, want %v", err, models.ErrUserNotFound)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := authService.ChangePassword(ctx, user.ID, "password123", "newpassword456"); err != nil {
+		t.Fatalf("ChangePassword() error = %v", err)
+	}
+	saved, err := uow.UserRepository().GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if !saved.CheckPassword("newpassword456") || !saved.UpdatedAt.After(registeredAt) {
+		t.Errorf("saved user password changed = %v, updated at %v after %v", saved.CheckPassword("newpassword456"), saved.UpdatedAt, registeredAt)
+	}
+}
+
+// TestUserSessions tests that concurrent logins are all indexed and can be revoked together
+func TestUserSessions(t *testing.T) {
+	ctx := context.Background()
+	authService := newAuthFixture(t)
+	bob, err := authService.Register(ctx, &auth.RegisterRequest{Username: "bob", Email: "bob@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	bobSession, err := authService.Login(ctx, &auth.LoginRequest{Username: "bob", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Login(bob) error = %v", err)
+	}
+
+	const logins = 20
+	var wg sync.WaitGroup
+	sessions := make(chan *auth.Session, logins)
+	for i := 0; i < logins; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session, err := authService.Login(ctx, &auth.LoginRequest{Username: "alice", Password: "password123"})
+			if err != nil {
+				t.Errorf("Login(alice) error = %v", err)
+				return
+			}
+			sessions <- session
+		}()
+	}
+	wg.Wait()
+	close(sessions)
+
+	var aliceID, loggedOut string
+	for session := range sessions {
+		aliceID, loggedOut = session.UserID, session.ID
+	}
+
+	listed, err := authService.ListSessions(ctx, aliceID)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(listed) != logins {
+		t.Fatalf("ListSessions() = %d sessions, want %d", len(listed), logins)
+	}
+	for i := 1; i < len(listed); i++ {
+		if listed[i].CreatedAt.Before(listed[i-1].CreatedAt) {
+			t.Errorf("ListSessions() not oldest first at %d", i)
+		}
+	}
+
+	// Logging out drops the session from the index
+	if err := authService.Logout(ctx, loggedOut); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if listed, _ := authService.ListSessions(ctx, aliceID); len(listed) != logins-1 {
+		t.Errorf("ListSessions() after logout = %d sessions, want %d", len(listed), logins-1)
+	}
+
+	if err := authService.RevokeAllSessions(ctx, aliceID); err != nil {
+		t.Fatalf("RevokeAllSessions() error = %v", err)
+	}
+	if listed, err := authService.ListSessions(ctx, aliceID); err != nil || len(listed) != 0 {
+		t.Errorf("ListSessions() after revoke = %d sessions, %v, want none", len(listed), err)
+	}
+	for _, session := range listed {
+		if _, err := authService.ValidateSession(ctx, session.ID); !errors.Is(err, auth.ErrSessionNotFound) {
+			t.Errorf("ValidateSession(%s) after revoke error = %v, want %v", session.ID, err, auth.ErrSessionNotFound)
+		}
+	}
+
+	// Other users keep their sessions
+	if _, err := authService.ValidateSession(ctx, bobSession.ID); err != nil {
+		t.Errorf("ValidateSession(bob) after revoking alice error = %v", err)
+	}
+	if listed, _ := authService.ListSessions(ctx, bob.ID); len(listed) != 1 {
+		t.Errorf("ListSessions(bob) = %d sessions, want 1", len(listed))
+	}
+	if got := authService.Stats().ActiveSessions; got != 1 {
+		t.Errorf("Stats().ActiveSessions = %d, want 1", got)
+	}
+}
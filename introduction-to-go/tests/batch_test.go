@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// countingCacheRepo counts deletes of one cache key
+type countingCacheRepo struct {
+	models.CacheRepository
+	key     string
+	deletes atomic.Int64
+}
+
+func (r *countingCacheRepo) Delete(ctx context.Context, key string) error {
+	if key == r.key {
+		r.deletes.Add(1)
+	}
+	return r.CacheRepository.Delete(ctx, key)
+}
+
+// batchFixture is a leaderboard service with a capped leaderboard and four players
+type batchFixture struct {
+	svc   *leaderboard.LeaderboardService
+	cache *countingCacheRepo
+	lb    *models.Leaderboard
+	users map[string]*models.User
+}
+
+func newBatchFixture(t *testing.T, maxEntries int) *batchFixture {
+	t.Helper()
+
+	uow := utils.NewInMemoryUnitOfWork()
+	cache := &countingCacheRepo{CacheRepository: uow.CacheRepository()}
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), cache, 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(context.Background(), "Round", models.LeaderboardTypeGlobal, maxEntries)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	cache.key = fmt.Sprintf("leaderboard:%s", lb.ID)
+
+	return &batchFixture{
+		svc:   svc,
+		cache: cache,
+		lb:    lb,
+		users: seedUsers(t, uow.UserRepository(), "ann", "ben", "cat", "dan"),
+	}
+}
+
+// scores returns each user's score on the fixture's leaderboard, by username
+func (f *batchFixture) scores(t *testing.T) map[string]int64 {
+	t.Helper()
+
+	page, err := f.svc.GetTopEntries(context.Background(), f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	scores := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		scores[entry.Username] = entry.Score
+	}
+	return scores
+}
+
+// TestAddScores tests that a batch is applied at once, invalidates the cache
+// once and publishes a single update
+func TestAddScores(t *testing.T) {
+	ctx := context.Background()
+	f := newBatchFixture(t, 10)
+
+	sub, err := f.svc.Subscribe(f.lb.ID, leaderboard.DefaultSubscription)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	t.Cleanup(sub.Close)
+
+	err = f.svc.AddScores(ctx, f.lb.ID, map[string]int64{
+		f.users["ann"].ID: 30,
+		f.users["ben"].ID: 10,
+		f.users["cat"].ID: 20,
+	})
+	if err != nil {
+		t.Fatalf("AddScores() error = %v", err)
+	}
+
+	if got := f.cache.deletes.Load(); got != 1 {
+		t.Errorf("cache invalidations = %d, want 1", got)
+	}
+
+	var updates []*leaderboard.LeaderboardUpdate
+	for len(sub.C()) > 0 {
+		updates = append(updates, <-sub.C())
+	}
+	if len(updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(updates))
+	}
+	if updates[0].Type != "batch_updated" || len(updates[0].Entries) != 3 {
+		t.Errorf("update = %s with %d entries, want batch_updated with 3", updates[0].Type, len(updates[0].Entries))
+	}
+	wantRanks := map[string]int{"ann": 1, "cat": 2, "ben": 3}
+	for _, entry := range updates[0].Entries {
+		if entry.Rank != wantRanks[entry.Username] {
+			t.Errorf("update rank for %s = %d, want %d", entry.Username, entry.Rank, wantRanks[entry.Username])
+		}
+	}
+}
+
+// TestAddScoresRollback tests that a batch with any bad score leaves the board untouched
+func TestAddScoresRollback(t *testing.T) {
+	tests := []struct {
+		name    string
+		scores  func(users map[string]*models.User) map[string]int64
+		wantErr error
+	}{
+		{
+			name: "unknown user",
+			scores: func(users map[string]*models.User) map[string]int64 {
+				return map[string]int64{users["ann"].ID: 99, "nobody": 50}
+			},
+			wantErr: models.ErrUserNotFound,
+		},
+		{
+			name: "negative score",
+			scores: func(users map[string]*models.User) map[string]int64 {
+				return map[string]int64{users["ann"].ID: 99, users["cat"].ID: -1}
+			},
+			wantErr: leaderboard.ErrInvalidScore,
+		},
+		{
+			// ann's raise is rolled back along with cat's score, which misses the full board
+			name: "one score misses a full board",
+			scores: func(users map[string]*models.User) map[string]int64 {
+				return map[string]int64{users["ann"].ID: 99, users["cat"].ID: 1}
+			},
+			wantErr: models.ErrLeaderboardFull,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			f := newBatchFixture(t, 2)
+			if err := f.svc.AddScores(ctx, f.lb.ID, map[string]int64{f.users["ann"].ID: 30, f.users["ben"].ID: 20}); err != nil {
+				t.Fatalf("AddScores() error = %v", err)
+			}
+			invalidations := f.cache.deletes.Load()
+
+			err := f.svc.AddScores(ctx, f.lb.ID, tt.scores(f.users))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddScores() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := map[string]int64{"ann": 30, "ben": 20}
+			got := f.scores(t)
+			if len(got) != len(want) || got["ann"] != want["ann"] || got["ben"] != want["ben"] {
+				t.Errorf("scores after failed batch = %v, want %v", got, want)
+			}
+			if f.cache.deletes.Load() != invalidations {
+				t.Errorf("failed batch invalidated the cache")
+			}
+		})
+	}
+}
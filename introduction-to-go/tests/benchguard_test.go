@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+var updateBaselines = flag.Bool("update-baselines", false, "rewrite "+baselinesFile+" from this machine's benchmark results")
+
+// baselinesFile holds the committed numbers the hot path benchmarks are held to
+const baselinesFile = "testdata/bench_baselines.json"
+
+// benchBaseline is one benchmark's committed result
+type benchBaseline struct {
+	NsPerOp     int64 `json:"ns_per_op"`
+	AllocsPerOp int64 `json:"allocs_per_op"`
+}
+
+// benchBaselines is the contents of baselinesFile. Timings vary between
+// machines, so NsTolerance is generous; allocation counts do not, so any
+// increase over the baseline fails.
+type benchBaselines struct {
+	// NsTolerance is how much slower than its baseline a benchmark may run,
+	// as a fraction: 1.0 allows twice the baseline ns/op
+	NsTolerance float64                  `json:"ns_tolerance"`
+	Benchmarks  map[string]benchBaseline `json:"benchmarks"`
+}
+
+// guardedBenchmarks are the hot paths checked against the baselines
+var guardedBenchmarks = map[string]func(*testing.B){
+	"NewUser":             BenchmarkNewUser,
+	"LeaderboardAddEntry": BenchmarkLeaderboardAddEntry,
+	"CacheGet":            BenchmarkCacheGet,
+}
+
+// BenchmarkLeaderboardAddEntry updates scores on a full 1000-entry board
+func BenchmarkLeaderboardAddEntry(b *testing.B) {
+	lb := models.NewLeaderboard("Bench", models.LeaderboardTypeGlobal, 1000)
+	for i := 0; i < 1000; i++ {
+		if err := lb.AddEntry(fmt.Sprintf("user%d", i), fmt.Sprintf("player%d", i), int64(i)); err != nil {
+			b.Fatalf("AddEntry() error = %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := lb.AddEntry(fmt.Sprintf("user%d", i%1000), "player", int64(i%5000)); err != nil {
+			b.Fatalf("AddEntry() error = %v", err)
+		}
+	}
+}
+
+// BenchmarkCacheGet reads a cached leaderboard entry back from the in-memory cache
+func BenchmarkCacheGet(b *testing.B) {
+	ctx := context.Background()
+	cache := utils.NewInMemoryUnitOfWork().CacheRepository()
+	entry := models.LeaderboardEntry{UserID: "user1", Username: "player1", Score: 1200, Rank: 3}
+	if err := cache.Set(ctx, "entry", entry, 3600); err != nil {
+		b.Fatalf("Set() error = %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var got models.LeaderboardEntry
+		if err := cache.Get(ctx, "entry", &got); err != nil {
+			b.Fatalf("Get() error = %v", err)
+		}
+	}
+}
+
+// TestBenchmarkRegression runs the hot path benchmarks and fails when one is
+// slower or allocates more than its committed baseline allows. Run it with
+// -update-baselines to record new baselines after a deliberate change.
+func TestBenchmarkRegression(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benchmarks take a few seconds; skipped in short mode")
+	}
+	if raceEnabled {
+		t.Skip("the race detector distorts timings")
+	}
+
+	baselines := benchBaselines{NsTolerance: 1.0, Benchmarks: map[string]benchBaseline{}}
+	if data, err := os.ReadFile(baselinesFile); err == nil {
+		if err := json.Unmarshal(data, &baselines); err != nil {
+			t.Fatalf("invalid %s: %v", baselinesFile, err)
+		}
+	} else if !*updateBaselines {
+		t.Fatalf("read %s: %v; run with -update-baselines to create it", baselinesFile, err)
+	}
+
+	names := make([]string, 0, len(guardedBenchmarks))
+	for name := range guardedBenchmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		result := testing.Benchmark(guardedBenchmarks[name])
+		got := benchBaseline{NsPerOp: result.NsPerOp(), AllocsPerOp: result.AllocsPerOp()}
+
+		if *updateBaselines {
+			baselines.Benchmarks[name] = got
+			t.Logf("%s: %d ns/op, %d allocs/op", name, got.NsPerOp, got.AllocsPerOp)
+			continue
+		}
+
+		want, ok := baselines.Benchmarks[name]
+		if !ok {
+			t.Errorf("%s has no baseline in %s; run with -update-baselines", name, baselinesFile)
+			continue
+		}
+		if limit := float64(want.NsPerOp) * (1 + baselines.NsTolerance); float64(got.NsPerOp) > limit {
+			t.Errorf("%s: %d ns/op, want at most %.0f (baseline %d + %.0f%%)", name, got.NsPerOp, limit, want.NsPerOp, baselines.NsTolerance*100)
+		}
+		if got.AllocsPerOp > want.AllocsPerOp {
+			t.Errorf("%s: %d allocs/op, want at most the baseline %d", name, got.AllocsPerOp, want.AllocsPerOp)
+		}
+	}
+
+	if *updateBaselines {
+		data, err := json.MarshalIndent(baselines, "", "  ")
+		if err != nil {
+			t.Fatalf("MarshalIndent() error = %v", err)
+		}
+		if err := os.WriteFile(baselinesFile, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("write %s: %v", baselinesFile, err)
+		}
+	}
+}
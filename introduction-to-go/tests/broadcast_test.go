@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/pkg/broadcast"
+)
+
+// receive reads everything currently buffered on ch
+func receive(ch <-chan int) []int {
+	var got []int
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		default:
+			return got
+		}
+	}
+}
+
+// TestBroadcastPolicies tests what each policy keeps when a subscriber falls behind
+func TestBroadcastPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      broadcast.Policy
+		want        []int
+		wantDropped uint64
+	}{
+		{name: "drop newest keeps the first N", policy: broadcast.DropNewest, want: []int{1, 2, 3}, wantDropped: 7},
+		{name: "drop oldest keeps the latest N", policy: broadcast.DropOldest, want: []int{8, 9, 10}, wantDropped: 7},
+		{name: "block with timeout gives up on a stalled subscriber", policy: broadcast.BlockWithTimeout, want: []int{1, 2, 3}, wantDropped: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := broadcast.New[int]()
+			defer b.Close()
+			sub := b.Subscribe(broadcast.Options{Buffer: 3, Policy: tt.policy, Timeout: time.Millisecond})
+
+			dropped := 0
+			for i := 1; i <= 10; i++ {
+				dropped += b.Publish(i)
+			}
+
+			got := receive(sub.C())
+			if len(got) != len(tt.want) {
+				t.Fatalf("received %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("received %v, want %v", got, tt.want)
+				}
+			}
+			if sub.Dropped() != tt.wantDropped || uint64(dropped) != tt.wantDropped {
+				t.Errorf("Dropped() = %v, Publish reported %v, want %v", sub.Dropped(), dropped, tt.wantDropped)
+			}
+			if stats := b.Stats(); stats.Published != 10 || stats.Dropped != tt.wantDropped || stats.Subscribers != 1 {
+				t.Errorf("Stats() = %+v, want 10 published, %v dropped, 1 subscriber", stats, tt.wantDropped)
+			}
+		})
+	}
+}
+
+// TestBroadcastBlockWithTimeoutSlowConsumer tests that a slow subscriber that
+// keeps up within the timeout gets every message
+func TestBroadcastBlockWithTimeoutSlowConsumer(t *testing.T) {
+	b := broadcast.New[int]()
+	defer b.Close()
+	sub := b.Subscribe(broadcast.Options{Buffer: 1, Policy: broadcast.BlockWithTimeout, Timeout: time.Second})
+
+	const messages = 20
+	var got []int
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for v := range sub.C() {
+			time.Sleep(time.Millisecond)
+			got = append(got, v)
+			if len(got) == messages {
+				return
+			}
+		}
+	}()
+
+	for i := 1; i <= messages; i++ {
+		if dropped := b.Publish(i); dropped != 0 {
+			t.Fatalf("Publish(%d) dropped for %d subscribers, want 0", i, dropped)
+		}
+	}
+	wg.Wait()
+
+	if len(got) != messages || got[0] != 1 || got[messages-1] != messages {
+		t.Errorf("received %v, want 1..%d in order", got, messages)
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("Dropped() = %v, want 0", sub.Dropped())
+	}
+}
+
+// TestBroadcastIsolatesSubscribers tests that drops are counted per subscriber
+// and that closing one subscription leaves the others running
+func TestBroadcastIsolatesSubscribers(t *testing.T) {
+	b := broadcast.New[int]()
+	fast := b.Subscribe(broadcast.Options{Buffer: 10})
+	slow := b.Subscribe(broadcast.Options{Buffer: 2})
+	leaving := b.Subscribe(broadcast.Options{Buffer: 10})
+
+	leaving.Close()
+	leaving.Close() // closing twice is harmless
+	if _, ok := <-leaving.C(); ok {
+		t.Error("closed subscription channel is still open")
+	}
+
+	for i := 1; i <= 5; i++ {
+		b.Publish(i)
+	}
+
+	if got := receive(fast.C()); len(got) != 5 || fast.Dropped() != 0 {
+		t.Errorf("fast subscriber got %v with %d dropped, want 5 messages and none dropped", got, fast.Dropped())
+	}
+	if got := receive(slow.C()); len(got) != 2 || slow.Dropped() != 3 {
+		t.Errorf("slow subscriber got %v with %d dropped, want 2 messages and 3 dropped", got, slow.Dropped())
+	}
+	if stats := b.Stats(); stats.Subscribers != 2 || stats.Dropped != 3 {
+		t.Errorf("Stats() = %+v, want 2 subscribers and 3 dropped", stats)
+	}
+
+	b.Close()
+	if _, ok := <-fast.C(); ok {
+		t.Error("subscription channel is still open after the broadcaster closed")
+	}
+	if dropped := b.Publish(6); dropped != 0 {
+		t.Errorf("Publish() after Close dropped %d, want 0", dropped)
+	}
+}
+
+// TestBroadcastGapMarker tests that only subscribers asking for it get the first message after a drop marked
+func TestBroadcastGapMarker(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy broadcast.Policy
+		want   []int
+	}{
+		// 3 and 4 are dropped, so 5 follows the gap
+		{"drop newest", broadcast.DropNewest, []int{1, 2, -5, 6}},
+		// 3 and 4 each evict an older message, so both follow a gap
+		{"drop oldest", broadcast.DropOldest, []int{-3, -4, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := broadcast.New[int]()
+			b.SetGapMarker(func(v int) int { return -v })
+			marked := b.Subscribe(broadcast.Options{Buffer: 2, Policy: tt.policy, MarkGaps: true})
+			plain := b.Subscribe(broadcast.Options{Buffer: 10, Policy: tt.policy, MarkGaps: true})
+			unmarked := b.Subscribe(broadcast.Options{Buffer: 2, Policy: tt.policy})
+
+			for v := 1; v <= 4; v++ {
+				b.Publish(v)
+			}
+			got := receive(marked.C())
+			for v := 5; v <= 6; v++ {
+				b.Publish(v)
+			}
+			got = append(got, receive(marked.C())...)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("marked subscriber got %v, want %v", got, tt.want)
+			}
+			if got := receive(plain.C()); !slices.Equal(got, []int{1, 2, 3, 4, 5, 6}) {
+				t.Errorf("subscriber that kept up got %v, want every message unmarked", got)
+			}
+			for _, v := range receive(unmarked.C()) {
+				if v < 0 {
+					t.Errorf("subscriber without MarkGaps got marked message %d", v)
+				}
+			}
+		})
+	}
+}
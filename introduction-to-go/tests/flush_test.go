@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// countingLeaderboardRepo counts the writes that reach the wrapped repository
+type countingLeaderboardRepo struct {
+	models.LeaderboardRepository
+	writes atomic.Int64
+}
+
+func (r *countingLeaderboardRepo) Update(ctx context.Context, leaderboard *models.Leaderboard) error {
+	r.writes.Add(1)
+	return r.LeaderboardRepository.Update(ctx, leaderboard)
+}
+
+func (r *countingLeaderboardRepo) AddEntry(ctx context.Context, leaderboardID string, entry *models.LeaderboardEntry) error {
+	r.writes.Add(1)
+	return r.LeaderboardRepository.AddEntry(ctx, leaderboardID, entry)
+}
+
+func (r *countingLeaderboardRepo) RemoveEntry(ctx context.Context, leaderboardID, userID string) error {
+	r.writes.Add(1)
+	return r.LeaderboardRepository.RemoveEntry(ctx, leaderboardID, userID)
+}
+
+// flushFixture is a periodically flushed leaderboard service with three players
+type flushFixture struct {
+	svc     *leaderboard.LeaderboardService
+	repo    *countingLeaderboardRepo
+	backing models.LeaderboardRepository
+	lb      *models.Leaderboard
+	users   []string
+}
+
+func newFlushFixture(t *testing.T, interval time.Duration) *flushFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	repo := &countingLeaderboardRepo{LeaderboardRepository: uow.LeaderboardRepository()}
+	svc := leaderboard.NewLeaderboardService(repo, uow.UserRepository(), uow.CacheRepository(), 300, leaderboard.WithPeriodicFlush(interval))
+
+	lb, err := svc.CreateLeaderboard(ctx, "Buffered", models.LeaderboardTypeGlobal, 10)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+
+	f := &flushFixture{svc: svc, repo: repo, backing: uow.LeaderboardRepository(), lb: lb}
+	for i := 0; i < 3; i++ {
+		user, err := authService.Register(ctx, &auth.RegisterRequest{
+			Username: fmt.Sprintf("buffered%d", i),
+			Email:    fmt.Sprintf("buffered%d@example.com", i),
+			Password: "password123",
+		})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		f.users = append(f.users, user.ID)
+	}
+	return f
+}
+
+// play scores every player several times and removes the last one
+func (f *flushFixture) play(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+
+	for round := int64(1); round <= 5; round++ {
+		for i, userID := range f.users {
+			if err := f.svc.AddScore(ctx, f.lb.ID, userID, round*int64(10*(i+1))); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+		}
+	}
+	if err := f.svc.RemoveEntry(ctx, f.lb.ID, f.users[2]); err != nil {
+		t.Fatalf("RemoveEntry() error = %v", err)
+	}
+}
+
+// assertStored checks the final state of the board in the backing repository
+func (f *flushFixture) assertStored(t *testing.T) {
+	t.Helper()
+
+	entries, _, err := f.backing.GetTopEntries(context.Background(), f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("stored %d entries, want 2", len(entries))
+	}
+	if entries[0].UserID != f.users[1] || entries[0].Score != 100 {
+		t.Errorf("stored entries[0] = %+v, want second player with 100", entries[0])
+	}
+	if entries[1].UserID != f.users[0] || entries[1].Score != 50 {
+		t.Errorf("stored entries[1] = %+v, want first player with 50", entries[1])
+	}
+}
+
+// TestPeriodicFlushOnClose tests that changes stay in memory until the service is closed
+func TestPeriodicFlushOnClose(t *testing.T) {
+	f := newFlushFixture(t, time.Hour)
+	ctx := context.Background()
+	f.play(t)
+
+	if writes := f.repo.writes.Load(); writes != 0 {
+		t.Errorf("repository writes before flush = %d, want 0", writes)
+	}
+	if entries, _, err := f.backing.GetTopEntries(ctx, f.lb.ID, 0, 10, false); err != nil || len(entries) != 0 {
+		t.Errorf("stored entries before flush = %d, %v, want none", len(entries), err)
+	}
+
+	// The service reads its in-memory boards, so it is current regardless
+	page, err := f.svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if len(entries) != 2 || entries[0].Score != 100 {
+		t.Errorf("GetTopEntries() = %+v, want 2 entries led by 100", entries)
+	}
+
+	f.svc.Close()
+	if writes := f.repo.writes.Load(); writes != 1 {
+		t.Errorf("repository writes after Close = %d, want 1", writes)
+	}
+	f.assertStored(t)
+}
+
+// TestPeriodicFlushScheduled tests that dirty boards are written once per interval
+func TestPeriodicFlushScheduled(t *testing.T) {
+	f := newFlushFixture(t, 20*time.Millisecond)
+	defer f.svc.Close()
+	f.play(t)
+
+	// A tick may land mid-game, so wait for the flush that carries the removal
+	waitFor(t, "scheduled flush", func() bool {
+		entries, _, err := f.backing.GetTopEntries(context.Background(), f.lb.ID, 0, 10, false)
+		return err == nil && len(entries) == 2 && entries[0].Score == 100
+	})
+	f.assertStored(t)
+
+	// Nothing changed since, so later ticks do not write again
+	writes := f.repo.writes.Load()
+	time.Sleep(60 * time.Millisecond)
+	if got := f.repo.writes.Load(); got != writes {
+		t.Errorf("repository writes with no changes = %d, want %d", got, writes)
+	}
+}
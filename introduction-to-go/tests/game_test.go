@@ -0,0 +1,1260 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/game"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// gameFixture wires a game service to fresh in-memory repositories with two registered players
+type gameFixture struct {
+	uow     models.UnitOfWork
+	svc     *game.GameService
+	player1 *models.User
+	player2 *models.User
+}
+
+func newGameFixture(t *testing.T) *gameFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+
+	player1, err := authService.Register(ctx, &auth.RegisterRequest{Username: "player1", Email: "p1@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register(player1) error = %v", err)
+	}
+	player2, err := authService.Register(ctx, &auth.RegisterRequest{Username: "player2", Email: "p2@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register(player2) error = %v", err)
+	}
+
+	svc := game.NewGameService(
+		uow.GameRepository(),
+		uow.UserRepository(),
+		uow.LeaderboardRepository(),
+		uow.CacheRepository(),
+		2,
+		10,
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	return &gameFixture{uow: uow, svc: svc, player1: player1, player2: player2}
+}
+
+// TestCancelGame tests cancelling games in the waiting and playing states
+func TestCancelGame(t *testing.T) {
+	tests := []struct {
+		name  string
+		start bool
+	}{
+		{name: "waiting game", start: false},
+		{name: "playing game", start: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+
+			g, err := f.svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+			if err != nil {
+				t.Fatalf("CreateGame() error = %v", err)
+			}
+
+			if tt.start {
+				if err := f.svc.StartGame(ctx, g.ID); err != nil {
+					t.Fatalf("StartGame() error = %v", err)
+				}
+				if err := f.svc.UpdateScore(ctx, g.ID, f.player1.ID, 50); err != nil {
+					t.Fatalf("UpdateScore() error = %v", err)
+				}
+			}
+
+			if err := f.svc.CancelGame(ctx, g.ID); err != nil {
+				t.Fatalf("CancelGame() error = %v", err)
+			}
+
+			// Drain the event processor before inspecting stats
+			f.svc.Close()
+
+			cancelled, err := f.svc.GetGame(ctx, g.ID)
+			if err != nil {
+				t.Fatalf("GetGame() error = %v", err)
+			}
+			if cancelled.State != models.GameStateCancelled {
+				t.Errorf("CancelGame() state = %v, want %v", cancelled.State, models.GameStateCancelled)
+			}
+
+			active, _ := f.svc.GetActiveGames(ctx)
+			for _, a := range active {
+				if a.ID == g.ID {
+					t.Errorf("CancelGame() game %s still in active set", g.ID)
+				}
+			}
+
+			for _, player := range []*models.User{f.player1, f.player2} {
+				stats, err := f.uow.UserRepository().GetStats(ctx, player.ID)
+				if err != nil {
+					t.Fatalf("GetStats() error = %v", err)
+				}
+				if stats.TotalGames != 0 || stats.Wins != 0 || stats.Losses != 0 || stats.TotalScore != 0 {
+					t.Errorf("CancelGame() changed stats for %s: %+v", player.Username, stats)
+				}
+			}
+
+			if err := f.svc.CancelGame(ctx, g.ID); err == nil {
+				t.Errorf("CancelGame() on cancelled game expected error but got none")
+			}
+		})
+	}
+}
+
+// blockingCache holds event handlers inside cache writes for game keys until released,
+// keeping every event worker busy
+type blockingCache struct {
+	models.CacheRepository
+	release chan struct{}
+}
+
+func (c *blockingCache) Set(ctx context.Context, key string, value interface{}, ttl int) error {
+	if strings.HasPrefix(key, "game:") {
+		<-c.release
+	}
+	return c.CacheRepository.Set(ctx, key, value, ttl)
+}
+
+// TestUpdateScoreQueueSaturation tests that events dropped by a saturated queue are counted
+func TestUpdateScoreQueueSaturation(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []game.GameServiceOption
+		wantMinDrop uint64
+	}{
+		{name: "non-blocking enqueue", opts: nil, wantMinDrop: 4},
+		{name: "blocking enqueue with timeout", opts: []game.GameServiceOption{game.WithEnqueueTimeout(10 * time.Millisecond)}, wantMinDrop: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+
+			cache := &blockingCache{CacheRepository: f.uow.CacheRepository(), release: make(chan struct{})}
+			svc := game.NewGameService(
+				f.uow.GameRepository(),
+				f.uow.UserRepository(),
+				f.uow.LeaderboardRepository(),
+				cache,
+				1, // one worker, blocked on the first event
+				1, // room for one waiting event
+				tt.opts...,
+			)
+			t.Cleanup(func() { svc.Close() })
+			t.Cleanup(func() { close(cache.release) })
+
+			g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+			if err != nil {
+				t.Fatalf("CreateGame() error = %v", err)
+			}
+			if err := svc.StartGame(ctx, g.ID); err != nil {
+				t.Fatalf("StartGame() error = %v", err)
+			}
+
+			// At most one event is being handled and one is waiting; the rest must be dropped
+			const updates = 5
+			for i := 1; i <= updates; i++ {
+				if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, int64(i*10)); err != nil {
+					t.Fatalf("UpdateScore() error = %v", err)
+				}
+			}
+
+			stats := svc.EventStats()
+			if stats.Dropped < tt.wantMinDrop {
+				t.Errorf("EventStats().Dropped = %v, want >= %v", stats.Dropped, tt.wantMinDrop)
+			}
+			if stats.Queued+stats.Dropped != updates+1 {
+				t.Errorf("EventStats() queued %v + dropped %v, want %v events accounted for", stats.Queued, stats.Dropped, updates+1)
+			}
+			if stats.QueueCapacity != 1 {
+				t.Errorf("EventStats().QueueCapacity = %v, want 1", stats.QueueCapacity)
+			}
+
+			// The score itself is still saved even though its event was dropped
+			saved, err := svc.GetGame(ctx, g.ID)
+			if err != nil {
+				t.Fatalf("GetGame() error = %v", err)
+			}
+			if saved.Score1 != updates*10 {
+				t.Errorf("Score1 = %v, want %v", saved.Score1, updates*10)
+			}
+		})
+	}
+}
+
+// TestQueueEventWithContext tests that a blocking enqueue waits for room in a
+// full queue and gets in once the queue drains, while QueueEvent drops
+func TestQueueEventWithContext(t *testing.T) {
+	uow := utils.NewInMemoryUnitOfWork()
+	cache := &blockingCache{CacheRepository: uow.CacheRepository(), release: make(chan struct{})}
+	svc := game.NewGameService(
+		uow.GameRepository(),
+		uow.UserRepository(),
+		uow.LeaderboardRepository(),
+		cache,
+		1, // one worker, blocked on the first event
+		1, // room for one waiting event
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	event := func(gameID string) *game.GameEvent {
+		return &game.GameEvent{GameID: gameID, EventType: "game_started", Timestamp: time.Now()}
+	}
+
+	// The first event blocks the worker; once it is taken the second fills the queue
+	if err := svc.QueueEvent(event("first")); err != nil {
+		t.Fatalf("QueueEvent() error = %v", err)
+	}
+	waitFor(t, "worker to take the first event", func() bool { return svc.EventStats().QueueLength == 0 })
+	if err := svc.QueueEvent(event("second")); err != nil {
+		t.Fatalf("QueueEvent() error = %v", err)
+	}
+
+	if err := svc.QueueEvent(event("dropped")); !errors.Is(err, game.ErrEventQueueFull) {
+		t.Fatalf("QueueEvent() on a full queue error = %v, want ErrEventQueueFull", err)
+	}
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := svc.QueueEventWithContext(timeoutCtx, event("timed out")); !errors.Is(err, game.ErrEventQueueFull) {
+		t.Fatalf("QueueEventWithContext() past its deadline error = %v, want ErrEventQueueFull", err)
+	}
+
+	queued := make(chan error, 1)
+	go func() { queued <- svc.QueueEventWithContext(context.Background(), event("waiting")) }()
+	select {
+	case err := <-queued:
+		t.Fatalf("QueueEventWithContext() returned %v before the queue had room", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(cache.release)
+	select {
+	case err := <-queued:
+		if err != nil {
+			t.Fatalf("QueueEventWithContext() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("QueueEventWithContext() still blocked after the queue drained")
+	}
+	waitFor(t, "queue to drain", func() bool { return svc.EventStats().Processed == 3 })
+
+	stats := svc.EventStats()
+	if stats.Queued != 3 || stats.Dropped != 2 {
+		t.Errorf("EventStats() queued %v, dropped %v, want 3 queued and 2 dropped", stats.Queued, stats.Dropped)
+	}
+}
+
+// TestQueueMetrics tests that the metrics snapshot tracks queue depth, worker
+// availability and the event counters as load builds up and drains
+func TestQueueMetrics(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+
+	cache := &blockingCache{CacheRepository: f.uow.CacheRepository(), release: make(chan struct{})}
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		cache,
+		1, // one worker, blocked on the first event
+		2,
+	)
+
+	if _, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID); err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if got := svc.Metrics(); got != (game.QueueMetrics{QueueCapacity: 2, Workers: 1, IdleWorkers: 1, MaxWorkers: 1, ActiveGames: 1}) {
+		t.Errorf("Metrics() before any events = %+v, want an idle worker and an empty queue", got)
+	}
+
+	// The first event holds the only worker, the next two fill the queue and the rest are dropped
+	event := &game.GameEvent{GameID: "load", EventType: "game_started", Timestamp: time.Now()}
+	if err := svc.QueueEvent(event); err != nil {
+		t.Errorf("QueueEvent() error = %v", err)
+	}
+	waitFor(t, "worker to take the first event", func() bool {
+		m := svc.Metrics()
+		return m.QueueDepth == 0 && m.IdleWorkers == 0
+	})
+	for i := 0; i < 4; i++ {
+		svc.QueueEvent(event)
+	}
+
+	got := svc.Metrics()
+	if got.QueueDepth != 2 || got.IdleWorkers != 0 || got.Workers != 1 {
+		t.Errorf("Metrics() under load = %+v, want a full queue and no idle workers", got)
+	}
+	if got.Queued != 3 || got.Dropped != 2 || got.Processed != 0 {
+		t.Errorf("Metrics() counted %d queued, %d dropped, %d processed, want 3, 2, 0", got.Queued, got.Dropped, got.Processed)
+	}
+
+	close(cache.release)
+	waitFor(t, "queue to drain", func() bool {
+		m := svc.Metrics()
+		return m.Processed == 3 && m.IdleWorkers == 1
+	})
+	if got := svc.Metrics(); got.QueueDepth != 0 || got.Draining {
+		t.Errorf("Metrics() after draining = %+v, want an empty queue on a running service", got)
+	}
+
+	svc.Close()
+	if got := svc.Metrics(); !got.Draining || got.Processed != 3 {
+		t.Errorf("Metrics() after Close = %+v, want draining with every queued event processed", got)
+	}
+}
+
+// TestCloseDrainsEvents tests that Close processes events still waiting in the
+// queue before it returns, and rejects events queued afterwards
+func TestCloseDrainsEvents(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+
+	cache := &blockingCache{CacheRepository: f.uow.CacheRepository(), release: make(chan struct{})}
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		cache,
+		1, // one worker, blocked on the first event
+		10,
+	)
+
+	// Hold the only worker so every game_ended event is still queued when Close is called
+	if err := svc.QueueEvent(&game.GameEvent{GameID: "blocker", EventType: "game_started", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("QueueEvent() error = %v", err)
+	}
+	const games = 5
+	for i := 0; i < games; i++ {
+		result := &game.GameResult{
+			GameID:      fmt.Sprintf("game-%d", i),
+			WinnerID:    f.player1.ID,
+			LoserID:     f.player2.ID,
+			WinnerScore: 30,
+			LoserScore:  10,
+		}
+		if err := svc.QueueEvent(&game.GameEvent{GameID: result.GameID, EventType: "game_ended", Data: result, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("QueueEvent() error = %v", err)
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		svc.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close() returned while an event was still being processed")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(cache.release)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close() did not return after the queue drained")
+	}
+
+	for _, tt := range []struct {
+		user       *models.User
+		wantWins   int
+		wantLosses int
+	}{
+		{user: f.player1, wantWins: games},
+		{user: f.player2, wantLosses: games},
+	} {
+		stats, err := f.uow.UserRepository().GetStats(ctx, tt.user.ID)
+		if err != nil {
+			t.Fatalf("GetStats(%s) error = %v", tt.user.Username, err)
+		}
+		if stats.TotalGames != games || stats.Wins != tt.wantWins || stats.Losses != tt.wantLosses {
+			t.Errorf("%s stats = %d games, %d wins, %d losses, want %d, %d, %d",
+				tt.user.Username, stats.TotalGames, stats.Wins, stats.Losses, games, tt.wantWins, tt.wantLosses)
+		}
+	}
+
+	if got := svc.EventStats().Processed; got != games+1 {
+		t.Errorf("EventStats().Processed = %d, want %d", got, games+1)
+	}
+	if err := svc.QueueEvent(&game.GameEvent{GameID: "late", EventType: "game_started", Timestamp: time.Now()}); !errors.Is(err, game.ErrServiceClosed) {
+		t.Errorf("QueueEvent() after Close error = %v, want ErrServiceClosed", err)
+	}
+}
+
+// TestGetUserGames tests the game history limits, its ordering, and that cached
+// histories see later changes to the games in them
+func TestGetUserGames(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+
+	if games, err := f.svc.GetUserGames(ctx, f.player1.ID, 0); err != nil || games == nil || len(games) != 0 {
+		t.Fatalf("GetUserGames() with no games = %v, %v, want an empty history", games, err)
+	}
+
+	const played = game.MaxUserGamesLimit + 5
+	for i := 0; i < played; i++ {
+		if _, err := f.svc.CreateGame(ctx, f.player1.ID, f.player2.ID); err != nil {
+			t.Fatalf("CreateGame() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "default", limit: 0, want: game.DefaultUserGamesLimit},
+		{name: "negative uses default", limit: -1, want: game.DefaultUserGamesLimit},
+		{name: "explicit", limit: 5, want: 5},
+		{name: "capped", limit: played * 2, want: game.MaxUserGamesLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games, err := f.svc.GetUserGames(ctx, f.player2.ID, tt.limit)
+			if err != nil {
+				t.Fatalf("GetUserGames() error = %v", err)
+			}
+			if len(games) != tt.want {
+				t.Fatalf("GetUserGames(%d) returned %d games, want %d", tt.limit, len(games), tt.want)
+			}
+			for i := 1; i < len(games); i++ {
+				if games[i].CreatedAt.After(games[i-1].CreatedAt) {
+					t.Fatalf("game %d was created after game %d; want newest first", i, i-1)
+				}
+			}
+		})
+	}
+
+	// A cached history reflects a game that is cancelled after it was cached
+	games, err := f.svc.GetUserGames(ctx, f.player1.ID, 5)
+	if err != nil {
+		t.Fatalf("GetUserGames() error = %v", err)
+	}
+	if err := f.svc.CancelGame(ctx, games[0].ID); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+	games, err = f.svc.GetUserGames(ctx, f.player1.ID, 5)
+	if err != nil {
+		t.Fatalf("GetUserGames() error = %v", err)
+	}
+	if games[0].State != models.GameStateCancelled {
+		t.Errorf("cached history shows state %v, want %v", games[0].State, models.GameStateCancelled)
+	}
+
+	// So does a history cached by another instance sharing the cache
+	other := game.NewGameService(f.uow.GameRepository(), f.uow.UserRepository(), f.uow.LeaderboardRepository(), f.uow.CacheRepository(), 2, 10)
+	t.Cleanup(func() { other.Close() })
+	if err := other.CancelGame(ctx, games[1].ID); err != nil {
+		t.Fatalf("CancelGame() on the other instance error = %v", err)
+	}
+	games, err = f.svc.GetUserGames(ctx, f.player1.ID, 5)
+	if err != nil {
+		t.Fatalf("GetUserGames() error = %v", err)
+	}
+	if games[1].State != models.GameStateCancelled {
+		t.Errorf("history cached by another instance shows state %v, want %v", games[1].State, models.GameStateCancelled)
+	}
+}
+
+// fakeClock is a manually advanced clock for GameService
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestArchiveEndedGames tests that ended games past retention are archived and recent ones kept
+func TestArchiveEndedGames(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		f.uow.CacheRepository(),
+		2,
+		10,
+		game.WithClock(clock.Now),
+		game.WithArchiving(time.Hour, time.Hour),
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	playGame := func(score1, score2 int64) *models.Game {
+		t.Helper()
+		g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+		if err != nil {
+			t.Fatalf("CreateGame() error = %v", err)
+		}
+		if err := svc.StartGame(ctx, g.ID); err != nil {
+			t.Fatalf("StartGame() error = %v", err)
+		}
+		if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, score1); err != nil {
+			t.Fatalf("UpdateScore() error = %v", err)
+		}
+		if err := svc.UpdateScore(ctx, g.ID, f.player2.ID, score2); err != nil {
+			t.Fatalf("UpdateScore() error = %v", err)
+		}
+		if _, err := svc.EndGame(ctx, g.ID); err != nil {
+			t.Fatalf("EndGame() error = %v", err)
+		}
+		return g
+	}
+
+	// Two games end before the clock moves past retention
+	oldFinished := playGame(30, 10)
+	if err := f.uow.GameRepository().AddEvent(ctx, &models.GameEvent{GameID: oldFinished.ID, EventType: "score_updated"}); err != nil {
+		t.Fatalf("AddEvent() error = %v", err)
+	}
+	oldCancelled, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := svc.CancelGame(ctx, oldCancelled.ID); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+
+	clock.Advance(2 * time.Hour)
+
+	// These are recent or still running and must survive the sweep
+	recent := playGame(5, 20)
+	running, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+
+	archived, err := svc.ArchiveEndedGames(ctx)
+	if err != nil {
+		t.Fatalf("ArchiveEndedGames() error = %v", err)
+	}
+	if archived != 2 {
+		t.Errorf("ArchiveEndedGames() = %v, want 2", archived)
+	}
+
+	for _, id := range []string{oldFinished.ID, oldCancelled.ID} {
+		if _, err := svc.GetGame(ctx, id); err == nil {
+			t.Errorf("GetGame(%s) expected error for archived game but got none", id)
+		}
+		events, _ := f.uow.GameRepository().GetGameEvents(ctx, id)
+		if len(events) != 0 {
+			t.Errorf("GetGameEvents(%s) = %d events, want 0 after archiving", id, len(events))
+		}
+	}
+
+	summary, err := svc.GetArchivedGame(ctx, oldFinished.ID)
+	if err != nil {
+		t.Fatalf("GetArchivedGame() error = %v", err)
+	}
+	if summary.State != models.GameStateFinished || summary.Score1 != 30 || summary.Score2 != 10 {
+		t.Errorf("GetArchivedGame() = %+v, want finished 30-10", summary)
+	}
+	if summary.WinnerID != f.player1.ID {
+		t.Errorf("GetArchivedGame().WinnerID = %v, want %v", summary.WinnerID, f.player1.ID)
+	}
+	if summary.EventCount != 1 {
+		t.Errorf("GetArchivedGame().EventCount = %v, want 1", summary.EventCount)
+	}
+	if !summary.ArchivedAt.Equal(clock.Now()) {
+		t.Errorf("GetArchivedGame().ArchivedAt = %v, want %v", summary.ArchivedAt, clock.Now())
+	}
+
+	cancelled, err := svc.GetArchivedGame(ctx, oldCancelled.ID)
+	if err != nil {
+		t.Fatalf("GetArchivedGame() error = %v", err)
+	}
+	if cancelled.State != models.GameStateCancelled {
+		t.Errorf("GetArchivedGame().State = %v, want %v", cancelled.State, models.GameStateCancelled)
+	}
+
+	for _, id := range []string{recent.ID, running.ID} {
+		if _, err := svc.GetGame(ctx, id); err != nil {
+			t.Errorf("GetGame(%s) error = %v, want game still queryable", id, err)
+		}
+		if _, err := svc.GetArchivedGame(ctx, id); err == nil {
+			t.Errorf("GetArchivedGame(%s) expected error for unarchived game but got none", id)
+		}
+	}
+}
+
+// TestActiveGameReconciliation tests that playing games already in the repository are tracked
+// and auto-ended on startup only when reconciliation is enabled
+func TestActiveGameReconciliation(t *testing.T) {
+	tests := []struct {
+		name       string
+		reconcile  bool
+		wantActive bool
+	}{
+		{name: "reconciliation enabled", reconcile: true, wantActive: true},
+		{name: "reconciliation disabled", reconcile: false, wantActive: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+			clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+			// A game left playing by a previous run
+			seeded, err := models.NewGame(f.player1.ID, f.player2.ID)
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			seeded.State = models.GameStatePlaying
+			seeded.StartedAt = clock.Now()
+			seeded.Score1 = 40
+			if err := f.uow.GameRepository().Create(ctx, seeded); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			opts := []game.GameServiceOption{
+				game.WithClock(clock.Now),
+				game.WithAutoEnd(time.Hour, time.Hour),
+			}
+			if tt.reconcile {
+				opts = append(opts, game.WithActiveGameReconciliation())
+			}
+			svc := game.NewGameService(
+				f.uow.GameRepository(),
+				f.uow.UserRepository(),
+				f.uow.LeaderboardRepository(),
+				f.uow.CacheRepository(),
+				2,
+				10,
+				opts...,
+			)
+			t.Cleanup(func() { svc.Close() })
+
+			isActive := func() bool {
+				active, err := svc.GetActiveGames(ctx)
+				if err != nil {
+					t.Fatalf("GetActiveGames() error = %v", err)
+				}
+				for _, g := range active {
+					if g.ID == seeded.ID {
+						return true
+					}
+				}
+				return false
+			}
+
+			if got := isActive(); got != tt.wantActive {
+				t.Fatalf("GetActiveGames() contains seeded game = %v, want %v", got, tt.wantActive)
+			}
+
+			// Still within the limit: nothing is ended
+			clock.Advance(30 * time.Minute)
+			if n, err := svc.EndTimedOutGames(ctx); err != nil || n != 0 {
+				t.Fatalf("EndTimedOutGames() = %v, %v, want 0 before the limit", n, err)
+			}
+
+			clock.Advance(time.Hour)
+			ended, err := svc.EndTimedOutGames(ctx)
+			if err != nil {
+				t.Fatalf("EndTimedOutGames() error = %v", err)
+			}
+
+			wantEnded := 0
+			wantState := models.GameStatePlaying
+			if tt.wantActive {
+				wantEnded = 1
+				wantState = models.GameStateFinished
+			}
+			if ended != wantEnded {
+				t.Errorf("EndTimedOutGames() = %v, want %v", ended, wantEnded)
+			}
+
+			stored, err := f.uow.GameRepository().GetByID(ctx, seeded.ID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if stored.State != wantState {
+				t.Errorf("seeded game state = %v, want %v", stored.State, wantState)
+			}
+			if tt.wantActive {
+				if !stored.FinishedAt.Equal(clock.Now()) {
+					t.Errorf("seeded game FinishedAt = %v, want %v", stored.FinishedAt, clock.Now())
+				}
+				if isActive() {
+					t.Errorf("GetActiveGames() still contains auto-ended game %s", seeded.ID)
+				}
+			}
+		})
+	}
+}
+
+// sweeps are the background sweeps that end or cancel stale games, each with
+// the option that makes it treat a game left playing for two hours as stale
+var sweeps = []struct {
+	name  string
+	opt   game.GameServiceOption
+	sweep func(*game.GameService, context.Context) (int, error)
+}{
+	{"auto-end", game.WithAutoEnd(time.Hour, time.Hour), (*game.GameService).EndTimedOutGames},
+	{"idle", game.WithIdleTimeout(time.Hour, time.Hour), (*game.GameService).CancelIdleGames},
+}
+
+// failingGameRepo fails to save one game
+type failingGameRepo struct {
+	models.GameRepository
+	failID string
+}
+
+func (r *failingGameRepo) Update(ctx context.Context, g *models.Game) error {
+	if g.ID == r.failID {
+		return errors.New("disk full")
+	}
+	return r.GameRepository.Update(ctx, g)
+}
+
+// TestSweepsContinuePastFailures tests that a game the sweep fails to save does
+// not stop it from ending or cancelling the rest
+func TestSweepsContinuePastFailures(t *testing.T) {
+	for _, tt := range sweeps {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+			clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+			repo := &failingGameRepo{GameRepository: f.uow.GameRepository()}
+
+			svc := game.NewGameService(repo, f.uow.UserRepository(), f.uow.LeaderboardRepository(), f.uow.CacheRepository(), 2, 10,
+				game.WithClock(clock.Now),
+				tt.opt,
+			)
+			t.Cleanup(func() { svc.Close() })
+
+			for i := 0; i < 3; i++ {
+				g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+				if err != nil {
+					t.Fatalf("CreateGame() error = %v", err)
+				}
+				if err := svc.StartGame(ctx, g.ID); err != nil {
+					t.Fatalf("StartGame() error = %v", err)
+				}
+				repo.failID = g.ID
+			}
+			clock.Advance(2 * time.Hour)
+
+			n, err := tt.sweep(svc, ctx)
+			if n != 2 {
+				t.Errorf("sweep = %d, want the 2 games that could be saved", n)
+			}
+			if err == nil || !strings.Contains(err.Error(), repo.failID) {
+				t.Errorf("sweep error = %v, want the failure for game %s", err, repo.failID)
+			}
+		})
+	}
+}
+
+// TestSweepsRaceRequests tests that a sweep reading game state races neither
+// StartGame nor EndGame, and that games ended by a request after the sweep
+// picked them are skipped rather than failing the sweep
+func TestSweepsRaceRequests(t *testing.T) {
+	for _, tt := range sweeps {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+			clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+			svc := game.NewGameService(
+				f.uow.GameRepository(),
+				f.uow.UserRepository(),
+				f.uow.LeaderboardRepository(),
+				f.uow.CacheRepository(),
+				2,
+				100,
+				game.WithClock(clock.Now),
+				tt.opt,
+			)
+			t.Cleanup(func() { svc.Close() })
+
+			const games = 200
+			var playing, waiting []string
+			for i := 0; i < 2*games; i++ {
+				g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+				if err != nil {
+					t.Fatalf("CreateGame() error = %v", err)
+				}
+				if i%2 == 1 {
+					waiting = append(waiting, g.ID)
+					continue
+				}
+				if err := svc.StartGame(ctx, g.ID); err != nil {
+					t.Fatalf("StartGame() error = %v", err)
+				}
+				playing = append(playing, g.ID)
+			}
+			clock.Advance(2 * time.Hour)
+
+			// Sweep over and over while other requests end the stale games and start the rest
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for _, id := range playing {
+					svc.EndGame(ctx, id)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for _, id := range waiting {
+					svc.StartGame(ctx, id)
+				}
+			}()
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			swept := 0
+			for finished := false; !finished; {
+				select {
+				case <-done:
+					finished = true
+				default:
+				}
+				n, err := tt.sweep(svc, ctx)
+				if err != nil {
+					t.Fatalf("sweep error = %v, want games ended by other requests skipped", err)
+				}
+				swept += n
+			}
+			if swept > games {
+				t.Errorf("sweeps ended %d games, want at most the %d stale ones", swept, games)
+			}
+			for _, id := range playing {
+				if stored, err := f.uow.GameRepository().GetByID(ctx, id); err != nil || stored.IsPlaying() {
+					t.Errorf("stale game %s = %v, %v, want it ended", id, stored.State, err)
+				}
+			}
+		})
+	}
+}
+
+// TestIdleTimeout tests that a playing game with no recent score updates is
+// cancelled and removed from the active set, and that updates keep it alive
+func TestIdleTimeout(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		f.uow.CacheRepository(),
+		2,
+		10,
+		game.WithClock(clock.Now),
+		game.WithIdleTimeout(time.Minute, 5*time.Millisecond),
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := svc.StartGame(ctx, g.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+
+	isActive := func() bool {
+		active, err := svc.GetActiveGames(ctx)
+		if err != nil {
+			t.Fatalf("GetActiveGames() error = %v", err)
+		}
+		for _, active := range active {
+			if active.ID == g.ID {
+				return true
+			}
+		}
+		return false
+	}
+	state := func() models.GameState {
+		stored, err := f.uow.GameRepository().GetByID(ctx, g.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		return stored.State
+	}
+
+	// A score update within the timeout restarts the idle timer
+	clock.Advance(50 * time.Second)
+	if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, 10); err != nil {
+		t.Fatalf("UpdateScore() error = %v", err)
+	}
+	clock.Advance(50 * time.Second)
+	if n, err := svc.CancelIdleGames(ctx); err != nil || n != 0 {
+		t.Fatalf("CancelIdleGames() = %v, %v, want 0 while the game is active", n, err)
+	}
+	if !isActive() || state() != models.GameStatePlaying {
+		t.Fatalf("game was cancelled within the idle timeout")
+	}
+
+	clock.Advance(2 * time.Minute)
+	waitFor(t, "idle game removed", func() bool { return !isActive() })
+	if got := state(); got != models.GameStateCancelled {
+		t.Errorf("idle game state = %v, want %v", got, models.GameStateCancelled)
+	}
+}
+
+// TestIncrementScoreConcurrent tests that concurrent increments are all applied
+func TestIncrementScoreConcurrent(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+
+	g, err := f.svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := f.svc.StartGame(ctx, g.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+
+	const goroutines = 50
+	var wg sync.WaitGroup
+	var want1, want2 int64
+	for i := 1; i <= goroutines; i++ {
+		playerID, delta := f.player1.ID, int64(i)
+		if i%2 == 0 {
+			playerID, delta = f.player2.ID, int64(-i)
+			want2 += delta
+		} else {
+			want1 += delta
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f.svc.IncrementScore(ctx, g.ID, playerID, delta); err != nil {
+				t.Errorf("IncrementScore() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	saved, err := f.svc.GetGame(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("GetGame() error = %v", err)
+	}
+	if saved.Score1 != want1 || saved.Score2 != want2 {
+		t.Errorf("scores = %v-%v, want %v-%v", saved.Score1, saved.Score2, want1, want2)
+	}
+}
+
+// TestIncrementScoreGuards tests overflow, monotonic and state checks on increments
+func TestIncrementScoreGuards(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		f.uow.CacheRepository(),
+		2,
+		10,
+		game.WithMonotonicScores(),
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := svc.IncrementScore(ctx, g.ID, f.player1.ID, 1); !errors.Is(err, models.ErrGameNotStarted) {
+		t.Errorf("IncrementScore() before start error = %v, want %v", err, models.ErrGameNotStarted)
+	}
+	if err := svc.StartGame(ctx, g.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+
+	if err := svc.IncrementScore(ctx, g.ID, f.player1.ID, -5); !errors.Is(err, game.ErrNegativeDelta) {
+		t.Errorf("IncrementScore() negative delta error = %v, want %v", err, game.ErrNegativeDelta)
+	}
+	if err := svc.IncrementScore(ctx, g.ID, "stranger", 5); !errors.Is(err, models.ErrInvalidPlayer) {
+		t.Errorf("IncrementScore() unknown player error = %v, want %v", err, models.ErrInvalidPlayer)
+	}
+
+	if err := svc.IncrementScore(ctx, g.ID, f.player1.ID, math.MaxInt64-10); err != nil {
+		t.Fatalf("IncrementScore() error = %v", err)
+	}
+	if err := svc.IncrementScore(ctx, g.ID, f.player1.ID, 11); !errors.Is(err, models.ErrScoreOverflow) {
+		t.Errorf("IncrementScore() overflow error = %v, want %v", err, models.ErrScoreOverflow)
+	}
+
+	saved, err := svc.GetGame(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("GetGame() error = %v", err)
+	}
+	if saved.Score1 != math.MaxInt64-10 {
+		t.Errorf("Score1 = %v, want %v after rejected increments", saved.Score1, int64(math.MaxInt64-10))
+	}
+}
+
+// TestSuspiciousScore tests that implausibly high scores are accepted but flagged and reported
+func TestSuspiciousScore(t *testing.T) {
+	tests := []struct {
+		name           string
+		score          int64
+		wantSuspicious bool
+	}{
+		{name: "plausible score", score: 50, wantSuspicious: false},
+		{name: "implausible score for a short game", score: 1_000_000, wantSuspicious: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newGameFixture(t)
+			ctx := context.Background()
+
+			notified := make(chan *game.GameEvent, 1)
+			svc := game.NewGameService(
+				f.uow.GameRepository(),
+				f.uow.UserRepository(),
+				f.uow.LeaderboardRepository(),
+				f.uow.CacheRepository(),
+				2,
+				10,
+				// 100 points up front, then 10 a second; a game this short allows about 100
+				game.WithScorePlausibility(10, 100),
+				game.WithNotifier(game.NotifierFunc(func(ctx context.Context, event *game.GameEvent) error {
+					notified <- event
+					return nil
+				})),
+			)
+			t.Cleanup(func() { svc.Close() })
+
+			g, err := svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+			if err != nil {
+				t.Fatalf("CreateGame() error = %v", err)
+			}
+			if err := svc.StartGame(ctx, g.ID); err != nil {
+				t.Fatalf("StartGame() error = %v", err)
+			}
+			if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, tt.score); err != nil {
+				t.Fatalf("UpdateScore() error = %v", err)
+			}
+
+			result, err := svc.EndGame(ctx, g.ID)
+			if err != nil {
+				t.Fatalf("EndGame() error = %v", err)
+			}
+			if result.WinnerScore != tt.score {
+				t.Errorf("WinnerScore = %v, want the submitted %v", result.WinnerScore, tt.score)
+			}
+			if result.Suspicious != tt.wantSuspicious {
+				t.Errorf("result.Suspicious = %v, want %v", result.Suspicious, tt.wantSuspicious)
+			}
+			saved, err := svc.GetGame(ctx, g.ID)
+			if err != nil {
+				t.Fatalf("GetGame() error = %v", err)
+			}
+			if saved.IsSuspicious() != tt.wantSuspicious {
+				t.Errorf("game.IsSuspicious() = %v, want %v", saved.IsSuspicious(), tt.wantSuspicious)
+			}
+
+			if !tt.wantSuspicious {
+				// Leave room for a wrongly queued notification to show up
+				time.Sleep(50 * time.Millisecond)
+				select {
+				case event := <-notified:
+					t.Fatalf("unexpected notification %+v", event)
+				default:
+				}
+				return
+			}
+
+			select {
+			case event := <-notified:
+				data, ok := event.Data.(*game.SuspiciousScore)
+				if event.EventType != "suspicious_score" || !ok {
+					t.Fatalf("notified event = %+v, want suspicious_score with details", event)
+				}
+				if data.GameID != g.ID || data.PlayerID != f.player1.ID || data.Score != tt.score {
+					t.Errorf("details = %+v, want game %s, player %s, score %v", data, g.ID, f.player1.ID, tt.score)
+				}
+				if data.ExpectedMax < 100 || data.ExpectedMax >= tt.score {
+					t.Errorf("ExpectedMax = %v, want between the allowance and the score", data.ExpectedMax)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("suspicious score was not notified")
+			}
+		})
+	}
+}
+
+// slowCache delays every write so queued events take a while to process
+type slowCache struct {
+	models.CacheRepository
+	delay time.Duration
+}
+
+func (c *slowCache) Set(ctx context.Context, key string, value interface{}, ttl int) error {
+	time.Sleep(c.delay)
+	return c.CacheRepository.Set(ctx, key, value, ttl)
+}
+
+// TestWorkerAutoscaling tests that event workers grow under a burst and shrink back once it drains
+func TestWorkerAutoscaling(t *testing.T) {
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := game.NewGameService(
+		uow.GameRepository(),
+		uow.UserRepository(),
+		uow.LeaderboardRepository(),
+		&slowCache{CacheRepository: uow.CacheRepository(), delay: 10 * time.Millisecond},
+		4,
+		100,
+		game.WithWorkerAutoscaling(1, 5, 2*time.Millisecond),
+	)
+	defer svc.Close()
+
+	if workers := svc.Stats().Workers; workers != 1 {
+		t.Fatalf("Stats().Workers before the burst = %d, want 1", workers)
+	}
+
+	for i := 0; i < 60; i++ {
+		if err := svc.QueueEvent(&game.GameEvent{GameID: "burst", EventType: "game_started", Timestamp: time.Now()}); err != nil {
+			t.Fatalf("QueueEvent() error = %v", err)
+		}
+	}
+
+	waitFor(t, "workers to scale up", func() bool { return svc.Stats().Workers == 4 })
+	waitFor(t, "workers to scale back down", func() bool {
+		stats := svc.Stats()
+		return stats.Events.QueueLength == 0 && stats.Workers == 1
+	})
+}
+
+// TestGameModes tests that games end on their own once their mode's end condition is met
+func TestGameModes(t *testing.T) {
+	f := newGameFixture(t)
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	svc := game.NewGameService(
+		f.uow.GameRepository(),
+		f.uow.UserRepository(),
+		f.uow.LeaderboardRepository(),
+		f.uow.CacheRepository(),
+		2,
+		10,
+		game.WithClock(clock.Now),
+		// No maximum duration: only modes end games
+		game.WithAutoEnd(0, time.Hour),
+	)
+	t.Cleanup(func() { svc.Close() })
+
+	startGame := func(mode models.GameModeSpec) *models.Game {
+		t.Helper()
+		g, err := svc.CreateGameWithMode(ctx, f.player1.ID, f.player2.ID, mode)
+		if err != nil {
+			t.Fatalf("CreateGameWithMode(%+v) error = %v", mode, err)
+		}
+		if err := svc.StartGame(ctx, g.ID); err != nil {
+			t.Fatalf("StartGame() error = %v", err)
+		}
+		return g
+	}
+	state := func(g *models.Game) models.GameState {
+		t.Helper()
+		stored, err := f.uow.GameRepository().GetByID(ctx, g.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		return stored.State
+	}
+
+	t.Run("target score", func(t *testing.T) {
+		g := startGame(models.GameModeSpec{Name: models.GameModeTargetScore, Target: 100})
+
+		if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, 99); err != nil {
+			t.Fatalf("UpdateScore() error = %v", err)
+		}
+		if got := state(g); got != models.GameStatePlaying {
+			t.Fatalf("state below the target = %v, want %v", got, models.GameStatePlaying)
+		}
+
+		if err := svc.IncrementScore(ctx, g.ID, f.player2.ID, 100); err != nil {
+			t.Fatalf("IncrementScore() error = %v", err)
+		}
+		if got := state(g); got != models.GameStateFinished {
+			t.Fatalf("state at the target = %v, want %v", got, models.GameStateFinished)
+		}
+		if winner := g.GetWinner(); winner != f.player2.ID {
+			t.Errorf("winner = %q, want player2", winner)
+		}
+		if err := svc.UpdateScore(ctx, g.ID, f.player1.ID, 150); err == nil {
+			t.Error("UpdateScore() on an ended game expected error but got none")
+		}
+	})
+
+	t.Run("timed", func(t *testing.T) {
+		g := startGame(models.GameModeSpec{Name: models.GameModeTimed, Duration: 10 * time.Minute})
+		classic := startGame(models.GameModeSpec{})
+
+		clock.Advance(9 * time.Minute)
+		if n, err := svc.EndTimedOutGames(ctx); err != nil || n != 0 {
+			t.Fatalf("EndTimedOutGames() = %v, %v, want 0 before the duration", n, err)
+		}
+
+		clock.Advance(time.Minute)
+		if n, err := svc.EndTimedOutGames(ctx); err != nil || n != 1 {
+			t.Fatalf("EndTimedOutGames() = %v, %v, want 1 at the duration", n, err)
+		}
+		if got := state(g); got != models.GameStateFinished {
+			t.Errorf("timed game state = %v, want %v", got, models.GameStateFinished)
+		}
+		if got := g.GetDuration(); got != 10*time.Minute {
+			t.Errorf("timed game duration = %v, want 10m", got)
+		}
+		if got := state(classic); got != models.GameStatePlaying {
+			t.Errorf("classic game state = %v, want %v", got, models.GameStatePlaying)
+		}
+	})
+
+	t.Run("invalid modes", func(t *testing.T) {
+		for _, mode := range []models.GameModeSpec{
+			{Name: "capture-the-flag"},
+			{Name: models.GameModeTimed},
+			{Name: models.GameModeTargetScore, Target: -5},
+			{Name: models.GameModeBestOf},
+		} {
+			if _, err := svc.CreateGameWithMode(ctx, f.player1.ID, f.player2.ID, mode); err == nil {
+				t.Errorf("CreateGameWithMode(%+v) expected error but got none", mode)
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"effective-golang/internal/health"
+)
+
+// stubComponent reports a fixed health result
+func stubComponent(err error) health.Healther {
+	return health.HealthFunc(func(ctx context.Context) error { return err })
+}
+
+// TestCheckerAggregation tests the overall status rollup from component results
+func TestCheckerAggregation(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		critical   error
+		optional   error
+		wantStatus health.Status
+	}{
+		{name: "all healthy", critical: nil, optional: nil, wantStatus: health.StatusHealthy},
+		{name: "non-critical unhealthy", critical: nil, optional: errDown, wantStatus: health.StatusDegraded},
+		{name: "critical unhealthy", critical: errDown, optional: nil, wantStatus: health.StatusUnhealthy},
+		{name: "both unhealthy", critical: errDown, optional: errDown, wantStatus: health.StatusUnhealthy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second)
+			checker.Register("database", stubComponent(tt.critical), true)
+			checker.Register("queue", stubComponent(tt.optional), false)
+
+			report := checker.Check(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("Check().Status = %v, want %v", report.Status, tt.wantStatus)
+			}
+			if len(report.Components) != 2 {
+				t.Fatalf("Check() reported %d components, want 2", len(report.Components))
+			}
+
+			db := report.Components["database"]
+			if !db.Critical {
+				t.Errorf("database Critical = false, want true")
+			}
+			if (tt.critical != nil) != (db.Status == health.StatusUnhealthy) {
+				t.Errorf("database Status = %v with error %v", db.Status, tt.critical)
+			}
+			if tt.critical != nil && db.Error != tt.critical.Error() {
+				t.Errorf("database Error = %q, want %q", db.Error, tt.critical.Error())
+			}
+		})
+	}
+}
+
+// TestCheckerTimeout tests that a component that does not answer in time is unhealthy
+func TestCheckerTimeout(t *testing.T) {
+	checker := health.NewChecker(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	checker.Register("stuck", health.HealthFunc(func(ctx context.Context) error {
+		<-release
+		return nil
+	}), true)
+
+	start := time.Now()
+	report := checker.Check(context.Background())
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Check() took %v, want it bounded by the timeout", elapsed)
+	}
+	if report.Status != health.StatusUnhealthy {
+		t.Errorf("Check().Status = %v, want %v", report.Status, health.StatusUnhealthy)
+	}
+	if report.Components["stuck"].Error == "" {
+		t.Errorf("stuck component Error is empty, want a timeout error")
+	}
+}
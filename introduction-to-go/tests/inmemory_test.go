@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// TestInMemoryUserListPagination tests that two pages of users cover every
+// user exactly once, with ties on CreatedAt broken by ID
+func TestInMemoryUserListPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := utils.NewInMemoryUnitOfWork().UserRepository()
+	seeded := seedUsers(t, repo, "ann", "bob", "cat", "dan", "eve")
+	for _, username := range []string{"fay", "gus"} {
+		user, err := models.NewUser(username, username+"@example.com", "password123")
+		if err != nil {
+			t.Fatalf("NewUser(%q) error = %v", username, err)
+		}
+		user.CreatedAt = seeded["ann"].CreatedAt
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create(%q) error = %v", username, err)
+		}
+		seeded[username] = user
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		first, err := repo.List(ctx, 0, 4)
+		if err != nil {
+			t.Fatalf("List(0, 4) error = %v", err)
+		}
+		second, err := repo.List(ctx, 4, 4)
+		if err != nil {
+			t.Fatalf("List(4, 4) error = %v", err)
+		}
+		if len(first) != 4 || len(second) != 3 {
+			t.Fatalf("pages have %d and %d users, want 4 and 3", len(first), len(second))
+		}
+
+		seen := make(map[string]bool, len(seeded))
+		all := append(first, second...)
+		for i, user := range all {
+			if seen[user.ID] {
+				t.Fatalf("attempt %d: user %s listed twice", attempt, user.Username)
+			}
+			seen[user.ID] = true
+			if i > 0 {
+				prev := all[i-1]
+				if user.CreatedAt.Before(prev.CreatedAt) || (user.CreatedAt.Equal(prev.CreatedAt) && user.ID < prev.ID) {
+					t.Fatalf("attempt %d: %s listed after %s", attempt, user.Username, prev.Username)
+				}
+			}
+		}
+		for username, user := range seeded {
+			if !seen[user.ID] {
+				t.Fatalf("attempt %d: user %s missing from both pages", attempt, username)
+			}
+		}
+	}
+}
+
+// TestInMemoryLeaderboardListPagination tests that two pages of leaderboards
+// cover every leaderboard exactly once, oldest first
+func TestInMemoryLeaderboardListPagination(t *testing.T) {
+	ctx := context.Background()
+	repo := utils.NewInMemoryUnitOfWork().LeaderboardRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var want []string
+	for i := 0; i < 6; i++ {
+		lb := models.NewLeaderboard(fmt.Sprintf("board-%d", i), models.LeaderboardTypeGlobal, 10)
+		lb.CreatedAt = base.Add(time.Duration(5-i) * time.Minute)
+		if err := repo.Create(ctx, lb); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		want = append([]string{lb.ID}, want...)
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		first, err := repo.List(ctx, 0, 3)
+		if err != nil {
+			t.Fatalf("List(0, 3) error = %v", err)
+		}
+		second, err := repo.List(ctx, 3, 3)
+		if err != nil {
+			t.Fatalf("List(3, 3) error = %v", err)
+		}
+		all := append(first, second...)
+		if len(all) != len(want) {
+			t.Fatalf("pages have %d leaderboards, want %d", len(all), len(want))
+		}
+		for i, lb := range all {
+			if lb.ID != want[i] {
+				t.Fatalf("attempt %d: leaderboard %d = %s, want %s", attempt, i, lb.Name, want[i])
+			}
+		}
+	}
+}
+
+// TestInMemoryActiveGamesOrder tests that active games are listed oldest first
+func TestInMemoryActiveGamesOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := utils.NewInMemoryUnitOfWork().GameRepository()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var want []string
+	for i := 0; i < 5; i++ {
+		g, err := models.NewGame("p1", fmt.Sprintf("p%d", i+2))
+		if err != nil {
+			t.Fatalf("NewGame() error = %v", err)
+		}
+		g.State = models.GameStatePlaying
+		g.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, g); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		want = append(want, g.ID)
+	}
+
+	games, err := repo.GetActiveGames(ctx)
+	if err != nil {
+		t.Fatalf("GetActiveGames() error = %v", err)
+	}
+	if len(games) != len(want) {
+		t.Fatalf("GetActiveGames() returned %d games, want %d", len(games), len(want))
+	}
+	for i, g := range games {
+		if g.ID != want[i] {
+			t.Errorf("GetActiveGames()[%d] = %s, want %s", i, g.ID, want[i])
+		}
+	}
+}
+
+// TestCacheJanitor tests that the janitor evicts expired keys that are never read
+func TestCacheJanitor(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	t.Cleanup(func() { uow.Close() })
+	cache := uow.CacheRepository().(*utils.InMemoryCacheRepository)
+
+	if err := cache.Set(ctx, "session:abandoned", "token", 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Set(ctx, "session:live", "token", 60); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	janitorCtx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	cache.StartJanitor(janitorCtx, 10*time.Millisecond)
+
+	waitFor(t, "the expired key to be evicted", func() bool { return cache.Len() == 1 })
+	if exists, _ := cache.Exists(ctx, "session:live"); !exists {
+		t.Errorf("janitor evicted a key that has not expired")
+	}
+}
+
+// TestCacheExpiredReads tests that expired keys read as missing until the
+// janitor deletes them, and that concurrent reads of one do not race. Run it with -race.
+func TestCacheExpiredReads(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	t.Cleanup(func() { uow.Close() })
+	cache := uow.CacheRepository().(*utils.InMemoryCacheRepository)
+
+	if err := cache.Set(ctx, "login:expired", 3, 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var attempts int
+			if err := cache.Get(ctx, "login:expired", &attempts); !errors.Is(err, models.ErrCacheMiss) {
+				t.Errorf("Get() of an expired key error = %v, want ErrCacheMiss", err)
+			}
+			if exists, _ := cache.Exists(ctx, "login:expired"); exists {
+				t.Errorf("Exists() of an expired key = true, want false")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := cache.Expire(ctx, "login:expired", 60); !errors.Is(err, models.ErrCacheMiss) {
+		t.Errorf("Expire() of an expired key error = %v, want ErrCacheMiss", err)
+	}
+	if ok, err := cache.SetNX(ctx, "login:expired", 1, 60); err != nil || !ok {
+		t.Errorf("SetNX() over an expired key = %v, %v, want true", ok, err)
+	}
+}
@@ -0,0 +1,1261 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/broadcast"
+	"effective-golang/pkg/utils"
+	"effective-golang/pkg/validate"
+)
+
+// tiedScores has two entries sharing second place and two sharing fourth
+var tiedScores = []int64{100, 90, 90, 80, 80, 70}
+
+// TestGetTopEntriesWithTies tests the top N cut-off with and without ties
+func TestGetTopEntriesWithTies(t *testing.T) {
+	lb := models.NewLeaderboard("Podium", models.LeaderboardTypeGlobal, 10)
+	for i, score := range tiedScores {
+		if err := lb.AddEntry(fmt.Sprintf("user%d", i), fmt.Sprintf("user%d", i), score); err != nil {
+			t.Fatalf("AddEntry() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		count       int
+		includeTies bool
+		wantRanks   []int
+	}{
+		{name: "top 2 without ties", count: 2, includeTies: false, wantRanks: []int{1, 2}},
+		{name: "top 2 with ties", count: 2, includeTies: true, wantRanks: []int{1, 2, 2}},
+		{name: "top 4 with ties", count: 4, includeTies: true, wantRanks: []int{1, 2, 2, 4, 4}},
+		{name: "top 3 no tie at boundary", count: 3, includeTies: true, wantRanks: []int{1, 2, 2}},
+		{name: "count beyond entries", count: 20, includeTies: true, wantRanks: []int{1, 2, 2, 4, 4, 6}},
+		{name: "zero count", count: 0, includeTies: true, wantRanks: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, _ := lb.GetTopEntries(0, tt.count, tt.includeTies)
+			if len(entries) != len(tt.wantRanks) {
+				t.Fatalf("GetTopEntries() len = %v, want %v", len(entries), len(tt.wantRanks))
+			}
+			for i, entry := range entries {
+				if entry.Rank != tt.wantRanks[i] {
+					t.Errorf("GetTopEntries()[%d].Rank = %v, want %v", i, entry.Rank, tt.wantRanks[i])
+				}
+			}
+		})
+	}
+}
+
+// TestLeaderboardServiceTopEntriesWithTies tests the includeTies flag through the service and its cache
+func TestLeaderboardServiceTopEntriesWithTies(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Podium", models.LeaderboardTypeGlobal, 10)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+
+	for i, score := range []int64{100, 90, 80, 80} {
+		user, err := authService.Register(ctx, &auth.RegisterRequest{
+			Username: fmt.Sprintf("player%d", i),
+			Email:    fmt.Sprintf("player%d@example.com", i),
+			Password: "password123",
+		})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		if err := svc.AddScore(ctx, lb.ID, user.ID, score); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	page, err := svc.GetTopEntries(ctx, lb.ID, 0, 3, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	plain := page.Entries
+	if len(plain) != 3 {
+		t.Errorf("GetTopEntries(includeTies=false) len = %v, want 3", len(plain))
+	}
+
+	page, err = svc.GetTopEntries(ctx, lb.ID, 0, 3, true)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	withTies := page.Entries
+	wantRanks := []int{1, 2, 3, 3}
+	if len(withTies) != len(wantRanks) {
+		t.Fatalf("GetTopEntries(includeTies=true) len = %v, want %v", len(withTies), len(wantRanks))
+	}
+	for i, entry := range withTies {
+		if entry.Rank != wantRanks[i] {
+			t.Errorf("GetTopEntries(includeTies=true)[%d].Rank = %v, want %v", i, entry.Rank, wantRanks[i])
+		}
+	}
+}
+
+// TestUnlimitedLeaderboard tests that a max entries of 0 never evicts entries
+func TestUnlimitedLeaderboard(t *testing.T) {
+	lb := models.NewLeaderboard("Unlimited", models.LeaderboardTypeGlobal, 0)
+
+	const total = 250
+	for i := 0; i < total; i++ {
+		// Descending scores so every new entry would be the first evicted from a capped board
+		if err := lb.AddEntry(fmt.Sprintf("user%d", i), fmt.Sprintf("user%d", i), int64(total-i)); err != nil {
+			t.Fatalf("AddEntry(%d) error = %v", i, err)
+		}
+	}
+
+	if len(lb.Entries) != total {
+		t.Errorf("len(Entries) = %v, want %v", len(lb.Entries), total)
+	}
+	if _, err := lb.GetUserRank("user0"); err != nil {
+		t.Errorf("GetUserRank(user0) error = %v, want highest score kept", err)
+	}
+	if rank, err := lb.GetUserRank(fmt.Sprintf("user%d", total-1)); err != nil || rank != total {
+		t.Errorf("GetUserRank(last) = %v, %v, want %v", rank, err, total)
+	}
+
+	// Positive caps still evict
+	capped := models.NewLeaderboard("Capped", models.LeaderboardTypeGlobal, 2)
+	capped.AddEntry("a", "a", 10)
+	capped.AddEntry("b", "b", 20)
+	if err := capped.AddEntry("c", "c", 5); err != models.ErrLeaderboardFull {
+		t.Errorf("AddEntry() on full board error = %v, want %v", err, models.ErrLeaderboardFull)
+	}
+}
+
+// TestCreateLeaderboardMaxEntries tests max entries validation in the service
+func TestCreateLeaderboardMaxEntries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxEntries int
+		wantErr    bool
+	}{
+		{name: "unlimited", maxEntries: 0, wantErr: false},
+		{name: "capped", maxEntries: 10, wantErr: false},
+		{name: "negative", maxEntries: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := utils.NewInMemoryUnitOfWork()
+			svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+			t.Cleanup(svc.Close)
+
+			_, err := svc.CreateLeaderboard(context.Background(), "Board", models.LeaderboardTypeGlobal, tt.maxEntries)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CreateLeaderboard() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLeaderboardSortOrder tests ranking, eviction and stats in both sort orders
+func TestLeaderboardSortOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       models.SortOrder
+		wantUsers   []string
+		wantRanks   []int
+		evictScore  int64 // fills the last place, pushing out the worst entry
+		rejectScore int64 // no better than the worst entry
+		wantEvicted string
+		wantBest    int64
+		wantWorst   int64
+	}{
+		{
+			name:        "descending",
+			order:       models.SortOrderDesc,
+			wantUsers:   []string{"b", "c", "d", "a"},
+			wantRanks:   []int{1, 2, 2, 4},
+			evictScore:  15,
+			rejectScore: 10,
+			wantEvicted: "a",
+			wantBest:    30,
+			wantWorst:   10,
+		},
+		{
+			name:        "ascending",
+			order:       models.SortOrderAsc,
+			wantUsers:   []string{"a", "c", "d", "b"},
+			wantRanks:   []int{1, 2, 2, 4},
+			evictScore:  25,
+			rejectScore: 30,
+			wantEvicted: "b",
+			wantBest:    10,
+			wantWorst:   30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := models.NewLeaderboardWithOrder("Board", models.LeaderboardTypeGlobal, 4, tt.order)
+			for _, e := range []struct {
+				user  string
+				score int64
+			}{{"a", 10}, {"b", 30}, {"c", 20}, {"d", 20}} {
+				if err := lb.AddEntry(e.user, e.user, e.score); err != nil {
+					t.Fatalf("AddEntry(%s) error = %v", e.user, err)
+				}
+			}
+
+			entries, _ := lb.GetTopEntries(0, 10, false)
+			if len(entries) != len(tt.wantUsers) {
+				t.Fatalf("GetTopEntries() len = %v, want %v", len(entries), len(tt.wantUsers))
+			}
+			for i, entry := range entries {
+				if entry.UserID != tt.wantUsers[i] || entry.Rank != tt.wantRanks[i] {
+					t.Errorf("GetTopEntries()[%d] = %s rank %d, want %s rank %d", i, entry.UserID, entry.Rank, tt.wantUsers[i], tt.wantRanks[i])
+				}
+			}
+
+			stats := lb.GetStats()
+			if stats.BestScore != tt.wantBest || stats.WorstScore != tt.wantWorst {
+				t.Errorf("GetStats() best, worst = %v, %v, want %v, %v", stats.BestScore, stats.WorstScore, tt.wantBest, tt.wantWorst)
+			}
+			if stats.HighestScore != 30 || stats.LowestScore != 10 {
+				t.Errorf("GetStats() highest, lowest = %v, %v, want 30, 10", stats.HighestScore, stats.LowestScore)
+			}
+
+			if err := lb.AddEntry("rejected", "rejected", tt.rejectScore); !errors.Is(err, models.ErrLeaderboardFull) {
+				t.Errorf("AddEntry(%d) on full board error = %v, want %v", tt.rejectScore, err, models.ErrLeaderboardFull)
+			}
+			if err := lb.AddEntry("newcomer", "newcomer", tt.evictScore); err != nil {
+				t.Fatalf("AddEntry(%d) error = %v", tt.evictScore, err)
+			}
+			if _, err := lb.GetUserRank(tt.wantEvicted); !errors.Is(err, models.ErrUserNotFoundInLeaderboard) {
+				t.Errorf("GetUserRank(%s) error = %v, want worst entry evicted", tt.wantEvicted, err)
+			}
+			if rank, err := lb.GetUserRank("newcomer"); err != nil || rank != 4 {
+				t.Errorf("GetUserRank(newcomer) = %v, %v, want 4", rank, err)
+			}
+		})
+	}
+}
+
+// TestCreateLeaderboardSortOrder tests sort order validation and defaults in the service
+func TestCreateLeaderboardSortOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   models.SortOrder
+		want    models.SortOrder
+		wantErr bool
+	}{
+		{name: "default", order: "", want: models.SortOrderDesc},
+		{name: "descending", order: models.SortOrderDesc, want: models.SortOrderDesc},
+		{name: "ascending", order: models.SortOrderAsc, want: models.SortOrderAsc},
+		{name: "unknown", order: "sideways", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := utils.NewInMemoryUnitOfWork()
+			svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+			t.Cleanup(svc.Close)
+
+			lb, err := svc.CreateLeaderboardWithOrder(context.Background(), "Board", models.LeaderboardTypeGlobal, 10, tt.order)
+			if tt.wantErr {
+				if !errors.Is(err, validate.ErrValidation) {
+					t.Errorf("CreateLeaderboardWithOrder() error = %v, want validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateLeaderboardWithOrder() error = %v", err)
+			}
+			if lb.SortOrder != tt.want {
+				t.Errorf("SortOrder = %q, want %q", lb.SortOrder, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetEntriesAround tests the window of entries around a user, including at the board's edges
+func TestGetEntriesAround(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Neighbours", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	// player0 ranks first with 100, player9 last with 10
+	names := make([]string, 10)
+	for i := range names {
+		names[i] = fmt.Sprintf("player%d", i)
+	}
+	seeded := seedUsers(t, uow.UserRepository(), names...)
+	users := make([]*models.User, len(names))
+	for i, name := range names {
+		users[i] = seeded[name]
+		if err := svc.AddScore(ctx, lb.ID, users[i].ID, int64(100-10*i)); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		user      int
+		radius    int
+		wantRanks []int
+	}{
+		{name: "middle", user: 5, radius: 2, wantRanks: []int{4, 5, 6, 7, 8}},
+		{name: "top edge", user: 0, radius: 2, wantRanks: []int{1, 2, 3}},
+		{name: "bottom edge", user: 9, radius: 2, wantRanks: []int{8, 9, 10}},
+		{name: "zero radius", user: 3, radius: 0, wantRanks: []int{4}},
+		{name: "radius beyond board", user: 4, radius: 50, wantRanks: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := svc.GetEntriesAround(ctx, lb.ID, users[tt.user].ID, tt.radius)
+			if err != nil {
+				t.Fatalf("GetEntriesAround() error = %v", err)
+			}
+			if len(entries) != len(tt.wantRanks) {
+				t.Fatalf("GetEntriesAround() len = %v, want %v", len(entries), len(tt.wantRanks))
+			}
+			for i, entry := range entries {
+				if entry.Rank != tt.wantRanks[i] {
+					t.Errorf("GetEntriesAround()[%d].Rank = %v, want %v", i, entry.Rank, tt.wantRanks[i])
+				}
+			}
+		})
+	}
+
+	if _, err := svc.GetEntriesAround(ctx, lb.ID, "nobody", 2); !errors.Is(err, models.ErrUserNotFoundInLeaderboard) {
+		t.Errorf("GetEntriesAround(unknown user) error = %v, want %v", err, models.ErrUserNotFoundInLeaderboard)
+	}
+	if _, err := svc.GetEntriesAround(ctx, "missing", users[0].ID, 2); !errors.Is(err, models.ErrLeaderboardNotFound) {
+		t.Errorf("GetEntriesAround(unknown leaderboard) error = %v, want %v", err, models.ErrLeaderboardNotFound)
+	}
+	if _, err := svc.GetEntriesAround(ctx, lb.ID, users[0].ID, -1); !errors.Is(err, leaderboard.ErrInvalidRadius) {
+		t.Errorf("GetEntriesAround(negative radius) error = %v, want %v", err, leaderboard.ErrInvalidRadius)
+	}
+}
+
+// TestTopEntriesCacheInvalidation tests that a score change drops cached top
+// entries for any count, not just small ones
+func TestTopEntriesCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Cached", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	users := seedUsers(t, uow.UserRepository(), "early", "late")
+	if err := svc.AddScore(ctx, lb.ID, users["early"].ID, 10); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+
+	if _, err := svc.GetTopEntries(ctx, lb.ID, 0, 250, false); err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+
+	// A write that skips the service does not invalidate, so the top 250 is served from cache
+	if err := uow.LeaderboardRepository().AddEntry(ctx, lb.ID, &models.LeaderboardEntry{UserID: users["late"].ID, Username: "late", Score: 5}); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if page, err := svc.GetTopEntries(ctx, lb.ID, 0, 250, false); err != nil || len(page.Entries) != 1 {
+		t.Fatalf("GetTopEntries() = %+v, %v, want the cached page with one entry", page, err)
+	}
+
+	if err := svc.AddScore(ctx, lb.ID, users["late"].ID, 20); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+
+	page, err := svc.GetTopEntries(ctx, lb.ID, 0, 250, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if len(entries) != 2 || entries[0].UserID != users["late"].ID {
+		t.Errorf("GetTopEntries() = %+v, want the new score first", entries)
+	}
+}
+
+// TestCacheSharedAcrossInstances tests that a score change on one server
+// instance drops the top entries another instance cached in the shared cache
+func TestCacheSharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	instances := make([]*leaderboard.LeaderboardService, 2)
+	for i := range instances {
+		instances[i] = leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 3600)
+		t.Cleanup(instances[i].Close)
+	}
+	a, b := instances[0], instances[1]
+
+	lb, err := a.CreateLeaderboard(ctx, "Shared", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	users := seedUsers(t, uow.UserRepository(), "early", "late")
+	if err := a.AddScore(ctx, lb.ID, users["early"].ID, 10); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	for _, ties := range []bool{false, true} {
+		if _, err := a.GetTopEntries(ctx, lb.ID, 0, 10, ties); err != nil {
+			t.Fatalf("GetTopEntries() error = %v", err)
+		}
+	}
+
+	if err := b.AddScore(ctx, lb.ID, users["late"].ID, 20); err != nil {
+		t.Fatalf("AddScore() on the other instance error = %v", err)
+	}
+	for _, ties := range []bool{false, true} {
+		page, err := a.GetTopEntries(ctx, lb.ID, 0, 10, ties)
+		if err != nil {
+			t.Fatalf("GetTopEntries() error = %v", err)
+		}
+		if len(page.Entries) != 2 || page.Entries[0].UserID != users["late"].ID {
+			t.Errorf("GetTopEntries(ties %v) = %+v, want the other instance's score first", ties, page.Entries)
+		}
+	}
+}
+
+// TestUserRankCacheInvalidation tests that a cached rank does not outlive a
+// score change or the user's removal from the board
+func TestUserRankCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 3600)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Ranks", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	users := seedUsers(t, uow.UserRepository(), "first", "second")
+	if err := svc.AddScore(ctx, lb.ID, users["first"].ID, 10); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	if rank, err := svc.GetUserRank(ctx, lb.ID, users["first"].ID); err != nil || rank.Rank != 1 {
+		t.Fatalf("GetUserRank() = %+v, %v, want rank 1", rank, err)
+	}
+
+	if err := svc.AddScore(ctx, lb.ID, users["second"].ID, 20); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	if rank, err := svc.GetUserRank(ctx, lb.ID, users["first"].ID); err != nil || rank.Rank != 2 {
+		t.Errorf("GetUserRank() after being overtaken = %+v, %v, want rank 2", rank, err)
+	}
+
+	if err := svc.RemoveEntry(ctx, lb.ID, users["first"].ID); err != nil {
+		t.Fatalf("RemoveEntry() error = %v", err)
+	}
+	rank, err := svc.GetUserRank(ctx, lb.ID, users["first"].ID)
+	if err != nil {
+		t.Fatalf("GetUserRank() error = %v", err)
+	}
+	if rank.Ranked || rank.Status != leaderboard.RankStatusUnranked {
+		t.Errorf("GetUserRank() after removal = %+v, want unranked", rank)
+	}
+}
+
+// scoredBoard creates a leaderboard holding scores, one player per score
+// named player0, player1 and so on, and returns it with the players' IDs
+func scoredBoard(t *testing.T, scores ...int64) (*leaderboard.LeaderboardService, *models.Leaderboard, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Distribution", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+
+	names := make([]string, len(scores))
+	for i := range names {
+		names[i] = fmt.Sprintf("player%d", i)
+	}
+	users := seedUsers(t, uow.UserRepository(), names...)
+	ids := make([]string, len(scores))
+	batch := make(map[string]int64, len(scores))
+	for i, name := range names {
+		ids[i] = users[name].ID
+		batch[ids[i]] = scores[i]
+	}
+	if err := svc.AddScores(ctx, lb.ID, batch); err != nil {
+		t.Fatalf("AddScores() error = %v", err)
+	}
+	return svc, lb, ids
+}
+
+// TestTopEntriesPagesWithTies tests that paging by NextOffset with ties included
+// returns every entry exactly once, even when a tie spans a page boundary
+func TestTopEntriesPagesWithTies(t *testing.T) {
+	ctx := context.Background()
+	svc, lb, ids := scoredBoard(t, tiedScores...)
+
+	var seen []string
+	offset := 0
+	for pages := 0; offset < len(ids); pages++ {
+		if pages == len(ids) {
+			t.Fatalf("still paging after %d pages, NextOffset is not moving forward", pages)
+		}
+		page, err := svc.GetTopEntries(ctx, lb.ID, offset, 2, true)
+		if err != nil {
+			t.Fatalf("GetTopEntries() error = %v", err)
+		}
+		if page.NextOffset != offset+len(page.Entries) {
+			t.Errorf("NextOffset = %d at offset %d with %d entries, want %d", page.NextOffset, offset, len(page.Entries), offset+len(page.Entries))
+		}
+		for _, entry := range page.Entries {
+			seen = append(seen, entry.UserID)
+		}
+		offset = page.NextOffset
+	}
+
+	// tiedScores is already in rank order, so the pages list the players in order
+	if len(seen) != len(ids) {
+		t.Fatalf("pages returned %d entries, want %d once each: %v", len(seen), len(ids), seen)
+	}
+	for i := range ids {
+		if seen[i] != ids[i] {
+			t.Errorf("entry %d = %s, want %s", i, seen[i], ids[i])
+		}
+	}
+}
+
+// TestStatsDistribution tests the median and standard deviation of known score distributions
+func TestStatsDistribution(t *testing.T) {
+	tests := []struct {
+		name       string
+		scores     []int64
+		wantMedian float64
+		wantStdDev float64
+	}{
+		{name: "even count", scores: []int64{2, 4, 4, 4, 5, 5, 7, 9}, wantMedian: 4.5, wantStdDev: 2},
+		{name: "odd count", scores: []int64{30, 10, 20}, wantMedian: 20, wantStdDev: math.Sqrt(200.0 / 3)},
+		{name: "single score", scores: []int64{42}, wantMedian: 42, wantStdDev: 0},
+		{name: "all equal", scores: []int64{7, 7, 7, 7}, wantMedian: 7, wantStdDev: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, lb, _ := scoredBoard(t, tt.scores...)
+
+			stats, err := svc.GetStats(context.Background(), lb.ID)
+			if err != nil {
+				t.Fatalf("GetStats() error = %v", err)
+			}
+			if stats.Median != tt.wantMedian {
+				t.Errorf("Median = %v, want %v", stats.Median, tt.wantMedian)
+			}
+			if math.Abs(stats.StdDev-tt.wantStdDev) > 1e-9 {
+				t.Errorf("StdDev = %v, want %v", stats.StdDev, tt.wantStdDev)
+			}
+		})
+	}
+}
+
+// TestGetUserPercentile tests the share of players a user is ahead of, with ties
+func TestGetUserPercentile(t *testing.T) {
+	ctx := context.Background()
+	svc, lb, users := scoredBoard(t, 40, 30, 30, 10)
+
+	tests := []struct {
+		name string
+		user int
+		want float64
+	}{
+		{name: "first", user: 0, want: 75},
+		{name: "tied second", user: 1, want: 25},
+		{name: "other tied second", user: 2, want: 25},
+		{name: "last", user: 3, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetUserPercentile(ctx, lb.ID, users[tt.user])
+			if err != nil {
+				t.Fatalf("GetUserPercentile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetUserPercentile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := svc.GetUserPercentile(ctx, lb.ID, "nobody"); !errors.Is(err, models.ErrUserNotFoundInLeaderboard) {
+		t.Errorf("GetUserPercentile(unknown user) error = %v, want %v", err, models.ErrUserNotFoundInLeaderboard)
+	}
+	if _, err := svc.GetUserPercentile(ctx, "missing", users[0]); !errors.Is(err, models.ErrLeaderboardNotFound) {
+		t.Errorf("GetUserPercentile(unknown leaderboard) error = %v, want %v", err, models.ErrLeaderboardNotFound)
+	}
+}
+
+// TestCreateLeaderboardConcurrentSameName tests that racing creates of one name yield exactly one leaderboard
+func TestCreateLeaderboardConcurrentSameName(t *testing.T) {
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	const attempts = 50
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		results = make(chan error, attempts)
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := svc.CreateLeaderboard(context.Background(), "Season 1", models.LeaderboardTypeSeasonal, 10)
+			results <- err
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, leaderboard.ErrLeaderboardExists):
+		default:
+			t.Errorf("CreateLeaderboard() error = %v, want nil or ErrLeaderboardExists", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("CreateLeaderboard() succeeded %d times, want exactly 1", created)
+	}
+
+	boards, err := uow.LeaderboardRepository().GetByType(context.Background(), models.LeaderboardTypeSeasonal)
+	if err != nil {
+		t.Fatalf("GetByType() error = %v", err)
+	}
+	if len(boards) != 1 {
+		t.Errorf("GetByType() = %d leaderboards, want 1", len(boards))
+	}
+}
+
+// TestAddEntrySanitizesUsername tests that control and formatting characters never reach stored entries
+func TestAddEntrySanitizesUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     string
+	}{
+		{name: "plain", username: "player_one", want: "player_one"},
+		{name: "newline injection", username: "eve\n*Admin*: reset all scores", want: "eve *Admin*: reset all scores"},
+		{name: "control characters", username: "ev\x00e\x1b[31m\x7f", want: "eve[31m"},
+		{name: "bidi override", username: "evil\u202egnp.exe", want: "evilgnp.exe"},
+		{name: "zero width and padding", username: "  ad\u200bmin\t ", want: "admin"},
+		{name: "nothing left", username: "\n\t\u200b", want: "user-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := models.NewLeaderboard("Sanitized", models.LeaderboardTypeGlobal, 10)
+			if err := lb.AddEntry("user-1", tt.username, 10); err != nil {
+				t.Fatalf("AddEntry() error = %v", err)
+			}
+
+			entries, _ := lb.GetTopEntries(0, 1, false)
+			if len(entries) != 1 {
+				t.Fatalf("GetTopEntries() = %d entries, want 1", len(entries))
+			}
+			if entries[0].Username != tt.want {
+				t.Errorf("Username = %q, want %q", entries[0].Username, tt.want)
+			}
+		})
+	}
+}
+
+// TestLeaderboardDecay tests that decayed scores rank recent activity above stale scores of equal value
+func TestLeaderboardDecay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	lb := models.NewLeaderboard("Trending", models.LeaderboardTypeWeekly, 10)
+	lb.SetClock(clock)
+	lb.SetDecay(24 * time.Hour)
+
+	if err := lb.AddEntry("stale", "stale", 1000); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	now = now.Add(48 * time.Hour)
+	if err := lb.AddEntry("fresh", "fresh", 1000); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+
+	entries, _ := lb.GetTopEntries(0, 2, false)
+	if len(entries) != 2 {
+		t.Fatalf("GetTopEntries() = %d entries, want 2", len(entries))
+	}
+	if entries[0].UserID != "fresh" || entries[0].Rank != 1 || entries[1].Rank != 2 {
+		t.Errorf("GetTopEntries() = %+v, want fresh ranked first", entries)
+	}
+	if entries[0].Score != 1000 || entries[1].Score != 1000 {
+		t.Errorf("GetTopEntries() raw scores = %d, %d, want 1000 for both", entries[0].Score, entries[1].Score)
+	}
+	if entries[0].EffectiveScore != 1000 || entries[1].EffectiveScore != 250 {
+		t.Errorf("GetTopEntries() effective scores = %v, %v, want 1000 and 250 after two half-lives", entries[0].EffectiveScore, entries[1].EffectiveScore)
+	}
+
+	// A stale high score is overtaken once it has decayed below a newer, lower one
+	if err := lb.AddEntry("steady", "steady", 600); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if rank, _ := lb.GetUserRank("steady"); rank != 2 {
+		t.Errorf("GetUserRank(steady) = %d, want 2", rank)
+	}
+	if rank, _ := lb.GetUserRank("stale"); rank != 3 {
+		t.Errorf("GetUserRank(stale) = %d, want 3", rank)
+	}
+
+	// Ranks are recomputed at read time as the clock moves, with no writes
+	now = now.Add(96 * time.Hour)
+	if rank, _ := lb.GetUserRank("fresh"); rank != 1 {
+		t.Errorf("GetUserRank(fresh) after more time = %d, want 1", rank)
+	}
+
+	// Without decay, equal raw scores tie
+	lb.SetDecay(0)
+	entries, _ = lb.GetTopEntries(0, 3, false)
+	if entries[0].Rank != 1 || entries[1].Rank != 1 || entries[0].EffectiveScore != 0 {
+		t.Errorf("GetTopEntries() without decay = %+v, want tied raw scores sharing rank 1", entries)
+	}
+}
+
+// TestLeaderboardDecayFull tests that a full, capped, decaying board compares a
+// new score against the current worst entry, not the scores and order of the last write
+func TestLeaderboardDecayFull(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	lb := models.NewLeaderboard("Capped", models.LeaderboardTypeGlobal, 2)
+	lb.SetClock(func() time.Time { return now })
+	lb.SetDecay(time.Hour)
+
+	// Two half-lives on, both entries have decayed to a quarter: 250 and 100
+	if err := lb.AddEntry("leader", "leader", 1000); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if err := lb.AddEntry("trailer", "trailer", 400); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	now = start.Add(2 * time.Hour)
+	if err := lb.AddEntry("newcomer", "newcomer", 300); err != nil {
+		t.Fatalf("AddEntry(newcomer) error = %v, want it to evict trailer", err)
+	}
+	if _, err := lb.GetUserRank("trailer"); !errors.Is(err, models.ErrUserNotFoundInLeaderboard) {
+		t.Errorf("GetUserRank(trailer) error = %v, want trailer evicted", err)
+	}
+
+	// An entry stamped ahead of the clock, e.g. replayed from a node whose clock
+	// runs fast, does not decay until then, so it overtakes an entry it trailed
+	lb.Clear()
+	now = start
+	if err := lb.AddEntry("steady", "steady", 500); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if err := lb.AddEntryAt("early", "early", 400, nil, start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("AddEntryAt() error = %v", err)
+	}
+	now = start.Add(2 * time.Hour)
+	if err := lb.AddEntry("newcomer", "newcomer", 300); err != nil {
+		t.Fatalf("AddEntry(newcomer) error = %v, want it to evict steady", err)
+	}
+	entries, _ := lb.GetTopEntries(0, 2, false)
+	if len(entries) != 2 || entries[0].UserID != "early" || entries[1].UserID != "newcomer" {
+		t.Errorf("GetTopEntries() = %+v, want early then newcomer", entries)
+	}
+}
+
+// TestMinUpdateDelta tests that small score changes without a rank change are coalesced
+func TestMinUpdateDelta(t *testing.T) {
+	tests := []struct {
+		name        string
+		delta       int64
+		wantUpdates int
+	}{
+		{name: "no threshold emits every change", delta: 0, wantUpdates: 20},
+		{name: "threshold suppresses small changes", delta: 50, wantUpdates: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			uow := utils.NewInMemoryUnitOfWork()
+			authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+			svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+			t.Cleanup(svc.Close)
+
+			lb, err := svc.CreateLeaderboard(ctx, "Noisy", models.LeaderboardTypeGlobal, 10)
+			if err != nil {
+				t.Fatalf("CreateLeaderboard() error = %v", err)
+			}
+			if err := svc.SetMinUpdateDelta(ctx, lb.ID, tt.delta); err != nil {
+				t.Fatalf("SetMinUpdateDelta() error = %v", err)
+			}
+			updates, err := svc.SubscribeToUpdates(lb.ID)
+			if err != nil {
+				t.Fatalf("SubscribeToUpdates() error = %v", err)
+			}
+			drain := func() []*leaderboard.LeaderboardUpdate {
+				var got []*leaderboard.LeaderboardUpdate
+				for {
+					select {
+					case u := <-updates:
+						got = append(got, u)
+					default:
+						return got
+					}
+				}
+			}
+
+			var users []string
+			for i := 0; i < 2; i++ {
+				user, err := authService.Register(ctx, &auth.RegisterRequest{
+					Username: fmt.Sprintf("noisy%d", i),
+					Email:    fmt.Sprintf("noisy%d@example.com", i),
+					Password: "password123",
+				})
+				if err != nil {
+					t.Fatalf("Register() error = %v", err)
+				}
+				users = append(users, user.ID)
+			}
+			leader, chaser := users[0], users[1]
+
+			// First scores always emit
+			if err := svc.AddScore(ctx, lb.ID, leader, 1000); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			if err := svc.AddScore(ctx, lb.ID, chaser, 100); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			if got := len(drain()); got != 2 {
+				t.Fatalf("initial updates = %d, want 2", got)
+			}
+
+			// Twenty +1 changes, none of which move the chaser off rank 2 or past the delta
+			for score := int64(101); score <= 120; score++ {
+				if err := svc.AddScore(ctx, lb.ID, chaser, score); err != nil {
+					t.Fatalf("AddScore() error = %v", err)
+				}
+			}
+			if got := len(drain()); got != tt.wantUpdates {
+				t.Errorf("updates for small changes = %d, want %d", got, tt.wantUpdates)
+			}
+
+			// The stored score is accurate even when its update was coalesced
+			page, err := svc.GetTopEntries(ctx, lb.ID, 0, 2, false)
+			if err != nil {
+				t.Fatalf("GetTopEntries() error = %v", err)
+			}
+			entries := page.Entries
+			if entries[1].UserID != chaser || entries[1].Score != 120 {
+				t.Errorf("GetTopEntries()[1] = %+v, want chaser with 120", entries[1])
+			}
+
+			// Small changes accumulate until they exceed the delta from the last emitted score
+			if err := svc.AddScore(ctx, lb.ID, chaser, 151); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			if got := len(drain()); got != 1 {
+				t.Errorf("updates after accumulated change = %d, want 1", got)
+			}
+
+			// A rank change always emits, however small the score change
+			if err := svc.AddScore(ctx, lb.ID, leader, 160); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			drain()
+			if err := svc.AddScore(ctx, lb.ID, chaser, 170); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			got := drain()
+			if len(got) != 1 || got[0].UserID != chaser || got[0].OldRank != 2 || got[0].NewRank != 1 {
+				t.Errorf("updates after rank change = %+v, want one update moving the chaser from rank 2 to 1", got)
+			}
+		})
+	}
+}
+
+// TestSubscribeBackpressure tests per-subscriber policies on leaderboard updates and the dropped count in Stats
+func TestSubscribeBackpressure(t *testing.T) {
+	f := newWebhookFixture(t, 1)
+	ctx := context.Background()
+
+	latest, err := f.svc.Subscribe(f.lb.ID, broadcast.Options{Buffer: 1, Policy: broadcast.DropOldest})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer latest.Close()
+	if _, err := f.svc.Subscribe("missing", broadcast.Options{Buffer: 1}); !errors.Is(err, leaderboard.ErrLeaderboardNotFound) {
+		t.Errorf("Subscribe() unknown leaderboard error = %v, want %v", err, leaderboard.ErrLeaderboardNotFound)
+	}
+
+	for score := int64(10); score <= 30; score += 10 {
+		if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, score); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	select {
+	case update := <-latest.C():
+		if update.Type != "score_updated" || update.UserID != f.userID {
+			t.Errorf("update = %+v, want a score_updated for %s", update, f.userID)
+		}
+	default:
+		t.Fatal("no update buffered")
+	}
+	if latest.Dropped() != 2 {
+		t.Errorf("Dropped() = %v, want 2", latest.Dropped())
+	}
+	if stats := f.svc.Stats(); stats.Subscribers != 1 || stats.DroppedUpdates != 2 {
+		t.Errorf("Stats() = %+v, want 1 subscriber and 2 dropped updates", stats)
+	}
+}
+
+// TestUpdateCallbacks tests that every registered callback sees an update, even when another one panics
+func TestUpdateCallbacks(t *testing.T) {
+	f := newWebhookFixture(t, 1)
+	ctx := context.Background()
+
+	var first, second []*leaderboard.LeaderboardUpdate
+	f.svc.OnUpdate(func(update *leaderboard.LeaderboardUpdate) { first = append(first, update) })
+	f.svc.OnUpdate(func(update *leaderboard.LeaderboardUpdate) { panic("broken integration") })
+	unregister := f.svc.OnUpdate(func(update *leaderboard.LeaderboardUpdate) { second = append(second, update) })
+
+	updates, err := f.svc.SubscribeToUpdates(f.lb.ID)
+	if err != nil {
+		t.Fatalf("SubscribeToUpdates() error = %v", err)
+	}
+
+	if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, 40); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+
+	// Callbacks run synchronously, so both have fired by the time AddScore returns
+	for name, got := range map[string][]*leaderboard.LeaderboardUpdate{"first": first, "second": second} {
+		if len(got) != 1 || got[0].Type != "score_updated" || got[0].UserID != f.userID || got[0].NewRank != 1 {
+			t.Errorf("%s callback got %+v, want one score_updated at rank 1 for %s", name, got, f.userID)
+		}
+	}
+	select {
+	case update := <-updates:
+		if update != first[0] {
+			t.Errorf("channel update = %+v, want the update the callbacks saw", update)
+		}
+	default:
+		t.Error("no update on the subscriber channel")
+	}
+
+	unregister()
+	if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, 80); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	if len(first) != 2 || len(second) != 1 {
+		t.Errorf("callbacks fired %d and %d times, want 2 and 1 after unregistering the second", len(first), len(second))
+	}
+}
+
+// TestEntryMetadata tests that entry metadata is stored, survives caching and is left out of JSON when unset
+func TestEntryMetadata(t *testing.T) {
+	f := newWebhookFixture(t, 1)
+	ctx := context.Background()
+
+	metadata := map[string]string{"game_id": "game-42", "region": "eu"}
+	if err := f.svc.AddScoreWithMetadata(ctx, f.lb.ID, f.userID, 70, metadata); err != nil {
+		t.Fatalf("AddScoreWithMetadata() error = %v", err)
+	}
+	metadata["region"] = "changed after the call"
+
+	// The second read is served from the cache
+	for _, read := range []string{"repository", "cache"} {
+		page, err := f.svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+		if err != nil {
+			t.Fatalf("GetTopEntries() error = %v", err)
+		}
+		entries := page.Entries
+		if len(entries) != 1 || entries[0].Metadata["game_id"] != "game-42" || entries[0].Metadata["region"] != "eu" {
+			t.Errorf("GetTopEntries() from %s = %+v, want the metadata as added", read, entries)
+		}
+	}
+
+	// A score without metadata keeps the entry's existing metadata
+	if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, 90); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	page, err := f.svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if entries[0].Score != 90 || entries[0].Metadata["game_id"] != "game-42" {
+		t.Errorf("entry after AddScore = %+v, want score 90 with the earlier metadata", entries[0])
+	}
+
+	data, err := json.Marshal(models.LeaderboardEntry{UserID: "plain", Score: 1})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), "metadata") {
+		t.Errorf("entry without metadata marshals to %s, want no metadata field", data)
+	}
+}
+
+// TestGetLeaderboardReturnsCopy tests that mutating a fetched leaderboard
+// affects neither the stored board nor the cached one. Run with -race to also
+// catch copies that share entries with the live board.
+func TestGetLeaderboardReturnsCopy(t *testing.T) {
+	f := newWebhookFixture(t, 1)
+	ctx := context.Background()
+
+	if err := f.svc.AddScoreWithMetadata(ctx, f.lb.ID, f.userID, 50, map[string]string{"game_id": "game-1"}); err != nil {
+		t.Fatalf("AddScoreWithMetadata() error = %v", err)
+	}
+
+	// The first read comes from the repository, the second from the cache
+	for _, read := range []string{"repository", "cache"} {
+		lb, err := f.svc.GetLeaderboard(ctx, f.lb.ID)
+		if err != nil {
+			t.Fatalf("GetLeaderboard() error = %v", err)
+		}
+		lb.Name = "Mutated"
+		lb.Entries[0].Score = 9999
+		lb.Entries[0].Metadata["game_id"] = "mutated"
+		lb.Entries = append(lb.Entries, models.LeaderboardEntry{UserID: "intruder", Score: 1})
+
+		again, err := f.svc.GetLeaderboard(ctx, f.lb.ID)
+		if err != nil {
+			t.Fatalf("GetLeaderboard() error = %v", err)
+		}
+		if again.Name != f.lb.Name || len(again.Entries) != 1 || again.Entries[0].Score != 50 || again.Entries[0].Metadata["game_id"] != "game-1" {
+			t.Errorf("GetLeaderboard() after mutating the %s copy = %+v, want the original board", read, again)
+		}
+	}
+
+	page, err := f.svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if len(entries) != 1 || entries[0].Score != 50 {
+		t.Errorf("stored entries = %+v, want the single score of 50", entries)
+	}
+
+	// Readers mutating their copies while scores are written must not race
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(score int64) {
+			defer wg.Done()
+			for j := int64(0); j < 20; j++ {
+				if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, score+j); err != nil {
+					t.Errorf("AddScore() error = %v", err)
+					return
+				}
+			}
+		}(int64(100 * (i + 1)))
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				lb, err := f.svc.GetLeaderboard(ctx, f.lb.ID)
+				if err != nil {
+					t.Errorf("GetLeaderboard() error = %v", err)
+					return
+				}
+				for k := range lb.Entries {
+					lb.Entries[k].Score = -1
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	page, err = f.svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries = page.Entries
+	for _, entry := range entries {
+		if entry.Score < 0 {
+			t.Errorf("stored entry %+v was changed through a returned copy", entry)
+		}
+	}
+}
+
+// TestMaxTopEntries tests that the service clamps top-entries counts for every caller
+func TestMaxTopEntries(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300, leaderboard.WithMaxTopEntries(3))
+	defer svc.Close()
+
+	lb, err := svc.CreateLeaderboard(ctx, "Capped", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		user, err := authService.Register(ctx, &auth.RegisterRequest{
+			Username: fmt.Sprintf("capped%d", i),
+			Email:    fmt.Sprintf("capped%d@example.com", i),
+			Password: "password123",
+		})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		if err := svc.AddScore(ctx, lb.ID, user.ID, int64(100-i)); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	if count, clamped := svc.ClampTopCount(1_000_000); count != 3 || !clamped {
+		t.Errorf("ClampTopCount(1000000) = %d, %v, want 3, true", count, clamped)
+	}
+	if count, clamped := svc.ClampTopCount(2); count != 2 || clamped {
+		t.Errorf("ClampTopCount(2) = %d, %v, want 2, false", count, clamped)
+	}
+
+	page, err := svc.GetTopEntries(ctx, lb.ID, 0, 1_000_000, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if len(entries) != 3 || entries[0].Score != 100 || entries[2].Score != 98 {
+		t.Errorf("GetTopEntries(1000000) = %+v, want the top 3", entries)
+	}
+}
+
+// TestUpdateDropsMarkedAsGap tests that updates dropped for a slow subscriber
+// are counted and the next update it receives is flagged as following a gap
+func TestUpdateDropsMarkedAsGap(t *testing.T) {
+	f := newWebhookFixture(t, 1)
+	ctx := context.Background()
+
+	sub, err := f.svc.Subscribe(f.lb.ID, broadcast.Options{Buffer: 2, Policy: broadcast.DropNewest, MarkGaps: true})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer sub.Close()
+
+	// Nobody reads, so the last three of five updates overflow the buffer
+	for score := int64(10); score <= 50; score += 10 {
+		if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, score); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	stats, err := f.svc.UpdateStats(f.lb.ID)
+	if err != nil {
+		t.Fatalf("UpdateStats() error = %v", err)
+	}
+	if stats.Published != 5 || stats.Dropped != 3 || sub.Dropped() != 3 {
+		t.Errorf("UpdateStats() = %+v with %d dropped for the subscriber, want 5 published and 3 dropped", stats, sub.Dropped())
+	}
+	if _, err := f.svc.UpdateStats("missing"); !errors.Is(err, leaderboard.ErrLeaderboardNotFound) {
+		t.Errorf("UpdateStats(missing) error = %v, want %v", err, leaderboard.ErrLeaderboardNotFound)
+	}
+
+	for i := 0; i < 2; i++ {
+		if update := <-sub.C(); update.Gap {
+			t.Errorf("buffered update %d = %+v, want no gap", i, update)
+		}
+	}
+
+	for _, score := range []int64{60, 70} {
+		if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, score); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+	if update := <-sub.C(); !update.Gap || update.NewRank != 1 {
+		t.Errorf("first update after the drops = %+v, want it flagged with Gap", update)
+	}
+	if update := <-sub.C(); update.Gap {
+		t.Errorf("second update after the drops = %+v, want no gap", update)
+	}
+}
+
+// TestTopEntriesPagination tests paging through a 50-entry board, including pages served from the cache
+func TestTopEntriesPagination(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Paged", models.LeaderboardTypeGlobal, 0)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	const boardSize, pageSize = 50, 10
+	for i := 0; i < boardSize; i++ {
+		user, err := authService.Register(ctx, &auth.RegisterRequest{
+			Username: fmt.Sprintf("paged%d", i),
+			Email:    fmt.Sprintf("paged%d@example.com", i),
+			Password: "password123",
+		})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		if err := svc.AddScore(ctx, lb.ID, user.ID, int64(1000-i)); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+
+	// The second pass is served from the cache
+	for _, read := range []string{"repository", "cache"} {
+		var seen []models.LeaderboardEntry
+		for offset := 0; ; offset += pageSize {
+			page, err := svc.GetTopEntries(ctx, lb.ID, offset, pageSize, false)
+			if err != nil {
+				t.Fatalf("GetTopEntries(offset=%d) error = %v", offset, err)
+			}
+			if page.Total != boardSize || page.Offset != offset || page.Limit != pageSize {
+				t.Fatalf("GetTopEntries(offset=%d) from %s = total %d, offset %d, limit %d, want %d, %d, %d",
+					offset, read, page.Total, page.Offset, page.Limit, boardSize, offset, pageSize)
+			}
+			if len(page.Entries) == 0 {
+				break
+			}
+			seen = append(seen, page.Entries...)
+		}
+
+		if len(seen) != boardSize {
+			t.Fatalf("paged through %d entries from %s, want %d", len(seen), read, boardSize)
+		}
+		for i, entry := range seen {
+			if entry.Rank != i+1 || entry.Score != int64(1000-i) {
+				t.Errorf("entry %d from %s = rank %d score %d, want rank %d score %d", i, read, entry.Rank, entry.Score, i+1, 1000-i)
+			}
+		}
+	}
+
+	if _, err := svc.GetTopEntries(ctx, lb.ID, -1, pageSize, false); !errors.Is(err, leaderboard.ErrInvalidOffset) {
+		t.Errorf("GetTopEntries(offset=-1) error = %v, want %v", err, leaderboard.ErrInvalidOffset)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package tests
+
+// raceEnabled reports whether the race detector is on; it slows code down too
+// much for timing comparisons
+const raceEnabled = false
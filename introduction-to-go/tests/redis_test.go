@@ -0,0 +1,115 @@
+//go:build redis
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// newRedisCache connects to the Redis at REDIS_URL, or a local one, and
+// deletes the given keys before and after the test. Run with -tags redis.
+func newRedisCache(t *testing.T, keys ...string) (*utils.RedisCacheRepository, *redis.Client) {
+	t.Helper()
+
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		url = "redis://localhost:6379/0"
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("ParseURL(%q) error = %v", url, err)
+	}
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Ping() error = %v; is Redis running at %s?", err, url)
+	}
+	client.Del(ctx, keys...)
+	t.Cleanup(func() { client.Del(context.Background(), keys...) })
+
+	return utils.NewRedisCacheRepository(client), client
+}
+
+// TestRedisCacheRepository tests every CacheRepository operation against Redis
+func TestRedisCacheRepository(t *testing.T) {
+	const (
+		valueKey   = "test:redis:value"
+		counterKey = "test:redis:counter"
+		lockKey    = "test:redis:lock"
+	)
+	ctx := context.Background()
+	var cache models.CacheRepository
+	cache, client := newRedisCache(t, valueKey, counterKey, lockKey)
+
+	var entries []models.LeaderboardEntry
+	if err := cache.Get(ctx, valueKey, &entries); !errors.Is(err, models.ErrCacheMiss) {
+		t.Fatalf("Get() of a missing key error = %v, want ErrCacheMiss", err)
+	}
+
+	want := []models.LeaderboardEntry{{UserID: "u1", Username: "ann", Score: 30, Rank: 1}}
+	if err := cache.Set(ctx, valueKey, want, 60); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := cache.Get(ctx, valueKey, &entries); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].UserID != "u1" || entries[0].Score != 30 || entries[0].Rank != 1 {
+		t.Errorf("Get() = %+v, want %+v", entries, want)
+	}
+	if ttl := client.TTL(ctx, valueKey).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL after Set(60) = %v, want up to a minute", ttl)
+	}
+	if exists, err := cache.Exists(ctx, valueKey); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true", exists, err)
+	}
+
+	if err := cache.Delete(ctx, valueKey); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if exists, err := cache.Exists(ctx, valueKey); err != nil || exists {
+		t.Errorf("Exists() after Delete = %v, %v, want false", exists, err)
+	}
+
+	if ok, err := cache.SetNX(ctx, lockKey, "first", 60); err != nil || !ok {
+		t.Errorf("SetNX() on a new key = %v, %v, want true", ok, err)
+	}
+	if ok, err := cache.SetNX(ctx, lockKey, "second", 60); err != nil || ok {
+		t.Errorf("SetNX() on an existing key = %v, %v, want false", ok, err)
+	}
+	var holder string
+	if err := cache.Get(ctx, lockKey, &holder); err != nil || holder != "first" {
+		t.Errorf("Get() after SetNX = %q, %v, want first", holder, err)
+	}
+
+	for i, want := range []int64{5, 3} {
+		got, err := cache.Increment(ctx, counterKey, []int64{5, -2}[i])
+		if err != nil || got != want {
+			t.Errorf("Increment() #%d = %v, %v, want %v", i+1, got, err, want)
+		}
+	}
+	var counter int64
+	if err := cache.Get(ctx, counterKey, &counter); err != nil || counter != 3 {
+		t.Errorf("Get() of a counter = %v, %v, want 3", counter, err)
+	}
+
+	if err := cache.Expire(ctx, counterKey, 30); err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+	if ttl := client.TTL(ctx, counterKey).Val(); ttl <= 0 || ttl > 30*time.Second {
+		t.Errorf("TTL after Expire(30) = %v, want up to 30s", ttl)
+	}
+	if err := cache.Expire(ctx, valueKey, 30); !errors.Is(err, models.ErrCacheMiss) {
+		t.Errorf("Expire() of a missing key error = %v, want ErrCacheMiss", err)
+	}
+}
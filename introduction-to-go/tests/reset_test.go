@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// TestPeriodStart tests the reset boundaries of each leaderboard type
+func TestPeriodStart(t *testing.T) {
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		typ    models.LeaderboardType
+		now    time.Time
+		want   time.Time
+		resets bool
+	}{
+		{name: "weekly midweek", typ: models.LeaderboardTypeWeekly, now: at(time.January, 7, 15), want: at(time.January, 5, 0), resets: true},
+		{name: "weekly on monday midnight", typ: models.LeaderboardTypeWeekly, now: at(time.January, 5, 0), want: at(time.January, 5, 0), resets: true},
+		{name: "weekly on sunday", typ: models.LeaderboardTypeWeekly, now: at(time.January, 4, 23), want: at(time.December, 29, 0).AddDate(-1, 0, 0), resets: true},
+		{name: "monthly", typ: models.LeaderboardTypeMonthly, now: at(time.March, 31, 23), want: at(time.March, 1, 0), resets: true},
+		{name: "global", typ: models.LeaderboardTypeGlobal, now: at(time.March, 31, 23), resets: false},
+		{name: "seasonal", typ: models.LeaderboardTypeSeasonal, now: at(time.March, 31, 23), resets: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, resets := tt.typ.PeriodStart(tt.now)
+			if resets != tt.resets || !got.Equal(tt.want) {
+				t.Errorf("PeriodStart(%v) = %v, %v, want %v, %v", tt.now, got, resets, tt.want, tt.resets)
+			}
+		})
+	}
+}
+
+// TestResetScheduler tests that periodic leaderboards are archived and cleared
+// as the clock crosses their boundaries, and others are left alone
+func TestResetScheduler(t *testing.T) {
+	ctx := context.Background()
+	// Sunday, one minute before the weekly boundary
+	clock := &fakeClock{now: time.Date(2026, 1, 4, 23, 59, 0, 0, time.UTC)}
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300,
+		leaderboard.WithClock(clock.Now),
+		leaderboard.WithResetCheckInterval(5*time.Millisecond),
+	)
+	t.Cleanup(svc.Close)
+
+	users := seedUsers(t, uow.UserRepository(), "ann", "ben")
+	boards := make(map[models.LeaderboardType]*models.Leaderboard)
+	for _, typ := range []models.LeaderboardType{models.LeaderboardTypeWeekly, models.LeaderboardTypeMonthly, models.LeaderboardTypeGlobal} {
+		lb, err := svc.CreateLeaderboard(ctx, string(typ), typ, 10)
+		if err != nil {
+			t.Fatalf("CreateLeaderboard(%s) error = %v", typ, err)
+		}
+		boards[typ] = lb
+		if err := svc.AddScores(ctx, lb.ID, map[string]int64{users["ann"].ID: 20, users["ben"].ID: 10}); err != nil {
+			t.Fatalf("AddScores(%s) error = %v", typ, err)
+		}
+	}
+
+	archives := func(typ models.LeaderboardType) []*models.LeaderboardArchive {
+		archives, err := svc.GetArchives(ctx, boards[typ].ID)
+		if err != nil {
+			t.Fatalf("GetArchives(%s) error = %v", typ, err)
+		}
+		return archives
+	}
+	entries := func(typ models.LeaderboardType) int {
+		top, err := svc.GetTopEntries(ctx, boards[typ].ID, 0, 10, false)
+		if err != nil {
+			t.Fatalf("GetTopEntries(%s) error = %v", typ, err)
+		}
+		return len(top.Entries)
+	}
+
+	svc.StartResetScheduler(ctx)
+
+	// Into Monday: only the weekly board resets
+	clock.Advance(2 * time.Minute)
+	waitFor(t, "weekly reset", func() bool { return len(archives(models.LeaderboardTypeWeekly)) == 1 })
+
+	weekly := archives(models.LeaderboardTypeWeekly)[0]
+	if !weekly.PeriodStart.Equal(time.Date(2025, 12, 29, 0, 0, 0, 0, time.UTC)) || !weekly.PeriodEnd.Equal(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly archive period = %v to %v, want the week starting Monday 29 December", weekly.PeriodStart, weekly.PeriodEnd)
+	}
+	if len(weekly.Entries) != 2 || weekly.Entries[0].UserID != users["ann"].ID || weekly.Entries[0].Rank != 1 {
+		t.Errorf("weekly archive entries = %+v, want ann then ben", weekly.Entries)
+	}
+	if got := entries(models.LeaderboardTypeWeekly); got != 0 {
+		t.Errorf("weekly board has %d entries after reset, want 0", got)
+	}
+	if got := entries(models.LeaderboardTypeMonthly); got != 2 {
+		t.Errorf("monthly board has %d entries, want 2 before the month ends", got)
+	}
+
+	// Into February: the monthly board resets too
+	clock.Advance(27 * 24 * time.Hour)
+	waitFor(t, "monthly reset", func() bool { return len(archives(models.LeaderboardTypeMonthly)) == 1 })
+
+	monthly := archives(models.LeaderboardTypeMonthly)[0]
+	if !monthly.PeriodStart.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !monthly.PeriodEnd.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("monthly archive period = %v to %v, want January", monthly.PeriodStart, monthly.PeriodEnd)
+	}
+	if got := entries(models.LeaderboardTypeMonthly); got != 0 {
+		t.Errorf("monthly board has %d entries after reset, want 0", got)
+	}
+	if got := entries(models.LeaderboardTypeGlobal); got != 2 {
+		t.Errorf("global board has %d entries, want 2; global boards never reset", got)
+	}
+	if got := archives(models.LeaderboardTypeGlobal); len(got) != 0 {
+		t.Errorf("global board has %d archives, want 0", len(got))
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// TestSnapshotRoundTrip tests that users, games and leaderboards saved to a
+// snapshot are restored unchanged into a fresh unit of work
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f := newGameFixture(t)
+
+	g, err := f.svc.CreateGame(ctx, f.player1.ID, f.player2.ID)
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if err := f.svc.StartGame(ctx, g.ID); err != nil {
+		t.Fatalf("StartGame() error = %v", err)
+	}
+	if err := f.svc.UpdateScore(ctx, g.ID, f.player1.ID, 30); err != nil {
+		t.Fatalf("UpdateScore() error = %v", err)
+	}
+	if _, err := f.svc.EndGame(ctx, g.ID); err != nil {
+		t.Fatalf("EndGame() error = %v", err)
+	}
+	// Closing drains the game_ended event, so user stats are final
+	f.svc.Close()
+
+	lbSvc := leaderboard.NewLeaderboardService(f.uow.LeaderboardRepository(), f.uow.UserRepository(), f.uow.CacheRepository(), 300)
+	t.Cleanup(lbSvc.Close)
+	lb, err := lbSvc.CreateLeaderboardWithOrder(ctx, "Speedrun", models.LeaderboardTypeGlobal, 10, models.SortOrderAsc)
+	if err != nil {
+		t.Fatalf("CreateLeaderboardWithOrder() error = %v", err)
+	}
+	if err := lbSvc.AddScores(ctx, lb.ID, map[string]int64{f.player1.ID: 90, f.player2.ID: 75}); err != nil {
+		t.Fatalf("AddScores() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := f.uow.(*utils.InMemoryUnitOfWork).SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	restored := utils.NewInMemoryUnitOfWork()
+	if err := restored.(*utils.InMemoryUnitOfWork).LoadSnapshot(path); err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+
+	for _, want := range []*models.User{f.player1, f.player2} {
+		got, err := restored.UserRepository().GetByID(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetByID(%s) error = %v", want.Username, err)
+		}
+		if got.Username != want.Username || got.Email != want.Email || got.Password != want.Password || !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("restored user = %+v, want %+v", got, want)
+		}
+
+		wantStats, _ := f.uow.UserRepository().GetStats(ctx, want.ID)
+		gotStats, err := restored.UserRepository().GetStats(ctx, want.ID)
+		if err != nil {
+			t.Fatalf("GetStats(%s) error = %v", want.Username, err)
+		}
+		if !reflect.DeepEqual(gotStats, wantStats) {
+			t.Errorf("restored stats = %+v, want %+v", gotStats, wantStats)
+		}
+	}
+	if _, err := auth.NewAuthService(restored.UserRepository(), restored.CacheRepository()).Login(ctx, &auth.LoginRequest{Username: "player1", Password: "password123"}); err != nil {
+		t.Errorf("Login() with a restored user error = %v", err)
+	}
+
+	gotGame, err := restored.GameRepository().GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("GetByID(game) error = %v", err)
+	}
+	if gotGame.State != models.GameStateFinished || gotGame.Score1 != 30 || gotGame.WinnerID == nil || *gotGame.WinnerID != f.player1.ID {
+		t.Errorf("restored game = %+v, want finished 30-0 won by player1", gotGame)
+	}
+
+	gotLB, err := restored.LeaderboardRepository().GetByID(ctx, lb.ID)
+	if err != nil {
+		t.Fatalf("GetByID(leaderboard) error = %v", err)
+	}
+	wantEntries, _, _ := f.uow.LeaderboardRepository().GetTopEntries(ctx, lb.ID, 0, 10, false)
+	gotEntries, _, _ := restored.LeaderboardRepository().GetTopEntries(ctx, lb.ID, 0, 10, false)
+	if gotLB.Name != lb.Name || gotLB.SortOrder != models.SortOrderAsc || len(gotEntries) != len(wantEntries) {
+		t.Fatalf("restored leaderboard %s (%s) has %d entries, want %s (asc) with %d", gotLB.Name, gotLB.SortOrder, len(gotEntries), lb.Name, len(wantEntries))
+	}
+	for i, want := range wantEntries {
+		got := gotEntries[i]
+		if got.UserID != want.UserID || got.Score != want.Score || got.Rank != want.Rank || !got.UpdatedAt.Equal(want.UpdatedAt) {
+			t.Errorf("restored entry %d = %+v, want %+v", i, *got, *want)
+		}
+	}
+
+	// Restored leaderboards only get update feeds when the service reconciles them
+	plain := leaderboard.NewLeaderboardService(restored.LeaderboardRepository(), restored.UserRepository(), restored.CacheRepository(), 300)
+	t.Cleanup(plain.Close)
+	if _, err := plain.Subscribe(lb.ID, leaderboard.DefaultSubscription); err == nil {
+		t.Errorf("Subscribe() to an unreconciled leaderboard succeeded")
+	}
+	reconciled := leaderboard.NewLeaderboardService(restored.LeaderboardRepository(), restored.UserRepository(), restored.CacheRepository(), 300,
+		leaderboard.WithLeaderboardReconciliation(),
+	)
+	t.Cleanup(reconciled.Close)
+	sub, err := reconciled.Subscribe(lb.ID, leaderboard.DefaultSubscription)
+	if err != nil {
+		t.Fatalf("Subscribe() to a reconciled leaderboard error = %v", err)
+	}
+	sub.Close()
+}
+
+// TestSnapshotOnClose tests that Close saves a snapshot only when a path is set,
+// and that loading a missing snapshot reports os.ErrNotExist
+func TestSnapshotOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	uow := utils.NewInMemoryUnitOfWork().(*utils.InMemoryUnitOfWork)
+	users := seedUsers(t, uow.UserRepository(), "ann")
+
+	if err := uow.Close(); err != nil {
+		t.Fatalf("Close() without a snapshot path error = %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Stat() after Close without a snapshot path error = %v, want os.ErrNotExist", err)
+	}
+	if err := utils.NewInMemoryUnitOfWork().(*utils.InMemoryUnitOfWork).LoadSnapshot(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadSnapshot() of a missing file error = %v, want os.ErrNotExist", err)
+	}
+
+	uow.SetSnapshotPath(path)
+	if err := uow.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	restored := utils.NewInMemoryUnitOfWork().(*utils.InMemoryUnitOfWork)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if _, err := restored.UserRepository().GetByID(context.Background(), users["ann"].ID); err != nil {
+		t.Errorf("GetByID() after reload error = %v", err)
+	}
+}
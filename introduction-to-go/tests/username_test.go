@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// seedUsers stores users directly, bypassing registration's collision check,
+// as data from before case-insensitive lookups would be
+func seedUsers(t *testing.T, repo models.UserRepository, usernames ...string) map[string]*models.User {
+	t.Helper()
+
+	seeded := make(map[string]*models.User, len(usernames))
+	for i, username := range usernames {
+		user, err := models.NewUser(username, username+"@example.com", "password123")
+		if err != nil {
+			t.Fatalf("NewUser(%q) error = %v", username, err)
+		}
+		user.CreatedAt = time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)
+		if err := repo.Create(context.Background(), user); err != nil {
+			t.Fatalf("Create(%q) error = %v", username, err)
+		}
+		seeded[username] = user
+	}
+	return seeded
+}
+
+// TestUsernameLookupCollisions tests how case-insensitive lookups resolve usernames that differ only in case
+func TestUsernameLookupCollisions(t *testing.T) {
+	ctx := context.Background()
+	repo := utils.NewInMemoryUnitOfWork().UserRepository()
+	seeded := seedUsers(t, repo, "Alice", "alice", "bob")
+
+	tests := []struct {
+		name     string
+		username string
+		wantID   string
+		wantErr  error
+	}{
+		{"exact match preferred", "Alice", seeded["Alice"].ID, nil},
+		{"other exact match preferred", "alice", seeded["alice"].ID, nil},
+		{"no exact match is ambiguous", "ALICE", "", models.ErrAmbiguousUsername},
+		{"single match ignores case", "BOB", seeded["bob"].ID, nil},
+		{"unknown user", "carol", "", models.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := repo.GetByUsername(ctx, tt.username)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByUsername(%q) error = %v, want %v", tt.username, err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.ID != tt.wantID {
+				t.Errorf("GetByUsername(%q) = %s, want %s", tt.username, user.Username, tt.wantID)
+			}
+		})
+	}
+
+	// The strict fallback refuses even an exact match while the name is shared
+	repo.(*utils.InMemoryUserRepository).SetUsernameFallback(models.UsernameFallbackError)
+	if _, err := repo.GetByUsername(ctx, "Alice"); !errors.Is(err, models.ErrAmbiguousUsername) {
+		t.Errorf("GetByUsername(Alice) with the error fallback = %v, want %v", err, models.ErrAmbiguousUsername)
+	}
+	if user, err := repo.GetByUsername(ctx, "Bob"); err != nil || user.ID != seeded["bob"].ID {
+		t.Errorf("GetByUsername(Bob) with the error fallback = %v, %v, want bob", user, err)
+	}
+}
+
+// TestRegisterRejectsCaseVariants tests that a username differing only in case counts as taken
+func TestRegisterRejectsCaseVariants(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	seedUsers(t, uow.UserRepository(), "Alice", "alice")
+
+	for _, username := range []string{"ALICE", "alice", "bob"} {
+		_, err := authService.Register(ctx, &auth.RegisterRequest{Username: username, Email: username + "-new@example.com", Password: "password123"})
+		wantTaken := username != "bob"
+		if gotTaken := errors.Is(err, auth.ErrUserAlreadyExists); gotTaken != wantTaken {
+			t.Errorf("Register(%q) error = %v, want taken %v", username, err, wantTaken)
+		}
+	}
+}
+
+// TestDedupeUsernames tests that colliding usernames are reported oldest first
+func TestDedupeUsernames(t *testing.T) {
+	repo := utils.NewInMemoryUnitOfWork().UserRepository()
+	seeded := seedUsers(t, repo, "Alice", "bob", "alice", "ALICE", "Carol", "carol", "dave")
+
+	conflicts, err := auth.DedupeUsernames(context.Background(), repo)
+	if err != nil {
+		t.Fatalf("DedupeUsernames() error = %v", err)
+	}
+	if len(conflicts) != 2 {
+		t.Fatalf("DedupeUsernames() reported %d conflicts, want 2: %+v", len(conflicts), conflicts)
+	}
+
+	want := []struct {
+		username string
+		users    []string
+	}{
+		{"alice", []string{"Alice", "alice", "ALICE"}},
+		{"carol", []string{"Carol", "carol"}},
+	}
+	for i, w := range want {
+		got := conflicts[i]
+		if got.Username != w.username || len(got.Users) != len(w.users) {
+			t.Errorf("conflicts[%d] = %s with %d users, want %s with %d", i, got.Username, len(got.Users), w.username, len(w.users))
+			continue
+		}
+		for j, username := range w.users {
+			if got.Users[j].ID != seeded[username].ID {
+				t.Errorf("conflicts[%d].Users[%d] = %s, want %s", i, j, got.Users[j].Username, username)
+			}
+		}
+	}
+}
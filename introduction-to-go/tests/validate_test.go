@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/game"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+	"effective-golang/pkg/validate"
+)
+
+// TestValidators tests each built-in rule on passing and failing values
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    validate.Rule
+		value   any
+		wantErr bool
+	}{
+		{"required string", validate.Required(), "alice", false},
+		{"required empty string", validate.Required(), "", true},
+		{"required blank string", validate.Required(), "   ", true},
+		{"required zero int", validate.Required(), 0, true},
+		{"required nil pointer", validate.Required(), (*string)(nil), true},
+		{"min length", validate.MinLen(3), "abc", false},
+		{"min length short", validate.MinLen(3), "ab", true},
+		{"min length counts characters", validate.MinLen(3), "äöü", false},
+		{"max length", validate.MaxLen(3), "abc", false},
+		{"max length long", validate.MaxLen(3), "abcd", true},
+		{"max length slice", validate.MaxLen(1), []int{1, 2}, true},
+		{"length of a number", validate.MinLen(1), 5, true},
+		{"min", validate.Min(0), 0, false},
+		{"min below", validate.Min(0), -1, true},
+		{"max", validate.Max(10), 10.0, false},
+		{"max above", validate.Max(10), uint(11), true},
+		{"range", validate.Range(1, 5), int64(3), false},
+		{"range below", validate.Range(1, 5), 0, true},
+		{"range above", validate.Range(1, 5), 6, true},
+		{"range of a string", validate.Range(1, 5), "3", true},
+		{"one of", validate.OneOf("red", "green"), "green", false},
+		{"one of named type", validate.OneOf("global"), models.LeaderboardTypeGlobal, false},
+		{"one of missing", validate.OneOf("red", "green"), "blue", true},
+		{"email", validate.Email(), "test@example.com", false},
+		{"email without domain", validate.Email(), "user@", true},
+		{"email without user", validate.Email(), "@domain.com", true},
+		{"email with display name", validate.Email(), "Test <test@example.com>", true},
+		{"email without at", validate.Email(), "invalid-email", true},
+		{"url", validate.URL(), "https://example.com/hook", false},
+		{"url relative", validate.URL(), "/hook", true},
+		{"url other scheme", validate.URL(), "ftp://example.com", true},
+		{"url garbage", validate.URL(), "http://[::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Value(tt.value, tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Value(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type signupForm struct {
+	Username string   `json:"username" validate:"required,min=3,max=20"`
+	Email    string   `json:"email" validate:"required,email"`
+	Website  string   `json:"website" validate:"url"`
+	Age      int      `json:"age" validate:"range=13:120"`
+	Plan     string   `json:"plan" validate:"oneof=free pro"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Referrer string   // untagged fields are ignored
+}
+
+// TestValidateStructReportsEveryField tests that all failing fields are reported together, in order
+func TestValidateStructReportsEveryField(t *testing.T) {
+	form := &signupForm{
+		Username: "al",
+		Email:    "not-an-email",
+		Website:  "example.com",
+		Age:      7,
+		Plan:     "enterprise",
+		Tags:     []string{"a", "b", "c"},
+	}
+
+	err := validate.Struct(form)
+	if !errors.Is(err, validate.ErrValidation) {
+		t.Fatalf("Struct() error = %v, want ErrValidation", err)
+	}
+
+	var errs validate.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Struct() error is %T, want validate.Errors", err)
+	}
+	want := []string{
+		"username: must be at least 3 characters",
+		"email: must be a valid email address",
+		"website: must be an absolute http or https URL",
+		"age: must be between 13 and 120",
+		"plan: must be one of free, pro",
+		"tags: must be at most 2 items",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Struct() reported %d fields, want %d: %v", len(errs), len(want), err)
+	}
+	for i, msg := range want {
+		if got := errs[i].Error(); got != msg {
+			t.Errorf("errs[%d] = %q, want %q", i, got, msg)
+		}
+	}
+
+	if _, ok := errs.Field("plan"); !ok {
+		t.Error("Field(plan) not found")
+	}
+	body, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(body), `{"field":"age","message":"must be between 13 and 120"}`) {
+		t.Errorf("Marshal() = %s, want field and message pairs", body)
+	}
+
+	valid := &signupForm{Username: "alice", Email: "alice@example.com", Website: "https://alice.dev", Age: 30, Plan: "pro"}
+	if err := validate.Struct(valid); err != nil {
+		t.Errorf("Struct(valid) error = %v", err)
+	}
+}
+
+// TestValidateRuleset tests declared rules and custom rules that return sentinel errors
+func TestValidateRuleset(t *testing.T) {
+	errTooBig := errors.New("too big")
+	rules := validate.Ruleset{
+		"Username": {validate.Required()},
+		"Age": {func(v any) error {
+			if v.(int) > 100 {
+				return errTooBig
+			}
+			return nil
+		}},
+	}
+
+	err := rules.Validate(signupForm{Age: 200})
+	if !errors.Is(err, validate.ErrValidation) || !errors.Is(err, errTooBig) {
+		t.Errorf("Validate() error = %v, want ErrValidation wrapping errTooBig", err)
+	}
+	if got := err.Error(); got != "username: is required; age: too big" {
+		t.Errorf("Validate() error = %q", got)
+	}
+
+	if err := (validate.Ruleset{"Missing": {validate.Required()}}).Validate(signupForm{}); err == nil || errors.Is(err, validate.ErrValidation) {
+		t.Errorf("Validate() with an unknown field = %v, want a non-validation error", err)
+	}
+	if err := validate.Struct(struct {
+		Name string `validate:"shiny"`
+	}{}); err == nil || errors.Is(err, validate.ErrValidation) {
+		t.Errorf("Struct() with an unknown rule = %v, want a non-validation error", err)
+	}
+}
+
+// TestRequestValidation tests that registration, game events and leaderboard creation reject invalid input
+func TestRequestValidation(t *testing.T) {
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	_, err := authService.Register(ctx, &auth.RegisterRequest{Username: "al", Email: "nope", Password: "123"})
+	var errs validate.Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("Register() error = %v, want all three fields reported", err)
+	}
+
+	gameSvc := game.NewGameService(uow.GameRepository(), uow.UserRepository(), uow.LeaderboardRepository(), uow.CacheRepository(), 1, 10)
+	t.Cleanup(func() { gameSvc.Close() })
+	if err := gameSvc.QueueEvent(&game.GameEvent{GameID: "g1"}); !errors.Is(err, game.ErrInvalidEvent) || !errors.Is(err, validate.ErrValidation) {
+		t.Errorf("QueueEvent() without a type error = %v, want ErrInvalidEvent", err)
+	}
+
+	lbSvc := leaderboard.NewLeaderboardService(uow.LeaderboardRepository(), uow.UserRepository(), uow.CacheRepository(), 300)
+	t.Cleanup(lbSvc.Close)
+	_, err = lbSvc.CreateLeaderboard(ctx, "", "daily", -1)
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("CreateLeaderboard() error = %v, want name, type and max_entries reported", err)
+	}
+	if !errors.Is(err, leaderboard.ErrInvalidMaxEntries) {
+		t.Errorf("CreateLeaderboard() error = %v, want it to wrap ErrInvalidMaxEntries", err)
+	}
+}
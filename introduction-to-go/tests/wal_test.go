@@ -0,0 +1,296 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/internal/wal"
+	"effective-golang/pkg/utils"
+)
+
+// walNode is one run of the leaderboard service over a WAL file; users live
+// in a store shared between runs, as they would in a real user database
+type walNode struct {
+	svc *leaderboard.LeaderboardService
+	log *wal.Log
+}
+
+func startWALNode(t *testing.T, path string, users models.UserRepository) *walNode {
+	t.Helper()
+
+	log, err := wal.Open(path)
+	if err != nil {
+		t.Fatalf("wal.Open() error = %v", err)
+	}
+	uow := utils.NewInMemoryUnitOfWork()
+	svc := leaderboard.NewLeaderboardService(
+		uow.LeaderboardRepository(),
+		users,
+		uow.CacheRepository(),
+		300,
+		leaderboard.WithWAL(log, 0),
+	)
+	return &walNode{svc: svc, log: log}
+}
+
+func (n *walNode) stop(t *testing.T) {
+	t.Helper()
+	n.svc.Close()
+	if err := n.log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+// walFixture writes a leaderboard with two players through a WAL-backed service
+type walFixture struct {
+	path  string
+	users models.UserRepository
+	lb    *models.Leaderboard
+	alice string
+	bob   string
+}
+
+func newWALFixture(t *testing.T) (*walFixture, *walNode) {
+	t.Helper()
+
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	f := &walFixture{path: filepath.Join(t.TempDir(), "leaderboards.wal"), users: uow.UserRepository()}
+
+	for _, name := range []string{"alice", "bob"} {
+		user, err := authService.Register(ctx, &auth.RegisterRequest{Username: name, Email: name + "@example.com", Password: "password123"})
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+		if name == "alice" {
+			f.alice = user.ID
+		} else {
+			f.bob = user.ID
+		}
+	}
+
+	node := startWALNode(t, f.path, f.users)
+	lb, err := node.svc.CreateLeaderboard(ctx, "Durable", models.LeaderboardTypeGlobal, 10)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	f.lb = lb
+
+	if err := node.svc.SetMinUpdateDelta(ctx, lb.ID, 5); err != nil {
+		t.Fatalf("SetMinUpdateDelta() error = %v", err)
+	}
+	for userID, score := range map[string]int64{f.alice: 100, f.bob: 250} {
+		if err := node.svc.AddScore(ctx, lb.ID, userID, score); err != nil {
+			t.Fatalf("AddScore() error = %v", err)
+		}
+	}
+	return f, node
+}
+
+// assertRecovered checks that a restarted node holds the fixture's leaderboard
+func (f *walFixture) assertRecovered(t *testing.T, svc *leaderboard.LeaderboardService) {
+	t.Helper()
+	ctx := context.Background()
+
+	lb, err := svc.GetLeaderboard(ctx, f.lb.ID)
+	if err != nil {
+		t.Fatalf("GetLeaderboard() error = %v", err)
+	}
+	if lb.Name != "Durable" || lb.GetMinUpdateDelta() != 5 {
+		t.Errorf("recovered leaderboard = %q with min delta %v, want %q with 5", lb.Name, lb.GetMinUpdateDelta(), "Durable")
+	}
+
+	page, err := svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	entries := page.Entries
+	if len(entries) != 2 {
+		t.Fatalf("recovered %d entries, want 2", len(entries))
+	}
+	if entries[0].UserID != f.bob || entries[0].Score != 250 || entries[0].Rank != 1 {
+		t.Errorf("entries[0] = %+v, want bob with 250 at rank 1", entries[0])
+	}
+	if entries[1].UserID != f.alice || entries[1].Score != 100 || entries[1].Rank != 2 {
+		t.Errorf("entries[1] = %+v, want alice with 100 at rank 2", entries[1])
+	}
+
+	// The recovered board keeps accepting writes
+	if err := svc.AddScore(ctx, f.lb.ID, f.alice, 300); err != nil {
+		t.Fatalf("AddScore() after recovery error = %v", err)
+	}
+	if rank, err := svc.GetUserRank(ctx, f.lb.ID, f.alice); err != nil || rank.Rank != 1 {
+		t.Errorf("GetUserRank() = %+v, %v, want rank 1", rank, err)
+	}
+}
+
+// TestWALRecovery tests that leaderboards survive a restart by replaying the log
+func TestWALRecovery(t *testing.T) {
+	f, node := newWALFixture(t)
+	node.stop(t)
+
+	restarted := startWALNode(t, f.path, f.users)
+	defer restarted.stop(t)
+
+	replayed, err := restarted.svc.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	// create, min delta and two scores
+	if replayed != 4 {
+		t.Errorf("Recover() replayed %d records, want 4", replayed)
+	}
+	f.assertRecovered(t, restarted.svc)
+}
+
+// TestWALRejectedWrites tests that writes the repository refused are logged but
+// skipped on replay instead of failing recovery
+func TestWALRejectedWrites(t *testing.T) {
+	ctx := context.Background()
+	f, node := newWALFixture(t)
+
+	if err := node.svc.AddScore(ctx, "lb_missing", f.alice, 10); !errors.Is(err, models.ErrLeaderboardNotFound) {
+		t.Fatalf("AddScore() on a missing leaderboard error = %v, want ErrLeaderboardNotFound", err)
+	}
+	full, err := node.svc.CreateLeaderboard(ctx, "Capped", models.LeaderboardTypeGlobal, 1)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	if err := node.svc.AddScore(ctx, full.ID, f.alice, 100); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	if err := node.svc.AddScore(ctx, full.ID, f.bob, 50); !errors.Is(err, models.ErrLeaderboardFull) {
+		t.Fatalf("AddScore() on a full leaderboard error = %v, want ErrLeaderboardFull", err)
+	}
+	node.stop(t)
+
+	restarted := startWALNode(t, f.path, f.users)
+	defer restarted.stop(t)
+
+	// The fixture's 4 records, the rejected score, the capped board and its two scores
+	if replayed, err := restarted.svc.Recover(ctx); err != nil || replayed != 8 {
+		t.Fatalf("Recover() = %v, %v, want 8 records replayed", replayed, err)
+	}
+	f.assertRecovered(t, restarted.svc)
+
+	page, err := restarted.svc.GetTopEntries(ctx, full.ID, 0, 10, false)
+	if err != nil {
+		t.Fatalf("GetTopEntries() error = %v", err)
+	}
+	if page.Total != 1 || page.Entries[0].UserID != f.alice {
+		t.Errorf("capped leaderboard = %+v, want only alice", page.Entries)
+	}
+}
+
+// TestWALEntryAge tests that entries keep their update time through replay and
+// snapshots, so a restart does not give stale scores on a decaying board their full value back
+func TestWALEntryAge(t *testing.T) {
+	ctx := context.Background()
+	f, node := newWALFixture(t)
+	if err := node.svc.SetDecay(ctx, f.lb.ID, time.Hour); err != nil {
+		t.Fatalf("SetDecay() error = %v", err)
+	}
+	if err := node.svc.AddScores(ctx, f.lb.ID, map[string]int64{f.bob: 250}); err != nil {
+		t.Fatalf("AddScores() error = %v", err)
+	}
+
+	updatedAt := func(svc *leaderboard.LeaderboardService) map[string]time.Time {
+		t.Helper()
+		page, err := svc.GetTopEntries(ctx, f.lb.ID, 0, 10, false)
+		if err != nil {
+			t.Fatalf("GetTopEntries() error = %v", err)
+		}
+		times := make(map[string]time.Time, len(page.Entries))
+		for _, entry := range page.Entries {
+			times[entry.UserID] = entry.UpdatedAt
+		}
+		return times
+	}
+	want := updatedAt(node.svc)
+
+	// Let the clock move on, so entries re-stamped on restart would be visibly younger
+	time.Sleep(10 * time.Millisecond)
+
+	for _, snapshot := range []bool{false, true} {
+		if snapshot {
+			if err := node.svc.Snapshot(ctx); err != nil {
+				t.Fatalf("Snapshot() error = %v", err)
+			}
+		}
+		node.stop(t)
+
+		node = startWALNode(t, f.path, f.users)
+		if _, err := node.svc.Recover(ctx); err != nil {
+			t.Fatalf("Recover() error = %v", err)
+		}
+		got := updatedAt(node.svc)
+		for userID, at := range want {
+			if !got[userID].Equal(at) {
+				t.Errorf("snapshot %v: UpdatedAt for %s = %v, want %v", snapshot, userID, got[userID], at)
+			}
+		}
+	}
+	node.stop(t)
+}
+
+// TestWALSnapshot tests that a snapshot truncates the log and still restores the state
+func TestWALSnapshot(t *testing.T) {
+	f, node := newWALFixture(t)
+	if err := node.svc.Snapshot(context.Background()); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	node.stop(t)
+
+	if info, err := os.Stat(f.path); err != nil || info.Size() != 0 {
+		t.Fatalf("log after snapshot = %v, %v, want an empty file", info, err)
+	}
+
+	restarted := startWALNode(t, f.path, f.users)
+	defer restarted.stop(t)
+
+	replayed, err := restarted.svc.Recover(context.Background())
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if replayed != 0 {
+		t.Errorf("Recover() replayed %d records, want 0", replayed)
+	}
+	f.assertRecovered(t, restarted.svc)
+}
+
+// TestWALTornWrite tests that a record cut short by a crash is skipped on replay
+func TestWALTornWrite(t *testing.T) {
+	f, node := newWALFixture(t)
+	node.stop(t)
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	file.WriteString(`{"op":"score","leaderboard_id":"` + f.lb.ID + `","user_id":"`)
+	file.Close()
+
+	restarted := startWALNode(t, f.path, f.users)
+
+	if replayed, err := restarted.svc.Recover(context.Background()); err != nil || replayed != 4 {
+		t.Fatalf("Recover() = %v, %v, want 4 records replayed", replayed, err)
+	}
+	f.assertRecovered(t, restarted.svc)
+	restarted.stop(t)
+
+	// The torn record was dropped, so the score written after recovery replays cleanly
+	again := startWALNode(t, f.path, f.users)
+	defer again.stop(t)
+
+	if replayed, err := again.svc.Recover(context.Background()); err != nil || replayed != 5 {
+		t.Fatalf("second Recover() = %v, %v, want 5 records replayed", replayed, err)
+	}
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"effective-golang/internal/auth"
+	"effective-golang/internal/leaderboard"
+	"effective-golang/internal/models"
+	"effective-golang/pkg/utils"
+)
+
+// webhookReceiver records webhook requests, answering the first `failures` of them with 503
+type webhookReceiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	bodies   [][]byte
+	sigs     []string
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.attempts <= r.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+	r.sigs = append(r.sigs, req.Header.Get(leaderboard.WebhookSignatureHeader))
+}
+
+func (r *webhookReceiver) counts() (attempts, delivered int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts, len(r.bodies)
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// webhookFixture is a leaderboard service with one leaderboard and one registered player
+type webhookFixture struct {
+	svc    *leaderboard.LeaderboardService
+	lb     *models.Leaderboard
+	userID string
+}
+
+func newWebhookFixture(t *testing.T, attempts int) *webhookFixture {
+	t.Helper()
+
+	ctx := context.Background()
+	uow := utils.NewInMemoryUnitOfWork()
+	authService := auth.NewAuthService(uow.UserRepository(), uow.CacheRepository())
+	svc := leaderboard.NewLeaderboardService(
+		uow.LeaderboardRepository(),
+		uow.UserRepository(),
+		uow.CacheRepository(),
+		300,
+		leaderboard.WithWebhookRetry(attempts, time.Millisecond),
+	)
+	t.Cleanup(svc.Close)
+
+	lb, err := svc.CreateLeaderboard(ctx, "Hooked", models.LeaderboardTypeGlobal, 10)
+	if err != nil {
+		t.Fatalf("CreateLeaderboard() error = %v", err)
+	}
+	user, err := authService.Register(ctx, &auth.RegisterRequest{Username: "hooked", Email: "hooked@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	return &webhookFixture{svc: svc, lb: lb, userID: user.ID}
+}
+
+// TestWebhookDeliversSignedUpdates tests that updates are POSTed with a valid signature
+func TestWebhookDeliversSignedUpdates(t *testing.T) {
+	f := newWebhookFixture(t, 3)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	cancel, err := f.svc.RegisterWebhook(f.lb.ID, server.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("RegisterWebhook() error = %v", err)
+	}
+	defer cancel()
+
+	if err := f.svc.AddScore(context.Background(), f.lb.ID, f.userID, 42); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	waitFor(t, "delivery", func() bool { _, delivered := receiver.counts(); return delivered == 1 })
+
+	receiver.mu.Lock()
+	body, sig := receiver.bodies[0], receiver.sigs[0]
+	receiver.mu.Unlock()
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := hex.EncodeToString(mac.Sum(nil)); sig != want {
+		t.Errorf("signature = %q, want %q", sig, want)
+	}
+
+	var update leaderboard.LeaderboardUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if update.LeaderboardID != f.lb.ID || update.Type != "score_updated" || update.UserID != f.userID || update.NewRank != 1 {
+		t.Errorf("delivered update = %+v, want score_updated for %s at rank 1", update, f.userID)
+	}
+}
+
+// TestWebhookRetries tests retries on failure and giving up once attempts run out
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		wantAttempts  int
+		wantDelivered int
+	}{
+		{name: "recovers after failures", failures: 2, wantAttempts: 3, wantDelivered: 1},
+		{name: "gives up when endpoint stays down", failures: 100, wantAttempts: 3, wantDelivered: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newWebhookFixture(t, 3)
+			receiver := &webhookReceiver{failures: tt.failures}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			cancel, err := f.svc.RegisterWebhook(f.lb.ID, server.URL, "s3cret")
+			if err != nil {
+				t.Fatalf("RegisterWebhook() error = %v", err)
+			}
+			defer cancel()
+
+			if err := f.svc.AddScore(context.Background(), f.lb.ID, f.userID, 42); err != nil {
+				t.Fatalf("AddScore() error = %v", err)
+			}
+			waitFor(t, "attempts", func() bool { attempts, _ := receiver.counts(); return attempts >= tt.wantAttempts })
+
+			// Leave room for any attempt beyond the limit to show up
+			time.Sleep(50 * time.Millisecond)
+			attempts, delivered := receiver.counts()
+			if attempts != tt.wantAttempts || delivered != tt.wantDelivered {
+				t.Errorf("attempts = %v, delivered = %v, want %v and %v", attempts, delivered, tt.wantAttempts, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+// TestWebhookCancel tests that cancelling a webhook stops delivery
+func TestWebhookCancel(t *testing.T) {
+	f := newWebhookFixture(t, 3)
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	ctx := context.Background()
+
+	cancel, err := f.svc.RegisterWebhook(f.lb.ID, server.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("RegisterWebhook() error = %v", err)
+	}
+
+	if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, 10); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	waitFor(t, "delivery", func() bool { _, delivered := receiver.counts(); return delivered == 1 })
+
+	cancel()
+	cancel() // cancelling twice is harmless
+
+	if err := f.svc.AddScore(ctx, f.lb.ID, f.userID, 20); err != nil {
+		t.Fatalf("AddScore() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if attempts, _ := receiver.counts(); attempts != 1 {
+		t.Errorf("attempts after cancel = %v, want 1", attempts)
+	}
+}
+
+// TestRegisterWebhookValidation tests rejection of bad URLs and unknown leaderboards
+func TestRegisterWebhookValidation(t *testing.T) {
+	f := newWebhookFixture(t, 3)
+
+	tests := []struct {
+		name          string
+		leaderboardID string
+		url           string
+		wantErr       error
+	}{
+		{name: "relative url", leaderboardID: f.lb.ID, url: "/hook", wantErr: leaderboard.ErrInvalidWebhookURL},
+		{name: "unsupported scheme", leaderboardID: f.lb.ID, url: "ftp://example.com/hook", wantErr: leaderboard.ErrInvalidWebhookURL},
+		{name: "unknown leaderboard", leaderboardID: "missing", url: "http://example.com/hook", wantErr: leaderboard.ErrLeaderboardNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancel, err := f.svc.RegisterWebhook(tt.leaderboardID, tt.url, "s3cret")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterWebhook() error = %v, want %v", err, tt.wantErr)
+			}
+			if cancel != nil {
+				t.Errorf("RegisterWebhook() returned a cancel func on error")
+			}
+		})
+	}
+}
@@ -39,6 +39,28 @@ async function joinGame(gameId) {
     return result;
 }
 
+// Step 3b: Find an opponent automatically
+async function findMatch() {
+    console.log('🔍 Looking for an opponent...');
+    
+    const result = await client.rpc(session, 'find_match', {});
+    if (result.payload.created) {
+        console.log('⏳ No open games, waiting for an opponent in:', result.payload.game_id);
+    } else {
+        console.log('🤝 Matched into game:', result.payload.game_id);
+    }
+    return result;
+}
+
+// Step 3c: Leave a game before it starts
+async function leaveGame(gameId) {
+    console.log('🚪 Leaving game:', gameId);
+    
+    const result = await client.rpc(session, 'leave_game', { game_id: gameId });
+    console.log('✅ Left game:', result);
+    return result;
+}
+
 // Step 4: Submit a score
 async function submitScore(score) {
     console.log('🏆 Submitting score:', score);
@@ -114,6 +136,8 @@ module.exports = {
     connect,
     createGame,
     joinGame,
+    findMatch,
+    leaveGame,
     submitScore,
     getLeaderboard,
     joinChat,
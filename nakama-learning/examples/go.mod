@@ -0,0 +1,7 @@
+module nakama-examples
+
+go 1.21
+
+require github.com/heroiclabs/nakama-common v1.32.0
+
+require google.golang.org/protobuf v1.34.1 // indirect
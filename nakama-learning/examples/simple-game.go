@@ -6,7 +6,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
@@ -15,6 +21,7 @@ import (
 // Simple game data structure
 type Game struct {
 	ID      string            `json:"id"`
+	HostID  string            `json:"host_id"`
 	Players map[string]Player `json:"players"`
 	State   string            `json:"state"` // "waiting", "playing", "finished"
 }
@@ -26,21 +33,95 @@ type Player struct {
 	Health int    `json:"health"`
 }
 
-// Store active games in memory
-var games = make(map[string]*Game)
+// Store active games in memory. Nakama runs RPCs concurrently, so games and
+// activeGames must only be touched through saveGame, getGame, addPlayer,
+// matchPlayer, removePlayer and finishGame, which hold gamesMu.
+//
+// Without the lock, two players calling join_game on the same game at the
+// same time would both write game.Players at once. Go maps are not safe for
+// concurrent writes, so that can crash the whole server with
+// "concurrent map writes" or silently lose one of the joins.
+var (
+	games       = make(map[string]*Game)
+	activeGames int
+	gamesMu     sync.RWMutex
+)
+
+// playersPerGame is how many players a game holds. A game starts as soon as it
+// is full, so every "waiting" game has an open slot.
+const playersPerGame = 2
+
+// maxConcurrentGames caps how many games can be waiting or playing at once.
+// Override it with the "max_concurrent_games" runtime env value, e.g.
+//
+//	runtime:
+//	  env:
+//	    - "max_concurrent_games=500"
+var maxConcurrentGames = 100
+
+// scoreWriteRetry bounds the leaderboard writes in end_game: at most 3
+// attempts per score, sleeping up to 100ms before the second and up to 200ms
+// before the third. Override the attempt cap with the "score_write_attempts"
+// runtime env value. See retryPolicy for why the sleeps are random.
+var scoreWriteRetry = retryPolicy{
+	attempts:  3,
+	baseDelay: 100 * time.Millisecond,
+	maxDelay:  2 * time.Second,
+}
+
+// errRetriesExhausted is wrapped by every error from retryPolicy.do that gave
+// up because it ran out of attempts
+var errRetriesExhausted = errors.New("retries exhausted")
+
+// maxPayloadBytes caps the size of an RPC payload. Every RPC decodes its
+// payload with decodePayload, which rejects anything larger before parsing it,
+// so a client cannot make the server buffer and parse huge JSON documents.
+// 4 KiB is far more than any request below needs.
+const maxPayloadBytes = 4 << 10
+
+// Payload errors returned by decodePayload
+var (
+	errEmptyPayload    = errors.New("payload is empty, send a JSON object")
+	errPayloadTooLarge = fmt.Errorf("payload is larger than %d bytes", maxPayloadBytes)
+)
 
 // This function is called when Nakama starts up
 func InitModule(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, initializer runtime.Initializer) error {
 	logger.Info("🎮 Game module loaded!")
 
+	// Read the capacity limit and retry cap from the runtime env, if set
+	if env, ok := ctx.Value(runtime.RUNTIME_CTX_ENV).(map[string]string); ok {
+		if value, ok := env["max_concurrent_games"]; ok {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit <= 0 {
+				return fmt.Errorf("max_concurrent_games must be a positive integer, got %q", value)
+			}
+			maxConcurrentGames = limit
+		}
+		if value, ok := env["score_write_attempts"]; ok {
+			attempts, err := strconv.Atoi(value)
+			if err != nil || attempts <= 0 {
+				return fmt.Errorf("score_write_attempts must be a positive integer, got %q", value)
+			}
+			scoreWriteRetry.attempts = attempts
+		}
+	}
+	logger.Info("🎯 Max concurrent games: %d", maxConcurrentGames)
+	logger.Info("🔁 Score write attempts: %d", scoreWriteRetry.attempts)
+
 	// Register our game functions so players can call them
 	initializer.RegisterRpc("create_game", createGame)
 	initializer.RegisterRpc("join_game", joinGame)
+	initializer.RegisterRpc("find_match", findMatch)
+	initializer.RegisterRpc("leave_game", leaveGame)
 	initializer.RegisterRpc("submit_score", submitScore)
+	initializer.RegisterRpc("end_game", endGame)
 	initializer.RegisterRpc("get_leaderboard", getLeaderboard)
 
-	// Create a leaderboard for scores
-	nk.LeaderboardCreate(ctx, "game_scores", "desc", "best", "weekly", 0, false)
+	// Create a leaderboard for scores, reset every Monday at midnight
+	if err := nk.LeaderboardCreate(ctx, "game_scores", false, "desc", "best", "0 0 * * 1", nil); err != nil {
+		logger.Error("Failed to create leaderboard: %v", err)
+	}
 
 	logger.Info("✅ All game functions registered")
 	return nil
@@ -48,6 +129,14 @@ func InitModule(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runti
 
 // Function 1: Create a new game
 func createGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	// Parse the request; the name is optional
+	request, err := decodePayload[struct {
+		Name string `json:"name"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+
 	// Get the player's info
 	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
 	username := ctx.Value(runtime.RUNTIME_CTX_USERNAME).(string)
@@ -55,7 +144,8 @@ func createGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runti
 	// Create a new game
 	gameID := fmt.Sprintf("game_%s", userID)
 	game := &Game{
-		ID: gameID,
+		ID:     gameID,
+		HostID: userID,
 		Players: map[string]Player{
 			userID: {
 				ID:     userID,
@@ -68,9 +158,11 @@ func createGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runti
 	}
 
 	// Save the game
-	games[gameID] = game
+	if err := saveGame(game); err != nil {
+		return "", err
+	}
 
-	logger.Info("🎮 Game created: %s by %s", gameID, username)
+	logger.Info("🎮 Game created: %s (%q) by %s", gameID, request.Name, username)
 
 	// Send response back to player
 	response := map[string]interface{}{
@@ -85,41 +177,123 @@ func createGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runti
 // Function 2: Join an existing game
 func joinGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
 	// Parse the request
-	var request struct {
+	request, err := decodePayload[struct {
 		GameID string `json:"game_id"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+	if request.GameID == "" {
+		return "", fmt.Errorf("invalid payload: game_id is required")
 	}
-	json.Unmarshal([]byte(payload), &request)
 
 	// Get player info
 	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
 	username := ctx.Value(runtime.RUNTIME_CTX_USERNAME).(string)
 
-	// Find the game
-	game, exists := games[request.GameID]
-	if !exists {
-		return "", fmt.Errorf("game not found")
+	// Add player to game
+	players, state, err := addPlayer(request.GameID, Player{
+		ID:     userID,
+		Name:   username,
+		Score:  0,
+		Health: 100,
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Add player to game
-	game.Players[userID] = Player{
+	logger.Info("👥 %s joined game %s", username, request.GameID)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Joined game!",
+		"players": players,
+		"state":   state,
+	}
+	responseJSON, _ := json.Marshal(response)
+	return string(responseJSON), nil
+}
+
+// Function 2b: Find an opponent automatically
+//
+// find_match joins the caller to a waiting game with an open slot, or creates
+// a new waiting game for the next player to find if there is none. The search
+// and the join happen under one lock, so two players can never both take the
+// last slot of a game. The payload is optional: send {} from the client, e.g.
+//
+//	const match = await client.rpc(session, 'find_match', {});
+//	if (match.payload.created) { /* wait for an opponent */ }
+func findMatch(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	if payload != "" {
+		if _, err := decodePayload[struct{}](payload); err != nil {
+			return "", err
+		}
+	}
+
+	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	username := ctx.Value(runtime.RUNTIME_CTX_USERNAME).(string)
+
+	game, created, err := matchPlayer(Player{
 		ID:     userID,
 		Name:   username,
 		Score:  0,
 		Health: 100,
+	})
+	if err != nil {
+		return "", err
 	}
 
-	// Start game if we have 2+ players
-	if len(game.Players) >= 2 {
-		game.State = "playing"
+	message := "Joined game!"
+	if created {
+		message = "No open games, created one. Waiting for an opponent..."
+		logger.Info("🎮 Game created by matchmaking: %s for %s", game.ID, username)
+	} else {
+		logger.Info("🤝 %s matched into game %s", username, game.ID)
 	}
 
-	logger.Info("👥 %s joined game %s", username, request.GameID)
+	response := map[string]interface{}{
+		"success": true,
+		"message": message,
+		"game_id": game.ID,
+		"created": created,
+		"game":    game,
+	}
+	responseJSON, _ := json.Marshal(response)
+	return string(responseJSON), nil
+}
+
+// Function 2c: Leave a game before it starts
+//
+// Only the players of a waiting game can leave it. If the host leaves, the
+// next player (by ID) becomes host; if nobody is left, the game is finished
+// and its capacity slot freed. Games that already started end through
+// end_game instead.
+func leaveGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request, err := decodePayload[struct {
+		GameID string `json:"game_id"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+	if request.GameID == "" {
+		return "", fmt.Errorf("invalid payload: game_id is required")
+	}
+
+	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+	username := ctx.Value(runtime.RUNTIME_CTX_USERNAME).(string)
+
+	game, err := removePlayer(request.GameID, userID)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("🚪 %s left game %s", username, request.GameID)
 
 	response := map[string]interface{}{
 		"success": true,
-		"message": "Joined game!",
-		"players": len(game.Players),
-		"state":   game.State,
+		"message": "Left game!",
+		"game_id": request.GameID,
+		"game":    game,
 	}
 	responseJSON, _ := json.Marshal(response)
 	return string(responseJSON), nil
@@ -127,34 +301,372 @@ func joinGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime
 
 // Function 3: Submit a score
 func submitScore(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
-	// Parse the score
-	var request struct {
-		Score int `json:"score"`
+	// Parse the score; "score" must be present, so a missing field is not
+	// silently recorded as 0
+	request, err := decodePayload[struct {
+		Score *int `json:"score"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+	if request.Score == nil || *request.Score < 0 {
+		return "", fmt.Errorf("invalid payload: score must be a non-negative integer")
 	}
-	json.Unmarshal([]byte(payload), &request)
+	score := *request.Score
 
 	// Get player info
 	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
 	username := ctx.Value(runtime.RUNTIME_CTX_USERNAME).(string)
 
 	// Save score to leaderboard
-	nk.LeaderboardRecordWrite(ctx, "game_scores", userID, username, int64(request.Score), nil, nil)
+	if err := writeScore(ctx, logger, nk, userID, username, int64(score)); err != nil {
+		return "", err
+	}
 
-	logger.Info("🏆 %s scored %d points", username, request.Score)
+	logger.Info("🏆 %s scored %d points", username, score)
 
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Score saved!",
-		"score":   request.Score,
+		"score":   score,
+	}
+	responseJSON, _ := json.Marshal(response)
+	return string(responseJSON), nil
+}
+
+// Function 4: End a game and record every player's score
+//
+// The game is only marked "finished" once every player's score has been
+// written to the leaderboard. If any write still fails after retrying, the
+// game stays "playing" and an error is returned so the client can call
+// end_game again. Retrying is safe: the leaderboard uses the "best" operator,
+// so re-writing a score that was already recorded does not change it.
+func endGame(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
+	request, err := decodePayload[struct {
+		GameID string `json:"game_id"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+	if request.GameID == "" {
+		return "", fmt.Errorf("invalid payload: game_id is required")
+	}
+
+	userID := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string)
+
+	// Snapshot the players; score writes can take a while with retries,
+	// so they run without holding the games lock
+	game, exists := getGame(request.GameID)
+	if !exists {
+		return "", fmt.Errorf("game not found")
+	}
+	if _, inGame := game.Players[userID]; !inGame {
+		return "", fmt.Errorf("only players in the game can end it")
+	}
+	if game.State != "playing" {
+		return "", fmt.Errorf("game is %s, not playing", game.State)
+	}
+
+	// Record every score before touching the game state
+	var failed []string
+	for _, player := range game.Players {
+		if err := writeScore(ctx, logger, nk, player.ID, player.Name, int64(player.Score)); err != nil {
+			failed = append(failed, player.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return "", fmt.Errorf("failed to record scores for %v, game not finished, please retry", failed)
+	}
+
+	finishGame(request.GameID)
+
+	logger.Info("🏁 Game %s finished", request.GameID)
+
+	response := map[string]interface{}{
+		"success": true,
+		"message": "Game finished!",
+		"game_id": request.GameID,
+		"state":   "finished",
 	}
 	responseJSON, _ := json.Marshal(response)
 	return string(responseJSON), nil
 }
 
-// Function 4: Get leaderboard
+// decodePayload parses an RPC payload into a T. It rejects empty payloads,
+// payloads over maxPayloadBytes and anything that is not a single JSON value
+// matching T, so RPCs never act on a half-parsed or zero-value request.
+func decodePayload[T any](payload string) (T, error) {
+	var request T
+
+	if len(payload) > maxPayloadBytes {
+		return request, errPayloadTooLarge
+	}
+	if strings.TrimSpace(payload) == "" {
+		return request, errEmptyPayload
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	if err := decoder.Decode(&request); err != nil {
+		return request, fmt.Errorf("invalid payload: %w", err)
+	}
+	if decoder.More() {
+		return request, fmt.Errorf("invalid payload: unexpected data after the JSON object")
+	}
+	return request, nil
+}
+
+// saveGame stores a new game, unless the server is full. Re-creating a game
+// the player already has running replaces it without using another slot.
+func saveGame(game *Game) error {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+	return storeGame(game)
+}
+
+// storeGame is saveGame for callers that already hold gamesMu
+func storeGame(game *Game) error {
+	if existing, ok := games[game.ID]; !ok || existing.State == "finished" {
+		if activeGames >= maxConcurrentGames {
+			return fmt.Errorf("server at capacity: %d games already running, try again later", maxConcurrentGames)
+		}
+		activeGames++
+	}
+	games[game.ID] = game
+	return nil
+}
+
+// getGame returns a copy of a game, so callers can read it without the lock
+func getGame(gameID string) (Game, bool) {
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+
+	game, exists := games[gameID]
+	if !exists {
+		return Game{}, false
+	}
+	return copyGame(game), true
+}
+
+// copyGame returns a copy of game that shares nothing with it. The caller must
+// hold gamesMu.
+func copyGame(game *Game) Game {
+	snapshot := *game
+	snapshot.Players = make(map[string]Player, len(game.Players))
+	for id, player := range game.Players {
+		snapshot.Players[id] = player
+	}
+	return snapshot
+}
+
+// addPlayer adds a player to a waiting game and starts it once two players are
+// in. The lookup, insert and state change happen under one lock, so
+// simultaneous joins to the same game are applied one after the other and none
+// are lost. Only waiting games can be joined: a game stops waiting when it
+// fills up, so the join that would overfill it is turned away.
+func addPlayer(gameID string, player Player) (int, string, error) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	game, exists := games[gameID]
+	if !exists {
+		return 0, "", fmt.Errorf("game not found")
+	}
+	if game.State != "waiting" {
+		return 0, "", fmt.Errorf("game is %s, only waiting games can be joined", game.State)
+	}
+
+	game.Players[player.ID] = player
+
+	// Start game once it is full
+	if len(game.Players) >= playersPerGame {
+		game.State = "playing"
+	}
+
+	return len(game.Players), game.State, nil
+}
+
+// matchPlayer adds a player to a waiting game with an open slot, starting it
+// if that fills it, or creates a new waiting game for them when there is none.
+// It returns a copy of the game and whether it was created.
+//
+// Finding the game and joining it happen under one lock, and the slot is
+// checked again right before the player is added, so two players matching at
+// once can never both take the last slot: the second one sees the game full
+// and moves on.
+//
+// A player already in a waiting or playing game is turned away. Otherwise a
+// host could match again and have the new game_<id> replace the game they are
+// playing, or one player could sit in two games at once.
+func matchPlayer(player Player) (Game, bool, error) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	for id, game := range games {
+		if _, inGame := game.Players[player.ID]; inGame && game.State != "finished" {
+			return Game{}, false, fmt.Errorf("you are already in game %s, leave or finish it first", id)
+		}
+	}
+
+	for _, game := range games {
+		if game.State != "waiting" || len(game.Players) >= playersPerGame {
+			continue
+		}
+
+		game.Players[player.ID] = player
+		if len(game.Players) >= playersPerGame {
+			game.State = "playing"
+		}
+		return copyGame(game), false, nil
+	}
+
+	game := &Game{
+		ID:      fmt.Sprintf("game_%s", player.ID),
+		HostID:  player.ID,
+		Players: map[string]Player{player.ID: player},
+		State:   "waiting",
+	}
+	if err := storeGame(game); err != nil {
+		return Game{}, false, err
+	}
+	return copyGame(game), true, nil
+}
+
+// removePlayer takes a player out of a waiting game and returns a copy of the
+// game afterwards. A new host is picked if the host left, and the game is
+// finished if it is now empty. Like addPlayer, it holds gamesMu throughout, so
+// a leave cannot be lost to a join or match happening at the same moment.
+func removePlayer(gameID, playerID string) (Game, error) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	game, exists := games[gameID]
+	if !exists {
+		return Game{}, fmt.Errorf("game not found")
+	}
+	if _, inGame := game.Players[playerID]; !inGame {
+		return Game{}, fmt.Errorf("you are not in game %s", gameID)
+	}
+	if game.State != "waiting" {
+		return Game{}, fmt.Errorf("game is %s, only waiting games can be left", game.State)
+	}
+
+	delete(game.Players, playerID)
+
+	if len(game.Players) == 0 {
+		game.HostID = ""
+		game.State = "finished"
+		activeGames--
+		return copyGame(game), nil
+	}
+	if game.HostID == playerID {
+		// Pick the lowest ID so the new host does not depend on map order
+		next := ""
+		for id := range game.Players {
+			if next == "" || id < next {
+				next = id
+			}
+		}
+		game.HostID = next
+	}
+	return copyGame(game), nil
+}
+
+// finishGame marks a game finished and frees its capacity slot. It is a no-op
+// if another end_game call already finished the game.
+func finishGame(gameID string) {
+	gamesMu.Lock()
+	defer gamesMu.Unlock()
+
+	if game, exists := games[gameID]; exists && game.State == "playing" {
+		game.State = "finished"
+		activeGames--
+	}
+}
+
+// writeScore writes a score to the leaderboard, retrying under scoreWriteRetry
+func writeScore(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, username string, score int64) error {
+	err := scoreWriteRetry.do(ctx, func(attempt int) error {
+		_, err := nk.LeaderboardRecordWrite(ctx, "game_scores", userID, username, score, 0, nil, nil)
+		if err != nil {
+			logger.Warn("⚠️ Score write for %s failed (attempt %d/%d): %v", username, attempt, scoreWriteRetry.attempts, err)
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save score for %s: %w", username, err)
+	}
+	return nil
+}
+
+// retryPolicy retries a failing write a bounded number of times. Between
+// attempts it sleeps for a random time between zero and a cap that starts at
+// baseDelay and doubles after every failure, up to maxDelay ("full jitter").
+//
+// The randomness matters when many callers fail at once, e.g. every game in
+// a tournament round calling end_game together while the database is busy.
+// With a fixed backoff they would all retry at the same instant and fail
+// together again; with jitter their retries spread out over the window.
+//
+// The same policy suits storage writes guarded by a version (optimistic
+// concurrency): when nk.StorageWrite fails with
+// runtime.ErrStorageRejectedVersion, re-read the object inside fn and return
+// the conflict so the next attempt writes against the new version.
+type retryPolicy struct {
+	attempts  int           // total tries, including the first
+	baseDelay time.Duration // cap on the first sleep
+	maxDelay  time.Duration // the sleep cap never grows past this
+}
+
+// do calls fn until it succeeds, ctx is done or the attempts run out. The
+// error after the last attempt wraps both errRetriesExhausted and fn's error.
+func (p retryPolicy) do(ctx context.Context, fn func(attempt int) error) error {
+	limit := p.baseDelay
+
+	var err error
+	for attempt := 1; attempt <= p.attempts; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if attempt == p.attempts {
+			break
+		}
+
+		var sleep time.Duration
+		if limit > 0 {
+			sleep = time.Duration(rand.Int63n(int64(limit) + 1))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+		limit = min(limit*2, p.maxDelay)
+	}
+
+	return fmt.Errorf("%w after %d attempts: %w", errRetriesExhausted, p.attempts, err)
+}
+
+// Function 5: Get leaderboard
 func getLeaderboard(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, payload string) (string, error) {
-	// Get top 10 scores
-	records, _, _ := nk.LeaderboardRecordsList(ctx, "game_scores", []string{}, 10, 10)
+	// Parse the request; limit defaults to 10 and is capped at 100
+	request, err := decodePayload[struct {
+		Limit int `json:"limit"`
+	}](payload)
+	if err != nil {
+		return "", err
+	}
+	if request.Limit < 0 || request.Limit > 100 {
+		return "", fmt.Errorf("invalid payload: limit must be between 1 and 100")
+	}
+	if request.Limit == 0 {
+		request.Limit = 10
+	}
+
+	// Get the top scores
+	records, _, _, _, err := nk.LeaderboardRecordsList(ctx, "game_scores", []string{}, request.Limit, "", 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to list leaderboard: %w", err)
+	}
 
 	// Format the response
 	var leaderboard []map[string]interface{}
@@ -0,0 +1,272 @@
+//go:build nakama
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDecodePayload tests that malformed, empty and oversized payloads are rejected
+func TestDecodePayload(t *testing.T) {
+	type joinRequest struct {
+		GameID string `json:"game_id"`
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+		wantErr error
+	}{
+		{name: "valid", payload: `{"game_id":"game_1"}`, want: "game_1"},
+		{name: "empty", payload: "  ", wantErr: errEmptyPayload},
+		{name: "oversized", payload: `{"game_id":"` + strings.Repeat("x", maxPayloadBytes) + `"}`, wantErr: errPayloadTooLarge},
+		{name: "malformed", payload: `{"game_id":`},
+		{name: "wrong type", payload: `{"game_id":42}`},
+		{name: "trailing data", payload: `{"game_id":"game_1"} {}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := decodePayload[joinRequest](tt.payload)
+			if tt.want != "" {
+				if err != nil || request.GameID != tt.want {
+					t.Fatalf("decodePayload() = %+v, %v, want game_id %q", request, err, tt.want)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("decodePayload() error = nil, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodePayload() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRetryPolicy tests that retries stop on success and give up after the attempt cap
+func TestRetryPolicy(t *testing.T) {
+	policy := retryPolicy{attempts: 3, baseDelay: time.Millisecond, maxDelay: 2 * time.Millisecond}
+	errBusy := errors.New("database busy")
+
+	calls := 0
+	err := policy.do(context.Background(), func(attempt int) error {
+		calls++
+		if attempt < 2 {
+			return errBusy
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("do() = %v after %d calls, want success on the second", err, calls)
+	}
+
+	calls = 0
+	err = policy.do(context.Background(), func(int) error {
+		calls++
+		return errBusy
+	})
+	if calls != 3 {
+		t.Errorf("do() made %d calls, want 3", calls)
+	}
+	if !errors.Is(err, errRetriesExhausted) || !errors.Is(err, errBusy) {
+		t.Errorf("do() error = %v, want it to wrap errRetriesExhausted and the last failure", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := retryPolicy{attempts: 3, baseDelay: time.Hour, maxDelay: time.Hour}
+	if err := slow.do(ctx, func(int) error { return errBusy }); !errors.Is(err, context.Canceled) {
+		t.Errorf("do() with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+// resetGames clears the in-memory games for a test
+func resetGames(t *testing.T) {
+	t.Helper()
+	gamesMu.Lock()
+	games = make(map[string]*Game)
+	activeGames = 0
+	gamesMu.Unlock()
+}
+
+// TestMatchPlayer tests that matchmaking pairs players up and never overfills a game
+func TestMatchPlayer(t *testing.T) {
+	resetGames(t)
+
+	first, created, err := matchPlayer(Player{ID: "alice", Name: "alice"})
+	if err != nil || !created || first.State != "waiting" {
+		t.Fatalf("matchPlayer() = %+v, created %v, %v, want a new waiting game", first, created, err)
+	}
+	second, created, err := matchPlayer(Player{ID: "bob", Name: "bob"})
+	if err != nil || created || second.ID != first.ID || second.State != "playing" || len(second.Players) != 2 {
+		t.Fatalf("matchPlayer() = %+v, created %v, %v, want to join %s and start it", second, created, err, first.ID)
+	}
+
+	// Many players matching at once must end up in full games, two per game
+	resetGames(t)
+	const players = 20
+	var wg sync.WaitGroup
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("player_%d", i)
+			if _, _, err := matchPlayer(Player{ID: id, Name: id}); err != nil {
+				t.Errorf("matchPlayer(%s) error = %v", id, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+	if len(games) != players/playersPerGame || activeGames != len(games) {
+		t.Errorf("got %d games (%d active), want %d", len(games), activeGames, players/playersPerGame)
+	}
+	for id, game := range games {
+		if len(game.Players) != playersPerGame || game.State != "playing" {
+			t.Errorf("game %s has %d players and is %s, want %d and playing", id, len(game.Players), game.State, playersPerGame)
+		}
+	}
+}
+
+// TestAddPlayer tests that only waiting games can be joined
+func TestAddPlayer(t *testing.T) {
+	resetGames(t)
+	gamesMu.Lock()
+	games["game_alice"] = &Game{ID: "game_alice", HostID: "alice", Players: map[string]Player{"alice": {ID: "alice"}}, State: "waiting"}
+	games["game_carol"] = &Game{ID: "game_carol", HostID: "carol", Players: map[string]Player{"carol": {ID: "carol"}}, State: "finished"}
+	activeGames = 1
+	gamesMu.Unlock()
+
+	if _, _, err := addPlayer("game_missing", Player{ID: "bob"}); err == nil {
+		t.Error("addPlayer() to a missing game succeeded, want an error")
+	}
+	if _, _, err := addPlayer("game_carol", Player{ID: "bob"}); err == nil {
+		t.Error("addPlayer() to a finished game succeeded, want an error")
+	}
+	players, state, err := addPlayer("game_alice", Player{ID: "bob"})
+	if err != nil || players != 2 || state != "playing" {
+		t.Fatalf("addPlayer() = %d, %s, %v, want 2 players and playing", players, state, err)
+	}
+	if _, _, err := addPlayer("game_alice", Player{ID: "dave"}); err == nil {
+		t.Error("addPlayer() to a playing game succeeded, want an error")
+	}
+}
+
+// TestConcurrentJoins tests that joins and matches running at the same time
+// neither lose a player nor overfill a game. Run it with -race.
+func TestConcurrentJoins(t *testing.T) {
+	resetGames(t)
+	const hosts, joiners = 10, 40
+
+	gamesMu.Lock()
+	for i := 0; i < hosts; i++ {
+		id := fmt.Sprintf("host_%d", i)
+		games["game_"+id] = &Game{ID: "game_" + id, HostID: id, Players: map[string]Player{id: {ID: id}}, State: "waiting"}
+	}
+	activeGames = hosts
+	gamesMu.Unlock()
+
+	// Half the players join a host's game by ID, the other half use matchmaking,
+	// so both paths race for the same open slots
+	joined := make([]bool, joiners)
+	var wg sync.WaitGroup
+	for i := 0; i < joiners; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			player := Player{ID: fmt.Sprintf("player_%d", i)}
+			if i%2 == 0 {
+				_, _, err := addPlayer(fmt.Sprintf("game_host_%d", i%hosts), player)
+				joined[i] = err == nil
+				return
+			}
+			if _, _, err := matchPlayer(player); err != nil {
+				t.Errorf("matchPlayer(%s) error = %v", player.ID, err)
+				return
+			}
+			joined[i] = true
+		}(i)
+	}
+	wg.Wait()
+
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+	seen := make(map[string]string)
+	for id, game := range games {
+		if len(game.Players) > playersPerGame {
+			t.Errorf("game %s has %d players, want at most %d", id, len(game.Players), playersPerGame)
+		}
+		for playerID := range game.Players {
+			if other, ok := seen[playerID]; ok {
+				t.Errorf("player %s is in both %s and %s", playerID, other, id)
+			}
+			seen[playerID] = id
+		}
+	}
+	for i, ok := range joined {
+		id := fmt.Sprintf("player_%d", i)
+		if _, inGame := seen[id]; inGame != ok {
+			t.Errorf("player %s in a game = %v, but the join reported success = %v", id, inGame, ok)
+		}
+	}
+	if activeGames != len(games) {
+		t.Errorf("activeGames = %d, want %d", activeGames, len(games))
+	}
+}
+
+// TestRemovePlayer tests leaving a waiting game
+func TestRemovePlayer(t *testing.T) {
+	resetGames(t)
+	gamesMu.Lock()
+	games["game_alice"] = &Game{
+		ID:     "game_alice",
+		HostID: "alice",
+		Players: map[string]Player{
+			"alice": {ID: "alice"},
+			"bob":   {ID: "bob"},
+			"carol": {ID: "carol"},
+		},
+		State: "waiting",
+	}
+	games["game_dave"] = &Game{ID: "game_dave", HostID: "dave", Players: map[string]Player{"dave": {ID: "dave"}, "erin": {ID: "erin"}}, State: "playing"}
+	activeGames = 2
+	gamesMu.Unlock()
+
+	if _, err := removePlayer("game_alice", "mallory"); err == nil {
+		t.Error("removePlayer() for a player not in the game succeeded, want an error")
+	}
+	if _, err := removePlayer("game_missing", "alice"); err == nil {
+		t.Error("removePlayer() for a missing game succeeded, want an error")
+	}
+	if _, err := removePlayer("game_dave", "dave"); err == nil {
+		t.Error("removePlayer() for a started game succeeded, want an error")
+	}
+
+	game, err := removePlayer("game_alice", "alice")
+	if err != nil || game.HostID != "bob" || len(game.Players) != 2 || game.State != "waiting" {
+		t.Fatalf("removePlayer(host) = %+v, %v, want bob as the new host of a waiting game", game, err)
+	}
+	if _, err := removePlayer("game_alice", "carol"); err != nil {
+		t.Fatalf("removePlayer(carol) error = %v", err)
+	}
+	game, err = removePlayer("game_alice", "bob")
+	if err != nil || game.State != "finished" || len(game.Players) != 0 {
+		t.Fatalf("removePlayer(last) = %+v, %v, want an empty finished game", game, err)
+	}
+
+	gamesMu.RLock()
+	defer gamesMu.RUnlock()
+	if activeGames != 1 {
+		t.Errorf("activeGames = %d after the game emptied, want 1", activeGames)
+	}
+}
@@ -0,0 +1,87 @@
+// Package severity decides how severity levels are rendered: the color,
+// emoji and display label every formatter uses for a level. The defaults can
+// be overridden per level with Register; overrides apply to the process that
+// registers them.
+//
+// It lives in the shared module, which slack-notifier and grafana-dashboard
+// both require through a replace directive, so they render levels the same way.
+package severity
+
+import (
+	"strings"
+	"sync"
+)
+
+// Style is how one severity level is rendered
+type Style struct {
+	Color string // hex color, e.g. "#E01E5A"
+	Emoji string
+	Label string
+}
+
+var defaults = map[string]Style{
+	"info":     {Color: "#439FE0", Emoji: "ℹ️", Label: "Info"},
+	"success":  {Color: "#2EB67D", Emoji: "✅", Label: "Success"},
+	"warning":  {Color: "#ECB22E", Emoji: "⚠️", Label: "Warning"},
+	"error":    {Color: "#E01E5A", Emoji: "❌", Label: "Error"},
+	"critical": {Color: "#A30200", Emoji: "🚨", Label: "Critical"},
+}
+
+var (
+	mu        sync.RWMutex
+	overrides = map[string]Style{}
+)
+
+// Register overrides the style for level. Empty fields keep the default, so
+// a caller can change just the emoji or just the color.
+func Register(level string, style Style) {
+	level = normalize(level)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	base := defaults[level]
+	if style.Color == "" {
+		style.Color = base.Color
+	}
+	if style.Emoji == "" {
+		style.Emoji = base.Emoji
+	}
+	if style.Label == "" {
+		style.Label = base.Label
+	}
+	overrides[level] = style
+}
+
+// Reset drops every override registered with Register
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	overrides = map[string]Style{}
+}
+
+// Lookup returns the style for level. Unknown levels render like info,
+// labelled with the level itself.
+func Lookup(level string) Style {
+	level = normalize(level)
+
+	mu.RLock()
+	style, ok := overrides[level]
+	mu.RUnlock()
+	if ok {
+		return style
+	}
+
+	if style, ok := defaults[level]; ok {
+		return style
+	}
+	style = defaults["info"]
+	if level != "" {
+		style.Label = level
+	}
+	return style
+}
+
+func normalize(level string) string {
+	return strings.ToLower(strings.TrimSpace(level))
+}
@@ -0,0 +1,43 @@
+package severity
+
+import "testing"
+
+func TestLookupDefaults(t *testing.T) {
+	tests := []struct {
+		level string
+		want  Style
+	}{
+		{"info", Style{Color: "#439FE0", Emoji: "ℹ️", Label: "Info"}},
+		{"success", Style{Color: "#2EB67D", Emoji: "✅", Label: "Success"}},
+		{"warning", Style{Color: "#ECB22E", Emoji: "⚠️", Label: "Warning"}},
+		{"error", Style{Color: "#E01E5A", Emoji: "❌", Label: "Error"}},
+		{"critical", Style{Color: "#A30200", Emoji: "🚨", Label: "Critical"}},
+		{" Critical ", Style{Color: "#A30200", Emoji: "🚨", Label: "Critical"}},
+		{"debug", Style{Color: "#439FE0", Emoji: "ℹ️", Label: "debug"}},
+	}
+
+	for _, tt := range tests {
+		if got := Lookup(tt.level); got != tt.want {
+			t.Errorf("Expected %q to render as %+v, got %+v", tt.level, tt.want, got)
+		}
+	}
+}
+
+func TestRegisterOverride(t *testing.T) {
+	t.Cleanup(Reset)
+
+	Register("Warning", Style{Emoji: ":fire:"})
+
+	want := Style{Color: "#ECB22E", Emoji: ":fire:", Label: "Warning"}
+	if got := Lookup("warning"); got != want {
+		t.Errorf("Expected override %+v, got %+v", want, got)
+	}
+	if got := Lookup("error"); got.Emoji != "❌" {
+		t.Errorf("Expected other levels to keep their defaults, got %+v", got)
+	}
+
+	Reset()
+	if got := Lookup("warning"); got.Emoji != "⚠️" {
+		t.Errorf("Expected Reset to restore the default, got %+v", got)
+	}
+}
@@ -0,0 +1,57 @@
+package tlsconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shared/tlsconfig"
+)
+
+// TestCertReloaderPicksUpRotation tests that a rotated certificate is served without a restart
+func TestCertReloaderPicksUpRotation(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := tlsconfig.WriteSelfSignedCert(certFile, keyFile, "tlsconfig test", "localhost"); err != nil {
+		t.Fatalf("WriteSelfSignedCert() error = %v", err)
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+	first, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+
+	// Rotate, making sure the modification time moves even on coarse filesystems
+	if err := tlsconfig.WriteSelfSignedCert(certFile, keyFile, "tlsconfig test", "localhost"); err != nil {
+		t.Fatalf("WriteSelfSignedCert() error = %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, later, later); err != nil {
+			t.Fatalf("Chtimes() error = %v", err)
+		}
+	}
+
+	second, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+	if string(first.Certificate[0]) == string(second.Certificate[0]) {
+		t.Error("GetCertificate() after rotation returned the old certificate")
+	}
+
+	// A broken rotation keeps serving the last good certificate
+	if err := os.WriteFile(certFile, []byte("not a cert"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	third, err := reloader.GetCertificate(nil)
+	if err != nil || third == nil || string(third.Certificate[0]) != string(second.Certificate[0]) {
+		t.Errorf("GetCertificate() after broken rotation = %v, %v, want previous certificate", third, err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"slack-notifier/internal/config"
+	"slack-notifier/internal/events"
+	"slack-notifier/internal/middleware"
+)
+
+// sender is the part of the notifier service the API uses
+type sender interface {
+	SendMessage(msg string) error
+	SendEvent(e *events.Event)
+}
+
+// newAPIServer builds the HTTP API server with the configured timeouts
+func newAPIServer(cfg *config.Config, svc sender) *http.Server {
+	return &http.Server{
+		Addr:              cfg.APIAddress,
+		Handler:           newAPIHandler(cfg, svc),
+		ReadHeaderTimeout: cfg.APIReadTimeout,
+		ReadTimeout:       cfg.APIReadTimeout,
+		WriteTimeout:      cfg.APIWriteTimeout,
+		IdleTimeout:       cfg.APIIdleTimeout,
+	}
+}
+
+// newAPIHandler routes the HTTP API
+func newAPIHandler(cfg *config.Config, svc sender) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+
+	// Ingest endpoints accept gzip-compressed bodies, capped both on the wire
+	// and once decompressed, and share a cap on concurrent requests
+	limit := middleware.Limit(cfg.APIMaxConcurrent)
+	maxBytes := middleware.MaxBytes(cfg.APIMaxBodyBytes)
+	decompress := middleware.Decompress(cfg.APIMaxBodyBytes)
+	ingest := func(h http.HandlerFunc) http.Handler {
+		return limit(maxBytes(decompress(h)))
+	}
+
+	mux.Handle("/send-message", ingest(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if body.Message == "" {
+			body.Message = "Hello from API"
+		}
+
+		if err := svc.SendMessage(body.Message); err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("sent"))
+	}))
+
+	mux.Handle("/send-event", ingest(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var body struct {
+			Type     string                 `json:"type"`
+			Title    string                 `json:"title"`
+			Message  string                 `json:"message"`
+			Severity *string                `json:"severity"`
+			Channel  string                 `json:"channel"`
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		builder := events.NewEvent(events.EventType(body.Type)).
+			WithTitle(body.Title).
+			WithMessage(body.Message).
+			WithChannel(body.Channel)
+		// Without a severity, the event keeps its type's registered default. A
+		// severity that is sent but empty is rejected like any unknown one.
+		if body.Severity != nil {
+			sev, err := events.ParseSeverity(*body.Severity)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			builder.WithSeverity(sev)
+		}
+		evt, err := builder.BuildValidated()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		for k, v := range body.Metadata {
+			evt.Metadata[k] = v
+		}
+		svc.SendEvent(evt)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+
+	return mux
+}
+
+// decodeBody decodes a JSON request body into v. An empty body leaves v
+// untouched. On failure it writes 413 for an oversized body or 400 for a
+// malformed one and returns false.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+	http.Error(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+	return false
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"slack-notifier/internal/config"
+	"slack-notifier/internal/events"
+)
+
+// stubSender records what the API hands to the notifier
+type stubSender struct {
+	messages []string
+	events   []*events.Event
+}
+
+func (s *stubSender) SendMessage(msg string) error {
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+func (s *stubSender) SendEvent(e *events.Event) {
+	s.events = append(s.events, e)
+}
+
+func testConfig() *config.Config {
+	return &config.Config{APIMaxBodyBytes: 64, APIMaxConcurrent: 4}
+}
+
+func TestAPIRejectsMalformedBody(t *testing.T) {
+	svc := &stubSender{}
+	handler := newAPIHandler(testConfig(), svc)
+
+	for _, path := range []string{"/send-message", "/send-event"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"title":`)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for malformed %s body, got %d", path, rec.Code)
+		}
+	}
+	if len(svc.messages) != 0 || len(svc.events) != 0 {
+		t.Errorf("Expected nothing sent, got %d messages and %d events", len(svc.messages), len(svc.events))
+	}
+}
+
+func TestAPIRejectsOversizedBody(t *testing.T) {
+	svc := &stubSender{}
+	handler := newAPIHandler(testConfig(), svc)
+
+	body := `{"title":"` + strings.Repeat("x", 100) + `"}`
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader(body)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for oversized body, got %d", rec.Code)
+	}
+	if len(svc.events) != 0 {
+		t.Errorf("Expected no events queued, got %d", len(svc.events))
+	}
+}
+
+func TestAPIAcceptsValidBodies(t *testing.T) {
+	svc := &stubSender{}
+	handler := newAPIHandler(testConfig(), svc)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader(`{"type":"user_login","title":"Login"}`)))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", rec.Code)
+	}
+	if len(svc.events) != 1 || svc.events[0].Title != "Login" {
+		t.Errorf("Expected one queued event titled Login, got %+v", svc.events)
+	}
+	if len(svc.events) == 1 && svc.events[0].Severity != events.SeverityInfo {
+		t.Errorf("Expected an omitted severity to use the type's default, got %s", svc.events[0].Severity)
+	}
+
+	// An empty body still falls back to the default message
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-message", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+	if len(svc.messages) != 1 || svc.messages[0] != "Hello from API" {
+		t.Errorf("Expected the default message, got %v", svc.messages)
+	}
+}
+
+func TestAPIRejectsInvalidEvents(t *testing.T) {
+	svc := &stubSender{}
+	handler := newAPIHandler(testConfig(), svc)
+
+	for name, body := range map[string]string{
+		"unknown type":   `{"type":"user_teleported","title":"Teleport"}`,
+		"missing type":   `{"title":"Login"}`,
+		"missing title":  `{"type":"user_login"}`,
+		"empty severity": `{"type":"user_login","title":"a","severity":""}`,
+		"blank severity": `{"type":"user_login","title":"a","severity":" "}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", name, rec.Code)
+		}
+	}
+	if len(svc.events) != 0 {
+		t.Errorf("Expected no events queued, got %d", len(svc.events))
+	}
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
 	"os"
@@ -38,72 +37,7 @@ func main() {
 	demoEvents(svc)
 
 	// HTTP API for testing
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("ok"))
-	})
-
-	mux.HandleFunc("/send-message", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		var body struct {
-			Message string `json:"message"`
-		}
-		_ = json.NewDecoder(r.Body).Decode(&body)
-		if body.Message == "" {
-			body.Message = "Hello from API"
-		}
-
-		if err := svc.SendMessage(body.Message); err != nil {
-			w.WriteHeader(http.StatusBadGateway)
-			w.Write([]byte(err.Error()))
-			return
-		}
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("sent"))
-	})
-
-	mux.HandleFunc("/send-event", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		var body struct {
-			Type     string                 `json:"type"`
-			Title    string                 `json:"title"`
-			Message  string                 `json:"message"`
-			Severity string                 `json:"severity"`
-			Channel  string                 `json:"channel"`
-			Metadata map[string]interface{} `json:"metadata"`
-		}
-		_ = json.NewDecoder(r.Body).Decode(&body)
-		sev := events.SeverityInfo
-		switch body.Severity {
-		case "warning":
-			sev = events.SeverityWarning
-		case "error":
-			sev = events.SeverityError
-		case "critical":
-			sev = events.SeverityCritical
-		}
-		evt := events.NewEvent(events.EventType(body.Type)).
-			WithTitle(body.Title).
-			WithMessage(body.Message).
-			WithSeverity(sev).
-			WithChannel(body.Channel).
-			Build()
-		for k, v := range body.Metadata {
-			evt.Metadata[k] = v
-		}
-		svc.SendEvent(evt)
-		w.WriteHeader(http.StatusAccepted)
-		w.Write([]byte("queued"))
-	})
-
-	server := &http.Server{Addr: cfg.APIAddress, Handler: mux}
+	server := newAPIServer(cfg, svc)
 	go func() {
 		log.Printf("api listening on %s", cfg.APIAddress)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
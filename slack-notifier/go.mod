@@ -5,9 +5,12 @@ go 1.21
 require (
 	github.com/joho/godotenv v1.4.0
 	github.com/slack-go/slack v0.12.3
+	shared v0.0.0
 )
 
 require (
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/stretchr/testify v1.8.4 // indirect
 )
+
+replace shared => ../shared
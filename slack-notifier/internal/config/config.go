@@ -3,9 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"slack-notifier/internal/events"
 )
 
 // Config holds all configuration for the application
@@ -19,6 +22,43 @@ type Config struct {
 	SlackChannel      string
 	Environment       string
 	APIAddress        string
+
+	// Channel per severity (SLACK_CHANNEL_<SEVERITY>) for events without an explicit channel
+	SeverityChannelMap map[events.Severity]string
+
+	// Metadata rendering policy for Slack messages (comma-separated keys)
+	SlackMetadataInclude []string
+	SlackMetadataExclude []string
+	SlackMetadataHash    []string
+
+	// Metadata keys shown as primary Slack fields, per event type, in display order
+	SlackFieldLayouts map[string][]string
+
+	// Retries of transient Slack failures: total attempts, exponential backoff bounds and jitter fraction
+	SlackRetryMaxAttempts int
+	SlackRetryBaseDelay   time.Duration
+	SlackRetryMaxDelay    time.Duration
+	SlackRetryJitter      float64
+
+	// JSON-lines file that events are appended to when they cannot be delivered; empty disables it
+	SlackDeadLetterFile string
+
+	// Events with the same type, title and these metadata values are sent once per window;
+	// a zero window disables deduplication
+	DedupWindow       time.Duration
+	DedupMetadataKeys []string
+
+	// Upper bound on a decompressed API request body, in bytes
+	APIMaxBodyBytes int64
+
+	// API server timeouts and the number of requests it serves at once
+	APIReadTimeout   time.Duration
+	APIWriteTimeout  time.Duration
+	APIIdleTimeout   time.Duration
+	APIMaxConcurrent int
+
+	// How long Stop waits for queued events to be delivered before giving up on them
+	ShutdownTimeout time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -34,10 +74,79 @@ func LoadConfig() (*Config, error) {
 		SlackAppLevelToken: getEnv("SLACK_APP_LEVEL_TOKEN", ""),
 		SlackBotToken:      getEnv("SLACK_BOT_TOKEN", ""),
 		SlackChannel:       getEnv("SLACK_CHANNEL", "#general"),
+		SlackDeadLetterFile: getEnv("SLACK_DEAD_LETTER_FILE", "dead_letters.jsonl"),
 		Environment:        getEnv("ENVIRONMENT", "development"),
 		APIAddress:         getEnv("API_ADDR", ":8081"),
+
+		SlackMetadataInclude: getEnvAsList("SLACK_METADATA_INCLUDE"),
+		SlackMetadataExclude: getEnvAsList("SLACK_METADATA_EXCLUDE"),
+		SlackMetadataHash:    getEnvAsList("SLACK_METADATA_HASH"),
+
+		DedupMetadataKeys: getEnvAsList("NOTIFIER_DEDUP_KEYS"),
 	}
 
+	config.SeverityChannelMap = make(map[events.Severity]string)
+	for _, sev := range []events.Severity{events.SeverityInfo, events.SeverityWarning, events.SeverityError, events.SeverityCritical} {
+		if channel := getEnv("SLACK_CHANNEL_"+strings.ToUpper(string(sev)), ""); channel != "" {
+			config.SeverityChannelMap[sev] = channel
+		}
+	}
+
+	layouts, err := parseFieldLayouts(getEnv("SLACK_FIELD_LAYOUTS", "order_created=order_id,amount"))
+	if err != nil {
+		return nil, err
+	}
+	config.SlackFieldLayouts = layouts
+
+	attempts, err := strconv.Atoi(getEnv("SLACK_RETRY_MAX_ATTEMPTS", "4"))
+	if err != nil || attempts <= 0 {
+		return nil, fmt.Errorf("SLACK_RETRY_MAX_ATTEMPTS must be a positive number")
+	}
+	config.SlackRetryMaxAttempts = attempts
+	if config.SlackRetryBaseDelay, err = getEnvAsDuration("SLACK_RETRY_BASE_DELAY", "500ms"); err != nil {
+		return nil, err
+	}
+	if config.SlackRetryMaxDelay, err = getEnvAsDuration("SLACK_RETRY_MAX_DELAY", "10s"); err != nil {
+		return nil, err
+	}
+	jitter, err := strconv.ParseFloat(getEnv("SLACK_RETRY_JITTER", "0.2"), 64)
+	if err != nil || jitter < 0 || jitter > 1 {
+		return nil, fmt.Errorf("SLACK_RETRY_JITTER must be a fraction between 0 and 1")
+	}
+	config.SlackRetryJitter = jitter
+
+	if getEnv("NOTIFIER_DEDUP_WINDOW", "") != "" {
+		if config.DedupWindow, err = getEnvAsDuration("NOTIFIER_DEDUP_WINDOW", "1m"); err != nil {
+			return nil, err
+		}
+	}
+
+	maxBody, err := strconv.ParseInt(getEnv("API_MAX_BODY_BYTES", "1048576"), 10, 64)
+	if err != nil || maxBody <= 0 {
+		return nil, fmt.Errorf("API_MAX_BODY_BYTES must be a positive number of bytes")
+	}
+	config.APIMaxBodyBytes = maxBody
+
+	if config.APIReadTimeout, err = getEnvAsDuration("API_READ_TIMEOUT", "10s"); err != nil {
+		return nil, err
+	}
+	if config.APIWriteTimeout, err = getEnvAsDuration("API_WRITE_TIMEOUT", "30s"); err != nil {
+		return nil, err
+	}
+	if config.APIIdleTimeout, err = getEnvAsDuration("API_IDLE_TIMEOUT", "60s"); err != nil {
+		return nil, err
+	}
+
+	if config.ShutdownTimeout, err = getEnvAsDuration("SHUTDOWN_TIMEOUT", "15s"); err != nil {
+		return nil, err
+	}
+
+	maxConcurrent, err := strconv.Atoi(getEnv("API_MAX_CONCURRENT_REQUESTS", "64"))
+	if err != nil || maxConcurrent <= 0 {
+		return nil, fmt.Errorf("API_MAX_CONCURRENT_REQUESTS must be a positive number")
+	}
+	config.APIMaxConcurrent = maxConcurrent
+
 	// Validate required fields and common misconfigurations
 	if config.SlackBotToken == "" {
 		return nil, fmt.Errorf("SLACK_BOT_TOKEN is required (must be a Bot User OAuth Token starting with 'xoxb-')")
@@ -60,6 +169,57 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvAsList gets a comma-separated environment variable as a list of trimmed, non-empty values
+func getEnvAsList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvAsDuration gets an environment variable as a positive duration such as "10s"
+func getEnvAsDuration(key, fallback string) (time.Duration, error) {
+	d, err := time.ParseDuration(getEnv(key, fallback))
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as %q", key, fallback)
+	}
+	return d, nil
+}
+
+// parseFieldLayouts parses "event_type=key1,key2;other_type=key3" into primary field keys per event type
+func parseFieldLayouts(value string) (map[string][]string, error) {
+	layouts := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		eventType, keys, ok := strings.Cut(entry, "=")
+		eventType = strings.TrimSpace(eventType)
+		if !ok || eventType == "" {
+			return nil, fmt.Errorf("SLACK_FIELD_LAYOUTS entry %q must look like event_type=key1,key2", entry)
+		}
+
+		var fields []string
+		for _, key := range strings.Split(keys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				fields = append(fields, key)
+			}
+		}
+		layouts[eventType] = fields
+	}
+	return layouts, nil
+}
+
 // IsDevelopment returns true if the application is running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -1,6 +1,11 @@
 package events
 
 import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -32,6 +37,50 @@ const (
 	EventTypeServiceDown      EventType = "service_down"
 )
 
+// eventTypes maps every known event type to the severity NewEvent gives it. The
+// constants above are registered with info; RegisterEventType adds more.
+var (
+	eventTypesMu sync.RWMutex
+	eventTypes   = map[EventType]Severity{
+		EventTypeSystemStartup:    SeverityInfo,
+		EventTypeSystemShutdown:   SeverityInfo,
+		EventTypeSystemError:      SeverityInfo,
+		EventTypeUserLogin:        SeverityInfo,
+		EventTypeUserLogout:       SeverityInfo,
+		EventTypeUserRegistration: SeverityInfo,
+		EventTypeOrderCreated:     SeverityInfo,
+		EventTypeOrderCompleted:   SeverityInfo,
+		EventTypeOrderCancelled:   SeverityInfo,
+		EventTypePaymentReceived:  SeverityInfo,
+		EventTypePaymentFailed:    SeverityInfo,
+		EventTypeHighCPUUsage:     SeverityInfo,
+		EventTypeHighMemoryUsage:  SeverityInfo,
+		EventTypeDiskSpaceLow:     SeverityInfo,
+		EventTypeServiceDown:      SeverityInfo,
+	}
+)
+
+// RegisterEventType makes name a known event type whose events default to
+// defaultSeverity. An unknown severity registers info. Registering a type again,
+// built-in or not, replaces its default severity.
+func RegisterEventType(name string, defaultSeverity Severity) {
+	if !defaultSeverity.valid() {
+		defaultSeverity = SeverityInfo
+	}
+
+	eventTypesMu.Lock()
+	defer eventTypesMu.Unlock()
+	eventTypes[EventType(name)] = defaultSeverity
+}
+
+// lookupEventType returns the default severity of t and whether t is registered
+func lookupEventType(t EventType) (Severity, bool) {
+	eventTypesMu.RLock()
+	defer eventTypesMu.RUnlock()
+	sev, ok := eventTypes[t]
+	return sev, ok
+}
+
 // Event represents a notification event
 type Event struct {
 	ID          string                 `json:"id"`
@@ -45,6 +94,21 @@ type Event struct {
 	Channel     string                 `json:"channel,omitempty"`
 }
 
+// Validate reports why the event would make a confusing Slack message: a type that
+// is not registered, an empty title or a severity that is not one of the Severity constants.
+func (e *Event) Validate() error {
+	if _, ok := lookupEventType(e.Type); !ok {
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	if strings.TrimSpace(e.Title) == "" {
+		return fmt.Errorf("event title is required")
+	}
+	if !e.Severity.valid() {
+		return fmt.Errorf("unknown severity %q", e.Severity)
+	}
+	return nil
+}
+
 // Severity represents the severity level of an event
 type Severity string
 
@@ -55,19 +119,52 @@ const (
 	SeverityCritical Severity = "critical"
 )
 
+// valid reports whether s is one of the Severity constants
+func (s Severity) valid() bool {
+	switch s {
+	case SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// severityAliases maps accepted severity spellings to their canonical level
+var severityAliases = map[string]Severity{
+	"info":     SeverityInfo,
+	"warning":  SeverityWarning,
+	"warn":     SeverityWarning,
+	"error":    SeverityError,
+	"critical": SeverityCritical,
+	"crit":     SeverityCritical,
+}
+
+// ParseSeverity converts a user-supplied severity (canonical name or alias) into a Severity.
+// Unknown values, including the empty string, return an error.
+func ParseSeverity(s string) (Severity, error) {
+	if sev, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q (expected info, warning|warn, error, critical|crit)", s)
+}
+
 // EventBuilder provides a fluent interface for building events
 type EventBuilder struct {
 	event *Event
 }
 
-// NewEvent creates a new event builder
+// NewEvent creates a new event builder. The severity defaults to the one registered
+// for eventType, or info for unknown types.
 func NewEvent(eventType EventType) *EventBuilder {
+	severity, ok := lookupEventType(eventType)
+	if !ok {
+		severity = SeverityInfo
+	}
 	return &EventBuilder{
 		event: &Event{
 			ID:        generateEventID(),
 			Type:      eventType,
 			Timestamp: time.Now(),
-			Severity:  SeverityInfo,
+			Severity:  severity,
 			Metadata:  make(map[string]interface{}),
 		},
 	}
@@ -114,17 +211,31 @@ func (eb *EventBuilder) Build() *Event {
 	return eb.event
 }
 
-// generateEventID generates a unique event ID
+// BuildValidated returns the constructed event, or the error from Validate
+func (eb *EventBuilder) BuildValidated() (*Event, error) {
+	if err := eb.event.Validate(); err != nil {
+		return nil, err
+	}
+	return eb.event, nil
+}
+
+// generateEventID generates a unique event ID. Twelve random characters keep IDs made
+// within the same second from colliding.
 func generateEventID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
+	return time.Now().Format("20060102150405") + "-" + randomString(12)
 }
 
-// randomString generates a random string of specified length
+// randomString generates a random string of specified length using crypto/rand
 func randomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	max := big.NewInt(int64(len(charset)))
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("events: reading random bytes: %v", err))
+		}
+		b[i] = charset[n.Int64()]
 	}
 	return string(b)
 }
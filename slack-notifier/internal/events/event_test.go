@@ -1,6 +1,7 @@
 package events
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -215,6 +216,34 @@ func TestRandomStringGeneration(t *testing.T) {
 	}
 }
 
+func TestEventIDUniqueness(t *testing.T) {
+	seen := make(map[string]bool, 10000)
+	for i := 0; i < 10000; i++ {
+		id := NewEvent(EventTypeUserLogin).Build().ID
+		if seen[id] {
+			t.Fatalf("Duplicate event ID %s after %d events", id, i)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRandomStringUsesCharset(t *testing.T) {
+	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
+	chars := make(map[rune]bool)
+	for i := 0; i < 100; i++ {
+		for _, c := range randomString(6) {
+			if !strings.ContainsRune(charset, c) {
+				t.Fatalf("Unexpected character %q in random string", c)
+			}
+			chars[c] = true
+		}
+	}
+	// A repeated character per string would give only a handful of distinct ones
+	if len(chars) < 20 {
+		t.Errorf("Expected varied characters, got only %d distinct", len(chars))
+	}
+}
+
 func TestEventTypeConstants(t *testing.T) {
 	// Verify all event type constants are defined
 	expectedTypes := map[string]EventType{
@@ -241,3 +270,108 @@ func TestEventTypeConstants(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSeverity(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected Severity
+		wantErr  bool
+	}{
+		{"info", SeverityInfo, false},
+		{"warning", SeverityWarning, false},
+		{"error", SeverityError, false},
+		{"critical", SeverityCritical, false},
+		{"warn", SeverityWarning, false},
+		{"crit", SeverityCritical, false},
+		{" Critical ", SeverityCritical, false},
+		{"WARN", SeverityWarning, false},
+		{"fatal", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range testCases {
+		severity, err := ParseSeverity(tc.input)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseSeverity(%q) expected error, got %s", tc.input, severity)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSeverity(%q) unexpected error: %v", tc.input, err)
+			continue
+		}
+		if severity != tc.expected {
+			t.Errorf("ParseSeverity(%q) = %s, expected %s", tc.input, severity, tc.expected)
+		}
+	}
+}
+
+func TestEventValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   *Event
+		wantErr string
+	}{
+		{"valid", NewEvent(EventTypeUserLogin).WithTitle("Login").Build(), ""},
+		{"unknown type", NewEvent(EventType("user_teleported")).WithTitle("Teleport").Build(), "unknown event type"},
+		{"empty type", NewEvent("").WithTitle("Login").Build(), "unknown event type"},
+		{"empty title", NewEvent(EventTypeUserLogin).Build(), "title is required"},
+		{"blank title", NewEvent(EventTypeUserLogin).WithTitle("  ").Build(), "title is required"},
+		{"unknown severity", NewEvent(EventTypeUserLogin).WithTitle("Login").WithSeverity("fatal").Build(), "unknown severity"},
+		{"empty severity", NewEvent(EventTypeUserLogin).WithTitle("Login").WithSeverity("").Build(), "unknown severity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.event.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildValidated(t *testing.T) {
+	if _, err := NewEvent(EventTypeUserLogin).BuildValidated(); err == nil {
+		t.Error("Expected error for an event without a title")
+	}
+	event, err := NewEvent(EventTypeUserLogin).WithTitle("Login").BuildValidated()
+	if err != nil || event.Title != "Login" {
+		t.Errorf("Expected a valid event titled Login, got %+v, %v", event, err)
+	}
+}
+
+func TestRegisterEventType(t *testing.T) {
+	const inventoryLow EventType = "inventory_low"
+
+	if err := NewEvent(inventoryLow).WithTitle("Stock low").Build().Validate(); err == nil {
+		t.Fatal("Expected an unregistered type to fail validation")
+	}
+
+	RegisterEventType(string(inventoryLow), SeverityWarning)
+	event, err := NewEvent(inventoryLow).WithTitle("Stock low").BuildValidated()
+	if err != nil {
+		t.Fatalf("Expected the registered type to validate, got %v", err)
+	}
+	if event.Severity != SeverityWarning {
+		t.Errorf("Expected the registered default severity %s, got %s", SeverityWarning, event.Severity)
+	}
+	if event = NewEvent(inventoryLow).WithSeverity(SeverityCritical).Build(); event.Severity != SeverityCritical {
+		t.Errorf("Expected an explicit severity to win over the default, got %s", event.Severity)
+	}
+
+	RegisterEventType("refund_issued", Severity("loud"))
+	if event := NewEvent("refund_issued").Build(); event.Severity != SeverityInfo {
+		t.Errorf("Expected an invalid default severity to register as info, got %s", event.Severity)
+	}
+	if event := NewEvent(EventTypeSystemError).Build(); event.Severity != SeverityInfo {
+		t.Errorf("Expected built-in types to keep defaulting to info, got %s", event.Severity)
+	}
+}
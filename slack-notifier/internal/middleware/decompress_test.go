@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatalf("Expected no error compressing, got %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Expected no error closing gzip writer, got %v", err)
+	}
+	return &buf
+}
+
+// decodeHandler echoes the "title" field of a JSON body
+func decodeHandler(called *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*called = true
+		var body struct {
+			Title string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(body.Title))
+	})
+}
+
+func TestDecompressGzipBody(t *testing.T) {
+	var called bool
+	handler := Decompress(1024)(decodeHandler(&called))
+
+	req := httptest.NewRequest(http.MethodPost, "/send-event", gzipBytes(t, []byte(`{"title":"Order Created"}`)))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Body.String() != "Order Created" {
+		t.Errorf("Expected decoded title 'Order Created', got %q", rec.Body.String())
+	}
+}
+
+func TestDecompressPlainBodyPassesThrough(t *testing.T) {
+	var called bool
+	handler := Decompress(1024)(decodeHandler(&called))
+
+	req := httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader(`{"title":"Plain"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "Plain" {
+		t.Errorf("Expected plain body to pass through, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDecompressRejectsOversizedBody(t *testing.T) {
+	var called bool
+	handler := Decompress(1024)(decodeHandler(&called))
+
+	// 1 MiB of zeros compresses to about 1 KiB
+	payload := append([]byte(`{"title":"`), bytes.Repeat([]byte("0"), 1<<20)...)
+	payload = append(payload, []byte(`"}`)...)
+	compressed := gzipBytes(t, payload)
+	if compressed.Len() > 4096 {
+		t.Fatalf("Expected a small compressed payload, got %d bytes", compressed.Len())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/send-event", compressed)
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rec.Code)
+	}
+	if called {
+		t.Error("Expected handler not to be called for an oversized body")
+	}
+}
+
+func TestDecompressRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     string
+		want     int
+	}{
+		{"corrupt gzip", "gzip", "not gzip at all", http.StatusBadRequest},
+		{"unsupported encoding", "br", "{}", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			handler := Decompress(1024)(decodeHandler(&called))
+
+			req := httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, rec.Code)
+			}
+			if called {
+				t.Error("Expected handler not to be called")
+			}
+		})
+	}
+}
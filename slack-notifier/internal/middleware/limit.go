@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// Limit serves at most maxConcurrent requests at once. Requests arriving while
+// every slot is taken are turned away with 503 rather than queued, so a burst of
+// slow clients cannot pile up goroutines behind the ones already being served.
+func Limit(maxConcurrent int) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, maxConcurrent)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "too many concurrent requests", http.StatusServiceUnavailable)
+				return
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// MaxBytes caps the request body as sent on the wire at maxBytes. Reads past the
+// cap fail with an *http.MaxBytesError, which handlers should answer with 413.
+func MaxBytes(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
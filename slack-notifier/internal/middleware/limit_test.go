@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitRejectsOverCapacity(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := Limit(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/send-event", nil))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-event", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while the only slot is taken, got %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+
+	// The slot is free again once the first request finishes
+	go func() { <-entered }()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/send-event", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after the slot was released, got %d", rec.Code)
+	}
+}
+
+func TestMaxBytesCapsBody(t *testing.T) {
+	var readErr error
+	handler := MaxBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/send-event", strings.NewReader("0123456789")))
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Errorf("Expected *http.MaxBytesError reading past the cap, got %v", readErr)
+	}
+}
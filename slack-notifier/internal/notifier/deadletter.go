@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"slack-notifier/internal/events"
+)
+
+// DeadLetterHandler receives events that could not be delivered to Slack, after retries
+// were exhausted or the failure was permanent, along with the last error.
+type DeadLetterHandler func(*events.Event, error)
+
+// deadLetter is one line of the dead-letter file.
+type deadLetter struct {
+	Event    *events.Event `json:"event"`
+	Error    string        `json:"error"`
+	FailedAt time.Time     `json:"failed_at"`
+}
+
+// deadLetterFile stores dead letters as JSON lines, one per failed event.
+type deadLetterFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Append is a DeadLetterHandler that appends the event to the file. Write errors are
+// logged, since there is nowhere left to send the event.
+func (f *deadLetterFile) Append(e *events.Event, sendErr error) {
+	line, err := json.Marshal(deadLetter{Event: e, Error: sendErr.Error(), FailedAt: time.Now().UTC()})
+	if err != nil {
+		logDeadLetterError(e, err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		logDeadLetterError(e, err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logDeadLetterError(e, err)
+	}
+}
+
+// Drain returns every event in the file and empties it. A missing file has no events.
+func (f *deadLetterFile) Drain() ([]*events.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open dead letters: %w", err)
+	}
+	defer file.Close()
+
+	var drained []*events.Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var letter deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &letter); err != nil {
+			return nil, fmt.Errorf("decode dead letter: %w", err)
+		}
+		if letter.Event == nil {
+			continue
+		}
+		drained = append(drained, letter.Event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read dead letters: %w", err)
+	}
+
+	if err := os.Truncate(f.path, 0); err != nil {
+		return nil, fmt.Errorf("clear dead letters: %w", err)
+	}
+	return drained, nil
+}
+
+func logDeadLetterError(e *events.Event, err error) {
+	log.Printf("notifier: could not dead-letter event %s (%s): %v", e.ID, e.Type, err)
+}
@@ -0,0 +1,103 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"slack-notifier/internal/events"
+)
+
+// FingerprintFunc identifies events that count as duplicates of each other.
+type FingerprintFunc func(*events.Event) string
+
+// MetadataFingerprint fingerprints an event by its type, title and the values of the
+// given metadata keys, in order. Other metadata, such as timestamps, is ignored.
+func MetadataFingerprint(keys []string) FingerprintFunc {
+	return func(e *events.Event) string {
+		parts := []string{string(e.Type), e.Title}
+		for _, k := range keys {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, e.Metadata[k]))
+		}
+		return strings.Join(parts, "\x00")
+	}
+}
+
+// dedupEntry tracks one fingerprint during its window.
+type dedupEntry struct {
+	first      *events.Event
+	suppressed int
+	timer      *time.Timer
+}
+
+// deduplicate reports whether e duplicates an event seen within the window, counting it
+// if so. The first event of a fingerprint opens a window; when it closes, a summary is
+// sent if any duplicates were suppressed.
+func (s *Service) deduplicate(e *events.Event) bool {
+	if s.cfg.DedupWindow <= 0 {
+		return false
+	}
+	fingerprint := s.Fingerprint
+	if fingerprint == nil {
+		fingerprint = MetadataFingerprint(s.cfg.DedupMetadataKeys)
+	}
+	key := fingerprint(e)
+
+	s.dedupMutex.Lock()
+	defer s.dedupMutex.Unlock()
+
+	if entry, ok := s.dedup[key]; ok {
+		entry.suppressed++
+		return true
+	}
+	if s.dedup == nil {
+		s.dedup = make(map[string]*dedupEntry)
+	}
+	s.dedup[key] = &dedupEntry{
+		first: e,
+		timer: time.AfterFunc(s.cfg.DedupWindow, func() { s.closeWindow(key) }),
+	}
+	return false
+}
+
+// closeWindow ends the window for key and sends its summary.
+func (s *Service) closeWindow(key string) {
+	s.dedupMutex.Lock()
+	entry, ok := s.dedup[key]
+	delete(s.dedup, key)
+	s.dedupMutex.Unlock()
+
+	if ok && entry.suppressed > 0 {
+		s.enqueue(repeatSummary(entry.first, entry.suppressed, s.cfg.DedupWindow))
+	}
+}
+
+// flushDedup closes every open window now, so Stop does not lose suppressed counts.
+func (s *Service) flushDedup() {
+	s.dedupMutex.Lock()
+	var keys []string
+	for key, entry := range s.dedup {
+		if entry.timer.Stop() {
+			keys = append(keys, key)
+		}
+	}
+	s.dedupMutex.Unlock()
+
+	for _, key := range keys {
+		s.closeWindow(key)
+	}
+}
+
+// repeatSummary reports that first was repeated count more times within window.
+func repeatSummary(first *events.Event, count int, window time.Duration) *events.Event {
+	builder := events.NewEvent(first.Type).
+		WithTitle(first.Title).
+		WithMessage(fmt.Sprintf("Repeated %d times within %s", count, window)).
+		WithSeverity(first.Severity).
+		WithChannel(first.Channel).
+		WithUserID(first.UserID)
+	for k, v := range first.Metadata {
+		builder.WithMetadata(k, v)
+	}
+	return builder.WithMetadata("repeated", count).Build()
+}
@@ -15,12 +15,37 @@ import (
 // Service coordinates event processing and Slack posting.
 type Service struct {
 	cfg         *config.Config
-	slack       *slackpkg.Client
+	slack       slackClient
 	events      chan *events.Event
 	workers     int
 	wg          sync.WaitGroup
 	ctx         context.Context
 	cancel      context.CancelFunc
+
+	// stopMutex guards stopped, so SendEvent never sends on the closed events channel
+	stopMutex   sync.RWMutex
+	stopped     bool
+
+	// DeadLetterHandler receives events the worker could not deliver. It defaults to
+	// appending them to cfg.SlackDeadLetterFile, which DrainDeadLetters reads back.
+	DeadLetterHandler DeadLetterHandler
+	deadLetters       *deadLetterFile
+
+	// Fingerprint decides which events are duplicates when cfg.DedupWindow is set. It
+	// defaults to MetadataFingerprint(cfg.DedupMetadataKeys).
+	Fingerprint FingerprintFunc
+	dedup       map[string]*dedupEntry
+	dedupMutex  sync.Mutex
+}
+
+// defaultShutdownTimeout bounds Stop when the config sets no ShutdownTimeout.
+const defaultShutdownTimeout = 15 * time.Second
+
+// slackClient is the part of the Slack client the service uses.
+type slackClient interface {
+	SendEvent(ctx context.Context, event *events.Event) error
+	SendMessage(ctx context.Context, message string) error
+	TestConnection(ctx context.Context) error
 }
 
 func NewNotifierService(cfg *config.Config, workers int) (*Service, error) {
@@ -31,7 +56,23 @@ func NewNotifierService(cfg *config.Config, workers int) (*Service, error) {
 	}
 
 	s.ctx, s.cancel = context.WithCancel(context.Background())
-	s.slack = slackpkg.NewClient(cfg.SlackBotToken, cfg.SlackChannel)
+	if cfg.SlackDeadLetterFile != "" {
+		s.deadLetters = &deadLetterFile{path: cfg.SlackDeadLetterFile}
+		s.DeadLetterHandler = s.deadLetters.Append
+	}
+	s.slack = slackpkg.NewClient(cfg.SlackBotToken, cfg.SlackChannel).
+		WithMetadataPolicy(slackpkg.MetadataPolicy{
+			Include: cfg.SlackMetadataInclude,
+			Exclude: cfg.SlackMetadataExclude,
+			Hash:    cfg.SlackMetadataHash,
+		}).
+		WithFieldLayouts(fieldLayouts(cfg.SlackFieldLayouts)).
+		WithRetryPolicy(slackpkg.RetryPolicy{
+			MaxAttempts: cfg.SlackRetryMaxAttempts,
+			BaseDelay:   cfg.SlackRetryBaseDelay,
+			MaxDelay:    cfg.SlackRetryMaxDelay,
+			Jitter:      cfg.SlackRetryJitter,
+		})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -41,6 +82,15 @@ func NewNotifierService(cfg *config.Config, workers int) (*Service, error) {
 	return s, nil
 }
 
+// fieldLayouts converts the configured primary keys per event type into Slack field layouts.
+func fieldLayouts(primary map[string][]string) slackpkg.FieldLayouts {
+	layouts := make(slackpkg.FieldLayouts, len(primary))
+	for eventType, keys := range primary {
+		layouts[events.EventType(eventType)] = slackpkg.FieldLayout{Primary: keys}
+	}
+	return layouts
+}
+
 func (s *Service) Start() error {
 	log.Printf("notifier: starting with %d workers", s.workers)
 	for i := 0; i < s.workers; i++ {
@@ -65,15 +115,56 @@ func (s *Service) Stop() error {
 		WithSeverity(events.SeverityInfo).
 		Build())
 
-	s.cancel()
+	// Summarize suppressed duplicates while the intake is still open
+	s.flushDedup()
+
+	// Close the intake; workers deliver what is queued, then exit
+	s.stopMutex.Lock()
+	if s.stopped {
+		s.stopMutex.Unlock()
+		return nil
+	}
+	s.stopped = true
 	close(s.events)
-	s.wg.Wait()
+	s.stopMutex.Unlock()
+
+	timeout := s.cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+	case <-time.After(timeout):
+		// Abort in-flight sends; what is left fails fast and goes to the dead-letter handler
+		log.Printf("notifier: %d events still queued after %s, giving up on them", len(s.events), timeout)
+		s.cancel()
+		<-done
+	}
 	return nil
 }
 
 func (s *Service) SendEvent(e *events.Event) {
-	if e.Channel == "" {
-		e.Channel = s.cfg.SlackChannel
+	e.Channel = s.channelFor(e)
+	if s.deduplicate(e) {
+		return
+	}
+	s.enqueue(e)
+}
+
+// enqueue queues e for a worker, or drops it once the service is stopping.
+func (s *Service) enqueue(e *events.Event) {
+	s.stopMutex.RLock()
+	defer s.stopMutex.RUnlock()
+	if s.stopped {
+		log.Printf("notifier: drop event %s (%s): stopped", e.ID, e.Type)
+		return
 	}
 	select {
 	case s.events <- e:
@@ -83,6 +174,23 @@ func (s *Service) SendEvent(e *events.Event) {
 	}
 }
 
+// QueueDepth returns the number of events waiting for a worker.
+func (s *Service) QueueDepth() int {
+	return len(s.events)
+}
+
+// channelFor returns the event's own channel, else the channel configured for its
+// severity, else the default channel.
+func (s *Service) channelFor(e *events.Event) string {
+	if e.Channel != "" {
+		return e.Channel
+	}
+	if channel, ok := s.cfg.SeverityChannelMap[e.Severity]; ok {
+		return channel
+	}
+	return s.cfg.SlackChannel
+}
+
 func (s *Service) SendMessage(msg string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -91,24 +199,20 @@ func (s *Service) SendMessage(msg string) error {
 
 func (s *Service) worker(id int) {
 	defer s.wg.Done()
-	for {
-		select {
-		case e, ok := <-s.events:
-			if !ok {
-				return
-			}
-			s.process(e)
-		case <-s.ctx.Done():
-			return
-		}
+	// Runs until Stop closes the channel, so queued events are delivered first
+	for e := range s.events {
+		s.process(e)
 	}
 }
 
 func (s *Service) process(e *events.Event) {
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	ctx, cancel := context.WithTimeout(s.ctx, 20*time.Second)
 	defer cancel()
 	if err := s.slack.SendEvent(ctx, e); err != nil {
 		log.Printf("notifier: send failed: %v", err)
+		if s.DeadLetterHandler != nil {
+			s.DeadLetterHandler(e, err)
+		}
 		// emit error event without requeue to avoid loops
 		errEvent := events.NewEvent(events.EventTypeSystemError).
 			WithTitle("Notification Failed").
@@ -121,9 +225,25 @@ func (s *Service) process(e *events.Event) {
 	}
 }
 
+// DrainDeadLetters re-enqueues every event in the dead-letter file and empties it,
+// returning how many were re-enqueued. It does nothing without a dead-letter file.
+func (s *Service) DrainDeadLetters() (int, error) {
+	if s.deadLetters == nil {
+		return 0, nil
+	}
+	drained, err := s.deadLetters.Drain()
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range drained {
+		s.SendEvent(e)
+	}
+	return len(drained), nil
+}
+
 func (s *Service) GetStats() map[string]interface{} {
 	return map[string]interface{}{
-		"queue_size": len(s.events),
+		"queue_size": s.QueueDepth(),
 		"workers":   s.workers,
 	}
 }
@@ -0,0 +1,297 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"slack-notifier/internal/config"
+	"slack-notifier/internal/events"
+)
+
+func TestChannelForSeverity(t *testing.T) {
+	s := &Service{cfg: &config.Config{
+		SlackChannel: "#general",
+		SeverityChannelMap: map[events.Severity]string{
+			events.SeverityCritical: "#oncall",
+			events.SeverityError:    "#alerts",
+		},
+	}}
+
+	for sev, want := range map[events.Severity]string{
+		events.SeverityCritical: "#oncall",
+		events.SeverityError:    "#alerts",
+		events.SeverityWarning:  "#general",
+		events.SeverityInfo:     "#general",
+	} {
+		e := events.NewEvent(events.EventTypeSystemError).WithSeverity(sev).Build()
+		if got := s.channelFor(e); got != want {
+			t.Errorf("channelFor(%s) = %q, want %q", sev, got, want)
+		}
+	}
+
+	e := events.NewEvent(events.EventTypeSystemError).WithSeverity(events.SeverityCritical).WithChannel("#payments").Build()
+	if got := s.channelFor(e); got != "#payments" {
+		t.Errorf("channelFor() with an explicit channel = %q, want #payments", got)
+	}
+}
+
+// newTestService returns a service posting to slack, without connecting to Slack
+func newTestService(t *testing.T, slack slackClient, workers int) *Service {
+	t.Helper()
+	s := &Service{
+		cfg:     &config.Config{SlackChannel: "#general"},
+		slack:   slack,
+		events:  make(chan *events.Event, 128),
+		workers: workers,
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	t.Cleanup(s.cancel)
+	return s
+}
+
+// failingSlack fails every send with err
+type failingSlack struct {
+	err  error
+	sent []*events.Event
+}
+
+func (f *failingSlack) SendEvent(ctx context.Context, e *events.Event) error {
+	f.sent = append(f.sent, e)
+	return f.err
+}
+
+func (f *failingSlack) SendMessage(ctx context.Context, message string) error { return f.err }
+
+func (f *failingSlack) TestConnection(ctx context.Context) error { return nil }
+
+func TestDeadLetterOnPermanentFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead_letters.jsonl")
+	s := newTestService(t, &failingSlack{err: errors.New("channel_not_found")}, 1)
+	s.deadLetters = &deadLetterFile{path: path}
+	s.DeadLetterHandler = s.deadLetters.Append
+
+	e := events.NewEvent(events.EventTypeOrderCreated).WithTitle("Order placed").WithChannel("#gone").Build()
+	s.process(e)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected a dead-letter file, got %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], e.ID) || !strings.Contains(lines[0], "channel_not_found") {
+		t.Fatalf("Expected one dead letter for %s with its error, got %q", e.ID, data)
+	}
+
+	n, err := s.DrainDeadLetters()
+	if err != nil || n != 1 {
+		t.Fatalf("DrainDeadLetters() = %d, %v, want 1", n, err)
+	}
+	if requeued := <-s.events; requeued.ID != e.ID || requeued.Channel != "#gone" {
+		t.Errorf("Expected %s re-enqueued for #gone, got %s for %s", e.ID, requeued.ID, requeued.Channel)
+	}
+	if n, err := s.DrainDeadLetters(); err != nil || n != 0 {
+		t.Errorf("Second DrainDeadLetters() = %d, %v, want 0", n, err)
+	}
+}
+
+func TestDeadLetterHandlerOverride(t *testing.T) {
+	var got []*events.Event
+	s := newTestService(t, &failingSlack{err: errors.New("invalid_auth")}, 1)
+	s.DeadLetterHandler = func(e *events.Event, err error) {
+		got = append(got, e)
+	}
+
+	e := events.NewEvent(events.EventTypeSystemError).Build()
+	s.process(e)
+	if len(got) != 1 || got[0] != e {
+		t.Errorf("Expected the custom handler to receive %s, got %v", e.ID, got)
+	}
+	if n, err := s.DrainDeadLetters(); err != nil || n != 0 {
+		t.Errorf("DrainDeadLetters() without a file = %d, %v, want 0", n, err)
+	}
+}
+
+// recordingSlack records every event it is sent, taking delay per send
+type recordingSlack struct {
+	delay time.Duration
+	mu    sync.Mutex
+	sent  []*events.Event
+}
+
+func (r *recordingSlack) SendEvent(ctx context.Context, e *events.Event) error {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, e)
+	return nil
+}
+
+func (r *recordingSlack) SendMessage(ctx context.Context, message string) error { return nil }
+
+func (r *recordingSlack) TestConnection(ctx context.Context) error { return nil }
+
+func (r *recordingSlack) sentIDs() map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make(map[string]bool, len(r.sent))
+	for _, e := range r.sent {
+		ids[e.ID] = true
+	}
+	return ids
+}
+
+func TestStopDeliversQueuedEvents(t *testing.T) {
+	slack := &recordingSlack{delay: 10 * time.Millisecond}
+	s := newTestService(t, slack, 2)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	var queued []*events.Event
+	for i := 0; i < 10; i++ {
+		e := events.NewEvent(events.EventTypeOrderCreated).WithTitle("Order placed").Build()
+		s.SendEvent(e)
+		queued = append(queued, e)
+	}
+	if s.QueueDepth() == 0 {
+		t.Fatal("Expected events to still be queued before Stop")
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	sent := slack.sentIDs()
+	for i, e := range queued {
+		if !sent[e.ID] {
+			t.Errorf("Event %d (%s) was not delivered before Stop returned", i, e.ID)
+		}
+	}
+	if s.QueueDepth() != 0 {
+		t.Errorf("QueueDepth() after Stop = %d, want 0", s.QueueDepth())
+	}
+
+	// Sends after Stop are dropped rather than panicking on the closed channel
+	s.SendEvent(events.NewEvent(events.EventTypeOrderCreated).Build())
+}
+
+func TestStopGivesUpAfterShutdownTimeout(t *testing.T) {
+	slack := &recordingSlack{delay: time.Hour}
+	s := newTestService(t, slack, 1)
+	s.cfg.ShutdownTimeout = 50 * time.Millisecond
+	var dead []*events.Event
+	var mu sync.Mutex
+	s.DeadLetterHandler = func(e *events.Event, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		dead = append(dead, e)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	s.SendEvent(events.NewEvent(events.EventTypeOrderCreated).Build())
+
+	start := time.Now()
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Stop() took %v, want it bounded by the shutdown timeout", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	// startup, order and shutdown events
+	if len(dead) != 3 {
+		t.Errorf("Expected 3 undelivered events dead-lettered, got %d", len(dead))
+	}
+}
+
+func TestDedupSuppressesRepeatedEvents(t *testing.T) {
+	slack := &recordingSlack{}
+	s := newTestService(t, slack, 1)
+	s.cfg.DedupWindow = 50 * time.Millisecond
+	s.cfg.DedupMetadataKeys = []string{"host"}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	highCPU := func(host string) *events.Event {
+		return events.NewEvent(events.EventTypeHighCPUUsage).
+			WithTitle("High CPU").
+			WithMetadata("host", host).
+			WithMetadata("cpu", time.Now().UnixNano()).
+			Build()
+	}
+	first := highCPU("web-1")
+	s.SendEvent(first)
+	for i := 0; i < 9; i++ {
+		s.SendEvent(highCPU("web-1"))
+	}
+	other := highCPU("web-2")
+	s.SendEvent(other)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(slack.sentIDs()) < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	slack.mu.Lock()
+	var summaries []*events.Event
+	var highCPUSent int
+	for _, e := range slack.sent {
+		if e.Type != events.EventTypeHighCPUUsage {
+			continue
+		}
+		highCPUSent++
+		if e.Metadata["repeated"] != nil {
+			summaries = append(summaries, e)
+		}
+	}
+	slack.mu.Unlock()
+
+	// web-1 once plus its summary, and web-2 once
+	if highCPUSent != 3 || len(summaries) != 1 {
+		t.Fatalf("Expected 3 high CPU sends including 1 summary, got %d with %d summaries", highCPUSent, len(summaries))
+	}
+	if summary := summaries[0]; summary.Metadata["repeated"] != 9 || summary.Metadata["host"] != "web-1" || !strings.Contains(summary.Message, "Repeated 9 times") {
+		t.Errorf("Expected a summary of 9 repeats on web-1, got %q with %v", summary.Message, summary.Metadata)
+	}
+	if sent := slack.sentIDs(); !sent[first.ID] || !sent[other.ID] {
+		t.Errorf("Expected the first event per host to be sent as is")
+	}
+}
+
+func TestDedupFlushesOnStop(t *testing.T) {
+	slack := &recordingSlack{}
+	s := newTestService(t, slack, 1)
+	s.cfg.DedupWindow = time.Hour
+	s.Fingerprint = func(e *events.Event) string { return string(e.Type) }
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	s.SendEvent(events.NewEvent(events.EventTypeHighCPUUsage).WithTitle("CPU 91%").Build())
+	s.SendEvent(events.NewEvent(events.EventTypeHighCPUUsage).WithTitle("CPU 97%").Build())
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	var highCPU []*events.Event
+	for _, e := range slack.sent {
+		if e.Type == events.EventTypeHighCPUUsage {
+			highCPU = append(highCPU, e)
+		}
+	}
+	if len(highCPU) != 2 || highCPU[1].Metadata["repeated"] != 1 {
+		t.Errorf("Expected the first event and a summary of 1 repeat, got %d events", len(highCPU))
+	}
+}
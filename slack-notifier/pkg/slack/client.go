@@ -6,13 +6,18 @@ import (
 	"time"
 
 	githubslack "github.com/slack-go/slack"
+	"shared/severity"
 	"slack-notifier/internal/events"
 )
 
 // Client wraps the slack-go client with a minimal API we need.
 type Client struct {
-	api     *githubslack.Client
-	channel string
+	api      *githubslack.Client
+	channel  string
+	metadata MetadataPolicy
+	layouts  FieldLayouts
+	retry    RetryPolicy
+	sleep    func(ctx context.Context, d time.Duration) error
 }
 
 // NewClient constructs a new Client using the bot token and default channel.
@@ -20,16 +25,39 @@ func NewClient(botToken, channel string) *Client {
 	return &Client{
 		api:     githubslack.New(botToken),
 		channel: channel,
+		retry:   DefaultRetryPolicy,
+		sleep:   sleepContext,
 	}
 }
 
-// SendMessage posts a plain text message to the configured channel.
+// WithMetadataPolicy sets the policy used to filter and hash event metadata when formatting.
+func (c *Client) WithMetadataPolicy(policy MetadataPolicy) *Client {
+	c.metadata = policy
+	return c
+}
+
+// WithFieldLayouts sets the per-event-type layouts deciding which metadata is shown as primary fields.
+func (c *Client) WithFieldLayouts(layouts FieldLayouts) *Client {
+	c.layouts = layouts
+	return c
+}
+
+// WithRetryPolicy sets how posts that fail with a transient error are retried.
+func (c *Client) WithRetryPolicy(policy RetryPolicy) *Client {
+	c.retry = policy
+	return c
+}
+
+// SendMessage posts a plain text message to the configured channel, retrying transient failures.
 func (c *Client) SendMessage(ctx context.Context, message string) error {
-	_, _, err := c.api.PostMessageContext(ctx, c.channel, githubslack.MsgOptionText(message, false))
-	return err
+	return c.withRetry(ctx, func() error {
+		_, _, err := c.api.PostMessageContext(ctx, c.channel, githubslack.MsgOptionText(message, false))
+		return err
+	})
 }
 
-// SendEvent posts a rich-formatted message for an event. If event.Channel is empty, default channel is used.
+// SendEvent posts a rich-formatted message for an event, retrying transient failures.
+// If event.Channel is empty, default channel is used.
 func (c *Client) SendEvent(ctx context.Context, event *events.Event) error {
 	channel := event.Channel
 	if channel == "" {
@@ -37,7 +65,10 @@ func (c *Client) SendEvent(ctx context.Context, event *events.Event) error {
 	}
 
 	blocks := c.buildBlocks(event)
-	_, _, err := c.api.PostMessageContext(ctx, channel, githubslack.MsgOptionBlocks(blocks...))
+	err := c.withRetry(ctx, func() error {
+		_, _, err := c.api.PostMessageContext(ctx, channel, githubslack.MsgOptionBlocks(blocks...))
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("post slack message: %w", err)
 	}
@@ -53,7 +84,8 @@ func (c *Client) TestConnection(ctx context.Context) error {
 func (c *Client) buildBlocks(event *events.Event) []githubslack.Block {
 	var blocks []githubslack.Block
 
-	header := fmt.Sprintf("*%s* %s", event.Title, emojiFor(event.Severity))
+	style := severity.Lookup(string(event.Severity))
+	header := fmt.Sprintf("*%s* %s", event.Title, style.Emoji)
 	blocks = append(blocks, githubslack.NewSectionBlock(
 		githubslack.NewTextBlockObject("mrkdwn", header, false, false), nil, nil,
 	))
@@ -65,20 +97,23 @@ func (c *Client) buildBlocks(event *events.Event) []githubslack.Block {
 	}
 
 	ctxElems := []githubslack.MixedElement{
+		githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*Severity:* %s", style.Label), false, false),
 		githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*Type:* %s", event.Type), false, false),
 		githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*Time:* %s", event.Timestamp.Format(time.RFC3339)), false, false),
 		githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*ID:* `%s`", event.ID), false, false),
 	}
 	if event.UserID != "" {
-		ctxElems = append(ctxElems, githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*User:* %s", event.UserID), false, false))
+		ctxElems = append(ctxElems, githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*User:* %s", EscapeText(event.UserID)), false, false))
 	}
 	blocks = append(blocks, githubslack.NewContextBlock("", ctxElems...))
 
-	if len(event.Metadata) > 0 {
-		md := "*Details:*\n"
-		for k, v := range event.Metadata {
-			md += fmt.Sprintf("• %s: %v\n", k, v)
-		}
+	metadata := c.metadata.Apply(event.Metadata)
+	primary, details := c.layouts[event.Type].Split(metadata)
+	if len(primary) > 0 {
+		blocks = append(blocks, githubslack.NewSectionBlock(nil, primaryFields(primary, metadata), nil))
+	}
+
+	if md := formatDetails(metadata, details); md != "" {
 		blocks = append(blocks, githubslack.NewSectionBlock(
 			githubslack.NewTextBlockObject("mrkdwn", md, false, false), nil, nil,
 		))
@@ -88,17 +123,17 @@ func (c *Client) buildBlocks(event *events.Event) []githubslack.Block {
 	return blocks
 }
 
-func emojiFor(s events.Severity) string {
-	switch s {
-	case events.SeverityInfo:
-		return "ℹ️"
-	case events.SeverityWarning:
-		return "⚠️"
-	case events.SeverityError:
-		return "❌"
-	case events.SeverityCritical:
-		return "🚨"
-	default:
+// formatDetails renders the given metadata keys as a bulleted list, in order. Keys come
+// from FieldLayout.Split, so identical events render identically. Values are escaped
+// with EscapeText.
+func formatDetails(metadata map[string]interface{}, keys []string) string {
+	if len(keys) == 0 {
 		return ""
 	}
+
+	md := "*Details:*\n"
+	for _, k := range keys {
+		md += fmt.Sprintf("• %s: %s\n", k, EscapeText(fmt.Sprint(metadata[k])))
+	}
+	return md
 }
@@ -0,0 +1,55 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	githubslack "github.com/slack-go/slack"
+	"shared/severity"
+	"slack-notifier/internal/events"
+)
+
+// renderedText joins the text of every section and context block
+func renderedText(blocks []githubslack.Block) string {
+	var rendered []string
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case *githubslack.SectionBlock:
+			if b.Text != nil {
+				rendered = append(rendered, b.Text.Text)
+			}
+		case *githubslack.ContextBlock:
+			for _, elem := range b.ContextElements.Elements {
+				if text, ok := elem.(*githubslack.TextBlockObject); ok {
+					rendered = append(rendered, text.Text)
+				}
+			}
+		}
+	}
+	return strings.Join(rendered, "\n")
+}
+
+func TestBuildBlocksSeverityStyle(t *testing.T) {
+	client := NewClient("xoxb-test", "#test")
+	event := events.NewEvent(events.EventTypeSystemError).
+		WithTitle("Disk full").
+		WithSeverity(events.SeverityError).
+		Build()
+
+	output := renderedText(client.buildBlocks(event))
+	for _, want := range []string{"*Disk full* ❌", "*Severity:* Error"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in default rendering, got %q", want, output)
+		}
+	}
+
+	t.Cleanup(severity.Reset)
+	severity.Register("error", severity.Style{Emoji: ":boom:", Label: "Broken"})
+
+	output = renderedText(client.buildBlocks(event))
+	for _, want := range []string{"*Disk full* :boom:", "*Severity:* Broken"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q with the registered override, got %q", want, output)
+		}
+	}
+}
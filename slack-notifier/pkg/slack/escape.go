@@ -0,0 +1,36 @@
+package slack
+
+import (
+	"strings"
+	"unicode"
+)
+
+// mrkdwnEscaper replaces the characters Slack treats as control sequences. &, < and >
+// become HTML entities so values cannot form links or mentions such as <!channel>.
+// The mrkdwn formatting characters get a zero-width space in front of them so
+// they are shown literally instead of starting bold, italic, strike or code text.
+var mrkdwnEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"*", "\u200b*",
+	"_", "\u200b_",
+	"~", "\u200b~",
+	"`", "\u200b`",
+)
+
+// EscapeText makes untrusted text, like usernames or metadata values, safe to embed in
+// mrkdwn. Control characters are removed, line breaks become spaces and Slack special
+// characters are escaped.
+func EscapeText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\r' || r == '\t':
+			return ' '
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, s)
+	return mrkdwnEscaper.Replace(s)
+}
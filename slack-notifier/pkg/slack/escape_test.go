@@ -0,0 +1,61 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	githubslack "github.com/slack-go/slack"
+	"slack-notifier/internal/events"
+)
+
+const maliciousUsername = "<!channel> *eve*\n_x_ ~y~ `z` & <http://evil.example|click>\x07"
+
+func TestEscapeText(t *testing.T) {
+	escaped := EscapeText(maliciousUsername)
+
+	expected := "&lt;!channel&gt; \u200b*eve\u200b* \u200b_x\u200b_ \u200b~y\u200b~ \u200b`z\u200b` &amp; &lt;http://evil.example|click&gt;"
+	if escaped != expected {
+		t.Errorf("Expected %q, got %q", expected, escaped)
+	}
+}
+
+func TestBuildBlocksEscapesUsername(t *testing.T) {
+	client := NewClient("xoxb-test", "#test").WithFieldLayouts(FieldLayouts{
+		events.EventTypeUserLogin: {Primary: []string{"username"}},
+	})
+	event := events.NewEvent(events.EventTypeUserLogin).
+		WithTitle("Login").
+		WithUserID(maliciousUsername).
+		WithMetadata("username", maliciousUsername).
+		WithMetadata("previous_name", maliciousUsername).
+		Build()
+
+	var rendered []string
+	for _, block := range client.buildBlocks(event) {
+		switch b := block.(type) {
+		case *githubslack.SectionBlock:
+			if b.Text != nil {
+				rendered = append(rendered, b.Text.Text)
+			}
+			for _, field := range b.Fields {
+				rendered = append(rendered, field.Text)
+			}
+		case *githubslack.ContextBlock:
+			for _, elem := range b.ContextElements.Elements {
+				if text, ok := elem.(*githubslack.TextBlockObject); ok {
+					rendered = append(rendered, text.Text)
+				}
+			}
+		}
+	}
+	output := strings.Join(rendered, "\n")
+
+	for _, raw := range []string{"<!channel>", "*eve*", "<http://", "\x07", "eve*\n"} {
+		if strings.Contains(output, raw) {
+			t.Errorf("Expected %q to be escaped, got %q", raw, output)
+		}
+	}
+	if count := strings.Count(output, "&lt;!channel&gt;"); count != 3 {
+		t.Errorf("Expected escaped username in user context, primary field and details, found %d in %q", count, output)
+	}
+}
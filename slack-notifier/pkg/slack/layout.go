@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"fmt"
+	"sort"
+
+	githubslack "github.com/slack-go/slack"
+	"slack-notifier/internal/events"
+)
+
+// FieldLayout controls where an event type's metadata appears in Slack messages.
+// Primary keys are rendered, in order, as prominent section fields. Detail keys are
+// listed first, in order, in the details section; any other key follows them sorted by name.
+type FieldLayout struct {
+	Primary []string
+	Detail  []string
+}
+
+// FieldLayouts maps event types to their metadata layout. Event types without a
+// layout render all metadata as details.
+type FieldLayouts map[events.EventType]FieldLayout
+
+// Split divides metadata into primary fields and detail keys according to the layout.
+// Primary keys missing from metadata are skipped.
+func (l FieldLayout) Split(metadata map[string]interface{}) (primary, details []string) {
+	placed := make(map[string]bool, len(metadata))
+	for _, k := range l.Primary {
+		if _, ok := metadata[k]; ok && !placed[k] {
+			primary = append(primary, k)
+			placed[k] = true
+		}
+	}
+	for _, k := range l.Detail {
+		if _, ok := metadata[k]; ok && !placed[k] {
+			details = append(details, k)
+			placed[k] = true
+		}
+	}
+
+	var rest []string
+	for k := range metadata {
+		if !placed[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return primary, append(details, rest...)
+}
+
+// primaryFields renders the given metadata keys as section block fields, escaping values with EscapeText.
+func primaryFields(keys []string, metadata map[string]interface{}) []*githubslack.TextBlockObject {
+	fields := make([]*githubslack.TextBlockObject, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, githubslack.NewTextBlockObject("mrkdwn", fmt.Sprintf("*%s*\n%s", k, EscapeText(fmt.Sprint(metadata[k]))), false, false))
+	}
+	return fields
+}
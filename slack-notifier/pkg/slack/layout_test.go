@@ -0,0 +1,95 @@
+package slack
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	githubslack "github.com/slack-go/slack"
+	"slack-notifier/internal/events"
+)
+
+func orderCreatedEvent() *events.Event {
+	return events.NewEvent(events.EventTypeOrderCreated).
+		WithTitle("New Order").
+		WithMetadata("order_id", "ORD-1001").
+		WithMetadata("amount", 299.99).
+		WithMetadata("region", "eu-west").
+		WithMetadata("customer", "acme").
+		Build()
+}
+
+func TestFieldLayoutSplit(t *testing.T) {
+	layout := FieldLayout{Primary: []string{"amount", "order_id", "missing"}, Detail: []string{"region"}}
+
+	primary, details := layout.Split(testMetadata())
+
+	if !reflect.DeepEqual(primary, []string{"amount", "order_id"}) {
+		t.Errorf("Expected primary keys [amount order_id], got %v", primary)
+	}
+	if !reflect.DeepEqual(details, []string{"region"}) {
+		t.Errorf("Expected detail keys [region], got %v", details)
+	}
+}
+
+func TestFieldLayoutUnknownKeysFallIntoDetails(t *testing.T) {
+	layout := FieldLayout{Primary: []string{"order_id"}, Detail: []string{"region"}}
+	metadata := map[string]interface{}{"order_id": "ORD-1", "zeta": 1, "region": "eu", "alpha": 2}
+
+	_, details := layout.Split(metadata)
+
+	expected := []string{"region", "alpha", "zeta"}
+	if !reflect.DeepEqual(details, expected) {
+		t.Errorf("Expected detail keys %v, got %v", expected, details)
+	}
+}
+
+func TestBuildBlocksOrderCreatedLayout(t *testing.T) {
+	client := NewClient("xoxb-test", "#test").WithFieldLayouts(FieldLayouts{
+		events.EventTypeOrderCreated: {Primary: []string{"order_id", "amount"}},
+	})
+
+	blocks := client.buildBlocks(orderCreatedEvent())
+
+	var fields []*githubslack.TextBlockObject
+	var details string
+	for _, block := range blocks {
+		section, ok := block.(*githubslack.SectionBlock)
+		if !ok {
+			continue
+		}
+		if len(section.Fields) > 0 {
+			fields = section.Fields
+		}
+		if section.Text != nil && strings.HasPrefix(section.Text.Text, "*Details:*") {
+			details = section.Text.Text
+		}
+	}
+
+	if len(fields) != 2 {
+		t.Fatalf("Expected 2 primary fields, got %d", len(fields))
+	}
+	if fields[0].Text != "*order_id*\nORD-1001" {
+		t.Errorf("Expected first field to be order_id, got %q", fields[0].Text)
+	}
+	if fields[1].Text != "*amount*\n299.99" {
+		t.Errorf("Expected second field to be amount, got %q", fields[1].Text)
+	}
+
+	expected := "*Details:*\n• customer: acme\n• region: eu-west\n"
+	if details != expected {
+		t.Errorf("Expected details %q, got %q", expected, details)
+	}
+}
+
+func TestBuildBlocksWithoutLayoutUsesDetails(t *testing.T) {
+	client := NewClient("xoxb-test", "#test")
+
+	blocks := client.buildBlocks(orderCreatedEvent())
+
+	for _, block := range blocks {
+		if section, ok := block.(*githubslack.SectionBlock); ok && len(section.Fields) > 0 {
+			t.Errorf("Expected no primary fields without a layout, got %d", len(section.Fields))
+		}
+	}
+}
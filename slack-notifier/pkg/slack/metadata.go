@@ -0,0 +1,52 @@
+package slack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// MetadataPolicy controls which event metadata keys are rendered in Slack messages.
+// Include (when non-empty) acts as an allow-list, Exclude drops keys, and Hash replaces
+// the value of high-cardinality keys with a stable digest so messages stay groupable.
+type MetadataPolicy struct {
+	Include []string
+	Exclude []string
+	Hash    []string
+}
+
+// Apply returns a filtered copy of metadata according to the policy. The input is not modified.
+func (p MetadataPolicy) Apply(metadata map[string]interface{}) map[string]interface{} {
+	include := toSet(p.Include)
+	exclude := toSet(p.Exclude)
+	hash := toSet(p.Hash)
+
+	result := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if len(include) > 0 && !include[k] {
+			continue
+		}
+		if exclude[k] {
+			continue
+		}
+		if hash[k] {
+			v = hashValue(v)
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// hashValue returns a short, stable digest of a metadata value.
+func hashValue(v interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(v)))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+func toSet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		set[k] = true
+	}
+	return set
+}
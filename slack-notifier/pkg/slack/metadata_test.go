@@ -0,0 +1,71 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+)
+
+func testMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"order_id": "ORD-1001",
+		"amount":   299.99,
+		"region":   "eu-west",
+	}
+}
+
+func TestMetadataPolicyInclude(t *testing.T) {
+	policy := MetadataPolicy{Include: []string{"amount", "region"}}
+
+	md := detailsFor(policy.Apply(testMetadata()))
+
+	expected := "*Details:*\n• amount: 299.99\n• region: eu-west\n"
+	if md != expected {
+		t.Errorf("Expected details %q, got %q", expected, md)
+	}
+}
+
+func TestMetadataPolicyExclude(t *testing.T) {
+	policy := MetadataPolicy{Exclude: []string{"order_id"}}
+
+	md := detailsFor(policy.Apply(testMetadata()))
+
+	if strings.Contains(md, "order_id") {
+		t.Errorf("Expected order_id to be excluded, got %q", md)
+	}
+	if !strings.Contains(md, "• amount: 299.99") || !strings.Contains(md, "• region: eu-west") {
+		t.Errorf("Expected amount and region to be rendered, got %q", md)
+	}
+}
+
+func TestMetadataPolicyHash(t *testing.T) {
+	policy := MetadataPolicy{Hash: []string{"order_id"}}
+
+	first := policy.Apply(testMetadata())
+	second := policy.Apply(testMetadata())
+
+	hashed, ok := first["order_id"].(string)
+	if !ok || hashed == "ORD-1001" || !strings.HasPrefix(hashed, "sha256:") {
+		t.Fatalf("Expected order_id to be hashed, got %v", first["order_id"])
+	}
+	if second["order_id"] != hashed {
+		t.Errorf("Expected stable hash, got %v and %v", hashed, second["order_id"])
+	}
+	if first["amount"] != 299.99 {
+		t.Errorf("Expected amount to be untouched, got %v", first["amount"])
+	}
+}
+
+func TestMetadataPolicyEmptyKeepsAll(t *testing.T) {
+	metadata := testMetadata()
+	result := MetadataPolicy{}.Apply(metadata)
+
+	if len(result) != len(metadata) {
+		t.Errorf("Expected %d metadata items, got %d", len(metadata), len(result))
+	}
+}
+
+// detailsFor renders metadata the way events without a field layout are rendered.
+func detailsFor(metadata map[string]interface{}) string {
+	_, keys := FieldLayout{}.Split(metadata)
+	return formatDetails(metadata, keys)
+}
@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	githubslack "github.com/slack-go/slack"
+)
+
+// RetryPolicy controls how failed posts to Slack are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of tries, including the first; 1 or less disables retries.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry; it doubles after each further failure.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff delay. A Retry-After from Slack is always honored in full.
+	MaxDelay time.Duration
+	// Jitter randomizes each backoff delay by up to this fraction of it, e.g. 0.2 for ±20%.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries transient failures up to three times over a few seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Jitter:      0.2,
+}
+
+// backoff returns the delay before retry number n, counting from 1.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < n && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// retryable reports whether err is a transient failure worth retrying: rate limits, 5xx
+// responses and network errors. Slack API errors such as channel_not_found or invalid_auth
+// are permanent, as is the caller's context ending.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay returns how long to wait before retry number n after err, honoring Slack's
+// Retry-After on HTTP 429.
+func (p RetryPolicy) retryDelay(n int, err error) time.Duration {
+	var limited *githubslack.RateLimitedError
+	if errors.As(err, &limited) && limited.RetryAfter > 0 {
+		return limited.RetryAfter
+	}
+	return p.backoff(n)
+}
+
+// sleepContext waits for d, or returns the context's error if it ends first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withRetry calls post until it succeeds, fails permanently or the policy runs out of
+// attempts, returning the last error.
+func (c *Client) withRetry(ctx context.Context, post func() error) error {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = post(); err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+		if waitErr := c.sleep(ctx, c.retry.retryDelay(attempt, err)); waitErr != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	githubslack "github.com/slack-go/slack"
+	"slack-notifier/internal/events"
+)
+
+// stubSlack serves chat.postMessage, answering each call with the next response in turn
+// and repeating the last one. It records the delays the client would have slept for.
+func stubSlack(t *testing.T, responses ...func(w http.ResponseWriter)) (*Client, *int32, *[]time.Duration) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(responses) {
+			n = len(responses)
+		}
+		responses[n-1](w)
+	}))
+	t.Cleanup(server.Close)
+
+	var delays []time.Duration
+	client := NewClient("xoxb-test", "#test").WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    time.Second,
+	})
+	client.api = githubslack.New("xoxb-test", githubslack.OptionAPIURL(server.URL+"/"))
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return client, &calls, &delays
+}
+
+func respondOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1.0"}`))
+}
+
+func respondError(code string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":false,"error":"` + code + `"}`))
+	}
+}
+
+func respondStatus(status int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+	}
+}
+
+func TestSendMessageRetriesServerErrors(t *testing.T) {
+	client, calls, delays := stubSlack(t, respondStatus(http.StatusBadGateway), respondStatus(http.StatusServiceUnavailable), respondOK)
+
+	if err := client.SendMessage(context.Background(), "hello"); err != nil {
+		t.Fatalf("Expected success after two failures, got %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 calls, got %d", *calls)
+	}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+	if len(*delays) != len(want) || (*delays)[0] != want[0] || (*delays)[1] != want[1] {
+		t.Errorf("Expected backoff delays %v, got %v", want, *delays)
+	}
+}
+
+func TestSendEventHonorsRetryAfter(t *testing.T) {
+	rateLimited := func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	client, calls, delays := stubSlack(t, rateLimited, respondOK)
+
+	event := events.NewEvent(events.EventTypeSystemError).WithTitle("Disk full").Build()
+	if err := client.SendEvent(context.Background(), event); err != nil {
+		t.Fatalf("Expected success after a rate limit, got %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", *calls)
+	}
+	if len(*delays) != 1 || (*delays)[0] != 3*time.Second {
+		t.Errorf("Expected a single 3s wait from Retry-After, got %v", *delays)
+	}
+}
+
+func TestSendMessageDoesNotRetryPermanentErrors(t *testing.T) {
+	for _, code := range []string{"channel_not_found", "invalid_auth"} {
+		client, calls, _ := stubSlack(t, respondError(code))
+
+		if err := client.SendMessage(context.Background(), "hello"); err == nil || err.Error() != code {
+			t.Errorf("Expected %s error, got %v", code, err)
+		}
+		if *calls != 1 {
+			t.Errorf("Expected %s to be tried once, got %d calls", code, *calls)
+		}
+	}
+}
+
+func TestSendMessageGivesUpAfterMaxAttempts(t *testing.T) {
+	client, calls, delays := stubSlack(t, respondStatus(http.StatusInternalServerError))
+
+	if err := client.SendMessage(context.Background(), "hello"); err == nil {
+		t.Fatal("Expected an error once attempts run out")
+	}
+	if *calls != 4 {
+		t.Errorf("Expected 4 calls, got %d", *calls)
+	}
+	if len(*delays) != 3 {
+		t.Errorf("Expected 3 waits between 4 attempts, got %v", *delays)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for n, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second} {
+		if got := policy.backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(2); got < time.Second || got > 3*time.Second {
+			t.Fatalf("backoff(2) with 50%% jitter = %v, want within 1s..3s", got)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"tutorial/pkg/benchutil"
 )
 
 // =============================================================================
@@ -451,5 +454,15 @@ func benchmarkingDemo() {
 	fmt.Println("   - Benchmarking concurrent operations")
 	fmt.Println("   - Table-driven benchmarks")
 
+	fmt.Println("\n7. Comparing Implementations Outside go test:")
+	fmt.Println("   benchutil.Compare runs each candidate with testing.Benchmark")
+	testData := []string{"hello", "world", "golang", "benchmarking", "performance", "testing"}
+	results := benchutil.Compare(
+		benchutil.Candidate{Name: "String_Plus", Fn: func() { concatenateStrings(testData) }},
+		benchutil.Candidate{Name: "Strings_Builder", Fn: func() { concatenateWithBuilderBench(testData) }},
+		benchutil.Candidate{Name: "Strings_Join", Fn: func() { concatenateWithJoin(testData) }},
+	)
+	benchutil.Write(os.Stdout, results, benchutil.Options{Format: benchutil.FormatText})
+
 	fmt.Println("\n✅ Benchmarks help you write faster, more efficient code!")
 }
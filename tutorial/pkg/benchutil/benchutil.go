@@ -0,0 +1,114 @@
+// Package benchutil runs ad-hoc performance comparisons between a set of
+// candidate functions using testing.Benchmark, outside of `go test`.
+package benchutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"testing"
+	"text/tabwriter"
+)
+
+// Format selects how results are written
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// Candidate is a named function to benchmark
+type Candidate struct {
+	Name string
+	Fn   func()
+}
+
+// Result holds the measurements for a single candidate
+type Result struct {
+	Name        string  `json:"name"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	Relative    float64 `json:"relative"` // ns/op relative to the fastest candidate
+}
+
+// Options controls how results are written
+type Options struct {
+	Format Format
+	// Verbose adds iteration counts and bytes/op to text output.
+	// JSON output always includes every field.
+	Verbose bool
+}
+
+// Compare benchmarks each candidate and returns the results ordered fastest first
+func Compare(candidates ...Candidate) []Result {
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		fn := c.Fn
+		br := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				fn()
+			}
+		})
+
+		results = append(results, Result{
+			Name:        c.Name,
+			Iterations:  br.N,
+			NsPerOp:     br.NsPerOp(),
+			AllocsPerOp: br.AllocsPerOp(),
+			BytesPerOp:  br.AllocedBytesPerOp(),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].NsPerOp < results[j].NsPerOp
+	})
+
+	if len(results) > 0 && results[0].NsPerOp > 0 {
+		fastest := float64(results[0].NsPerOp)
+		for i := range results {
+			results[i].Relative = float64(results[i].NsPerOp) / fastest
+		}
+	}
+
+	return results
+}
+
+// Write prints results to w as a comparison table or JSON
+func Write(w io.Writer, results []Result, opts Options) error {
+	switch opts.Format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case FormatText, "":
+		return writeTable(w, results, opts.Verbose)
+	default:
+		return fmt.Errorf("unsupported format: %q", opts.Format)
+	}
+}
+
+// writeTable prints results as an aligned text table
+func writeTable(w io.Writer, results []Result, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if verbose {
+		fmt.Fprintln(tw, "NAME\tITERATIONS\tNS/OP\tALLOCS/OP\tB/OP\tRELATIVE")
+	} else {
+		fmt.Fprintln(tw, "NAME\tNS/OP\tALLOCS/OP\tRELATIVE")
+	}
+
+	for _, r := range results {
+		if verbose {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.2fx\n", r.Name, r.Iterations, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp, r.Relative)
+		} else {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%.2fx\n", r.Name, r.NsPerOp, r.AllocsPerOp, r.Relative)
+		}
+	}
+
+	return tw.Flush()
+}
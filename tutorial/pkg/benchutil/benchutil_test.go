@@ -0,0 +1,100 @@
+package benchutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var sink string
+
+func TestCompareOrdersByCost(t *testing.T) {
+	parts := make([]string, 500)
+	for i := range parts {
+		parts[i] = "x"
+	}
+
+	results := Compare(
+		Candidate{Name: "expensive", Fn: func() {
+			s := ""
+			for _, p := range parts {
+				s += p
+			}
+			sink = s
+		}},
+		Candidate{Name: "cheap", Fn: func() {
+			sink = parts[0]
+		}},
+	)
+
+	if len(results) != 2 {
+		t.Fatalf("Compare() returned %d results, want 2", len(results))
+	}
+	if results[0].Name != "cheap" || results[1].Name != "expensive" {
+		t.Errorf("Compare() order = [%s %s], want [cheap expensive]", results[0].Name, results[1].Name)
+	}
+	if results[0].Relative != 1 {
+		t.Errorf("Compare() fastest relative = %v, want 1", results[0].Relative)
+	}
+	if results[1].Relative <= 1 {
+		t.Errorf("Compare() slowest relative = %v, want > 1", results[1].Relative)
+	}
+	if results[1].AllocsPerOp <= results[0].AllocsPerOp {
+		t.Errorf("Compare() allocs expensive = %d, cheap = %d, want expensive > cheap", results[1].AllocsPerOp, results[0].AllocsPerOp)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results := []Result{
+		{Name: "fast", Iterations: 1000, NsPerOp: 10, AllocsPerOp: 0, BytesPerOp: 0, Relative: 1},
+		{Name: "slow", Iterations: 10, NsPerOp: 100, AllocsPerOp: 3, BytesPerOp: 64, Relative: 10},
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    []string
+		notWant []string
+	}{
+		{name: "text", opts: Options{Format: FormatText}, want: []string{"NAME", "fast", "10.00x"}, notWant: []string{"ITERATIONS"}},
+		{name: "text verbose", opts: Options{Format: FormatText, Verbose: true}, want: []string{"ITERATIONS", "B/OP", "64"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, results, tt.opts); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Write() output missing %q:\n%s", s, buf.String())
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("Write() output unexpectedly contains %q:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Write(&buf, results, Options{Format: FormatJSON}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var decoded []Result
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Write() produced invalid JSON: %v", err)
+		}
+		if len(decoded) != 2 || decoded[1].Name != "slow" {
+			t.Errorf("Write() json = %+v, want results round-tripped", decoded)
+		}
+	})
+
+	if err := Write(&bytes.Buffer{}, results, Options{Format: "xml"}); err == nil {
+		t.Error("Write() with unknown format expected error but got none")
+	}
+}